@@ -0,0 +1,312 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/paulmanoni/livenest/protocol"
+)
+
+// ErrLeft is returned by calls on a channel after Leave
+var ErrLeft = errors.New("livenest channel left")
+
+// Update is a message the server sent to a channel: a Render, or an Error that doesn't
+// answer a Push, e.g. from a timer handler
+type Update struct {
+	Render *protocol.Render
+	Error  *protocol.Error
+}
+
+// Channel is a component mounted on a client
+// It keeps the component's current HTML, title and streams as renders arrive
+type Channel struct {
+	Topic     string
+	Component string
+
+	client  *Client
+	joined  chan error
+	updates chan Update
+	done    chan struct{}
+
+	mu       sync.Mutex
+	mounted  bool
+	tree     *protocol.Tree
+	statics  []string // of the last split render
+	dynamics []string
+	title    string
+	streams  map[string][]string
+	cursors  map[string]string
+	pending  []*pendingPush
+	err      error
+	closeErr sync.Once
+}
+
+// pendingPush is an event waiting for its acknowledgement
+type pendingPush struct {
+	ref   string
+	event string
+	err   *protocol.Error
+	reply chan pushReply
+}
+
+// pushReply answers a Push
+type pushReply struct {
+	render *protocol.Render
+	err    error
+}
+
+// newChannel creates a channel waiting for its join render
+func newChannel(c *Client, topic, component string) *Channel {
+	return &Channel{
+		Topic:     topic,
+		Component: component,
+		client:    c,
+		joined:    make(chan error, 1),
+		updates:   make(chan Update, 64),
+		done:      make(chan struct{}),
+		tree:      &protocol.Tree{},
+		streams:   make(map[string][]string),
+		cursors:   make(map[string]string),
+	}
+}
+
+// Push sends an event to the component and waits until the server acknowledges it
+// It returns the render that did, and the component's *protocol.Error if handling failed
+func (ch *Channel) Push(ctx context.Context, event string, payload map[string]interface{}) (*protocol.Render, error) {
+	p := &pendingPush{ref: ch.client.nextRef(), event: event, reply: make(chan pushReply, 1)}
+	ch.mu.Lock()
+	if ch.err != nil {
+		ch.mu.Unlock()
+		return nil, ch.err
+	}
+	ch.pending = append(ch.pending, p)
+	ch.mu.Unlock()
+
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	if err := ch.client.send(protocol.ClientMessage{Topic: ch.Topic, Event: event, Payload: payload, Ref: p.ref}); err != nil {
+		ch.dropPending(p)
+		return nil, err
+	}
+
+	select {
+	case reply := <-p.reply:
+		return reply.render, reply.err
+	case <-ctx.Done():
+		ch.dropPending(p)
+		return nil, ctx.Err()
+	case <-ch.done:
+		return nil, ch.Err()
+	}
+}
+
+// Leave unmounts the component
+func (ch *Channel) Leave() error {
+	ch.client.forget(ch.Topic)
+	err := ch.client.send(protocol.ClientMessage{Type: protocol.TypeLeave, Topic: ch.Topic, Event: protocol.EventLeave})
+	ch.close(ErrLeft)
+	return err
+}
+
+// HTML returns the component's current HTML, without the rows of its streams
+func (ch *Channel) HTML() string {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	return ch.tree.HTML()
+}
+
+// Title returns the document title the component set, if any
+func (ch *Channel) Title() string {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	return ch.title
+}
+
+// Stream returns the HTML appended to a stream container, batch by batch
+func (ch *Channel) Stream(id string) []string {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	return append([]string(nil), ch.streams[id]...)
+}
+
+// Cursors returns the last cursor of each stream and subscription, to resume them with JoinWith
+func (ch *Channel) Cursors() map[string]string {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	cursors := make(map[string]string, len(ch.cursors))
+	for id, cursor := range ch.cursors {
+		cursors[id] = cursor
+	}
+	return cursors
+}
+
+// Updates delivers every render and unanswered error of the channel
+// Updates are dropped while the buffer of 64 is full, so slow readers don't stall the client
+func (ch *Channel) Updates() <-chan Update {
+	return ch.updates
+}
+
+// Done is closed when the channel ends: after Leave, when the server unmounts the
+// component or when the connection closes
+func (ch *Channel) Done() <-chan struct{} {
+	return ch.done
+}
+
+// Err returns why the channel ended, or nil while it is mounted
+func (ch *Channel) Err() error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	return ch.err
+}
+
+// receive applies a server message to the channel
+func (ch *Channel) receive(msg protocol.ServerMessage) {
+	switch msg.Type {
+	case protocol.TypeRender:
+		render, err := msg.Render()
+		if err != nil {
+			return
+		}
+		ch.applyRender(render)
+	case protocol.TypeError:
+		e, err := msg.Error()
+		if err != nil {
+			return
+		}
+		ch.applyError(e)
+	}
+}
+
+// applyRender updates the channel state and answers the push the render acknowledges
+func (ch *Channel) applyRender(render *protocol.Render) {
+	ch.mu.Lock()
+	if render.HTML != "" {
+		if tree, err := protocol.ParseTree(render.HTML); err == nil {
+			ch.tree = tree
+		}
+	} else if len(render.Diff) > 0 {
+		ch.tree.Apply(render.Diff)
+	} else if render.Split != nil {
+		var html string
+		ch.statics, ch.dynamics, html = render.Split.Apply(ch.statics, ch.dynamics)
+		if tree, err := protocol.ParseTree(html); err == nil {
+			ch.tree = tree
+		}
+	}
+	if render.Title != nil {
+		ch.title = *render.Title
+	}
+	for _, op := range render.Streams {
+		if op.Reset {
+			delete(ch.streams, op.Target)
+			delete(ch.cursors, op.Target)
+		}
+		if op.HTML != "" {
+			ch.streams[op.Target] = append(ch.streams[op.Target], op.HTML)
+		}
+		if op.Cursor != "" {
+			ch.cursors[op.Target] = op.Cursor
+		}
+	}
+	for key, cursor := range render.Cursors {
+		ch.cursors[key] = cursor
+	}
+
+	first := !ch.mounted
+	ch.mounted = true
+	var answered *pendingPush
+	if render.Ref != "" {
+		answered = ch.takePending(func(p *pendingPush) bool { return p.ref == render.Ref })
+	}
+	ch.mu.Unlock()
+
+	if first {
+		ch.signalJoined(nil)
+	}
+	if answered != nil {
+		reply := pushReply{render: render}
+		if answered.err != nil {
+			reply.err = answered.err
+		}
+		answered.reply <- reply
+	}
+	ch.publish(Update{Render: render})
+}
+
+// applyError fails the join, marks the push it belongs to or ends the channel
+func (ch *Channel) applyError(e *protocol.Error) {
+	ch.mu.Lock()
+	if !ch.mounted {
+		ch.mu.Unlock()
+		ch.signalJoined(e)
+		return
+	}
+	var failed *pendingPush
+	for _, p := range ch.pending {
+		if p.event == e.Event && p.err == nil {
+			failed = p
+			break
+		}
+	}
+	if failed != nil {
+		// The acknowledging render follows and carries the error to Push
+		failed.err = e
+	}
+	ch.mu.Unlock()
+
+	if failed == nil {
+		ch.publish(Update{Error: e})
+	}
+	// The server unmounted the component after these
+	if e.Reason == protocol.ReasonTimeout || e.Reason == protocol.ReasonDisconnected {
+		ch.client.forget(ch.Topic)
+		ch.close(e)
+	}
+}
+
+// signalJoined reports the outcome of the join once; later outcomes are dropped
+func (ch *Channel) signalJoined(err error) {
+	select {
+	case ch.joined <- err:
+	default:
+	}
+}
+
+// publish hands an update to Updates without blocking
+func (ch *Channel) publish(update Update) {
+	select {
+	case ch.updates <- update:
+	default:
+	}
+}
+
+// takePending removes and returns the first pending push matching fn; ch.mu is held
+func (ch *Channel) takePending(match func(*pendingPush) bool) *pendingPush {
+	for i, p := range ch.pending {
+		if match(p) {
+			ch.pending = append(ch.pending[:i], ch.pending[i+1:]...)
+			return p
+		}
+	}
+	return nil
+}
+
+// dropPending forgets a push that is no longer waited for
+func (ch *Channel) dropPending(p *pendingPush) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.takePending(func(other *pendingPush) bool { return other == p })
+}
+
+// close ends the channel with err
+func (ch *Channel) close(err error) {
+	ch.closeErr.Do(func() {
+		ch.mu.Lock()
+		ch.err = err
+		ch.pending = nil
+		ch.mu.Unlock()
+		close(ch.done)
+	})
+}
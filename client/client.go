@@ -0,0 +1,269 @@
+// Package client connects to LiveNest LiveViews from Go over the WebSocket protocol,
+// e.g. to drive components from another backend, a native app bridge or a smoke test
+//
+//	c, err := client.Dial(ctx, "https://example.com", nil)
+//	counter, err := c.Join(ctx, "counter")
+//	_, err = counter.Push(ctx, "increment", nil)
+//	fmt.Println(counter.HTML())
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/paulmanoni/livenest/protocol"
+)
+
+// ErrClosed is returned by calls on a closed client
+var ErrClosed = errors.New("livenest client closed")
+
+// Options configures a connection
+type Options struct {
+	Header http.Header       // sent with the handshake, e.g. a session Cookie or Authorization
+	Params url.Values        // page query the components mount with
+	Dialer *websocket.Dialer // defaults to websocket.DefaultDialer
+}
+
+// Client is a WebSocket connection to a LiveNest server
+// Components joined on it share the connection; it is safe for concurrent use
+type Client struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	channels map[string]*Channel
+	replies  map[string]chan *protocol.Reply // by ref of the message waiting for them
+	topics   int
+	refs     int
+	err      error
+	done     chan struct{}
+}
+
+// Dial connects to the server at baseURL, an http(s) or ws(s) URL of the app
+func Dial(ctx context.Context, baseURL string, opts *Options) (*Client, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "ws":
+		u.Scheme = "ws"
+	case "https", "wss":
+		u.Scheme = "wss"
+	default:
+		return nil, fmt.Errorf("livenest client: unsupported scheme %q", u.Scheme)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + protocol.Path
+	query := url.Values{protocol.VersionParam: {protocol.Version}}
+	if len(opts.Params) > 0 {
+		query.Set("params", opts.Params.Encode())
+	}
+	u.RawQuery = query.Encode()
+
+	dialer := opts.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+	conn, resp, err := dialer.DialContext(ctx, u.String(), opts.Header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("livenest client: %w (HTTP %d)", err, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("livenest client: %w", err)
+	}
+
+	c := &Client{conn: conn, channels: make(map[string]*Channel), done: make(chan struct{})}
+	go c.read()
+	return c, nil
+}
+
+// Join mounts a component on a new channel and waits for its first render
+func (c *Client) Join(ctx context.Context, component string) (*Channel, error) {
+	return c.JoinWith(ctx, protocol.JoinPayload{Component: component})
+}
+
+// JoinWith mounts a component like Join with a full join payload, e.g. stream cursors
+func (c *Client) JoinWith(ctx context.Context, payload protocol.JoinPayload) (*Channel, error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.topics++
+	ch := newChannel(c, "c"+strconv.Itoa(c.topics), payload.Component)
+	c.channels[ch.Topic] = ch
+	c.mu.Unlock()
+
+	if err := c.send(protocol.ClientMessage{Type: protocol.TypeJoin, Topic: ch.Topic, Event: protocol.EventJoin, Payload: payload}); err != nil {
+		c.forget(ch.Topic)
+		return nil, err
+	}
+
+	select {
+	case err := <-ch.joined:
+		if err != nil {
+			c.forget(ch.Topic)
+			return nil, err
+		}
+		return ch, nil
+	case <-ctx.Done():
+		c.forget(ch.Topic)
+		return nil, ctx.Err()
+	case <-c.done:
+		return nil, c.Err()
+	}
+}
+
+// Heartbeat checks that the connection still works, waiting for the server's reply
+func (c *Client) Heartbeat(ctx context.Context) error {
+	ref := c.nextRef()
+	replied := make(chan *protocol.Reply, 1)
+	c.mu.Lock()
+	if c.replies == nil {
+		c.replies = make(map[string]chan *protocol.Reply)
+	}
+	c.replies[ref] = replied
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.replies, ref)
+		c.mu.Unlock()
+	}()
+
+	if err := c.send(protocol.ClientMessage{Type: protocol.TypeHeartbeat, Event: protocol.EventHeartbeat, Ref: ref}); err != nil {
+		return err
+	}
+	select {
+	case reply := <-replied:
+		if reply.Status != protocol.StatusOK {
+			return fmt.Errorf("livenest client: heartbeat failed: %s", reply.Status)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return c.Err()
+	}
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	c.writeMu.Lock()
+	c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	c.writeMu.Unlock()
+	return c.conn.Close()
+}
+
+// Done is closed when the connection ends
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection ended, or nil while it is open
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// send writes a message
+func (c *Client) send(msg protocol.ClientMessage) error {
+	if msg.Payload == nil {
+		msg.Payload = map[string]interface{}{}
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.Err(); err != nil {
+		return err
+	}
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// nextRef returns a ref for an event
+func (c *Client) nextRef() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refs++
+	return strconv.Itoa(c.refs)
+}
+
+// forget drops a channel
+func (c *Client) forget(topic string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.channels, topic)
+}
+
+// receiveReply hands a reply to the call waiting for it
+func (c *Client) receiveReply(msg protocol.ServerMessage) {
+	reply, err := msg.Reply()
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	replied := c.replies[reply.Ref]
+	c.mu.Unlock()
+	if replied != nil {
+		select {
+		case replied <- reply:
+		default:
+		}
+	}
+}
+
+// read dispatches server messages to their channels until the connection fails
+func (c *Client) read() {
+	var err error
+	for {
+		var data []byte
+		if _, data, err = c.conn.ReadMessage(); err != nil {
+			break
+		}
+		var msg protocol.ServerMessage
+		if json.Unmarshal(data, &msg) != nil {
+			continue
+		}
+		if msg.Type == protocol.TypeReply {
+			c.receiveReply(msg)
+			continue
+		}
+		c.mu.Lock()
+		ch := c.channels[msg.Topic]
+		c.mu.Unlock()
+		if ch != nil {
+			ch.receive(msg)
+		}
+	}
+
+	if websocket.IsCloseError(err, websocket.CloseNormalClosure) || errors.Is(err, net.ErrClosed) {
+		err = ErrClosed
+	} else {
+		err = fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	c.mu.Lock()
+	c.err = err
+	channels := c.channels
+	c.channels = make(map[string]*Channel)
+	c.mu.Unlock()
+
+	for _, ch := range channels {
+		ch.close(err)
+	}
+	close(c.done)
+}
@@ -0,0 +1,95 @@
+// Command livenest-probe checks that a LiveNest server accepts LiveView connections:
+// it connects, mounts a component, optionally sends it an event and prints the result.
+// It exits with status 1 when any step fails, so it can run as a smoke test after deploys.
+//
+// Usage:
+//
+//	livenest-probe -url https://example.com -component counter -event increment
+//	livenest-probe -url http://localhost:8080 -component search -params 'q=go' -header 'Cookie: session=...'
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/paulmanoni/livenest/client"
+)
+
+// headers collects repeated -header flags
+type headers http.Header
+
+func (h headers) String() string { return "" }
+
+func (h headers) Set(value string) error {
+	name, val, ok := strings.Cut(value, ":")
+	if !ok {
+		return fmt.Errorf("header %q is not \"Name: value\"", value)
+	}
+	http.Header(h).Add(strings.TrimSpace(name), strings.TrimSpace(val))
+	return nil
+}
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080", "base URL of the app")
+	component := flag.String("component", "", "component to mount (required)")
+	event := flag.String("event", "", "event to send after mounting (empty to skip)")
+	payload := flag.String("payload", "{}", "JSON payload of the event")
+	params := flag.String("params", "", "page query the component mounts with, e.g. 'id=1&tab=info'")
+	timeout := flag.Duration("timeout", 10*time.Second, "time allowed for the whole probe")
+	quiet := flag.Bool("quiet", false, "print timings only, not the HTML")
+	header := headers{}
+	flag.Var(header, "header", "handshake header as 'Name: value' (repeatable)")
+	flag.Parse()
+
+	if *component == "" {
+		log.Fatalf("livenest-probe: -component is required")
+	}
+	var eventPayload map[string]interface{}
+	if err := json.Unmarshal([]byte(*payload), &eventPayload); err != nil {
+		log.Fatalf("livenest-probe: -payload: %v", err)
+	}
+	query, err := url.ParseQuery(*params)
+	if err != nil {
+		log.Fatalf("livenest-probe: -params: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	start := time.Now()
+	c, err := client.Dial(ctx, *baseURL, &client.Options{Header: http.Header(header), Params: query})
+	if err != nil {
+		log.Fatalf("livenest-probe: connect: %v", err)
+	}
+	defer c.Close()
+	fmt.Printf("connected in %v\n", time.Since(start).Round(time.Millisecond))
+
+	start = time.Now()
+	ch, err := c.Join(ctx, *component)
+	if err != nil {
+		log.Fatalf("livenest-probe: join %s: %v", *component, err)
+	}
+	fmt.Printf("joined %s in %v\n", *component, time.Since(start).Round(time.Millisecond))
+	if !*quiet {
+		fmt.Println(ch.HTML())
+	}
+
+	if *event == "" {
+		return
+	}
+	start = time.Now()
+	if _, err := ch.Push(ctx, *event, eventPayload); err != nil {
+		log.Fatalf("livenest-probe: event %s: %v", *event, err)
+	}
+	fmt.Printf("handled %s in %v\n", *event, time.Since(start).Round(time.Millisecond))
+	if !*quiet {
+		fmt.Println(ch.HTML())
+	}
+}
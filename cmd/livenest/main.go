@@ -0,0 +1,155 @@
+// Command livenest scaffolds LiveNest projects: a new project layout, and components,
+// GORM models and auto-form structs to add to it. Fields are given as name:type, with
+// types string, text, email, int, float, bool and time. demo generates a complete example
+// app with sign-in, a CRUD resource, uploads, a chat with presence and the LiveDashboard.
+//
+// Usage:
+//
+//	livenest new blog [-module github.com/me/blog]
+//	livenest gen component post_list
+//	livenest gen model post title:string body:text published:bool
+//	livenest gen form contact name email:email message:text
+//	livenest demo demo [-module github.com/me/demo]
+//
+// gen writes into the project in the current directory, or the one given with -dir.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/paulmanoni/livenest/scaffold"
+)
+
+const usage = `Usage:
+  livenest new <dir> [-module path]
+  livenest gen component <name> [-dir project]
+  livenest gen model <name> [field:type...] [-dir project]
+  livenest gen form <name> field:type... [-dir project]
+  livenest demo <dir> [-module path]
+`
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("livenest: ")
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "new":
+		newProject(os.Args[2:])
+	case "gen":
+		generate(os.Args[2:])
+	case "demo":
+		demo(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
+
+// newProject runs "livenest new"
+func newProject(args []string) {
+	flags := flag.NewFlagSet("new", flag.ExitOnError)
+	module := flags.String("module", "", "module path (defaults to the directory name)")
+	positional := interleavedArgs(flags, args)
+	if len(positional) != 1 {
+		log.Fatal("new: give one project directory")
+	}
+	dir := positional[0]
+
+	files, err := scaffold.NewProject(dir, *module)
+	report(files, err)
+	fmt.Printf("\nNext:\n  cd %s\n  go get github.com/paulmanoni/livenest@latest\n  go mod tidy\n  go run .\n", dir)
+}
+
+// demo runs "livenest demo"
+func demo(args []string) {
+	flags := flag.NewFlagSet("demo", flag.ExitOnError)
+	module := flags.String("module", "", "module path (defaults to the directory name)")
+	positional := interleavedArgs(flags, args)
+	if len(positional) != 1 {
+		log.Fatal("demo: give one project directory")
+	}
+	dir := positional[0]
+
+	files, err := scaffold.Demo(dir, *module)
+	report(files, err)
+	fmt.Printf("\nNext:\n  cd %s\n  go get github.com/paulmanoni/livenest@latest\n  go mod tidy\n  go run .\n\nThen open http://localhost:8080 and create an account; the first one is an admin.\n", dir)
+}
+
+// generate runs "livenest gen"
+func generate(args []string) {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	dir := flags.String("dir", ".", "project directory")
+	positional := interleavedArgs(flags, args)
+	if len(positional) < 2 {
+		log.Fatal("gen: give a kind and a name, e.g. gen component post_list")
+	}
+	kind, name, specs := positional[0], positional[1], positional[2:]
+
+	fields, err := scaffold.ParseFields(specs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var files []string
+	switch kind {
+	case "component":
+		if len(fields) > 0 {
+			log.Fatal("gen component: components take no fields")
+		}
+		files, err = scaffold.Component(*dir, name)
+		report(files, err)
+		fmt.Printf("\nRegister it in main.go:\n  app.NewHandler().Path(\"/%s\").AsLive().AddComponent(&components.%sComponent{}).WithName(%q).Build()\n",
+			routePath(name), scaffold.Pascal(name), scaffold.Snake(name))
+	case "model":
+		files, err = scaffold.Model(*dir, name, fields)
+		report(files, err)
+		fmt.Printf("\nMigrate it after connecting the database:\n  app.DB.AutoMigrate(&models.%s{})\n", scaffold.Pascal(name))
+	case "form":
+		files, err = scaffold.Form(*dir, name, fields)
+		report(files, err)
+		fmt.Printf("\nRegister it in main.go:\n  app.NewHandler().Path(\"/%s\").AsLive().AddComponent(forms.New%sForm()).WithName(%q).Build()\n",
+			routePath(name), scaffold.Pascal(name), scaffold.Snake(name)+"_form")
+	default:
+		log.Fatalf("gen: unknown kind %q, use component, model or form", kind)
+	}
+}
+
+// routePath suggests the URL path of a generated component, e.g. post-list
+func routePath(name string) string {
+	return strings.ReplaceAll(scaffold.Snake(name), "_", "-")
+}
+
+// interleavedArgs parses flags that may come before, between or after the positional
+// arguments, and returns the positional ones
+func interleavedArgs(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// report prints the files written, or exits with the error
+func report(files []string, err error) {
+	for _, file := range files {
+		fmt.Println("  create", file)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,46 @@
+// Command lvgen generates typed event constants and TypeScript definitions
+// from the Handle* methods of LiveView components.
+//
+// Usage:
+//
+//	//go:generate go run github.com/paulmanoni/livenest/cmd/lvgen -ts static/events.d.ts
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/paulmanoni/livenest/codegen"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory containing the component package")
+	goOut := flag.String("out", "events_gen.go", "Go output file (empty to skip)")
+	tsOut := flag.String("ts", "", "TypeScript output file (empty to skip)")
+	check := flag.Bool("check", false, "report lv-* bindings without a matching handler instead of generating")
+	flag.Parse()
+
+	if *check {
+		_, components, err := codegen.ScanDir(*dir)
+		if err != nil {
+			log.Fatalf("lvgen: %v", err)
+		}
+		problems, err := codegen.CheckBindings(*dir, components)
+		if err != nil {
+			log.Fatalf("lvgen: %v", err)
+		}
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := codegen.WriteFiles(*dir, *goOut, *tsOut); err != nil {
+		log.Fatalf("lvgen: %v", err)
+	}
+}
@@ -0,0 +1,343 @@
+// Package codegen generates typed event definitions from LiveView components
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ComponentEvents holds the events found on a single component type
+type ComponentEvents struct {
+	Component string
+	Events    []Event
+}
+
+// Event describes a Handle* method and the payload keys it reads
+type Event struct {
+	Name    string
+	Method  string
+	Payload []PayloadField
+}
+
+// PayloadField is a payload key read by an event handler
+type PayloadField struct {
+	Key    string
+	GoType string
+}
+
+// ScanDir parses the Go files in dir and returns the events of every component
+func ScanDir(dir string) (string, []ComponentEvents, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && !strings.HasSuffix(info.Name(), "_gen.go")
+	}, 0)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var pkgName string
+	byComponent := make(map[string]*ComponentEvents)
+
+	for name, pkg := range pkgs {
+		pkgName = name
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 {
+					continue
+				}
+
+				event, ok := scanHandler(fn)
+				if !ok {
+					continue
+				}
+
+				component := receiverName(fn.Recv.List[0].Type)
+				if byComponent[component] == nil {
+					byComponent[component] = &ComponentEvents{Component: component}
+				}
+				byComponent[component].Events = append(byComponent[component].Events, event)
+			}
+		}
+	}
+
+	components := make([]ComponentEvents, 0, len(byComponent))
+	for _, c := range byComponent {
+		sort.Slice(c.Events, func(i, j int) bool { return c.Events[i].Name < c.Events[j].Name })
+		components = append(components, *c)
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i].Component < components[j].Component })
+
+	return pkgName, components, nil
+}
+
+// scanHandler checks that fn is a Handle*(socket, payload) method and collects its payload keys
+func scanHandler(fn *ast.FuncDecl) (Event, bool) {
+	name := fn.Name.Name
+	if !strings.HasPrefix(name, "Handle") || name == "HandleEvent" || len(name) == len("Handle") {
+		return Event{}, false
+	}
+
+	params := fn.Type.Params.List
+	if len(params) != 2 || len(params[0].Names) != 1 || len(params[1].Names) != 1 {
+		return Event{}, false
+	}
+	if !isSocketType(params[0].Type) || !isPayloadType(params[1].Type) {
+		return Event{}, false
+	}
+
+	payloadName := params[1].Names[0].Name
+	suffix := strings.TrimPrefix(name, "Handle")
+	event := Event{
+		Name:   strings.ToLower(suffix[:1]) + suffix[1:],
+		Method: name,
+	}
+
+	seen := make(map[string]int)
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		var goType string
+		index, ok := n.(*ast.IndexExpr)
+		if assert, isAssert := n.(*ast.TypeAssertExpr); isAssert {
+			index, ok = assert.X.(*ast.IndexExpr)
+			if assert.Type != nil {
+				goType = exprString(assert.Type)
+			}
+		}
+		if !ok {
+			return true
+		}
+
+		ident, ok := index.X.(*ast.Ident)
+		if !ok || ident.Name != payloadName {
+			return true
+		}
+		lit, ok := index.Index.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		key, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return true
+		}
+
+		if i, exists := seen[key]; exists {
+			if event.Payload[i].GoType == "" {
+				event.Payload[i].GoType = goType
+			}
+			return true
+		}
+		seen[key] = len(event.Payload)
+		event.Payload = append(event.Payload, PayloadField{Key: key, GoType: goType})
+		return true
+	})
+
+	sort.Slice(event.Payload, func(i, j int) bool { return event.Payload[i].Key < event.Payload[j].Key })
+	return event, true
+}
+
+// isSocketType matches *Socket and *liveview.Socket
+func isSocketType(expr ast.Expr) bool {
+	star, ok := expr.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	s := exprString(star.X)
+	return s == "Socket" || strings.HasSuffix(s, ".Socket")
+}
+
+// isPayloadType matches map[string]interface{} and map[string]any
+func isPayloadType(expr ast.Expr) bool {
+	s := exprString(expr)
+	return s == "map[string]interface{}" || s == "map[string]any"
+}
+
+// receiverName returns the type name of a method receiver
+func receiverName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if index, ok := expr.(*ast.IndexExpr); ok {
+		expr = index.X
+	}
+	return exprString(expr)
+}
+
+// exprString renders a type expression back to source
+func exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), expr); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// constPrefix derives the constant prefix from a component type name
+func constPrefix(component string) string {
+	trimmed := strings.TrimSuffix(component, "Component")
+	if trimmed == "" {
+		trimmed = component
+	}
+	return "Event" + strings.ToUpper(trimmed[:1]) + trimmed[1:]
+}
+
+// GenerateGo emits typed event name constants for the scanned components
+func GenerateGo(pkgName string, components []ComponentEvents) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by lvgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+
+	for _, c := range components {
+		fmt.Fprintf(&buf, "// Events handled by %s\nconst (\n", c.Component)
+		for _, e := range c.Events {
+			fmt.Fprintf(&buf, "\t%s%s = %q\n", constPrefix(c.Component), strings.TrimPrefix(e.Method, "Handle"), e.Name)
+		}
+		buf.WriteString(")\n\n")
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// GenerateTypeScript emits event names and payload interfaces for the client
+func GenerateTypeScript(components []ComponentEvents) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by lvgen. DO NOT EDIT.\n\n")
+
+	for _, c := range components {
+		fmt.Fprintf(&buf, "export const %sEvents = {\n", c.Component)
+		for _, e := range c.Events {
+			fmt.Fprintf(&buf, "  %s: %q,\n", strings.TrimPrefix(e.Method, "Handle"), e.Name)
+		}
+		buf.WriteString("} as const;\n\n")
+
+		for _, e := range c.Events {
+			fmt.Fprintf(&buf, "export interface %s%sPayload {\n", c.Component, strings.TrimPrefix(e.Method, "Handle"))
+			for _, p := range e.Payload {
+				fmt.Fprintf(&buf, "  %q?: %s;\n", p.Key, tsType(p.GoType))
+			}
+			buf.WriteString("}\n\n")
+		}
+
+		fmt.Fprintf(&buf, "export interface %sEventMap {\n", c.Component)
+		for _, e := range c.Events {
+			fmt.Fprintf(&buf, "  %q: %s%sPayload;\n", e.Name, c.Component, strings.TrimPrefix(e.Method, "Handle"))
+		}
+		buf.WriteString("}\n\n")
+	}
+
+	return buf.Bytes()
+}
+
+// tsType maps a Go type assertion to a TypeScript type
+func tsType(goType string) string {
+	switch goType {
+	case "string":
+		return "string"
+	case "bool":
+		return "boolean"
+	case "float64", "float32", "int", "int64", "int32":
+		return "number"
+	case "[]interface{}", "[]any":
+		return "unknown[]"
+	case "map[string]interface{}", "map[string]any":
+		return "Record<string, unknown>"
+	default:
+		return "unknown"
+	}
+}
+
+// WriteFiles scans dir and writes the Go and (optionally) TypeScript outputs
+func WriteFiles(dir, goOut, tsOut string) error {
+	pkgName, components, err := ScanDir(dir)
+	if err != nil {
+		return err
+	}
+	if pkgName == "" {
+		return fmt.Errorf("no Go package found in %s", dir)
+	}
+
+	if goOut != "" {
+		src, err := GenerateGo(pkgName, components)
+		if err != nil {
+			return err
+		}
+		if !filepath.IsAbs(goOut) && filepath.Dir(goOut) == "." {
+			goOut = filepath.Join(dir, goOut)
+		}
+		if err := os.WriteFile(goOut, src, 0644); err != nil {
+			return err
+		}
+	}
+
+	if tsOut != "" {
+		if err := os.WriteFile(tsOut, GenerateTypeScript(components), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// eventAttrPattern matches event binding attributes such as lv-click="increment"
+var eventAttrPattern = regexp.MustCompile(`lv-(?:click|change|submit|keyup|keydown|blur|focus)="([A-Za-z0-9_\-]+)"`)
+
+// CheckBindings reports event bindings in templates and Go sources under dir
+// that don't match any known handler
+func CheckBindings(dir string, components []ComponentEvents) ([]string, error) {
+	known := make(map[string]bool)
+	for _, c := range components {
+		for _, e := range c.Events {
+			known[normalizeEventName(e.Name)] = true
+		}
+	}
+
+	var problems []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if info.IsDir() || (ext != ".html" && ext != ".tmpl" && ext != ".go") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		for i, line := range strings.Split(string(data), "\n") {
+			for _, m := range eventAttrPattern.FindAllStringSubmatch(line, -1) {
+				if !known[normalizeEventName(m[1])] {
+					problems = append(problems, fmt.Sprintf("%s:%d: no handler for event %q", path, i+1, m[1]))
+				}
+			}
+		}
+		return nil
+	})
+
+	return problems, err
+}
+
+// normalizeEventName lowercases a name and strips word separators, as LiveView does
+// when routing events, so "clear-completed" and "clear_completed" match clearCompleted
+func normalizeEventName(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r == '_' || r == '-' || r == ' ' || r == ':' || r == '.' {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
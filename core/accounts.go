@@ -0,0 +1,679 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paulmanoni/livenest/i18n"
+	"github.com/paulmanoni/livenest/liveview"
+	"github.com/paulmanoni/livenest/mail"
+)
+
+// ErrAccountNotFound is returned by an AccountStore for an unknown account
+var ErrAccountNotFound = errors.New("account not found")
+
+// ErrInvalidToken is returned for a reset or verification link that is malformed, has
+// expired or was already used
+var ErrInvalidToken = errors.New("invalid or expired link")
+
+// errWeakAccountKey refuses links signed with an empty or default secret, which anyone could forge
+var errWeakAccountKey = errors.New("account links need a random secret_key")
+
+// Account is a user account as the account flows see it
+type Account struct {
+	ID            string
+	Email         string
+	Name          string // greets the user in emails, if set
+	PasswordHash  string // reset links stop working once it changes, so each works once
+	EmailVerified bool
+}
+
+// AccountStore gives the account flows access to the app's users, e.g. a users table
+type AccountStore interface {
+	// FindAccountByEmail returns the account of an email address, or ErrAccountNotFound
+	FindAccountByEmail(ctx context.Context, email string) (*Account, error)
+	// FindAccount returns the account with an ID, or ErrAccountNotFound
+	FindAccount(ctx context.Context, id string) (*Account, error)
+	// SetPasswordHash replaces the password of an account with a hash made by HashPassword
+	SetPasswordHash(ctx context.Context, id, hash string) error
+	// MarkEmailVerified records that the account's email address is confirmed
+	MarkEmailVerified(ctx context.Context, id string) error
+}
+
+// ForgotPasswordForm is the form of the forgot password page
+type ForgotPasswordForm struct {
+	Email string `form:"label:Email;type:email;placeholder:you@example.com" validate:"required;email"`
+}
+
+// ResetPasswordForm is the form of the reset password page
+type ResetPasswordForm struct {
+	Password string `form:"label:New password;type:password" validate:"required;min:8"`
+	Confirm  string `form:"label:Confirm new password;type:password" validate:"required"`
+}
+
+// Token purposes, so a link of one flow can't be used in the other
+const (
+	purposeReset  = "reset"
+	purposeVerify = "verify"
+)
+
+// Accounts runs the password reset and email verification flows of an AccountStore
+// Links carry signed tokens, so they need no storage and work on every node
+type Accounts struct {
+	app            *App
+	store          AccountStore
+	prefix         string
+	baseURL        string
+	resetTTL       time.Duration
+	verifyTTL      time.Duration
+	resendInterval time.Duration
+	afterReset     string
+	afterVerify    string
+	resetTemplate  string
+	verifyTemplate string
+	onReset        func(*liveview.Socket, *Account) error
+	onVerified     func(*gin.Context, *Account) error
+	layout         liveview.Layout
+	rateLimit      *RateLimit
+	key            []byte
+	weakKey        bool // the SecretKey is empty or the default outside debug mode
+}
+
+// AccountsBuilder provides a fluent API for the account flows
+type AccountsBuilder struct {
+	accounts *Accounts
+}
+
+// NewAccounts starts configuring the account flows of store: a forgot password page
+// that emails a reset link, the reset password page it leads to, and the link that
+// verifies an email address. Build mounts them
+//
+//	accounts := app.NewAccounts(users).
+//		BaseURL("https://shop.example.com").
+//		AfterReset("/login").
+//		Build()
+func (a *App) NewAccounts(store AccountStore) *AccountsBuilder {
+	key := sha256.Sum256([]byte("livenest-accounts:" + a.config.SecretKey))
+	return &AccountsBuilder{accounts: &Accounts{
+		app:            a,
+		store:          store,
+		prefix:         "/account",
+		resetTTL:       time.Hour,
+		verifyTTL:      72 * time.Hour,
+		resendInterval: time.Minute,
+		afterReset:     "/",
+		afterVerify:    "/",
+		resetTemplate:  "account/reset_password.html",
+		verifyTemplate: "account/verify_email.html",
+		key:            key[:],
+		weakKey:        weakSecret(a.config.SecretKey) && !a.config.Debug,
+	}}
+}
+
+// Prefix sets the path the pages are mounted under (default "/account"):
+// <prefix>/forgot-password, <prefix>/reset-password and <prefix>/verify-email
+func (b *AccountsBuilder) Prefix(prefix string) *AccountsBuilder {
+	b.accounts.prefix = strings.TrimSuffix(prefix, "/")
+	return b
+}
+
+// BaseURL sets the scheme and host of the links in emails, e.g. "https://shop.example.com"
+// It is required outside debug mode: the host of a request can be forged, which would
+// send valid links to another site
+func (b *AccountsBuilder) BaseURL(baseURL string) *AccountsBuilder {
+	b.accounts.baseURL = strings.TrimSuffix(baseURL, "/")
+	return b
+}
+
+// ResetTTL sets how long a reset link works (default 1h)
+func (b *AccountsBuilder) ResetTTL(ttl time.Duration) *AccountsBuilder {
+	b.accounts.resetTTL = ttl
+	return b
+}
+
+// VerifyTTL sets how long a verification link works (default 72h)
+func (b *AccountsBuilder) VerifyTTL(ttl time.Duration) *AccountsBuilder {
+	b.accounts.verifyTTL = ttl
+	return b
+}
+
+// ResendInterval sets the least time between two reset emails to an account (default
+// 1m), so the forgot password page can't flood a mailbox; 0 disables the limit
+func (b *AccountsBuilder) ResendInterval(interval time.Duration) *AccountsBuilder {
+	b.accounts.resendInterval = interval
+	return b
+}
+
+// AfterReset sets the page shown once the password is changed (default "/"), e.g. "/login"
+func (b *AccountsBuilder) AfterReset(path string) *AccountsBuilder {
+	b.accounts.afterReset = path
+	return b
+}
+
+// AfterVerify sets the page a verification link leads to (default "/")
+func (b *AccountsBuilder) AfterVerify(path string) *AccountsBuilder {
+	b.accounts.afterVerify = path
+	return b
+}
+
+// ResetTemplate sets the mailer template of the reset email (default
+// "account/reset_password.html"); a built-in email is sent while it doesn't exist
+func (b *AccountsBuilder) ResetTemplate(name string) *AccountsBuilder {
+	b.accounts.resetTemplate = name
+	return b
+}
+
+// VerifyTemplate sets the mailer template of the verification email (default
+// "account/verify_email.html"); a built-in email is sent while it doesn't exist
+func (b *AccountsBuilder) VerifyTemplate(name string) *AccountsBuilder {
+	b.accounts.verifyTemplate = name
+	return b
+}
+
+// OnPasswordReset sets a function called once a password is changed, e.g. to log the
+// user in or end their other sessions
+func (b *AccountsBuilder) OnPasswordReset(fn func(socket *liveview.Socket, account *Account) error) *AccountsBuilder {
+	b.accounts.onReset = fn
+	return b
+}
+
+// OnEmailVerified sets a function called once an email address is verified, e.g. to log
+// the user in; it runs only for the visit that verifies the address
+func (b *AccountsBuilder) OnEmailVerified(fn func(c *gin.Context, account *Account) error) *AccountsBuilder {
+	b.accounts.onVerified = fn
+	return b
+}
+
+// WithLayout sets the page layout of the forgot and reset password pages
+func (b *AccountsBuilder) WithLayout(layout liveview.Layout) *AccountsBuilder {
+	b.accounts.layout = layout
+	return b
+}
+
+// RateLimit limits the requests to each page to limit per window for each client IP
+func (b *AccountsBuilder) RateLimit(limit int, window time.Duration) *AccountsBuilder {
+	b.accounts.rateLimit = &RateLimit{Limit: limit, Window: window}
+	return b
+}
+
+// Build mounts the pages and returns the flows, e.g. to send verification emails from a
+// registration form
+// Emails go through the app's mailer and flashes need sessions: call EnableMailer and
+// EnableSessions first
+func (b *AccountsBuilder) Build() *Accounts {
+	acc := b.accounts
+	app := acc.app
+	if acc.baseURL == "" && !app.config.Debug {
+		app.Logger().Error("Account emails not sent until the accounts' BaseURL is set")
+	}
+	if acc.weakKey {
+		app.Logger().Error("Account links disabled: set a random secret_key")
+	}
+
+	for _, page := range []struct {
+		path, route, name string
+		component         liveview.Component
+	}{
+		{acc.ForgotPasswordPath(), "account.forgot_password", "account_forgot_password", acc.ForgotPasswordComponent()},
+		{acc.ResetPasswordPath(), "account.reset_password", "account_reset_password", acc.ResetPasswordComponent()},
+	} {
+		route := app.NewHandler().Path(page.path).Name(page.route).AsLive()
+		if acc.layout != nil {
+			route.WithLayout(acc.layout)
+		}
+		if acc.rateLimit != nil {
+			route.RateLimit(acc.rateLimit.Limit, acc.rateLimit.Window)
+		}
+		route.AddComponent(page.component).WithName(page.name).Build()
+	}
+
+	for _, verify := range []*HandlerBuilder{
+		app.NewHandler().Path(acc.VerifyEmailPath()).Name("account.verify_email").Func(acc.VerifyEmailHandler()),
+		app.NewHandler().Path(acc.VerifyEmailPath()).AsPost().Func(acc.VerifyEmailHandler()),
+	} {
+		if acc.rateLimit != nil {
+			verify.RateLimit(acc.rateLimit.Limit, acc.rateLimit.Window)
+		}
+		verify.Build()
+	}
+
+	changed := app.NewHandler().Path(acc.PasswordChangedPath()).Name("account.password_changed").Func(acc.passwordChangedHandler())
+	if acc.rateLimit != nil {
+		changed.RateLimit(acc.rateLimit.Limit, acc.rateLimit.Window)
+	}
+	changed.Build()
+	return acc
+}
+
+// ForgotPasswordPath returns the path of the forgot password page
+func (acc *Accounts) ForgotPasswordPath() string {
+	return acc.prefix + "/forgot-password"
+}
+
+// ResetPasswordPath returns the path of the reset password page
+func (acc *Accounts) ResetPasswordPath() string {
+	return acc.prefix + "/reset-password"
+}
+
+// PasswordChangedPath returns the path a reset password form redirects through on its
+// way to the AfterReset page
+func (acc *Accounts) PasswordChangedPath() string {
+	return acc.prefix + "/password-changed"
+}
+
+// VerifyEmailPath returns the path of verification links
+func (acc *Accounts) VerifyEmailPath() string {
+	return acc.prefix + "/verify-email"
+}
+
+// ForgotPasswordComponent returns the form that emails a reset link, for mounting on a
+// page of your own
+// It answers the same whether or not an account uses the address, so it can't be used to
+// find out who has an account
+func (acc *Accounts) ForgotPasswordComponent() *liveview.FormComponent[ForgotPasswordForm] {
+	return liveview.NewFormComponent[ForgotPasswordForm]("Forgot your password?").
+		WithSubmitText("Send reset link").
+		WithoutReset().
+		WithSuccessMessage("account.forgot_password.sent", "If an account uses that address, we have sent it a link to reset the password").
+		OnSubmit(func(socket *liveview.Socket, data *ForgotPasswordForm) error {
+			account, err := acc.store.FindAccountByEmail(socket.Context(), strings.TrimSpace(data.Email))
+			if errors.Is(err, ErrAccountNotFound) {
+				return nil
+			}
+			if err != nil {
+				acc.app.Logger().Error("Account lookup failed", "error", err)
+				return errors.New(lookupText(socket.Localizer(), "account.error", "Something went wrong, please try again"))
+			}
+			if err := acc.SendPasswordReset(socket, account); err != nil {
+				acc.app.Logger().Error("Password reset email not sent", "account", account.ID, "error", err)
+			}
+			return nil
+		})
+}
+
+// ResetPasswordComponent returns the form that sets a new password with the token of a
+// reset link, for mounting on a page of your own; it reads the token from the page's
+// token query parameter
+func (acc *Accounts) ResetPasswordComponent() *liveview.FormComponent[ResetPasswordForm] {
+	return liveview.NewFormComponent[ResetPasswordForm]("Choose a new password").
+		WithSubmitText("Change password").
+		WithoutReset().
+		WithSuccessMessage("account.reset_password.done", "Your password has been changed").
+		WithInitial(func(socket *liveview.Socket) (ResetPasswordForm, error) {
+			// Check the link before asking for a password it can't set
+			if _, err := acc.checkToken(socket.Context(), purposeReset, socket.Params.Get("token")); err != nil {
+				acc.rejectLink(socket, err)
+			}
+			return ResetPasswordForm{}, nil
+		}).
+		AddRule("Confirm", func(data *ResetPasswordForm) error {
+			if data.Confirm != data.Password {
+				return &liveview.ValidationError{Key: "account.reset_password.mismatch", Message: "The passwords don't match"}
+			}
+			return nil
+		}).
+		OnSubmit(func(socket *liveview.Socket, data *ResetPasswordForm) error {
+			ctx := socket.Context()
+			account, err := acc.checkToken(ctx, purposeReset, socket.Params.Get("token"))
+			if err != nil {
+				// The form flashes the error, on the forgot password page when it was the link
+				if !errors.Is(err, ErrInvalidToken) {
+					acc.app.Logger().Error("Reset link not checked", "error", err)
+					return errors.New(lookupText(socket.Localizer(), "account.error", "Something went wrong, please try again"))
+				}
+				socket.Redirect(acc.ForgotPasswordPath())
+				return errors.New(lookupText(socket.Localizer(), "account.invalid_link", "This link is invalid or has expired"))
+			}
+			hash, err := HashPassword(data.Password)
+			if err == nil {
+				err = acc.store.SetPasswordHash(ctx, account.ID, hash)
+			}
+			if err != nil {
+				acc.app.Logger().Error("Password not reset", "account", account.ID, "error", err)
+				return errors.New(lookupText(socket.Localizer(), "account.error", "Something went wrong, please try again"))
+			}
+			account.PasswordHash = hash
+			if acc.onReset != nil {
+				if err := acc.onReset(socket, account); err != nil {
+					return err
+				}
+			}
+			// The session ID is renewed on the way, since the WebSocket can't set cookies
+			socket.Redirect(acc.PasswordChangedPath())
+			return nil
+		})
+}
+
+// rejectLink sends the visitor of an unusable reset link back to the forgot password page
+func (acc *Accounts) rejectLink(socket *liveview.Socket, err error) {
+	if !errors.Is(err, ErrInvalidToken) {
+		acc.app.Logger().Error("Reset link not checked", "error", err)
+	}
+	socket.PutFlash(liveview.FlashError, lookupText(socket.Localizer(), "account.invalid_link", "This link is invalid or has expired"))
+	socket.Redirect(acc.ForgotPasswordPath())
+}
+
+// passwordChangedHandler renews the session ID after a password reset, so a session
+// planted before it doesn't carry over, and redirects to the AfterReset page
+func (acc *Accounts) passwordChangedHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if session := GetSession(c); session != nil {
+			session.RenewID()
+		}
+		c.Redirect(http.StatusFound, acc.afterReset)
+	}
+}
+
+// VerifyEmailHandler returns the handler of verification links, for mounting on GET and
+// POST of a route of your own
+// A GET with the token query parameter shows a page asking to confirm the address, so
+// following the link changes nothing: mail scanners fetch links, and a verification
+// that signs the visitor in must not run from another site. The page posts the token
+// back, and a same-origin POST verifies the address and redirects to the AfterVerify
+// page with a flash saying how it went. A link stops working once used
+func (acc *Accounts) VerifyEmailHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if c.Request.Method != http.MethodPost {
+			token := c.Query("token")
+			account, err := acc.checkToken(ctx, purposeVerify, token)
+			if err == nil {
+				acc.renderVerifyPage(c, token, account)
+				return
+			}
+			acc.finishVerify(c, err)
+			return
+		}
+		if !sameOriginPost(c.Request) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		account, err := acc.checkToken(ctx, purposeVerify, c.PostForm("token"))
+		if err == nil && account.EmailVerified {
+			err = ErrInvalidToken
+		}
+		if err == nil {
+			err = acc.store.MarkEmailVerified(ctx, account.ID)
+			account.EmailVerified = err == nil
+		}
+		if err == nil && acc.onVerified != nil {
+			// OnVerified may sign the account in; a new session ID keeps a planted one out
+			if session := GetSession(c); session != nil {
+				session.RenewID()
+			}
+			err = acc.onVerified(c, account)
+		}
+		acc.finishVerify(c, err)
+	}
+}
+
+// finishVerify redirects to the AfterVerify page with a flash describing err
+func (acc *Accounts) finishVerify(c *gin.Context, err error) {
+	l := GetLocalizer(c)
+	flash := liveview.Flash{Type: liveview.FlashSuccess, Message: lookupText(l, "account.verify_email.done", "Your email address is verified")}
+	switch {
+	case errors.Is(err, ErrInvalidToken):
+		flash = liveview.Flash{Type: liveview.FlashError, Message: lookupText(l, "account.invalid_link", "This link is invalid or has expired")}
+	case err != nil:
+		acc.app.Logger().Error("Email not verified", "error", err)
+		flash = liveview.Flash{Type: liveview.FlashError, Message: lookupText(l, "account.error", "Something went wrong, please try again")}
+	}
+	if session := GetSession(c); session != nil {
+		session.AddFlash(flash)
+	}
+	c.Redirect(http.StatusSeeOther, acc.afterVerify)
+}
+
+// renderVerifyPage shows the page confirming the address of a verification link
+func (acc *Accounts) renderVerifyPage(c *gin.Context, token string, account *Account) {
+	l := GetLocalizer(c)
+	title := lookupText(l, "account.verify_email.title", "Verify your email address")
+	var content bytes.Buffer
+	err := verifyPage.Execute(&content, map[string]interface{}{
+		"Action": acc.VerifyEmailPath(),
+		"Token":  token,
+		"Email":  account.Email,
+		"Title":  title,
+		"Button": lookupText(l, "account.verify_email.button", "Confirm"),
+	})
+	layout := acc.layout
+	if layout == nil {
+		layout = liveview.DefaultLayout()
+	}
+	var page bytes.Buffer
+	if err == nil {
+		html := template.HTML(content.String())
+		err = layout.RenderLayout(&page, liveview.PageData{Title: title, Content: html, LiveView: html})
+	}
+	if err != nil {
+		acc.app.Logger().Error("Verification page not rendered", "error", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Header("Referrer-Policy", "no-referrer") // the URL carries the token
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// sameOriginPost reports whether a POST came from a page of this site, by its Origin or,
+// without one, its Referer; a POST with neither is refused
+func sameOriginPost(r *http.Request) bool {
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+	u, err := url.Parse(source)
+	return source != "" && err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// SendPasswordReset emails a reset link to an account; nothing is sent within
+// ResendInterval of the last one
+// Outside of a socket pass a nil socket; links then need BaseURL
+func (acc *Accounts) SendPasswordReset(socket *liveview.Socket, account *Account) error {
+	if acc.resendInterval > 0 {
+		ctx := context.Background()
+		if socket != nil {
+			ctx = socket.Context()
+		}
+		count, _, err := acc.app.RateLimitStore().Increment(ctx, "account-reset:"+account.ID, acc.resendInterval)
+		if err != nil {
+			return err
+		}
+		if count > 1 {
+			return nil
+		}
+	}
+	return acc.send(socket, account, purposeReset)
+}
+
+// SendVerification emails a link verifying the address of an account, e.g. from the
+// submit handler of a registration form
+// Outside of a socket, e.g. in a background job, pass a nil socket; links then need BaseURL
+func (acc *Accounts) SendVerification(socket *liveview.Socket, account *Account) error {
+	return acc.send(socket, account, purposeVerify)
+}
+
+// send renders and sends the email of a flow
+// Delivery runs in the background, so a slow mail server doesn't hold up the socket and
+// the time taken doesn't tell whether an account exists
+func (acc *Accounts) send(socket *liveview.Socket, account *Account, purpose string) error {
+	mailer := acc.app.Mailer()
+	if mailer == nil {
+		return errors.New("no mailer: call EnableMailer")
+	}
+	if acc.weakKey {
+		return errWeakAccountKey
+	}
+
+	base := acc.baseURL
+	var l *i18n.Localizer
+	ctx := context.Background()
+	if socket != nil {
+		// The request's host is only trusted while developing
+		if base == "" && socket.Request != nil && acc.app.config.Debug {
+			base = acc.app.proxies.scheme(socket.Request) + "://" + socket.Request.Host
+		}
+		l = socket.Localizer()
+		ctx = socket.Context()
+	}
+	if base == "" {
+		return errors.New("account links need a BaseURL")
+	}
+
+	path, ttl, name, fallback := acc.ResetPasswordPath(), acc.resetTTL, acc.resetTemplate, resetEmail
+	subject := lookupText(l, "account.reset_password.subject", "Reset your password")
+	if purpose == purposeVerify {
+		path, ttl, name, fallback = acc.VerifyEmailPath(), acc.verifyTTL, acc.verifyTemplate, verifyEmail
+		subject = lookupText(l, "account.verify_email.subject", "Verify your email address")
+	}
+	data := map[string]interface{}{
+		"Account":      account,
+		"URL":          base + path + "?token=" + url.QueryEscape(acc.token(purpose, account, ttl)),
+		"ExpiresIn":    formatTTL(ttl),
+		i18n.AssignKey: l,
+	}
+
+	msg := &mail.Message{To: []string{account.Email}, Subject: subject}
+	if mailer.Templates != nil && mailer.Templates.Exists(name) {
+		if err := mailer.Render(msg, name, data); err != nil {
+			return err
+		}
+	} else {
+		var buf bytes.Buffer
+		if err := fallback.Execute(&buf, data); err != nil {
+			return err
+		}
+		msg.HTML = buf.String()
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+		defer cancel()
+		if err := mailer.Send(ctx, msg); err != nil {
+			acc.app.Logger().Error("Account email not sent", "account", account.ID, "purpose", purpose, "error", err)
+		}
+	}()
+	return nil
+}
+
+// accountToken is the signed content of a link
+type accountToken struct {
+	Purpose string `json:"p"`
+	Account string `json:"a"`
+	Expires int64  `json:"e"` // unix seconds
+	State   string `json:"s"` // fingerprint of what the link is for, see fingerprint
+}
+
+// token returns a signed token for a link of purpose to account, valid for ttl
+func (acc *Accounts) token(purpose string, account *Account, ttl time.Duration) string {
+	payload, _ := json.Marshal(accountToken{
+		Purpose: purpose,
+		Account: account.ID,
+		Expires: time.Now().Add(ttl).Unix(),
+		State:   acc.fingerprint(purpose, account),
+	})
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(acc.sign(encoded))
+}
+
+// checkToken returns the account of a valid token for a link of purpose
+func (acc *Accounts) checkToken(ctx context.Context, purpose, token string) (*Account, error) {
+	if acc.weakKey {
+		return nil, errWeakAccountKey
+	}
+	encoded, signature, ok := strings.Cut(token, ".")
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if !ok || err != nil || !hmac.Equal(mac, acc.sign(encoded)) {
+		return nil, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	var t accountToken
+	if err != nil || json.Unmarshal(payload, &t) != nil || t.Purpose != purpose || time.Now().Unix() > t.Expires {
+		return nil, ErrInvalidToken
+	}
+
+	account, err := acc.store.FindAccount(ctx, t.Account)
+	if errors.Is(err, ErrAccountNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(t.State), []byte(acc.fingerprint(purpose, account))) {
+		return nil, ErrInvalidToken
+	}
+	return account, nil
+}
+
+// fingerprint identifies the state a link is for: the password a reset link replaces,
+// or the unverified address a verification link confirms, so either stops working once used
+func (acc *Accounts) fingerprint(purpose string, account *Account) string {
+	state := account.PasswordHash
+	if purpose == purposeVerify {
+		state = strings.ToLower(account.Email) + "\x00" + strconv.FormatBool(account.EmailVerified)
+	}
+	return base64.RawURLEncoding.EncodeToString(acc.sign(purpose + "\x00" + state)[:12])
+}
+
+// sign returns the MAC of s under the app's secret
+func (acc *Accounts) sign(s string) []byte {
+	mac := hmac.New(sha256.New, acc.key)
+	mac.Write([]byte(s))
+	return mac.Sum(nil)
+}
+
+// lookupText returns the message of key, or def when the localizer has none
+func lookupText(l *i18n.Localizer, key, def string) string {
+	if msg, ok := l.Lookup(key); ok {
+		return msg
+	}
+	return def
+}
+
+// formatTTL describes how long a link works, e.g. "1 hour" or "3 days"
+func formatTTL(ttl time.Duration) string {
+	count, unit := int(ttl/time.Minute), "minute"
+	switch {
+	case ttl >= 24*time.Hour && ttl%(24*time.Hour) == 0:
+		count, unit = int(ttl/(24*time.Hour)), "day"
+	case ttl >= time.Hour && ttl%time.Hour == 0:
+		count, unit = int(ttl/time.Hour), "hour"
+	}
+	if count != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", count, unit)
+}
+
+// resetEmail is the reset email sent while the app has no template for it
+var resetEmail = template.Must(template.New("reset").Parse(`<p>Hello{{with .Account.Name}} {{.}}{{end}},</p>
+<p>We received a request to reset the password of your account. Follow the link below to choose a new one; it works for {{.ExpiresIn}}.</p>
+<p><a href="{{.URL}}">Reset your password</a></p>
+<p>If you didn't ask for this, you can ignore this email and your password stays the same.</p>`))
+
+// verifyPage is the content of the page a verification link leads to
+var verifyPage = template.Must(template.New("verify-page").Parse(`<form method="post" action="{{.Action}}" class="lv-account-verify">
+<h1>{{.Title}}</h1>
+<p>{{.Email}}</p>
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">{{.Button}}</button>
+</form>`))
+
+// verifyEmail is the verification email sent while the app has no template for it
+var verifyEmail = template.Must(template.New("verify").Parse(`<p>Hello{{with .Account.Name}} {{.}}{{end}},</p>
+<p>Please confirm that this is your email address by following the link below; it works for {{.ExpiresIn}}.</p>
+<p><a href="{{.URL}}">Verify your email address</a></p>
+<p>If you didn't create an account, you can ignore this email.</p>`))
@@ -1,9 +1,20 @@
 package core
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"html/template"
+	"io/fs"
+	"net/url"
+	"sync"
+	"time"
 
+	"github.com/paulmanoni/livenest/cron"
+	"github.com/paulmanoni/livenest/jobs"
 	"github.com/paulmanoni/livenest/liveview"
+	"github.com/paulmanoni/livenest/mail"
+	"github.com/paulmanoni/livenest/orm"
+	"github.com/paulmanoni/livenest/pubsub"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -13,9 +24,36 @@ import (
 type App struct {
 	Router        *gin.Engine
 	DB            *gorm.DB
+	sqlDB         *sql.DB // set by ConnectConfiguredDB in the sql mode
 	config        *Config
 	lvHandler     *liveview.Handler
 	webComponents map[string]liveview.WebComponentConfig
+	migrations    []orm.Migration
+	indexAdvisor  *orm.IndexAdvisor
+	logger        liveview.Logger
+	proxies       trustedProxies // server.trusted_proxies
+	resilience    *orm.Resilience
+	outbox        *orm.OutboxRelay
+	events        *orm.EventStore
+	workflows     *orm.WorkflowEngine
+	jobs          *jobs.Runner
+	mailer        *mail.Mailer
+	sessions      *orm.SessionTable
+	assets        *StaticAssets
+	pubsub        pubsub.PubSub
+	pubsubMu      sync.Mutex // guards pubsub, created on first use
+
+	routes   map[string]string // paths by route name, see NameRoute
+	routesMu sync.RWMutex
+
+	rateLimitStore RateLimitStore
+	rateLimitMu    sync.Mutex // guards rateLimitStore, created on first use
+
+	scheduler   *cron.Scheduler
+	schedulerMu sync.Mutex // guards scheduler, created on first use
+
+	cancelWorkers context.CancelFunc // stops the background workers started by start
+	workers       sync.WaitGroup
 }
 
 // New creates a new LiveNest application
@@ -30,12 +68,91 @@ func New(config *Config) *App {
 	}
 
 	app := &App{
-		Router: gin.Default(),
+		Router: gin.New(),
 		config: config,
 	}
 
+	// Request IDs and logging go first, since middleware only applies to routes
+	// registered after it, and the LiveView WebSocket is registered below
+	app.Router.Use(RequestIDMiddleware())
+	if config.AccessLog {
+		app.Router.Use(accessLog(app.Logger))
+	} else {
+		app.Router.Use(gin.Logger())
+	}
+	app.Router.Use(gin.Recovery())
+	// Gin trusts X-Forwarded-For from everyone unless told otherwise, which lets clients
+	// pick the IP that rate limits and logs see
+	trusted := config.Server.TrustedProxies
+	proxies, err := parseTrustedProxies(trusted)
+	if err != nil {
+		app.Logger().Error("Invalid trusted_proxies", "error", err)
+		trusted = nil
+	}
+	app.proxies = proxies
+	app.Router.SetTrustedProxies(trusted)
+	if len(config.CORS.AllowOrigins) > 0 {
+		app.Router.Use(CORSMiddleware(config.CORS))
+	}
+
 	// Serve LiveNest static files
 	app.setupLiveNestStatic()
+	if logger := configLogger(config.LogFormat); logger != nil {
+		app.SetLogger(logger)
+	}
+	if config.Profiling {
+		if config.ProfilingToken == "" {
+			app.Logger().Warn("Profiling endpoints not mounted: set profiling_token")
+		} else {
+			app.EnableProfiling(ProfilingTokenAuth(config.ProfilingToken))
+		}
+	}
+	if config.Metrics {
+		if config.MetricsToken != "" {
+			app.EnableMetrics(ProfilingTokenAuth(config.MetricsToken))
+		} else {
+			app.EnableMetrics()
+		}
+	}
+	app.lvHandler.SetStrictCSP(config.StrictCSP)
+	app.lvHandler.SetStrictEvents(config.StrictEvents)
+	app.lvHandler.SetDebug(config.Debug)
+	app.lvHandler.SetNoJSAudit(config.NoJSAudit)
+	app.lvHandler.SetFormSecret(config.SecretKey)
+	app.lvHandler.SetAllowedOrigins(websocketOrigins(config.CORS.AllowOrigins))
+	app.lvHandler.SetLoadingTimeout(time.Duration(config.LoadingTimeout) * time.Millisecond)
+	app.lvHandler.SetEventTimeout(time.Duration(config.EventTimeout) * time.Millisecond)
+	app.lvHandler.SetPendingSocketTTL(time.Duration(config.PendingSocketTTL) * time.Millisecond)
+	app.lvHandler.SetSlowRenderThreshold(time.Duration(config.SlowRenderThreshold) * time.Millisecond)
+	app.lvHandler.SetLargePayloadThreshold(config.LargePayloadThreshold)
+	app.lvHandler.SetMessageLimits(liveview.MessageLimits{
+		MaxBytes: config.MaxMessageBytes,
+		MaxKeys:  config.MaxPayloadKeys,
+		MaxDepth: config.MaxPayloadDepth,
+	})
+	if config.ConnectsPerMinute > 0 || config.MaxSocketsPerClient > 0 || config.MaxSocketConnections > 0 {
+		app.lvHandler.SetConnectionLimits(liveview.ConnectionLimits{
+			ConnectsPerMinute: config.ConnectsPerMinute,
+			MaxPerClient:      config.MaxSocketsPerClient,
+			MaxConnections:    config.MaxSocketConnections,
+			KeyFunc:           app.proxies.clientIP, // the client IP rate limits see
+		})
+	}
+	app.lvHandler.SetReconnectPolicy(liveview.ReconnectPolicy{
+		MinDelay:    time.Duration(config.ReconnectMinDelay) * time.Millisecond,
+		MaxDelay:    time.Duration(config.ReconnectMaxDelay) * time.Millisecond,
+		MaxAttempts: config.ReconnectMaxAttempts,
+	})
+	if config.LocalesDir != "" {
+		if _, err := app.EnableI18n(); err != nil {
+			app.Logger().Error("Translations not loaded", "dir", config.LocalesDir, "error", err)
+		}
+	}
+	if config.Mail.Provider != "" || config.Mail.Host != "" {
+		if _, err := app.EnableMailer(); err != nil {
+			app.Logger().Error("Mailer not enabled", "provider", config.Mail.Provider, "error", err)
+		}
+	}
 
 	return app
 }
@@ -48,10 +165,8 @@ func (a *App) setupLiveNestStatic() {
 	}
 
 	// Serve embedded LiveView JavaScript (includes component tag)
-	a.Router.GET("/livenest/liveview.js", func(c *gin.Context) {
-		c.Header("Content-Type", "application/javascript")
-		c.String(200, liveview.GetLiveViewJS())
-	})
+	a.Router.GET(liveview.LiveViewJSPath, a.lvHandler.HandleLiveViewJS)
+	a.Router.GET(liveview.LiveViewJSPath+".map", a.lvHandler.HandleLiveViewJSMap)
 
 	// Serve web components JavaScript
 	a.Router.GET("/livenest/components.js", func(c *gin.Context) {
@@ -59,8 +174,117 @@ func (a *App) setupLiveNestStatic() {
 		c.String(200, a.GetWebComponentsJS())
 	})
 
+	// Serve the JS and CSS bundles of LiveView routes
+	a.Router.GET(liveview.AssetPath+":file", a.lvHandler.HandleAsset)
+
+	// Shared WebSocket for every LiveView container on a page
+	a.Router.GET("/live/ws", a.lvHandler.HandleMultiplexWebSocket)
+
+	// Readiness: database reachable and no pending migrations
+	a.Router.GET("/readyz", a.handleReadyz)
+
 	// Handle component tag requests
 	a.Router.GET("/livenest/component/:name", a.lvHandler.HandleComponentTag)
+
+	// Serve the component catalog in debug mode
+	if a.config.Debug {
+		a.Router.GET("/livenest/catalog", func(c *gin.Context) {
+			c.JSON(200, a.Catalog())
+		})
+	}
+}
+
+// SetAssetFS reads the JS and CSS assets of components from fsys, e.g. an embed.FS
+func (a *App) SetAssetFS(fsys fs.FS) {
+	a.lvHandler.SetAssetFS(fsys)
+}
+
+// SetLayout sets the default page layout for all LiveView routes
+func (a *App) SetLayout(layout liveview.Layout) {
+	a.lvHandler.SetLayout(layout)
+}
+
+// SetAppAssign sets an assign shared by every LiveView component
+// Connected components re-render when the value changes
+func (a *App) SetAppAssign(key string, value interface{}) {
+	a.lvHandler.SetAppAssign(key, value)
+}
+
+// SetAppAssigns sets several shared assigns at once
+func (a *App) SetAppAssigns(assigns map[string]interface{}) {
+	a.lvHandler.SetAppAssigns(assigns)
+}
+
+// RefreshAppAssigns recomputes per-socket shared assigns on every connected component
+func (a *App) RefreshAppAssigns(keys ...string) {
+	a.lvHandler.RefreshAppAssigns(keys...)
+}
+
+// OnMount adds a hook run before every LiveView component mounts, over HTTP and WebSocket
+// alike, e.g. to assign the current user, locale or tenant set by middleware
+func (a *App) OnMount(hook liveview.MountHook) {
+	a.lvHandler.OnMount(hook)
+}
+
+// RenderLive renders a registered component as an extra LiveView container for a page
+// All containers on a page share one WebSocket connection
+func (a *App) RenderLive(name string, c *gin.Context, nonce string) (template.HTML, error) {
+	return a.lvHandler.RenderLive(name, c, nonce)
+}
+
+// RecordSessions captures live sessions of a component for replaying with Replay
+func (a *App) RecordSessions(name string, save func(*liveview.Recording)) {
+	a.lvHandler.RecordSessions(name, save)
+}
+
+// Replay benchmarks a component by replaying a recorded session across simulated sockets
+func (a *App) Replay(ctx context.Context, rec *liveview.Recording, opts liveview.ReplayOptions) (liveview.ReplayReport, error) {
+	return a.lvHandler.Replay(ctx, rec, opts)
+}
+
+// Transcript mounts a component, sends it events in order and records what each step
+// changed, for tests to compare with a golden file
+func (a *App) Transcript(ctx context.Context, name string, params url.Values, events ...liveview.ScriptedEvent) (*liveview.Transcript, error) {
+	return a.lvHandler.Transcript(ctx, name, params, events...)
+}
+
+// Catalog returns documentation for all registered LiveView components
+func (a *App) Catalog() []liveview.ComponentDoc {
+	return a.lvHandler.Catalog()
+}
+
+// EnableCatalogAdmin mounts the component catalog as JSON under /admin/catalog, outside
+// debug mode too. Like EnableSocketAdmin it requires at least one auth middleware:
+//
+//	GET /admin/catalog        lists every component
+//	GET /admin/catalog/:name  shows one component
+func (a *App) EnableCatalogAdmin(auth ...gin.HandlerFunc) {
+	if len(auth) == 0 {
+		a.Logger().Warn("Catalog admin not mounted: EnableCatalogAdmin requires an auth middleware")
+		return
+	}
+
+	group := a.Router.Group("/admin/catalog", auth...)
+	group.GET("", func(c *gin.Context) {
+		c.JSON(200, a.Catalog())
+	})
+	group.GET("/:name", func(c *gin.Context) {
+		for _, doc := range a.Catalog() {
+			if doc.Name == c.Param("name") {
+				c.JSON(200, doc)
+				return
+			}
+		}
+		c.JSON(404, gin.H{"error": "Component not found"})
+	})
+
+	a.Logger().Info("Catalog admin mounted", "path", "/admin/catalog")
+}
+
+// RegisterService adds a client for an external service that components reach with
+// socket.Service(name); its calls show up in /metrics
+func (a *App) RegisterService(client *liveview.ServiceClient) {
+	a.lvHandler.RegisterService(client)
 }
 
 // ConnectDB connects to the database using GORM
@@ -69,21 +293,58 @@ func (a *App) ConnectDB(dialector gorm.Dialector, opts ...gorm.Option) error {
 	if err != nil {
 		return err
 	}
+	a.useDB(db)
+	return nil
+}
 
-	a.DB = db
+// ConnectConfiguredDB connects to the database of the database config through an
+// orm.Manager. In the default "gorm" mode it sets DB as ConnectDB does; in the "sql"
+// mode DB stays nil, Query runs on database/sql and the features built on GORM are off
+func (a *App) ConnectConfiguredDB() error {
+	config := a.config.Database
+	manager, err := orm.NewManager(&orm.DatabaseConfig{
+		Driver:   config.Driver,
+		Host:     config.Host,
+		Port:     config.Port,
+		Database: config.Database,
+		Username: config.Username,
+		Password: config.Password,
+		SSLMode:  config.SSLMode,
+		Mode:     config.Mode,
+	})
+	if err != nil {
+		return err
+	}
+	if manager.SQL != nil {
+		a.sqlDB = manager.SQL
+		if a.config.DBResilience {
+			a.EnableDBResilience()
+		}
+		return nil
+	}
+	a.useDB(manager.DB)
 	return nil
 }
 
+// useDB makes db the app's database
+func (a *App) useDB(db *gorm.DB) {
+	// Show queries in the debug overlay and suggest indexes on the LiveDashboard
+	if a.config.Debug {
+		a.indexAdvisor = orm.NewIndexAdvisor(db)
+		db.Logger = queryLogger{Interface: db.Logger, advisor: a.indexAdvisor}
+	}
+
+	a.DB = db
+	if a.config.DBResilience {
+		a.EnableDBResilience()
+	}
+}
+
 // Use adds middleware to the Gin router
 func (a *App) Use(middleware ...gin.HandlerFunc) {
 	a.Router.Use(middleware...)
 }
 
-// Group creates a new router group
-func (a *App) Group(relativePath string, handlers ...gin.HandlerFunc) *gin.RouterGroup {
-	return a.Router.Group(relativePath, handlers...)
-}
-
 // GET is a shortcut for router.Handle("GET", path, handlers)
 func (a *App) GET(path string, handlers ...gin.HandlerFunc) gin.IRoutes {
 	return a.Router.GET(path, handlers...)
@@ -110,14 +371,79 @@ func (a *App) PATCH(path string, handlers ...gin.HandlerFunc) gin.IRoutes {
 }
 
 // Run starts the HTTP server
+// It serves HTTPS instead when the server config sets a certificate or autocert domains
 func (a *App) Run(addr ...string) error {
+	server := a.config.Server
 	address := ":8080"
+	if server.CertFile != "" || len(server.AutocertDomains) > 0 {
+		address = ":443"
+	}
 	if len(addr) > 0 {
 		address = addr[0]
 	}
 
-	log.Printf("LiveNest server starting on %s", address)
-	return a.Router.Run(address)
+	switch {
+	case server.CertFile != "":
+		return a.RunTLS(address, server.CertFile, server.KeyFile)
+	case len(server.AutocertDomains) > 0:
+		return a.runAutocert(address)
+	}
+
+	if err := a.start(); err != nil {
+		return err
+	}
+	a.Logger().Info("LiveNest server starting", "address", address)
+	srv := a.HTTPServer(address)
+	return a.serve(srv, srv.Serve)
+}
+
+// start validates the config, checks migrations and starts the background workers before serving
+// The workers run until stopWorkers, which serve calls once the server stops
+func (a *App) start() error {
+	if err := a.config.Validate(); err != nil {
+		return err
+	}
+	if err := a.checkMigrations(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancelWorkers = cancel
+	var workers []func(context.Context) error
+	if a.outbox != nil {
+		workers = append(workers, a.outbox.Run)
+	}
+	if a.workflows != nil {
+		workers = append(workers, a.workflows.Run)
+	}
+	if a.jobs != nil {
+		workers = append(workers, a.jobs.Run)
+	}
+	a.schedulerMu.Lock()
+	if a.scheduler != nil {
+		workers = append(workers, a.scheduler.Run)
+	}
+	a.schedulerMu.Unlock()
+	if a.sessions != nil {
+		workers = append(workers, a.sessions.Run)
+	}
+	for _, run := range workers {
+		a.workers.Add(1)
+		go func() {
+			defer a.workers.Done()
+			run(ctx)
+		}()
+	}
+	return nil
+}
+
+// stopWorkers cancels the background workers and waits for them to return, e.g. for
+// the job runner to queue its interrupted attempts again, so another process picks
+// them up right away instead of once their lease expires
+func (a *App) stopWorkers() {
+	if a.cancelWorkers != nil {
+		a.cancelWorkers()
+	}
+	a.workers.Wait()
 }
 
 // GetDB returns the GORM database instance
@@ -1,22 +1,71 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds application configuration
 type Config struct {
+	Env            string `json:"env" toml:"env"` // Profile the configuration was loaded for, see LoadProfile
 	Debug          bool   `json:"debug" toml:"debug"`
 	TemplateDir    string `json:"template_dir" toml:"template_dir"`
 	StaticDir      string `json:"static_dir" toml:"static_dir"`
-	SecretKey      string `json:"secret_key" toml:"secret_key"`
-	LiveViewSecret string `json:"liveview_secret" toml:"liveview_secret"`
+	SecretKey      string `json:"secret_key" toml:"secret_key" secret:"true"`
+	LiveViewSecret string `json:"liveview_secret" toml:"liveview_secret" secret:"true"`
+	StrictCSP      bool   `json:"strict_csp" toml:"strict_csp"`                 // Emit a nonce-based Content-Security-Policy header on LiveView pages
+	StrictEvents   bool   `json:"strict_events" toml:"strict_events"`           // Reply with an error to events that have no handler
+	NoJSAudit      bool   `json:"nojs_audit" toml:"nojs_audit"`                 // Serve pages without the live runtime and report interactions without a fallback
+	LoadingTimeout int    `json:"loading_timeout_ms" toml:"loading_timeout_ms"` // Milliseconds before the client shows its loading indicator (0 keeps the client default)
+	EventTimeout   int    `json:"event_timeout_ms" toml:"event_timeout_ms"`     // Milliseconds an event handler may run before it is abandoned (0 disables)
+
+	ReconnectMinDelay    int `json:"reconnect_min_delay_ms" toml:"reconnect_min_delay_ms"` // Milliseconds before the client's first reconnect attempt
+	ReconnectMaxDelay    int `json:"reconnect_max_delay_ms" toml:"reconnect_max_delay_ms"` // Upper bound for the reconnect backoff in milliseconds
+	ReconnectMaxAttempts int `json:"reconnect_max_attempts" toml:"reconnect_max_attempts"` // Reconnect attempts before the client gives up (0 retries forever)
+
+	Profiling      bool   `json:"profiling" toml:"profiling"`                           // Mount pprof endpoints under /debug/pprof
+	ProfilingToken string `json:"profiling_token" toml:"profiling_token" secret:"true"` // Token required by the pprof endpoints; they stay off without one
+
+	Metrics      bool   `json:"metrics" toml:"metrics"`                           // Mount Prometheus metrics at /metrics
+	MetricsToken string `json:"metrics_token" toml:"metrics_token" secret:"true"` // Bearer token required by /metrics; open when empty
+
+	PendingMigrations string `json:"pending_migrations" toml:"pending_migrations"` // PendingMigrationsWarn (default) or PendingMigrationsRefuse
+
+	LogFormat string `json:"log_format" toml:"log_format"` // LogFormatText (default) or LogFormatJSON
+	AccessLog bool   `json:"access_log" toml:"access_log"` // Log requests with their request ID to the app's logger instead of gin's request log
+
+	LocalesDir    string `json:"locales_dir" toml:"locales_dir"`       // Load translations from this directory at startup, see EnableI18n
+	DefaultLocale string `json:"default_locale" toml:"default_locale"` // Locale messages fall back to (default "en")
+
+	DBResilience bool `json:"db_resilience" toml:"db_resilience"` // Retry transient errors of App.Query and open a circuit breaker when the database is down
+
+	PubSubFile     string `json:"pubsub_file" toml:"pubsub_file"`         // Keep broadcasts in this file so they survive restarts (in memory when empty)
+	PubSubCapacity int    `json:"pubsub_capacity" toml:"pubsub_capacity"` // Broadcasts retained for replay to reconnecting sockets (0 keeps the default of 1000)
+
+	SlowRenderThreshold   int `json:"slow_render_ms" toml:"slow_render_ms"`           // Log a warning for renders slower than this many milliseconds (0 disables)
+	LargePayloadThreshold int `json:"large_payload_bytes" toml:"large_payload_bytes"` // Log a warning for pages and render messages larger than this many bytes (0 disables)
+
+	MaxMessageBytes int `json:"max_message_bytes" toml:"max_message_bytes"` // Largest client message in bytes (0 keeps the 1 MiB default)
+	MaxPayloadKeys  int `json:"max_payload_keys" toml:"max_payload_keys"`   // Object keys allowed in a client message (0 keeps the default of 1000)
+	MaxPayloadDepth int `json:"max_payload_depth" toml:"max_payload_depth"` // Nesting allowed in a client message (0 keeps the default of 32)
+
+	ConnectsPerMinute    int `json:"ws_connects_per_minute" toml:"ws_connects_per_minute"` // New WebSocket connections per client IP per minute (0 disables)
+	MaxSocketsPerClient  int `json:"ws_max_per_client" toml:"ws_max_per_client"`           // Concurrent WebSocket connections per client IP (0 disables)
+	MaxSocketConnections int `json:"ws_max_connections" toml:"ws_max_connections"`         // Concurrent WebSocket connections in total (0 disables)
+
+	PendingSocketTTL int `json:"pending_socket_ttl_ms" toml:"pending_socket_ttl_ms"` // Milliseconds a server-rendered page's socket ID waits for its WebSocket join (0 keeps the default of one minute)
 
 	Database DatabaseConfig `json:"database" toml:"database"`
 	Server   ServerConfig   `json:"server" toml:"server"`
+	CORS     CORSConfig     `json:"cors" toml:"cors"`
+	Mail     MailConfig     `json:"mail" toml:"mail"`
 }
 
 // DatabaseConfig holds database configuration
@@ -26,14 +75,63 @@ type DatabaseConfig struct {
 	Port     int    `json:"port" toml:"port"`
 	Database string `json:"database" toml:"database"`
 	Username string `json:"username" toml:"username"`
-	Password string `json:"password" toml:"password"`
+	Password string `json:"password" toml:"password" secret:"true"`
 	SSLMode  string `json:"ssl_mode" toml:"ssl_mode"`
+	Mode     string `json:"mode" toml:"mode"` // orm.ModeGORM (the default) or orm.ModeSQL, see ConnectConfiguredDB
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
 	Host string `json:"host" toml:"host"`
 	Port int    `json:"port" toml:"port"`
+
+	CertFile string `json:"cert_file" toml:"cert_file"` // Serve HTTPS with this certificate, together with KeyFile
+	KeyFile  string `json:"key_file" toml:"key_file"`   // Private key of CertFile
+
+	AutocertDomains  []string `json:"autocert_domains" toml:"autocert_domains"`     // Serve HTTPS with Let's Encrypt certificates for these domains
+	AutocertEmail    string   `json:"autocert_email" toml:"autocert_email"`         // Contact address for the Let's Encrypt account
+	AutocertCacheDir string   `json:"autocert_cache_dir" toml:"autocert_cache_dir"` // Directory keeping issued certificates (default "certs")
+	AutocertHTTPAddr string   `json:"autocert_http_addr" toml:"autocert_http_addr"` // Listener for ACME challenges and HTTPS redirects (default ":80")
+
+	ReadTimeout       int  `json:"read_timeout_ms" toml:"read_timeout_ms"`               // Milliseconds to read a whole request, body included (0 disables)
+	ReadHeaderTimeout int  `json:"read_header_timeout_ms" toml:"read_header_timeout_ms"` // Milliseconds to read request headers (0 keeps the 10s default)
+	WriteTimeout      int  `json:"write_timeout_ms" toml:"write_timeout_ms"`             // Milliseconds to write a response (0 disables)
+	IdleTimeout       int  `json:"idle_timeout_ms" toml:"idle_timeout_ms"`               // Milliseconds an idle keep-alive connection stays open (0 keeps the 2m default)
+	MaxHeaderBytes    int  `json:"max_header_bytes" toml:"max_header_bytes"`             // Largest request header in bytes (0 keeps the 1 MiB default)
+	DisableKeepAlives bool `json:"disable_keep_alives" toml:"disable_keep_alives"`       // Close connections after every response
+
+	TrustedProxies []string `json:"trusted_proxies" toml:"trusted_proxies"` // IPs or CIDRs of proxies whose X-Forwarded-For names the client (none by default)
+
+	DisableHTTP2          bool `json:"disable_http2" toml:"disable_http2"`                         // Serve HTTPS over HTTP/1.1 only
+	H2C                   bool `json:"h2c" toml:"h2c"`                                             // Accept unencrypted HTTP/2, e.g. behind a proxy that speaks h2c
+	HTTP2MaxStreams       int  `json:"http2_max_streams" toml:"http2_max_streams"`                 // Concurrent streams per HTTP/2 connection (0 keeps the Go default)
+	HTTP2MaxReadFrameSize int  `json:"http2_max_read_frame_size" toml:"http2_max_read_frame_size"` // Largest HTTP/2 frame read in bytes (0 keeps the Go default)
+
+	HandoffSocket string `json:"handoff_socket" toml:"handoff_socket"`     // Unix socket through which a new process takes the listener over from the running one
+	HandoffDrain  int    `json:"handoff_drain_ms" toml:"handoff_drain_ms"` // Milliseconds over which the old process asks its clients to reconnect (0 keeps the 10s default)
+	HandoffWaves  int    `json:"handoff_waves" toml:"handoff_waves"`       // Batches the old process reconnects its clients in (0 keeps the default of 10)
+}
+
+// CORSConfig holds the cross-origin policy applied to every route; it is off without origins
+type CORSConfig struct {
+	AllowOrigins     []string `json:"allow_origins" toml:"allow_origins"`         // Origins allowed to call the app from a browser, e.g. "https://shop.example.com", "https://*.example.com" or "*"
+	AllowMethods     []string `json:"allow_methods" toml:"allow_methods"`         // Methods allowed in cross-origin requests (default GET, POST, HEAD)
+	AllowHeaders     []string `json:"allow_headers" toml:"allow_headers"`         // Request headers allowed in cross-origin requests (default Content-Type)
+	ExposeHeaders    []string `json:"expose_headers" toml:"expose_headers"`       // Response headers scripts on other origins may read
+	AllowCredentials bool     `json:"allow_credentials" toml:"allow_credentials"` // Let cross-origin requests send cookies, e.g. the session of a component tag
+	MaxAge           int      `json:"max_age_ms" toml:"max_age_ms"`               // Milliseconds browsers may cache a preflight (0 keeps the 10m default)
+}
+
+// MailConfig holds the mailer configuration, see EnableMailer
+type MailConfig struct {
+	Provider    string `json:"provider" toml:"provider"`               // "smtp", "log" or a provider registered with RegisterMailProvider (default "smtp" with a host, "log" without)
+	Host        string `json:"host" toml:"host"`                       // SMTP server
+	Port        int    `json:"port" toml:"port"`                       // SMTP port (default 587)
+	Username    string `json:"username" toml:"username"`               // SMTP user, authenticating with PLAIN when set
+	Password    string `json:"password" toml:"password" secret:"true"` // SMTP password, or the API key of other providers
+	ImplicitTLS bool   `json:"implicit_tls" toml:"implicit_tls"`       // Speak TLS from the start instead of STARTTLS (default on port 465)
+	From        string `json:"from" toml:"from"`                       // Sender of the app's mail, e.g. "Shop <no-reply@example.com>"
+	TemplateDir string `json:"template_dir" toml:"template_dir"`       // Email templates (default "emails" in template_dir)
 }
 
 // DefaultConfig returns default configuration
@@ -55,33 +153,58 @@ func DefaultConfig() *Config {
 	}
 }
 
-// LoadConfig loads configuration from a file (supports JSON and TOML)
+// LoadConfig loads configuration from a file (supports JSON, TOML and YAML)
+// The format follows the extension: .toml, .yaml or .yml, and JSON otherwise
+// Keys left out of the file keep their defaults, and secret references are resolved
 func LoadConfig(path string) (*Config, error) {
 	config := DefaultConfig()
+	if err := config.Overlay(path); err != nil {
+		return nil, err
+	}
+	if err := config.ResolveSecrets(context.Background()); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
 
+// Overlay applies a config file on top of the configuration
+// Keys in the file replace the current values and the others are kept, lists included
+func (c *Config) Overlay(path string) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	ext := strings.ToLower(filepath.Ext(path))
 	switch ext {
-	case ".json":
-		if err := json.Unmarshal(data, config); err != nil {
-			return nil, err
-		}
 	case ".toml":
-		// TOML support will be added when network is available
-		// For now, use JSON or implement custom TOML parser
-		return nil, nil
+		err = toml.Unmarshal(data, c)
+	case ".yaml", ".yml":
+		err = unmarshalYAML(data, c)
 	default:
 		// Try JSON as default
-		if err := json.Unmarshal(data, config); err != nil {
-			return nil, err
-		}
+		err = json.Unmarshal(data, c)
+	}
+	if err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
 	}
+	return nil
+}
 
-	return config, nil
+// unmarshalYAML decodes YAML into config through JSON, so the json keys apply
+func unmarshalYAML(data []byte, config *Config) error {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc == nil {
+		return nil
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, config)
 }
 
 // LoadConfigOrDefault loads config from file or returns default if file doesn't exist
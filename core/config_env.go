@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix starts the names of the environment variables that configure the app
+// Names follow the config keys: debug is LIVENEST_DEBUG, database.host is
+// LIVENEST_DATABASE_HOST and server.autocert_domains is LIVENEST_SERVER_AUTOCERT_DOMAINS
+const EnvPrefix = "LIVENEST_"
+
+// ConfigFileEnv names the variable LoadConfigFromEnv reads a config file path from
+const ConfigFileEnv = EnvPrefix + "CONFIG"
+
+// LoadConfigFromEnv builds the configuration from the defaults, then the file named by
+// LIVENEST_CONFIG if set, then the LIVENEST_* variables, each overriding the one before
+// Containers can be configured with variables alone; secret references are resolved last
+func LoadConfigFromEnv() (*Config, error) {
+	config := DefaultConfig()
+	if path := os.Getenv(ConfigFileEnv); path != "" {
+		if err := config.Overlay(path); err != nil {
+			return nil, fmt.Errorf("%s: %w", ConfigFileEnv, err)
+		}
+	}
+	if err := config.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	if err := config.ResolveSecrets(context.Background()); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// ApplyEnv overrides the configuration with the LIVENEST_* variables that are set
+// Lists such as server.autocert_domains are comma separated
+func (c *Config) ApplyEnv() error {
+	return applyEnv(reflect.ValueOf(c).Elem(), EnvPrefix)
+}
+
+// applyEnv sets the fields of a config struct from variables named after their json keys
+func applyEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		name := prefix + strings.ToUpper(key)
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnv(v.Field(i), name+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setEnvField(v.Field(i), value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setEnvField parses a variable into a config field
+func setEnvField(f reflect.Value, value string) error {
+	value = strings.TrimSpace(value)
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		f.SetInt(n)
+	case reflect.Slice:
+		if f.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list of %s", f.Type().Elem())
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ProfileEnv names the variable that selects the config profile
+const ProfileEnv = EnvPrefix + "ENV"
+
+// DefaultProfile is the profile used when LIVENEST_ENV is not set
+const DefaultProfile = "dev"
+
+// BaseProfile names the file shared by every profile
+const BaseProfile = "base"
+
+// profileExts are the config file extensions tried for a profile, in order
+var profileExts = []string{".toml", ".yaml", ".yml", ".json"}
+
+// LoadProfile loads the configuration of the profile selected by LIVENEST_ENV from dir
+// Each layer overrides the one before: the defaults, dir/base, dir/<profile>, then the
+// LIVENEST_* variables. Files may be TOML, YAML or JSON and only need the keys they change
+//
+//	config/base.toml   shared settings
+//	config/dev.toml    LIVENEST_ENV unset or dev
+//	config/prod.toml   LIVENEST_ENV=prod
+//
+// A profile named in LIVENEST_ENV must have a file; the base file and the default
+// profile's file are optional
+func LoadProfile(dir string) (*Config, error) {
+	profile := os.Getenv(ProfileEnv)
+	required := profile != ""
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	return loadProfile(dir, profile, required)
+}
+
+// LoadProfileNamed loads a profile from dir like LoadProfile, whatever LIVENEST_ENV says
+func LoadProfileNamed(dir, profile string) (*Config, error) {
+	return loadProfile(dir, profile, true)
+}
+
+// loadProfile layers the base and profile files and the environment over the defaults
+func loadProfile(dir, profile string, required bool) (*Config, error) {
+	config := DefaultConfig()
+
+	base, err := profileFile(dir, BaseProfile)
+	if err != nil {
+		return nil, err
+	}
+	if base != "" {
+		if err := config.Overlay(base); err != nil {
+			return nil, err
+		}
+	}
+
+	path, err := profileFile(dir, profile)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" && required {
+		return nil, fmt.Errorf("config profile %q: no %s file in %s", profile, profile, dir)
+	}
+	if path != "" {
+		if err := config.Overlay(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	if err := config.ResolveSecrets(context.Background()); err != nil {
+		return nil, err
+	}
+	config.Env = profile
+	return config, nil
+}
+
+// profileFile returns the config file of a profile in dir, or "" if it has none
+func profileFile(dir, profile string) (string, error) {
+	for _, ext := range profileExts {
+		path := filepath.Join(dir, profile+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", nil
+}
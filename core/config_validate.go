@@ -0,0 +1,202 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/paulmanoni/livenest/orm"
+)
+
+// defaultSecret is the placeholder secret of DefaultConfig
+const defaultSecret = "change-me-in-production"
+
+// ConfigError lists the problems found by Config.Validate
+type ConfigError struct {
+	Problems []string
+}
+
+// Error lists the problems, one per line
+func (e *ConfigError) Error() string {
+	return "invalid config:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks the configuration for settings that would fail or misbehave later:
+// missing secrets outside debug mode, invalid ports, unknown database drivers and
+// settings that contradict each other. It reports every problem at once in a *ConfigError
+// Run calls it before serving
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if !c.Debug {
+		for _, secret := range []struct{ key, value string }{
+			{"secret_key", c.SecretKey},
+			{"liveview_secret", c.LiveViewSecret},
+		} {
+			switch secret.value {
+			case "":
+				add("%s is empty; set a random secret, e.g. with LIVENEST_%s", secret.key, strings.ToUpper(secret.key))
+			case defaultSecret:
+				add("%s still has the default value; set a random secret, e.g. with LIVENEST_%s", secret.key, strings.ToUpper(secret.key))
+			}
+		}
+	}
+
+	checkNonNegative(reflect.ValueOf(*c), "", add)
+
+	if c.Server.Port > 65535 {
+		add("server.port %d is not a valid port (0-65535)", c.Server.Port)
+	}
+	if c.Database.Port > 65535 {
+		add("database.port %d is not a valid port (0-65535)", c.Database.Port)
+	}
+	if c.Mail.Port > 65535 {
+		add("mail.port %d is not a valid port (0-65535)", c.Mail.Port)
+	}
+
+	c.validateDatabase(add)
+	c.validateServer(add)
+	c.validateCORS(add)
+	c.validateMail(add)
+
+	if c.PendingMigrations != "" && c.PendingMigrations != PendingMigrationsWarn && c.PendingMigrations != PendingMigrationsRefuse {
+		add("pending_migrations %q is unknown; use %q or %q", c.PendingMigrations, PendingMigrationsWarn, PendingMigrationsRefuse)
+	}
+	if c.LogFormat != "" && c.LogFormat != LogFormatText && c.LogFormat != LogFormatJSON {
+		add("log_format %q is unknown; use %q or %q", c.LogFormat, LogFormatText, LogFormatJSON)
+	}
+	if c.Profiling && c.ProfilingToken == "" {
+		add("profiling is on but profiling_token is empty, so the endpoints would stay off; set a token or turn profiling off")
+	}
+	if c.ReconnectMinDelay > 0 && c.ReconnectMaxDelay > 0 && c.ReconnectMinDelay > c.ReconnectMaxDelay {
+		add("reconnect_min_delay_ms (%d) is larger than reconnect_max_delay_ms (%d)", c.ReconnectMinDelay, c.ReconnectMaxDelay)
+	}
+	if c.MaxSocketsPerClient > 0 && c.MaxSocketConnections > 0 && c.MaxSocketsPerClient > c.MaxSocketConnections {
+		add("ws_max_per_client (%d) is larger than ws_max_connections (%d)", c.MaxSocketsPerClient, c.MaxSocketConnections)
+	}
+
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// validateDatabase checks the database driver and the settings it needs
+func (c *Config) validateDatabase(add func(string, ...interface{})) {
+	db := c.Database
+	if db.Mode != "" && db.Mode != orm.ModeGORM && db.Mode != orm.ModeSQL {
+		add("database.mode %q is unknown; use %q or %q", db.Mode, orm.ModeGORM, orm.ModeSQL)
+	}
+	if db.Driver == "" {
+		return
+	}
+	if !slices.Contains(orm.Drivers(), db.Driver) {
+		add("database.driver %q is unknown; use one of %s or register it with orm.RegisterDriver", db.Driver, strings.Join(orm.Drivers(), ", "))
+		return
+	}
+	switch db.Driver {
+	case "sqlite":
+		if db.Database == "" {
+			add("database.database is empty; set the SQLite file, e.g. \"app.db\"")
+		}
+	case "postgres", "postgresql", "mysql":
+		if db.Host == "" {
+			add("database.host is empty; %s needs the server address", db.Driver)
+		}
+		if db.Database == "" {
+			add("database.database is empty; set the name of the %s database", db.Driver)
+		}
+	}
+}
+
+// validateServer checks TLS and HTTP settings that contradict each other
+func (c *Config) validateServer(add func(string, ...interface{})) {
+	s := c.Server
+	if (s.CertFile == "") != (s.KeyFile == "") {
+		add("server.cert_file and server.key_file must be set together")
+	}
+	if s.CertFile != "" && len(s.AutocertDomains) > 0 {
+		add("server.cert_file and server.autocert_domains are both set; use your own certificate or Let's Encrypt, not both")
+	}
+	if s.ReadTimeout > 0 && s.ReadHeaderTimeout > s.ReadTimeout {
+		add("server.read_header_timeout_ms (%d) is larger than server.read_timeout_ms (%d), which already covers the headers", s.ReadHeaderTimeout, s.ReadTimeout)
+	}
+	if s.DisableHTTP2 {
+		if s.H2C {
+			add("server.h2c needs HTTP/2, which server.disable_http2 turns off")
+		}
+		if s.HTTP2MaxStreams > 0 || s.HTTP2MaxReadFrameSize > 0 {
+			add("server.http2_* settings have no effect with server.disable_http2")
+		}
+	}
+	if s.HTTP2MaxReadFrameSize > 0 && (s.HTTP2MaxReadFrameSize < 16384 || s.HTTP2MaxReadFrameSize > 16777215) {
+		add("server.http2_max_read_frame_size %d is outside the HTTP/2 range 16384-16777215", s.HTTP2MaxReadFrameSize)
+	}
+	for _, proxy := range s.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				add("server.trusted_proxies entry %q is neither an IP nor a CIDR", proxy)
+			}
+		}
+	}
+}
+
+// validateCORS checks the allowed origins
+func (c *Config) validateCORS(add func(string, ...interface{})) {
+	cors := c.CORS
+	for _, origin := range cors.AllowOrigins {
+		if origin == "*" {
+			if cors.AllowCredentials {
+				add("cors.allow_origins \"*\" can't be combined with cors.allow_credentials; browsers refuse credentials for any origin, so list the origins")
+			}
+			continue
+		}
+		if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") || strings.HasSuffix(origin, "/") {
+			add("cors.allow_origins %q is not an origin; use a scheme and host without a path, e.g. \"https://example.com\"", origin)
+		}
+	}
+}
+
+// validateMail checks the mail provider and the sender
+func (c *Config) validateMail(add func(string, ...interface{})) {
+	m := c.Mail
+	if m.Provider == "" && m.Host == "" {
+		return
+	}
+	if m.Provider != "" && !slices.Contains(MailProviders(), m.Provider) {
+		add("mail.provider %q is unknown; use one of %s or register it with RegisterMailProvider", m.Provider, strings.Join(MailProviders(), ", "))
+	}
+	if m.Provider == "smtp" && m.Host == "" {
+		add("mail.host is empty; smtp needs the server address")
+	}
+	if m.From == "" {
+		add("mail.from is empty; set the sender of the app's mail, e.g. \"Shop <no-reply@example.com>\"")
+	} else if _, err := netmail.ParseAddress(m.From); err != nil {
+		add("mail.from %q is not an address: %v", m.From, err)
+	}
+}
+
+// checkNonNegative reports the integer settings below zero by their config keys
+func checkNonNegative(v reflect.Value, prefix string, add func(string, ...interface{})) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		switch f := v.Field(i); f.Kind() {
+		case reflect.Struct:
+			checkNonNegative(f, prefix+key+".", add)
+		case reflect.Int, reflect.Int64:
+			if f.Int() < 0 {
+				add("%s%s is %d; it can't be negative", prefix, key, f.Int())
+			}
+		}
+	}
+}
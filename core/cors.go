@@ -0,0 +1,105 @@
+package core
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSMiddleware answers preflight requests and adds CORS headers to the responses of
+// requests from the allowed origins; requests from other origins get no CORS headers,
+// so browsers keep their responses from scripts
+// Without methods and headers, the defaults cover component tags and form posts embedded
+// on other sites
+func CORSMiddleware(cfg CORSConfig) gin.HandlerFunc {
+	methods := cfg.AllowMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodPost, http.MethodHead}
+	}
+	headers := cfg.AllowHeaders
+	if len(headers) == 0 {
+		headers = []string{"Content-Type"}
+	}
+	maxAge := time.Duration(cfg.MaxAge) * time.Millisecond
+	if maxAge <= 0 {
+		maxAge = 10 * time.Minute
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+	exposeHeaders := strings.Join(cfg.ExposeHeaders, ", ")
+	maxAgeSeconds := strconv.Itoa(int(maxAge / time.Second))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		h := c.Writer.Header()
+		h.Add("Vary", "Origin")
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if !originAllowed(cfg.AllowOrigins, origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Set("Access-Control-Allow-Credentials", "true")
+		} else if slices.Contains(cfg.AllowOrigins, "*") {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if !preflight {
+			if exposeHeaders != "" {
+				h.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		h.Set("Access-Control-Allow-Methods", allowMethods)
+		h.Set("Access-Control-Allow-Headers", allowHeaders)
+		h.Set("Access-Control-Max-Age", maxAgeSeconds)
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// websocketOrigins returns the check of the other sites allowed to open LiveView
+// WebSockets: the CORS origins, except "*", since the WebSocket carries the user's cookies
+func websocketOrigins(allowed []string) func(origin string) bool {
+	patterns := slices.DeleteFunc(slices.Clone(allowed), func(pattern string) bool { return pattern == "*" })
+	return func(origin string) bool {
+		return originAllowed(patterns, origin)
+	}
+}
+
+// originAllowed reports whether origin matches one of the allowed origins
+// "*" matches any origin, and "https://*.example.com" any subdomain of example.com
+func originAllowed(allowed []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+		if prefix, suffix, ok := strings.Cut(pattern, "*."); ok &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, "."+suffix) &&
+			len(origin) > len(prefix)+len(suffix)+1 {
+			return true
+		}
+	}
+	return false
+}
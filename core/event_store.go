@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/paulmanoni/livenest/liveview"
+	"github.com/paulmanoni/livenest/orm"
+)
+
+// EnableEventStore creates the stored_events table and returns the app's event store
+func (a *App) EnableEventStore() (*orm.EventStore, error) {
+	if a.DB == nil {
+		return nil, fmt.Errorf("no database connected")
+	}
+	if err := a.DB.AutoMigrate(&orm.StoredEvent{}); err != nil {
+		return nil, err
+	}
+
+	a.events = orm.NewEventStore(a.DB)
+	a.events.OnError = func(err error) {
+		a.Logger().Warn("Event store error", "error", err)
+	}
+	return a.events, nil
+}
+
+// Events returns the event store, or nil before EnableEventStore
+func (a *App) Events() *orm.EventStore {
+	return a.events
+}
+
+// BindEvents folds a stream into the socket assign key and, on a live connection,
+// keeps it current as events are appended on any node; call it from Mount
+//
+//	func (d *Dashboard) Mount(socket *liveview.Socket) error {
+//		return core.BindEvents(socket, app.Events(), "orders", "totals", Totals{}, applyOrder)
+//	}
+func BindEvents[S any](socket *liveview.Socket, store *orm.EventStore, stream, key string, initial S, apply func(S, orm.StoredEvent) S) error {
+	state, version, err := orm.Fold(socket.Context(), store, stream, initial, apply)
+	if err != nil {
+		return err
+	}
+	socket.Set(key, state)
+
+	// The state is only touched on the connection goroutine from here on
+	socket.StartStream("events:"+key, func(ctx context.Context, push func(func(*liveview.Socket))) {
+		store.Subscribe(ctx, stream, version, func(event orm.StoredEvent) {
+			push(func(s *liveview.Socket) {
+				state = apply(state, event)
+				s.Set(key, state)
+			})
+		})
+	})
+	return nil
+}
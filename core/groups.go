@@ -0,0 +1,62 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paulmanoni/livenest/liveview"
+	"github.com/paulmanoni/livenest/protocol"
+)
+
+// RouteGroup is a gin router group that can also hold LiveView routes
+// LiveView routes built in a group are served only through it: the group gets its own
+// WebSocket endpoint and component tag endpoint behind its middleware, and its components
+// can't be joined through the root WebSocket
+type RouteGroup struct {
+	*gin.RouterGroup
+	app    *App
+	prefix string
+	live   bool // the group's LiveView endpoints are mounted
+}
+
+// Group creates a new router group under relativePath, e.g. "/admin", whose middleware
+// runs for each of its routes, LiveView pages and WebSockets included
+//
+//	admin := app.Group("/admin", requireAdmin)
+//	admin.NewHandler().Path("/users").AsLive().AddComponent(&Users{}).WithName("admin_users").Build()
+func (a *App) Group(relativePath string, handlers ...gin.HandlerFunc) *RouteGroup {
+	return newRouteGroup(a, a.Router.Group(relativePath), handlers)
+}
+
+// Subgroup creates a group nested in this one, e.g. app.Group("/admin").Subgroup("/reports")
+func (g *RouteGroup) Subgroup(relativePath string, handlers ...gin.HandlerFunc) *RouteGroup {
+	return newRouteGroup(g.app, g.RouterGroup.Group(relativePath), handlers)
+}
+
+// newRouteGroup tags the requests of a gin group with its prefix, then adds its handlers
+func newRouteGroup(app *App, router *gin.RouterGroup, handlers []gin.HandlerFunc) *RouteGroup {
+	prefix := strings.TrimSuffix(router.BasePath(), "/")
+	router.Use(func(c *gin.Context) {
+		c.Set(liveview.GroupContextKey, prefix)
+	})
+	router.Use(handlers...)
+	return &RouteGroup{RouterGroup: router, app: app, prefix: prefix}
+}
+
+// NewHandler creates a handler builder for a route in the group
+// Paths are relative to the group
+func (g *RouteGroup) NewHandler() *HandlerBuilder {
+	b := g.app.NewHandler()
+	b.group = g
+	return b
+}
+
+// mountLive serves the group's WebSocket and component tag endpoints, once
+func (g *RouteGroup) mountLive() {
+	if g.live {
+		return
+	}
+	g.live = true
+	g.GET(protocol.Path, g.app.lvHandler.HandleMultiplexWebSocket)
+	g.GET("/livenest/component/:name", g.app.lvHandler.HandleComponentTag)
+}
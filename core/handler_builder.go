@@ -2,7 +2,7 @@ package core
 
 import (
 	"fmt"
-	"log"
+	"time"
 
 	"github.com/paulmanoni/livenest/liveview"
 
@@ -18,6 +18,16 @@ type HandlerBuilder struct {
 	components       []liveview.Component
 	componentNames   []string
 	primaryComponent string
+	policies         []liveview.Policy
+	layout           liveview.Layout
+	hooks            []liveview.EventHook
+	budgets          map[string]time.Duration
+	assets           []liveview.Asset
+	assigns          []liveview.AssignFunc
+	features         *liveview.RenderFeatures
+	rateLimit        *RateLimit
+	name             string
+	group            *RouteGroup // nil for routes on the root router
 	isLive           bool
 }
 
@@ -40,6 +50,12 @@ func (b *HandlerBuilder) Path(path string) *HandlerBuilder {
 	return b
 }
 
+// Name names the route, e.g. "todo.index", for app.URLFor and the "route" template function
+func (b *HandlerBuilder) Name(name string) *HandlerBuilder {
+	b.name = name
+	return b
+}
+
 // AsGet sets the HTTP method to GET
 func (b *HandlerBuilder) AsGet() *HandlerBuilder {
 	b.method = "GET"
@@ -76,6 +92,71 @@ func (b *HandlerBuilder) AsLive() *HandlerBuilder {
 	return b
 }
 
+// WithPolicy adds an authorization policy to every component of this LiveView route
+func (b *HandlerBuilder) WithPolicy(policy liveview.Policy) *HandlerBuilder {
+	b.policies = append(b.policies, policy)
+	return b
+}
+
+// WithLayout sets the page layout used for the initial render of this LiveView route
+func (b *HandlerBuilder) WithLayout(layout liveview.Layout) *HandlerBuilder {
+	b.layout = layout
+	return b
+}
+
+// WithHook attaches an event hook to every component of this LiveView route
+func (b *HandlerBuilder) WithHook(hook liveview.EventHook) *HandlerBuilder {
+	b.hooks = append(b.hooks, hook)
+	return b
+}
+
+// WithBudget sets the latency budget of an event for every component of this LiveView route
+// Use liveview.AnyEvent for a default; slower events are logged and counted in handler stats
+func (b *HandlerBuilder) WithBudget(event string, budget time.Duration) *HandlerBuilder {
+	if b.budgets == nil {
+		b.budgets = make(map[string]time.Duration)
+	}
+	b.budgets[event] = budget
+	return b
+}
+
+// WithFeatures sets the render features of every component of this LiveView route, e.g.
+// liveview.RenderFeatures{UseDiff: true, UseStaticSplit: true} to try the static split
+func (b *HandlerBuilder) WithFeatures(features liveview.RenderFeatures) *HandlerBuilder {
+	b.features = &features
+	return b
+}
+
+// WithAssets adds JS or CSS files to the page of this LiveView route, next to the assets
+// its components declare; they are served as one hashed bundle per route
+func (b *HandlerBuilder) WithAssets(assets ...liveview.Asset) *HandlerBuilder {
+	b.assets = append(b.assets, assets...)
+	return b
+}
+
+// WithAssigns sets default assigns for every socket of this LiveView route, e.g. the page
+// title; they are set before Mount, which can override them
+func (b *HandlerBuilder) WithAssigns(assigns map[string]interface{}) *HandlerBuilder {
+	return b.WithAssignFunc(func(*gin.Context) map[string]interface{} { return assigns })
+}
+
+// WithAssignFunc sets default assigns computed from the request for every socket of this
+// LiveView route, e.g. the current user or feature flags
+func (b *HandlerBuilder) WithAssignFunc(fn func(c *gin.Context) map[string]interface{}) *HandlerBuilder {
+	b.assigns = append(b.assigns, fn)
+	return b
+}
+
+// RateLimit limits the requests to this route to limit per window for each client IP,
+// e.g. .RateLimit(100, time.Minute), answering the rest with 429 Too Many Requests
+// Routes count apart from each other and from App.RateLimit, in the app's rate limit
+// store; on a LiveView route the limit covers its page and form posts, while its socket
+// has the connection limits of the config
+func (b *HandlerBuilder) RateLimit(limit int, window time.Duration) *HandlerBuilder {
+	b.rateLimit = &RateLimit{Limit: limit, Window: window}
+	return b
+}
+
 // Func sets the handler function for regular routes
 func (b *HandlerBuilder) Func(handler gin.HandlerFunc) *HandlerBuilder {
 	b.handler = handler
@@ -138,6 +219,9 @@ func (b *HandlerBuilder) Build() {
 		b.path = "/"
 	}
 
+	if b.name != "" {
+		b.app.NameRoute(b.name, b.fullPath())
+	}
 	if b.isLive {
 		b.buildLiveView()
 	} else {
@@ -145,23 +229,58 @@ func (b *HandlerBuilder) Build() {
 	}
 }
 
+// routes returns the router the builder registers on: its group or the app's router
+func (b *HandlerBuilder) routes() gin.IRoutes {
+	if b.group != nil {
+		return b.group.RouterGroup
+	}
+	return b.app.Router
+}
+
+// fullPath returns the route path including the group prefix
+func (b *HandlerBuilder) fullPath() string {
+	if b.group == nil {
+		return b.path
+	}
+	if b.path == "/" {
+		return b.group.prefix
+	}
+	return b.group.prefix + b.path
+}
+
+// middleware returns the handlers that run before the route's own
+func (b *HandlerBuilder) middleware() []gin.HandlerFunc {
+	if b.rateLimit == nil {
+		return nil
+	}
+	limit := *b.rateLimit
+	limit.Store = b.app.RateLimitStore()
+	if limit.KeyFunc == nil {
+		// The app's router only trusts forwarded headers from server.trusted_proxies
+		limit.KeyFunc = (*gin.Context).ClientIP
+	}
+	limit.Scope = b.method + " " + b.fullPath()
+	return []gin.HandlerFunc{RateLimitMiddleware(limit)}
+}
+
 // buildRegular builds a regular HTTP route
 func (b *HandlerBuilder) buildRegular() {
 	if b.handler == nil {
 		return
 	}
 
+	handlers := append(b.middleware(), b.handler)
 	switch b.method {
 	case "GET":
-		b.app.GET(b.path, b.handler)
+		b.routes().GET(b.path, handlers...)
 	case "POST":
-		b.app.POST(b.path, b.handler)
+		b.routes().POST(b.path, handlers...)
 	case "PUT":
-		b.app.PUT(b.path, b.handler)
+		b.routes().PUT(b.path, handlers...)
 	case "DELETE":
-		b.app.DELETE(b.path, b.handler)
+		b.routes().DELETE(b.path, handlers...)
 	case "PATCH":
-		b.app.PATCH(b.path, b.handler)
+		b.routes().PATCH(b.path, handlers...)
 	}
 }
 
@@ -182,8 +301,8 @@ func (b *HandlerBuilder) buildLiveView() {
 		primaryName = b.componentNames[0]
 	}
 	if primaryName == "" {
-		primaryName = b.path
-		if primaryName == "/" {
+		primaryName = b.fullPath()
+		if primaryName == "/" || primaryName == "" {
 			primaryName = "index"
 		}
 	}
@@ -203,21 +322,64 @@ func (b *HandlerBuilder) buildLiveView() {
 		}
 
 		b.app.lvHandler.Register(name, component)
+		for _, policy := range b.policies {
+			b.app.lvHandler.RegisterPolicy(name, policy)
+		}
+		for _, hook := range b.hooks {
+			b.app.lvHandler.RegisterHook(name, hook)
+		}
+		for _, fn := range b.assigns {
+			b.app.lvHandler.RegisterAssigns(name, fn)
+		}
+		for event, budget := range b.budgets {
+			b.app.lvHandler.SetEventBudget(name, event, budget)
+		}
+		if b.layout != nil {
+			b.app.lvHandler.RegisterLayout(name, b.layout)
+		}
+		if b.features != nil {
+			b.app.lvHandler.SetRenderFeatures(name, *b.features)
+		}
+		if b.group != nil {
+			b.app.lvHandler.RegisterGroup(name, b.group.prefix)
+		}
 		registeredNames = append(registeredNames, name)
 	}
 
+	// Bundle the assets of the route's components for its page
+	if len(b.assets) > 0 {
+		b.app.lvHandler.RegisterAssets(primaryName, b.assets...)
+	}
+	if err := b.app.lvHandler.BundleAssets(primaryName, registeredNames...); err != nil {
+		b.app.Logger().Error("LiveView assets not bundled", "path", b.path, "error", err)
+	}
+
 	// Register HTTP handler (uses first component)
-	b.app.GET(b.path, b.app.lvHandler.HandleHTTP(primaryName))
+	middleware := b.middleware()
+	b.routes().GET(b.path, append(middleware, b.app.lvHandler.HandleHTTP(primaryName))...)
+	if b.group != nil {
+		b.group.mountLive()
+	}
+
+	// Generated forms also submit as a plain POST when JavaScript is unavailable
+	for i, name := range registeredNames {
+		if name == primaryName {
+			if _, ok := b.components[i].(liveview.FormPoster); ok {
+				b.routes().POST(b.path, append(middleware, b.app.lvHandler.HandlePost(primaryName))...)
+			}
+			break
+		}
+	}
 
 	// Register WebSocket handlers for all components
 	for _, name := range registeredNames {
 		wsPath := "/live/ws/" + name
 		componentName := name // capture for closure
-		b.app.GET(wsPath, func(c *gin.Context) {
+		b.routes().GET(wsPath, append(middleware, func(c *gin.Context) {
 			c.Params = append(c.Params, gin.Param{Key: "component", Value: componentName})
 			b.app.lvHandler.HandleWebSocket(c)
-		})
+		})...)
 	}
 
-	log.Printf("LiveView registered: %s (Components: %v)", b.path, registeredNames)
+	b.app.Logger().Info("LiveView registered", "path", b.fullPath(), "components", registeredNames)
 }
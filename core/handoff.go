@@ -0,0 +1,231 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Handoff defaults applied when the server config leaves them unset
+const (
+	defaultHandoffDrain = 10 * time.Second
+	defaultHandoffWaves = 10
+)
+
+// Messages of the handoff exchange, one per line on the control socket
+const (
+	handoffTakeover = "takeover" // new process: send me the listener
+	handoffListener = "listener" // old process: here it is, with the descriptor attached
+	handoffServing  = "serving"  // new process: I accept connections on it now
+	handoffReleased = "released" // old process: the control socket is free, I'm draining
+)
+
+// serve calls serve with a listener for srv.Addr
+// With server.handoff_socket set, the listener is taken over from the process serving
+// on that control socket, if one is, so no connection is refused while both run. The
+// process then waits on the socket for its own successor; once handed off, it stops
+// accepting, asks its LiveView clients to reconnect in waves and returns nil
+// SIGINT and SIGTERM shut the server down gracefully and return nil as well. Either way
+// the background workers are stopped and waited for before serve returns
+func (a *App) serve(srv *http.Server, serve func(net.Listener) error) error {
+	defer a.stopWorkers()
+	stopSignals := a.shutdownOnSignal(srv)
+	defer stopSignals()
+
+	path := a.config.Server.HandoffSocket
+	if path == "" {
+		ln, err := net.Listen("tcp", srv.Addr)
+		if err != nil {
+			return err
+		}
+		return ignoreClosed(serve(ln))
+	}
+
+	ln, previous, err := takeOverListener(path)
+	if err != nil {
+		return fmt.Errorf("handoff from %s: %w", path, err)
+	}
+	if ln == nil {
+		if ln, err = net.Listen("tcp", srv.Addr); err != nil {
+			return err
+		}
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- serve(ln) }()
+
+	if previous != nil {
+		if err := previous.release(); err != nil {
+			a.Logger().Warn("Previous process didn't confirm the handoff", "socket", path, "error", err)
+		}
+		a.Logger().Info("Took over the listener from the previous process", "address", ln.Addr().String())
+	}
+
+	control, err := listenHandoff(path)
+	if err != nil {
+		srv.Close()
+		return fmt.Errorf("handoff socket %s: %w", path, err)
+	}
+	defer control.Close()
+
+	handedOff := make(chan struct{})
+	drained := make(chan struct{})
+	go a.awaitHandoff(control, srv, ln, handedOff, drained)
+
+	err = <-served
+	select {
+	case <-handedOff:
+		<-drained
+		return nil
+	default:
+		return ignoreClosed(err)
+	}
+}
+
+// shutdownOnSignal shuts srv down gracefully on SIGINT or SIGTERM, giving in-flight
+// requests 30s, and stops the background workers meanwhile; the returned func stops
+// listening for the signals
+func (a *App) shutdownOnSignal(srv *http.Server) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		select {
+		case sig := <-signals:
+			a.Logger().Info("Shutting down", "signal", sig.String())
+			a.cancelWorkers()
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := srv.Shutdown(ctx); err != nil {
+				a.Logger().Warn("Requests still running at shutdown", "error", err)
+			}
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		<-finished // a shutdown under way lets its requests finish first
+	}
+}
+
+// ignoreClosed treats the end of a server shut down on purpose as a clean return
+func ignoreClosed(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// awaitHandoff hands the listener to the first successor that asks for it, then stops
+// serving and drains the LiveView connections
+func (a *App) awaitHandoff(control net.Listener, srv *http.Server, ln net.Listener, handedOff, drained chan struct{}) {
+	for {
+		conn, err := control.Accept()
+		if err != nil {
+			return
+		}
+		err = handOff(conn, ln, func() {
+			close(handedOff)
+			control.Close()
+		})
+		conn.Close()
+
+		select {
+		case <-handedOff:
+			if err != nil {
+				// The successor serves already; it only missed the confirmation
+				a.Logger().Warn("Handoff confirmation failed", "error", err)
+			}
+		default:
+			a.Logger().Warn("Handoff failed, still serving", "error", err)
+			continue
+		}
+		break
+	}
+
+	cfg := a.config.Server
+	over := time.Duration(cfg.HandoffDrain) * time.Millisecond
+	if over <= 0 {
+		over = defaultHandoffDrain
+	}
+	waves := cfg.HandoffWaves
+	if waves <= 0 {
+		waves = defaultHandoffWaves
+	}
+	a.Logger().Info("Handed the listener off; draining", "over", over, "waves", waves)
+	// The successor runs the background work from now on; interrupted jobs go back to
+	// the queue for it instead of waiting out their lease
+	a.cancelWorkers()
+
+	// In-flight requests and LiveView connections wind down side by side
+	ctx, cancel := context.WithTimeout(context.Background(), over+30*time.Second)
+	defer cancel()
+	shutdown := make(chan struct{})
+	go func() {
+		if err := srv.Shutdown(ctx); err != nil {
+			a.Logger().Warn("Requests still running after the drain", "error", err)
+		}
+		close(shutdown)
+	}()
+	n := a.DrainSockets(ctx, over, waves)
+	<-shutdown
+	a.Logger().Info("Drained after handoff", "connections", n)
+	close(drained)
+}
+
+// handOff runs the old process's side of the exchange on a successor's connection
+// released is called once the successor serves, before it is told the socket is free
+func handOff(conn net.Conn, ln net.Listener, released func()) error {
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	r := bufio.NewReader(conn)
+	if err := expectLine(r, handoffTakeover); err != nil {
+		return err
+	}
+	if err := sendListener(conn, ln); err != nil {
+		return err
+	}
+	if err := expectLine(r, handoffServing); err != nil {
+		return err
+	}
+	released()
+	_, err := fmt.Fprintln(conn, handoffReleased)
+	return err
+}
+
+// handoffPeer is the connection of a new process to the one it took the listener from
+type handoffPeer struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// release tells the previous process this one serves and waits until it freed the socket
+func (p *handoffPeer) release() error {
+	defer p.conn.Close()
+	if _, err := fmt.Fprintln(p.conn, handoffServing); err != nil {
+		return err
+	}
+	return expectLine(p.r, handoffReleased)
+}
+
+// expectLine reads a line and checks it is want
+func expectLine(r *bufio.Reader, want string) error {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if got := strings.TrimSpace(line); got != want {
+		return fmt.Errorf("expected %s, got %q", want, got)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+//go:build !unix
+
+package core
+
+import (
+	"errors"
+	"net"
+)
+
+// errHandoffUnsupported is returned when handoff_socket is set on a system without unix sockets
+var errHandoffUnsupported = errors.New("listener handoff needs a Unix system")
+
+// takeOverListener is unsupported on this system
+func takeOverListener(path string) (net.Listener, *handoffPeer, error) {
+	return nil, nil, errHandoffUnsupported
+}
+
+// sendListener is unsupported on this system
+func sendListener(conn net.Conn, ln net.Listener) error {
+	return errHandoffUnsupported
+}
+
+// listenHandoff is unsupported on this system
+func listenHandoff(path string) (net.Listener, error) {
+	return nil, errHandoffUnsupported
+}
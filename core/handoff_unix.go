@@ -0,0 +1,95 @@
+//go:build unix
+
+package core
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// takeOverListener asks the process serving the control socket at path for its listener
+// It returns a nil listener when no process does, e.g. on the first start or after a crash
+func takeOverListener(path string) (net.Listener, *handoffPeer, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, nil, nil
+	}
+	uc := conn.(*net.UnixConn)
+	uc.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := fmt.Fprintln(uc, handoffTakeover); err != nil {
+		uc.Close()
+		return nil, nil, err
+	}
+
+	// The descriptor travels with the listener line
+	buf := make([]byte, len(handoffListener)+1)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := uc.ReadMsgUnix(buf, oob)
+	if err == nil && string(buf[:n]) != handoffListener+"\n" {
+		err = fmt.Errorf("expected %s, got %q", handoffListener, buf[:n])
+	}
+	var fds []int
+	if err == nil {
+		fds, err = parseRights(oob[:oobn])
+	}
+	if err != nil {
+		uc.Close()
+		return nil, nil, err
+	}
+
+	f := os.NewFile(uintptr(fds[0]), "handoff-listener")
+	ln, err := net.FileListener(f)
+	f.Close()
+	if err != nil {
+		uc.Close()
+		return nil, nil, err
+	}
+	return ln, &handoffPeer{conn: uc, r: bufio.NewReader(uc)}, nil
+}
+
+// parseRights returns the descriptors passed in a control message
+func parseRights(oob []byte) ([]int, error) {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range msgs {
+		if fds, err := syscall.ParseUnixRights(&msg); err == nil && len(fds) > 0 {
+			return fds, nil
+		}
+	}
+	return nil, errors.New("no listener descriptor received")
+}
+
+// sendListener passes the descriptor of ln to the successor on conn
+func sendListener(conn net.Conn, ln net.Listener) error {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return errors.New("handoff needs a unix socket connection")
+	}
+	filer, ok := ln.(interface{ File() (*os.File, error) })
+	if !ok {
+		return fmt.Errorf("listener %T can't be handed off", ln)
+	}
+	f, err := filer.File()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, _, err = uc.WriteMsgUnix([]byte(handoffListener+"\n"), syscall.UnixRights(int(f.Fd())), nil)
+	return err
+}
+
+// listenHandoff listens on the control socket at path, replacing a stale one
+// The previous process has released it by now, if there was one
+func listenHandoff(path string) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paulmanoni/livenest/i18n"
+	"github.com/paulmanoni/livenest/liveview"
+)
+
+// localizerKey is the context key of the request's localizer
+const localizerKey = "livenest.localizer"
+
+// localeCookieMaxAge keeps a chosen locale for a year
+const localeCookieMaxAge = 365 * 24 * 60 * 60
+
+// EnableI18n loads the message files in locales_dir (default "locales"), falling back to
+// default_locale (default "en"), and translates with them: LiveView sockets pick their
+// locale and templates use {{t "key"}}, and routes registered afterwards go through
+// LocaleMiddleware
+func (a *App) EnableI18n() (*i18n.Catalog, error) {
+	dir := a.config.LocalesDir
+	if dir == "" {
+		dir = "locales"
+	}
+	fallback := a.config.DefaultLocale
+	if fallback == "" {
+		fallback = "en"
+	}
+
+	catalog := i18n.New(fallback)
+	if err := catalog.LoadDir(dir); err != nil {
+		return nil, err
+	}
+	a.SetTranslations(catalog)
+	a.Router.Use(LocaleMiddleware(catalog))
+	return catalog, nil
+}
+
+// SetTranslations sets the catalog LiveView sockets translate with, e.g. one loaded from
+// an embed.FS with LoadFS
+func (a *App) SetTranslations(catalog *i18n.Catalog) {
+	a.lvHandler.SetTranslations(catalog)
+}
+
+// Translations returns the app's catalog, or nil without EnableI18n or SetTranslations
+func (a *App) Translations() *i18n.Catalog {
+	return a.lvHandler.Translations()
+}
+
+// LocaleMiddleware picks the locale of each request and makes its localizer available
+// with GetLocalizer, and to the LiveView sockets the request renders. A ?locale= query
+// parameter switches locale and keeps it in the locale cookie and the session, so a
+// language menu can be plain links; otherwise the session, the locale cookie and the
+// Accept-Language header are tried in turn
+func LocaleMiddleware(catalog *i18n.Catalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := GetSession(c)
+		locale, chosen := catalog.Supported(c.Query(i18n.CookieName))
+		if chosen {
+			http.SetCookie(c.Writer, &http.Cookie{
+				Name:     i18n.CookieName,
+				Value:    locale,
+				Path:     "/",
+				MaxAge:   localeCookieMaxAge,
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+			if session != nil {
+				session.Put(liveview.LocaleSessionKey, locale)
+			}
+		} else if session != nil {
+			if value, ok := session.Get(liveview.LocaleSessionKey); ok {
+				name, _ := value.(string)
+				locale, chosen = catalog.Supported(name)
+			}
+		}
+		if !chosen {
+			locale = catalog.Negotiate(c.Request)
+		}
+
+		c.Set(localizerKey, catalog.Localizer(locale))
+		c.Request = c.Request.WithContext(liveview.WithLocale(c.Request.Context(), locale))
+		c.Next()
+	}
+}
+
+// GetLocalizer returns the localizer LocaleMiddleware picked for the request; it is nil,
+// returning keys untranslated, for requests that didn't go through it
+func GetLocalizer(c *gin.Context) *i18n.Localizer {
+	l, _ := c.Get(localizerKey)
+	localizer, _ := l.(*i18n.Localizer)
+	return localizer
+}
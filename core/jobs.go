@@ -0,0 +1,41 @@
+package core
+
+import (
+	"github.com/paulmanoni/livenest/jobs"
+	"github.com/paulmanoni/livenest/orm"
+)
+
+// EnableJobs returns the app's job runner, which runs due jobs while the app runs and
+// lets sockets follow them with MonitorJob. Jobs are kept in the jobs table when a
+// database is connected, so they survive restarts and are shared by every node, and in
+// memory otherwise
+func (a *App) EnableJobs() (*jobs.Runner, error) {
+	var queue jobs.Queue = jobs.NewMemoryQueue()
+	if a.DB != nil {
+		if err := a.DB.AutoMigrate(&jobs.Job{}); err != nil {
+			return nil, err
+		}
+		queue = orm.NewJobQueue(a.DB)
+	}
+	return a.SetJobQueue(queue), nil
+}
+
+// SetJobQueue makes the app run the jobs of queue, e.g. one on another store, and
+// returns its runner
+func (a *App) SetJobQueue(queue jobs.Queue) *jobs.Runner {
+	a.jobs = jobs.NewRunner(queue)
+	a.jobs.OnError = func(job jobs.Job, err error) {
+		if job.Kind == "" {
+			a.Logger().Error("Job runner error", "job", job.ID, "error", err)
+			return
+		}
+		a.Logger().Warn("Job failed", "job", job.ID, "kind", job.Kind, "attempts", job.Attempts, "status", job.Status, "error", err)
+	}
+	a.lvHandler.SetJobs(a.jobs)
+	return a.jobs
+}
+
+// Jobs returns the job runner, or nil before EnableJobs
+func (a *App) Jobs() *jobs.Runner {
+	return a.jobs
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paulmanoni/livenest/liveview"
+)
+
+// liveDashboardName is the component name the LiveDashboard is registered under
+const liveDashboardName = "livenest.dashboard"
+
+// EnableLiveDashboard mounts the LiveDashboard at /debug/dashboard
+// Like EnableProfiling it requires at least one auth middleware. The middleware also
+// guards the dashboard's socket: it runs against the WebSocket request, with the page's
+// query parameters, before the mount and every event. Browsers don't send an
+// Authorization header there, so use cookies or the token query parameter:
+//
+//	app.EnableLiveDashboard(core.ProfilingTokenAuth(os.Getenv("DASHBOARD_TOKEN")))
+//	// open /debug/dashboard?token=...
+func (a *App) EnableLiveDashboard(auth ...gin.HandlerFunc) {
+	if len(auth) == 0 {
+		a.Logger().Warn("LiveDashboard not mounted: EnableLiveDashboard requires an auth middleware")
+		return
+	}
+
+	dashboard := liveview.NewLiveDashboard(a.lvHandler)
+	dashboard.AddCheck("Migrations", a.migrationCheck)
+	dashboard.AddTable(liveview.DashboardTable{
+		Title:   "Index suggestions",
+		Columns: []string{"Index", "Query", "Calls", "Average", "Plan"},
+		Rows:    a.indexSuggestionRows,
+	})
+	a.RegisterComponent(liveDashboardName, dashboard)
+	a.lvHandler.RegisterPolicy(liveDashboardName, middlewarePolicy(auth))
+	a.Router.Group("/debug/dashboard", auth...).GET("", a.lvHandler.HandleHTTP(liveDashboardName))
+
+	a.Logger().Info("LiveDashboard mounted", "path", "/debug/dashboard")
+}
+
+// allowedHeader is set by the last handler of a middleware policy's chain
+const allowedHeader = "X-Livenest-Allowed"
+
+// middlewarePolicy checks a socket's request against gin middleware
+// The socket is allowed when no middleware aborts the chain
+func middlewarePolicy(middleware []gin.HandlerFunc) liveview.Policy {
+	engine := gin.New()
+	engine.Use(middleware...)
+	engine.NoRoute(func(c *gin.Context) {
+		c.Header(allowedHeader, "1")
+	})
+
+	return func(socket *liveview.Socket, event string) error {
+		if socket.Request == nil {
+			return liveview.ErrUnauthorized
+		}
+
+		// Page parameters, like a token, are sent along with the WebSocket request
+		req := socket.Request.Clone(socket.Request.Context())
+		query := req.URL.Query()
+		for key, values := range socket.Params {
+			if _, ok := query[key]; !ok {
+				query[key] = values
+			}
+		}
+		req.URL.RawQuery = query.Encode()
+
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		if rec.Header().Get(allowedHeader) == "" {
+			return liveview.ErrUnauthorized
+		}
+		return nil
+	}
+}
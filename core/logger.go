@@ -0,0 +1,37 @@
+package core
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/paulmanoni/livenest/liveview"
+)
+
+// Log formats for Config.LogFormat
+const (
+	LogFormatText = "text" // slog's default handler (default)
+	LogFormatJSON = "json" // one JSON object per record on stderr
+)
+
+// SetLogger sets the logger of the app and its LiveView handler
+// Any liveview.Logger works; *slog.Logger implements it
+func (a *App) SetLogger(logger liveview.Logger) {
+	a.logger = logger
+	a.lvHandler.SetLogger(logger)
+}
+
+// Logger returns the app's logger
+func (a *App) Logger() liveview.Logger {
+	if a.logger == nil {
+		return slog.Default()
+	}
+	return a.logger
+}
+
+// configLogger returns the logger for Config.LogFormat, or nil for the default
+func configLogger(format string) liveview.Logger {
+	if format == LogFormatJSON {
+		return slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/paulmanoni/livenest/mail"
+	"github.com/paulmanoni/livenest/template"
+)
+
+// MailProvider opens the transport of a mail provider from the mail settings
+type MailProvider func(config MailConfig) (mail.Transport, error)
+
+var (
+	mailProvidersMu sync.RWMutex
+	mailProviders   = map[string]MailProvider{
+		"smtp": func(config MailConfig) (mail.Transport, error) {
+			return &mail.SMTPTransport{
+				Host:        config.Host,
+				Port:        config.Port,
+				Username:    config.Username,
+				Password:    config.Password,
+				ImplicitTLS: config.ImplicitTLS,
+			}, nil
+		},
+		"log": func(config MailConfig) (mail.Transport, error) {
+			return &mail.LogTransport{}, nil
+		},
+	}
+)
+
+// RegisterMailProvider makes a provider available to the mail.provider setting, e.g.
+// "sendgrid" for a transport calling its HTTP API with mail.password as the key
+// The smtp and log providers are built in; registering them again replaces them
+func RegisterMailProvider(name string, provider MailProvider) {
+	mailProvidersMu.Lock()
+	defer mailProvidersMu.Unlock()
+	mailProviders[name] = provider
+}
+
+// MailProviders returns the names of the registered mail providers, sorted
+func MailProviders() []string {
+	mailProvidersMu.RLock()
+	defer mailProvidersMu.RUnlock()
+	names := make([]string, 0, len(mailProviders))
+	for name := range mailProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EnableMailer creates the app's mailer from the mail settings: it sends through
+// mail.provider, SMTP to mail.host by default and the log otherwise, from mail.from, and
+// renders templates from mail.template_dir (default "emails" in the template directory)
+// when that directory exists
+func (a *App) EnableMailer() (*mail.Mailer, error) {
+	config := a.config.Mail
+	name := config.Provider
+	if name == "" {
+		name = "log"
+		if config.Host != "" {
+			name = "smtp"
+		}
+	}
+	mailProvidersMu.RLock()
+	provider, ok := mailProviders[name]
+	mailProvidersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("mail provider %q not registered", name)
+	}
+	transport, err := provider(config)
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := transport.(*mail.LogTransport); ok && t.Logger == nil {
+		t.Logger = a.Logger()
+	}
+
+	mailer := mail.NewMailer(transport, config.From)
+	dir := config.TemplateDir
+	if dir == "" {
+		dir = filepath.Join(a.config.TemplateDir, "emails")
+	}
+	if _, err := os.Stat(dir); err == nil {
+		engine := template.NewEngine(dir)
+		if err := engine.Load(); err != nil {
+			return nil, err
+		}
+		mailer.Templates = engine
+	}
+	a.SetMailer(mailer)
+	return mailer, nil
+}
+
+// SetMailer makes the app send mail with mailer, e.g. one with a custom transport
+func (a *App) SetMailer(mailer *mail.Mailer) {
+	a.mailer = mailer
+}
+
+// Mailer returns the app's mailer, or nil before EnableMailer
+func (a *App) Mailer() *mail.Mailer {
+	return a.mailer
+}
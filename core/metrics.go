@@ -0,0 +1,16 @@
+package core
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// EnableMetrics mounts the LiveView metrics at /metrics in the Prometheus text format
+// Auth middleware is optional, since scrapers usually reach /metrics on an internal
+// network; pass ProfilingTokenAuth to require a bearer token
+func (a *App) EnableMetrics(auth ...gin.HandlerFunc) {
+	handlers := append([]gin.HandlerFunc{}, auth...)
+	handlers = append(handlers, gin.WrapH(a.lvHandler.MetricsHandler()))
+	a.Router.GET("/metrics", handlers...)
+
+	a.Logger().Info("Metrics mounted", "path", "/metrics")
+}
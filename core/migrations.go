@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paulmanoni/livenest/orm"
+)
+
+// Pending migration policies for Config.PendingMigrations
+const (
+	PendingMigrationsWarn   = "warn"   // log pending migrations and start anyway (the default)
+	PendingMigrationsRefuse = "refuse" // Run returns an error while migrations are pending
+)
+
+// RegisterMigrations adds migrations the app checks at startup and in /readyz
+// Apply them with Migrate, e.g. from a deploy step
+func (a *App) RegisterMigrations(migrations ...orm.Migration) {
+	a.migrations = append(a.migrations, migrations...)
+}
+
+// Migrator returns a migrator for the registered migrations on the app's database
+func (a *App) Migrator() (*orm.Migrator, error) {
+	if a.DB == nil {
+		return nil, fmt.Errorf("no database connected")
+	}
+	return orm.NewMigrator(a.DB, a.migrations...), nil
+}
+
+// MigrationStatus compares the applied migrations with the registered ones
+func (a *App) MigrationStatus() (orm.MigrationStatus, error) {
+	migrator, err := a.Migrator()
+	if err != nil {
+		return orm.MigrationStatus{}, err
+	}
+	return migrator.Status()
+}
+
+// Migrate applies the pending registered migrations
+func (a *App) Migrate() error {
+	migrator, err := a.Migrator()
+	if err != nil {
+		return err
+	}
+	return migrator.Migrate()
+}
+
+// checkMigrations reports pending migrations before the server starts
+// It returns an error when the config refuses to start with pending migrations
+func (a *App) checkMigrations() error {
+	if a.DB == nil || len(a.migrations) == 0 {
+		return nil
+	}
+
+	status, err := a.MigrationStatus()
+	if err != nil {
+		err = fmt.Errorf("checking migrations: %w", err)
+	} else if len(status.Pending) > 0 {
+		err = fmt.Errorf("%d pending migrations: %s", len(status.Pending), strings.Join(status.Pending, ", "))
+	}
+	if err == nil {
+		return nil
+	}
+
+	if a.config.PendingMigrations == PendingMigrationsRefuse {
+		return err
+	}
+	a.Logger().Warn("Pending migrations", "error", err)
+	return nil
+}
+
+// handleReadyz reports whether the app can serve traffic: the database answers and
+// no registered migration is pending
+// The endpoint is public, so the body only says ready or not; the reason is logged and
+// shown on the LiveDashboard
+func (a *App) handleReadyz(c *gin.Context) {
+	if err := a.readiness(c.Request.Context()); err != nil {
+		a.Logger().Warn("Not ready", "error", err)
+		c.JSON(503, gin.H{"status": "not ready"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "ready"})
+}
+
+// readiness returns why the app can't serve traffic, or nil
+func (a *App) readiness(ctx context.Context) error {
+	if a.DB == nil && a.sqlDB == nil {
+		return nil
+	}
+	if err := a.pingDB(ctx); err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
+	if len(a.migrations) == 0 || a.DB == nil {
+		return nil
+	}
+	status, err := a.MigrationStatus()
+	if err != nil {
+		return fmt.Errorf("migrations: %w", err)
+	}
+	if len(status.Pending) > 0 {
+		return fmt.Errorf("%d pending migrations: %s", len(status.Pending), strings.Join(status.Pending, ", "))
+	}
+	return nil
+}
+
+// migrationCheck shows the migration status on the LiveDashboard
+func (a *App) migrationCheck() (string, bool) {
+	if len(a.migrations) == 0 {
+		return "No migrations registered", true
+	}
+	status, err := a.MigrationStatus()
+	if err != nil {
+		return err.Error(), false
+	}
+	if len(status.Pending) > 0 {
+		return fmt.Sprintf("%d pending: %s", len(status.Pending), strings.Join(status.Pending, ", ")), false
+	}
+	return fmt.Sprintf("%d applied, none pending", len(status.Applied)), true
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/paulmanoni/livenest/liveview"
+	"github.com/paulmanoni/livenest/orm"
+)
+
+// EnableOutbox creates the outbox table and relays committed messages to publisher
+// while the app runs; enqueue them with orm.Enqueue in the transaction of the change
+// Tune the returned relay before Run
+func (a *App) EnableOutbox(publisher orm.Publisher) (*orm.OutboxRelay, error) {
+	if a.DB == nil {
+		return nil, fmt.Errorf("no database connected")
+	}
+	if err := a.DB.AutoMigrate(&orm.OutboxMessage{}); err != nil {
+		return nil, err
+	}
+
+	a.outbox = orm.NewOutboxRelay(a.DB, publisher)
+	a.outbox.OnError = func(msg orm.OutboxMessage, err error) {
+		if msg.ID == 0 {
+			a.Logger().Error("Outbox relay error", "error", err)
+			return
+		}
+		a.Logger().Warn("Outbox publish failed", "id", msg.ID, "topic", msg.Topic, "attempts", msg.Attempts, "error", err)
+	}
+	return a.outbox, nil
+}
+
+// SinkPublisher publishes outbox messages through a submission sink, e.g. a
+// liveview.WebhookSink or SinkFunc; the message topic becomes the submission's Form
+// and its JSON payload the Data
+func SinkPublisher(sink liveview.SubmissionSink) orm.Publisher {
+	return orm.PublisherFunc(func(ctx context.Context, msg orm.OutboxMessage) error {
+		return sink.Emit(ctx, liveview.Submission{
+			Form:        msg.Topic,
+			Data:        msg.Payload,
+			SubmittedAt: msg.CreatedAt,
+		})
+	})
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"crypto/subtle"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EnableProfiling mounts net/http/pprof and the LiveNest profiles under /debug/pprof
+// The endpoints expose internals, so at least one auth middleware is required:
+//
+//	app.EnableProfiling(core.ProfilingTokenAuth(os.Getenv("PPROF_TOKEN")))
+//
+// Besides the standard profiles, livenest.sockets lists connected sockets by where they
+// were mounted, and CPU and goroutine profiles carry livenest.component and livenest.event labels
+func (a *App) EnableProfiling(auth ...gin.HandlerFunc) {
+	if len(auth) == 0 {
+		a.Logger().Warn("Profiling endpoints not mounted: EnableProfiling requires an auth middleware")
+		return
+	}
+
+	a.lvHandler.SetProfiling(true)
+
+	group := a.Router.Group("/debug/pprof", auth...)
+	group.GET("/", gin.WrapF(pprof.Index))
+	group.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	group.GET("/profile", gin.WrapF(pprof.Profile))
+	group.GET("/symbol", gin.WrapF(pprof.Symbol))
+	group.POST("/symbol", gin.WrapF(pprof.Symbol))
+	group.GET("/trace", gin.WrapF(pprof.Trace))
+	group.GET("/:name", func(c *gin.Context) {
+		pprof.Handler(c.Param("name")).ServeHTTP(c.Writer, c.Request)
+	})
+
+	a.Logger().Info("Profiling endpoints mounted", "path", "/debug/pprof")
+}
+
+// ProfilingTokenAuth only lets through requests carrying token, either as a bearer
+// token or in the token query parameter (for go tool pprof, which can't set headers)
+func ProfilingTokenAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if given == "" {
+			given = c.Query("token")
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatus(401)
+			return
+		}
+		c.Next()
+	}
+}
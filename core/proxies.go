@@ -0,0 +1,91 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/paulmanoni/livenest/liveview"
+)
+
+// trustedProxies are the networks of server.trusted_proxies, whose forwarded headers
+// name the client
+type trustedProxies []*net.IPNet
+
+// parseTrustedProxies parses IPs and CIDRs; a bare IP trusts that address only
+func parseTrustedProxies(list []string) (trustedProxies, error) {
+	var proxies trustedProxies
+	for _, entry := range list {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("trusted proxy %q is neither an IP nor a CIDR", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			entry = fmt.Sprintf("%s/%d", ip, bits)
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q is neither an IP nor a CIDR", entry)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// trusts reports whether ip belongs to a trusted proxy
+func (p trustedProxies) trusts(ip net.IP) bool {
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// fromProxy reports whether the peer of r is a trusted proxy
+func (p trustedProxies) fromProxy(r *http.Request) bool {
+	ip := net.ParseIP(liveview.RemoteIP(r))
+	return ip != nil && p.trusts(ip)
+}
+
+// clientIP returns the IP of the client that made r, the way gin's ClientIP does with
+// the same proxies: forwarded headers are read only when the peer is a trusted proxy,
+// and X-Forwarded-For is walked from the right past the proxies it names
+func (p trustedProxies) clientIP(r *http.Request) string {
+	remote := liveview.RemoteIP(r)
+	if !p.fromProxy(r) {
+		return remote
+	}
+	for _, header := range []string{"X-Forwarded-For", "X-Real-IP"} {
+		value := r.Header.Get(header)
+		if value == "" {
+			continue
+		}
+		items := strings.Split(value, ",")
+		for i := len(items) - 1; i >= 0; i-- {
+			item := strings.TrimSpace(items[i])
+			ip := net.ParseIP(item)
+			if ip == nil {
+				break
+			}
+			if i == 0 || !p.trusts(ip) {
+				return item
+			}
+		}
+	}
+	return remote
+}
+
+// scheme returns the scheme r was made with, taking X-Forwarded-Proto from trusted
+// proxies only
+func (p trustedProxies) scheme(r *http.Request) string {
+	if r.TLS != nil || p.fromProxy(r) && r.Header.Get("X-Forwarded-Proto") == "https" {
+		return "https"
+	}
+	return "http"
+}
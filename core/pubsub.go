@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+
+	"github.com/paulmanoni/livenest/liveview"
+	"github.com/paulmanoni/livenest/orm"
+	"github.com/paulmanoni/livenest/pubsub"
+)
+
+// EnablePubSub opens the app's bus as configured: on pubsub_file when it is set, so
+// broadcasts survive restarts, and in memory otherwise, retaining pubsub_capacity messages
+func (a *App) EnablePubSub() (*pubsub.Bus, error) {
+	var bus *pubsub.Bus
+	if a.config.PubSubFile != "" {
+		var err error
+		if bus, err = pubsub.OpenBus(a.config.PubSubFile, a.config.PubSubCapacity); err != nil {
+			return nil, err
+		}
+	} else {
+		bus = pubsub.NewBus(a.config.PubSubCapacity)
+	}
+	a.SetPubSub(bus)
+	return bus, nil
+}
+
+// SetPubSub sets the app's bus, e.g. one backed by an external broker
+func (a *App) SetPubSub(ps pubsub.PubSub) {
+	a.pubsubMu.Lock()
+	defer a.pubsubMu.Unlock()
+	a.pubsub = ps
+}
+
+// PubSub returns the app's bus; without EnablePubSub or SetPubSub it is an in-memory Bus
+func (a *App) PubSub() pubsub.PubSub {
+	a.pubsubMu.Lock()
+	defer a.pubsubMu.Unlock()
+	if a.pubsub == nil {
+		a.pubsub = pubsub.NewBus(a.config.PubSubCapacity)
+	}
+	return a.pubsub
+}
+
+// Broadcast publishes payload on a topic of the app's bus, to every socket subscribed to it
+func (a *App) Broadcast(ctx context.Context, topic string, payload interface{}) error {
+	_, err := a.PubSub().Publish(ctx, topic, payload)
+	return err
+}
+
+// ThrottleTopic limits how many messages of a broadcast topic each socket receives per
+// second, e.g. app.ThrottleTopic("prices.*", liveview.Throttle{Rate: 10, Coalesce: true})
+// Rules can also be changed at runtime from the LiveDashboard
+func (a *App) ThrottleTopic(topic string, throttle liveview.Throttle) {
+	a.lvHandler.ThrottleTopic(topic, throttle)
+}
+
+// BusPublisher publishes outbox messages on a bus, so committed changes reach the
+// sockets subscribed to their topic
+//
+//	app.EnableOutbox(core.BusPublisher(app.PubSub()))
+func BusPublisher(ps pubsub.PubSub) orm.Publisher {
+	return orm.PublisherFunc(func(ctx context.Context, msg orm.OutboxMessage) error {
+		_, err := ps.Publish(ctx, msg.Topic, msg.Payload)
+		return err
+	})
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/paulmanoni/livenest/liveview"
+	"github.com/paulmanoni/livenest/orm"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// queryLogger forwards GORM queries to the LiveView debug overlay and the index advisor
+type queryLogger struct {
+	logger.Interface
+	advisor *orm.IndexAdvisor
+}
+
+// QueryLogger wraps a GORM logger so queries run with a socket's context show up in the debug overlay
+// ConnectDB installs it in debug mode; use it directly for databases opened elsewhere:
+//
+//	db.Logger = core.QueryLogger(db.Logger)
+func QueryLogger(base logger.Interface) logger.Interface {
+	return queryLogger{Interface: base}
+}
+
+// LogMode keeps the wrapper when the log level changes
+func (l queryLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return queryLogger{Interface: l.Interface.LogMode(level), advisor: l.advisor}
+}
+
+// Trace records the query for the overlay and passes it on to the wrapped logger
+func (l queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	recording := liveview.IsRecordingQueries(ctx)
+	if recording || l.advisor != nil {
+		sql, rows := fc()
+		fc = func() (string, int64) { return sql, rows }
+		if l.advisor != nil && (err == nil || errors.Is(err, gorm.ErrRecordNotFound)) {
+			l.advisor.Observe(sql, time.Since(begin))
+		}
+	}
+
+	if recording {
+		sql, rows := fc()
+		record := liveview.QueryRecord{SQL: sql, Duration: time.Since(begin), Rows: rows}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			record.Error = err.Error()
+		}
+		liveview.RecordQuery(ctx, record)
+	}
+	l.Interface.Trace(ctx, begin, fc, err)
+}
+
+// IndexSuggestions returns indexes that would speed up slow or frequent queries
+// Queries are collected in debug mode; it returns nil otherwise
+func (a *App) IndexSuggestions() []orm.IndexSuggestion {
+	if a.indexAdvisor == nil {
+		return nil
+	}
+	return a.indexAdvisor.Suggestions()
+}
+
+// indexSuggestionRows lists the index suggestions on the LiveDashboard
+func (a *App) indexSuggestionRows() [][]string {
+	var rows [][]string
+	for _, s := range a.IndexSuggestions() {
+		rows = append(rows, []string{
+			s.SQL,
+			s.Query,
+			strconv.Itoa(s.Calls),
+			s.AvgDuration.Round(time.Microsecond).String(),
+			s.Reason,
+		})
+	}
+	return rows
+}
@@ -0,0 +1,178 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paulmanoni/livenest/liveview"
+)
+
+// RateLimitStore counts requests per key in fixed windows
+// Implementations must be safe for concurrent use
+type RateLimitStore interface {
+	// Increment counts a request of key in its current window, starting a window of the
+	// given length when none is running, and returns the count and when the window ends
+	Increment(ctx context.Context, key string, window time.Duration) (count int64, reset time.Time, err error)
+}
+
+// RateLimit describes a rate limit: Limit requests per Window for each client
+type RateLimit struct {
+	Limit   int
+	Window  time.Duration
+	Store   RateLimitStore            // defaults to a MemoryRateLimitStore
+	KeyFunc func(*gin.Context) string // identifies the client; defaults to the IP of the connection's peer
+	Scope   string                    // counts apart from other limits in the same store, e.g. a route
+}
+
+// RateLimitMiddleware refuses requests over a rate limit with 429 Too Many Requests and a
+// Retry-After header. Responses carry X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset headers. When the store fails, e.g. Redis is down, requests go
+// through and the error is added to the gin context
+// Forwarded headers are ignored unless KeyFunc reads them, since any client can send one
+func RateLimitMiddleware(limit RateLimit) gin.HandlerFunc {
+	if limit.Store == nil {
+		limit.Store = NewMemoryRateLimitStore()
+	}
+	if limit.KeyFunc == nil {
+		limit.KeyFunc = func(c *gin.Context) string { return liveview.RemoteIP(c.Request) }
+	}
+	maxCount := strconv.Itoa(limit.Limit)
+
+	return func(c *gin.Context) {
+		key := limit.Scope + "|" + limit.KeyFunc(c)
+		count, reset, err := limit.Store.Increment(c.Request.Context(), key, limit.Window)
+		if err != nil {
+			c.Error(fmt.Errorf("rate limit: %w", err))
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("X-RateLimit-Limit", maxCount)
+		h.Set("X-RateLimit-Remaining", strconv.FormatInt(max(int64(limit.Limit)-count, 0), 10))
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		if count > int64(limit.Limit) {
+			// Round up, so clients retrying on time find the new window
+			retryAfter := (time.Until(reset) + time.Second - 1) / time.Second
+			h.Set("Retry-After", strconv.Itoa(int(max(retryAfter, 1))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimit limits every request to routes registered afterwards to limit per window for
+// each client IP, counted in the store set with SetRateLimitStore
+// The IP is read from forwarded headers only when they come from server.trusted_proxies
+// The LiveView WebSocket has its own limits, see Config.ConnectsPerMinute
+func (a *App) RateLimit(limit int, window time.Duration) {
+	a.Router.Use(RateLimitMiddleware(RateLimit{Limit: limit, Window: window, Store: a.RateLimitStore(), KeyFunc: (*gin.Context).ClientIP, Scope: "app"}))
+}
+
+// SetRateLimitStore sets where App.RateLimit and HandlerBuilder.RateLimit count requests,
+// e.g. a RedisRateLimitStore so the limits hold across nodes; call it before setting limits
+func (a *App) SetRateLimitStore(store RateLimitStore) {
+	a.rateLimitMu.Lock()
+	defer a.rateLimitMu.Unlock()
+	a.rateLimitStore = store
+}
+
+// RateLimitStore returns the app's rate limit store; it is kept in memory unless set
+// with SetRateLimitStore
+func (a *App) RateLimitStore() RateLimitStore {
+	a.rateLimitMu.Lock()
+	defer a.rateLimitMu.Unlock()
+	if a.rateLimitStore == nil {
+		a.rateLimitStore = NewMemoryRateLimitStore()
+	}
+	return a.rateLimitStore
+}
+
+// MemoryRateLimitStore counts requests in memory, for a single node
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	windows   map[string]rateWindow
+	lastSweep time.Time
+}
+
+// rateWindow is the count of a key in its current window
+type rateWindow struct {
+	count int64
+	reset time.Time
+}
+
+// NewMemoryRateLimitStore creates an empty store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{windows: make(map[string]rateWindow)}
+}
+
+// Increment counts a request of key
+func (s *MemoryRateLimitStore) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Time, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop the windows that ended, now and then
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, w := range s.windows {
+			if !now.Before(w.reset) {
+				delete(s.windows, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	w, ok := s.windows[key]
+	if !ok || !now.Before(w.reset) {
+		w = rateWindow{reset: now.Add(window)}
+	}
+	w.count++
+	s.windows[key] = w
+	return w.count, w.reset, nil
+}
+
+// RedisRateLimitStore counts requests in Redis under Prefix+key, so every node of the app
+// shares the counts
+type RedisRateLimitStore struct {
+	Client RedisDoer
+	Prefix string // key prefix (default "livenest:ratelimit:")
+}
+
+// NewRedisRateLimitStore creates a store on client, e.g. NewRedisClient or a go-redis
+// client adapted with RedisDoerFunc
+func NewRedisRateLimitStore(client RedisDoer) *RedisRateLimitStore {
+	return &RedisRateLimitStore{Client: client, Prefix: "livenest:ratelimit:"}
+}
+
+// incrementScript counts a request and starts the window's expiry on the first, in one
+// round trip; it returns the count and the milliseconds left in the window
+const incrementScript = `local n = redis.call('INCR', KEYS[1])
+if n == 1 then redis.call('PEXPIRE', KEYS[1], ARGV[1]) end
+return {n, redis.call('PTTL', KEYS[1])}`
+
+// Increment counts a request of key
+func (s *RedisRateLimitStore) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Time, error) {
+	reply, err := s.Client.Do(ctx, "EVAL", incrementScript, 1, s.Prefix+key, window.Milliseconds())
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return 0, time.Time{}, fmt.Errorf("redis EVAL: unexpected reply %T", reply)
+	}
+	count, ok1 := values[0].(int64)
+	ttl, ok2 := values[1].(int64)
+	if !ok1 || !ok2 {
+		return 0, time.Time{}, fmt.Errorf("redis EVAL: unexpected reply %v", values)
+	}
+	if ttl < 0 {
+		ttl = window.Milliseconds()
+	}
+	return count, time.Now().Add(time.Duration(ttl) * time.Millisecond), nil
+}
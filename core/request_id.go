@@ -0,0 +1,105 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paulmanoni/livenest/liveview"
+)
+
+// RequestIDHeader is the header a request ID is read from and returned in
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key of the request ID
+const requestIDKey = "livenest.request_id"
+
+// maxRequestIDLength bounds the request IDs accepted from clients and proxies
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware gives every request an ID: the one in its X-Request-ID header, as
+// set by a proxy in front of the app, or a new random one. The ID is returned in the
+// X-Request-ID response header, read with GetRequestID, and carried to the LiveView
+// sockets the request renders or opens, whose log records include it as request_id.
+// A socket joined from a server render keeps the ID of the page request, so a visitor's
+// page load and WebSocket share one ID. New installs it on every app
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(liveview.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID RequestIDMiddleware gave the request, or ""
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// AccessLogMiddleware logs every request once it is served, with its status, duration,
+// size and request ID; nil logs to slog.Default()
+// Set access_log in the config to log to the app's logger in place of gin's request log
+func AccessLogMiddleware(logger liveview.Logger) gin.HandlerFunc {
+	return accessLog(func() liveview.Logger {
+		if logger == nil {
+			return slog.Default()
+		}
+		return logger
+	})
+}
+
+// accessLog logs every request to the logger returned by logger at the time
+func accessLog(logger func() liveview.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		c.Next()
+
+		log := logger()
+		args := []any{
+			"method", c.Request.Method,
+			"path", path,
+			"status", c.Writer.Status(),
+			"duration", time.Since(start),
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+			"request_id", GetRequestID(c),
+		}
+		if errs := c.Errors.ByType(gin.ErrorTypePrivate); len(errs) > 0 {
+			log.Error("Request", append(args, "error", errs.String())...)
+			return
+		}
+		log.Info("Request", args...)
+	}
+}
+
+// newRequestID returns a random request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID reports whether a request ID received in a header can be used as is:
+// not empty, not too long, and made of characters that are safe in logs and headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
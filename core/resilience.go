@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+
+	"github.com/paulmanoni/livenest/orm"
+)
+
+// EnableDBResilience retries transient database errors of App.Query operations and
+// opens a circuit breaker while the database is down; call it after connecting
+// Components failing with the breaker open show a friendly unavailable state and mount
+// again once a recovery probe reaches the database. Tune the returned policy before use
+func (a *App) EnableDBResilience() *orm.Resilience {
+	if a.resilience != nil {
+		a.resilience.Stop()
+	}
+	a.resilience = orm.NewResilience(a.pingDB)
+	a.resilience.OnStateChange = func(open bool) {
+		if open {
+			a.Logger().Error("Database unavailable: circuit breaker opened")
+		} else {
+			a.Logger().Info("Database recovered: circuit breaker closed")
+		}
+	}
+	return a.resilience
+}
+
+// Query returns a QuerySet on the app's database, resilient if EnableDBResilience was called
+func (a *App) Query() orm.QuerySet {
+	var qs orm.QuerySet
+	if a.sqlDB != nil {
+		qs = orm.NewSQLQuerySet(a.sqlDB, a.config.Database.Driver)
+	} else {
+		qs = orm.NewQuerySet(a.DB)
+	}
+	if a.resilience != nil {
+		qs = a.resilience.Wrap(qs)
+	}
+	return qs
+}
+
+// pingDB checks that the app's database answers
+func (a *App) pingDB(ctx context.Context) error {
+	if a.sqlDB != nil {
+		return a.sqlDB.PingContext(ctx)
+	}
+	sqlDB, err := a.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+)
+
+// NameRoute names a route path, e.g. NameRoute("todo.show", "/todos/:id"), so URLFor and
+// the "route" template function can build its URLs. The handler builder's Name does this
+// for the routes it registers
+func (a *App) NameRoute(name, path string) {
+	a.routesMu.Lock()
+	defer a.routesMu.Unlock()
+	if existing, ok := a.routes[name]; ok && existing != path {
+		a.Logger().Error("Route name already used", "name", name, "path", existing, "ignored", path)
+		return
+	}
+	if a.routes == nil {
+		a.routes = make(map[string]string)
+	}
+	a.routes[name] = path
+}
+
+// URLFor returns the URL of a named route
+// params fill the route's :name and *name segments, and the rest become the query string:
+//
+//	app.URLFor("todo.show", map[string]interface{}{"id": 7, "tab": "notes"}) // /todos/7?tab=notes
+func (a *App) URLFor(name string, params map[string]interface{}) (string, error) {
+	path, err := a.routePath(name)
+	if err != nil {
+		return "", err
+	}
+	query := make(url.Values)
+	for key, value := range params {
+		query.Set(key, fmt.Sprint(value))
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == "" || segment[0] != ':' && segment[0] != '*' {
+			continue
+		}
+		key := segment[1:]
+		if !query.Has(key) {
+			return "", fmt.Errorf("route %q: missing parameter %q", name, key)
+		}
+		segments[i] = escapeRouteParam(query.Get(key), segment[0] == '*')
+		query.Del(key)
+	}
+
+	u := strings.Join(segments, "/")
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u, nil
+}
+
+// RouteFuncs returns the template function "route", which builds the URL of a named route
+// Arguments fill the path parameters in order, and any pairs after them the query string:
+//
+//	<a href="{{route "todo.show" .ID "tab" "notes"}}">
+func (a *App) RouteFuncs() template.FuncMap {
+	return template.FuncMap{"route": a.route}
+}
+
+// route builds a URL from positional arguments for the "route" template function
+func (a *App) route(name string, args ...interface{}) (string, error) {
+	path, err := a.routePath(name)
+	if err != nil {
+		return "", err
+	}
+
+	params := make(map[string]interface{})
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment[0] != ':' && segment[0] != '*' {
+			continue
+		}
+		if len(args) == 0 {
+			return "", fmt.Errorf("route %q: missing parameter %q", name, segment[1:])
+		}
+		params[segment[1:]] = args[0]
+		args = args[1:]
+	}
+	if len(args)%2 != 0 {
+		return "", fmt.Errorf("route %q: query arguments must be key and value pairs", name)
+	}
+	for i := 0; i < len(args); i += 2 {
+		params[fmt.Sprint(args[i])] = args[i+1]
+	}
+	return a.URLFor(name, params)
+}
+
+// routePath returns the path of a named route
+func (a *App) routePath(name string) (string, error) {
+	a.routesMu.RLock()
+	defer a.routesMu.RUnlock()
+	path, ok := a.routes[name]
+	if !ok {
+		return "", fmt.Errorf("route %q not found", name)
+	}
+	return path, nil
+}
+
+// escapeRouteParam escapes a path parameter; a catch-all keeps its slashes
+func escapeRouteParam(value string, catchAll bool) string {
+	if !catchAll {
+		return url.PathEscape(value)
+	}
+	parts := strings.Split(strings.TrimPrefix(value, "/"), "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+
+	"github.com/paulmanoni/livenest/cron"
+)
+
+// Schedule runs fn on a cron schedule while the app runs, e.g. "*/5 * * * *" for every
+// five minutes or "@every 30s"; see the cron package for the syntax. Failures and panics
+// are logged. Each node of the app runs its schedules
+//
+//	app.Schedule("*/5 * * * *", func(ctx context.Context) error {
+//		stats, err := loadStats(ctx)
+//		if err != nil {
+//			return err
+//		}
+//		return app.Broadcast(ctx, "stats", stats)
+//	})
+func (a *App) Schedule(spec string, fn func(ctx context.Context) error) (int, error) {
+	return a.Scheduler().Add(spec, fn)
+}
+
+// ScheduleBroadcast publishes what fn returns on a topic of the app's bus on a cron
+// schedule, so every socket subscribed to it refreshes at once instead of each client
+// polling
+//
+//	app.ScheduleBroadcast("@every 1m", "dashboard.stats", func(ctx context.Context) (interface{}, error) {
+//		return loadStats(ctx)
+//	})
+func (a *App) ScheduleBroadcast(spec, topic string, fn func(ctx context.Context) (interface{}, error)) (int, error) {
+	return a.Schedule(spec, func(ctx context.Context) error {
+		payload, err := fn(ctx)
+		if err != nil {
+			return err
+		}
+		return a.Broadcast(ctx, topic, payload)
+	})
+}
+
+// Scheduler returns the app's scheduler, created on first use
+func (a *App) Scheduler() *cron.Scheduler {
+	a.schedulerMu.Lock()
+	defer a.schedulerMu.Unlock()
+	if a.scheduler == nil {
+		a.scheduler = cron.New()
+		a.scheduler.OnError = func(entry cron.Entry, err error) {
+			a.Logger().Warn("Scheduled task failed", "task", entry.ID, "spec", entry.Spec, "error", err)
+		}
+	}
+	return a.scheduler
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ErrSecretNotFound is returned by a SecretProvider that has no secret by the name asked
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretProvider looks up secrets by name, e.g. in the environment, in files mounted by
+// the orchestrator or in a vault, so they don't have to be written in config files
+type SecretProvider interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// SecretProviderFunc adapts a function to a SecretProvider
+type SecretProviderFunc func(ctx context.Context, name string) (string, error)
+
+// Secret calls f
+func (f SecretProviderFunc) Secret(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+// EnvSecrets reads secrets from environment variables
+type EnvSecrets struct{}
+
+// Secret returns the value of the variable name
+func (EnvSecrets) Secret(ctx context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s: %w", name, ErrSecretNotFound)
+	}
+	return value, nil
+}
+
+// FileSecrets reads secrets from files, e.g. Docker or Kubernetes secrets
+// Relative names are read from Dir; a trailing newline is dropped
+type FileSecrets struct {
+	Dir string
+}
+
+// Secret returns the content of the file name
+func (f FileSecrets) Secret(ctx context.Context, name string) (string, error) {
+	path := name
+	if !filepath.IsAbs(path) && f.Dir != "" {
+		path = filepath.Join(f.Dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("file %s: %w", path, ErrSecretNotFound)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+var (
+	secretProvidersMu sync.RWMutex
+	secretProviders   = map[string]SecretProvider{
+		"env":  EnvSecrets{},
+		"file": FileSecrets{},
+	}
+)
+
+// RegisterSecretProvider makes a provider available to config values under a scheme,
+// e.g. "vault" for values like "${vault:secret/data/app#session_key}"
+// The env and file schemes are built in; registering them again replaces them
+func RegisterSecretProvider(scheme string, provider SecretProvider) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+	secretProviders[scheme] = provider
+}
+
+// secretReference parses a value written "${scheme:name}"; ok is false for values that
+// aren't references, and the error describes a reference that can't be resolved
+// A value starting with "$${" is literal, with the first $ dropped
+func secretReference(value string) (provider SecretProvider, name string, ok bool, err error) {
+	if !strings.HasPrefix(value, "${") {
+		return nil, "", false, nil
+	}
+	if !strings.HasSuffix(value, "}") {
+		return nil, "", true, errors.New(`secret reference isn't closed with "}"; write "$${" for a value starting with "${"`)
+	}
+	scheme, name, _ := strings.Cut(value[2:len(value)-1], ":")
+	if name == "" {
+		return nil, "", true, fmt.Errorf("secret reference of %q has no name", scheme)
+	}
+	secretProvidersMu.RLock()
+	defer secretProvidersMu.RUnlock()
+	provider, found := secretProviders[scheme]
+	if !found {
+		return nil, "", true, fmt.Errorf("no secret provider is registered for %q", scheme)
+	}
+	return provider, name, true, nil
+}
+
+// ResolveSecrets replaces secret references in the secret settings with the secrets
+// they name: secret_key, liveview_secret, profiling_token, metrics_token,
+// database.password and mail.password may be set to "${env:NAME}", "${file:/run/secrets/name}"
+// or a reference of a registered provider instead of the secret itself. Other values are kept as they are
+// The config loaders call it last; it reports every secret that can't be read in a *ConfigError
+func (c *Config) ResolveSecrets(ctx context.Context) error {
+	var problems []string
+	resolveSecrets(ctx, reflect.ValueOf(c).Elem(), "", &problems)
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// resolveSecrets resolves the string fields tagged secret:"true", by their config keys
+func resolveSecrets(ctx context.Context, v reflect.Value, prefix string, problems *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		f := v.Field(i)
+		if f.Kind() == reflect.Struct {
+			resolveSecrets(ctx, f, prefix+key+".", problems)
+			continue
+		}
+		if f.Kind() != reflect.String || field.Tag.Get("secret") != "true" {
+			continue
+		}
+		value := f.String()
+		if strings.HasPrefix(value, "$${") {
+			f.SetString(value[1:])
+			continue
+		}
+		provider, name, ok, err := secretReference(value)
+		if !ok {
+			continue
+		}
+		secret := ""
+		if err == nil {
+			secret, err = provider.Secret(ctx, name)
+		}
+		if err != nil {
+			// The reference is named rather than the value, which may hold part of a secret
+			*problems = append(*problems, fmt.Sprintf("%s%s: %v", prefix, key, err))
+			continue
+		}
+		f.SetString(secret)
+	}
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"net/http"
+	"time"
+)
+
+// Server defaults applied when the config leaves them unset; gin sets none,
+// which leaves slow clients free to hold connections open forever
+const (
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultIdleTimeout       = 2 * time.Minute
+)
+
+// HTTPServer returns the server Run would start on addr, tuned by the server config,
+// e.g. to call Shutdown on it or serve it on your own listener
+// WebSocket connections aren't bound by the read and write timeouts once upgraded
+func (a *App) HTTPServer(addr string) *http.Server {
+	cfg := a.config.Server
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           a.Router,
+		ReadTimeout:       time.Duration(cfg.ReadTimeout) * time.Millisecond,
+		ReadHeaderTimeout: time.Duration(cfg.ReadHeaderTimeout) * time.Millisecond,
+		WriteTimeout:      time.Duration(cfg.WriteTimeout) * time.Millisecond,
+		IdleTimeout:       time.Duration(cfg.IdleTimeout) * time.Millisecond,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		HTTP2: &http.HTTP2Config{
+			MaxConcurrentStreams: cfg.HTTP2MaxStreams,
+			MaxReadFrameSize:     cfg.HTTP2MaxReadFrameSize,
+		},
+	}
+	if srv.ReadHeaderTimeout == 0 {
+		srv.ReadHeaderTimeout = defaultReadHeaderTimeout
+	}
+	if srv.IdleTimeout == 0 {
+		srv.IdleTimeout = defaultIdleTimeout
+	}
+	if cfg.DisableKeepAlives {
+		srv.SetKeepAlivesEnabled(false)
+	}
+
+	srv.Protocols = new(http.Protocols)
+	srv.Protocols.SetHTTP1(true)
+	srv.Protocols.SetHTTP2(!cfg.DisableHTTP2)
+	srv.Protocols.SetUnencryptedHTTP2(cfg.H2C)
+	return srv
+}
@@ -0,0 +1,328 @@
+package core
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paulmanoni/livenest/liveview"
+)
+
+// SessionCookieName is the default name of the session cookie
+const SessionCookieName = "livenest_session"
+
+// maxCookieSize is the largest cookie browsers are guaranteed to keep
+const maxCookieSize = 4096
+
+// sessionKey is the gin context key of the request's session
+const sessionKey = "livenest.session"
+
+// ErrSessionTooLarge is returned by CookieStore.Save when the session doesn't fit in a cookie
+var ErrSessionTooLarge = errors.New("session too large for a cookie; use a server-side session store")
+
+// ErrWeakSecret is returned by session stores made with an empty or default secret,
+// which anyone could use to forge sessions, unless AllowWeakSecret is set
+var ErrWeakSecret = errors.New("session secret is empty or the default; set a random secret_key")
+
+// weakSecret reports whether secret is empty or the placeholder of DefaultConfig
+func weakSecret(secret string) bool {
+	return secret == "" || secret == defaultSecret
+}
+
+// SessionStore loads and saves the session of a request
+// Load returns an empty session for a request without one or with an invalid one
+type SessionStore interface {
+	Load(r *http.Request) (*liveview.Session, error)
+	Save(w http.ResponseWriter, r *http.Request, session *liveview.Session) error
+}
+
+// sessionState is the stored form of a session
+// Values go through JSON, so numbers come back as float64 and structs as maps
+type sessionState struct {
+	Values  map[string]interface{} `json:"v,omitempty"`
+	Flashes []liveview.Flash       `json:"f,omitempty"`
+	Expires int64                  `json:"e,omitempty"` // unix seconds; the cookie's own expiry can't be trusted
+}
+
+// encodeSession returns the stored form of a session
+func encodeSession(session *liveview.Session) ([]byte, error) {
+	return json.Marshal(sessionState{Values: session.Values(), Flashes: session.PeekFlashes()})
+}
+
+// decodeSession restores a session from its stored form
+func decodeSession(data []byte) (*liveview.Session, error) {
+	var state sessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	session := liveview.NewSession()
+	for key, value := range state.Values {
+		session.Put(key, value)
+	}
+	for _, flash := range state.Flashes {
+		session.AddFlash(flash)
+	}
+	return session, nil
+}
+
+// CookieStore keeps sessions in an encrypted cookie, so the server keeps no state
+// The cookie is sealed with AES-GCM under a key derived from the secret: the client can
+// neither read nor change it. Sessions are limited to about 3 KB of JSON
+type CookieStore struct {
+	Name     string        // cookie name (default SessionCookieName)
+	Path     string        // cookie path (default "/")
+	Domain   string        // cookie domain (default the request host)
+	MaxAge   time.Duration // lifetime; 0 keeps the session until the browser closes
+	Secure   bool          // send the cookie over HTTPS only
+	SameSite http.SameSite // default http.SameSiteLaxMode
+
+	// AllowWeakSecret accepts an empty or default secret, for development; EnableSessions
+	// sets it in debug mode. Otherwise such a store fails with ErrWeakSecret
+	AllowWeakSecret bool
+
+	aead cipher.AEAD
+	weak bool
+}
+
+// NewCookieStore creates a cookie store sealing sessions with secret, e.g. the SecretKey
+// Changing the secret invalidates every session
+func NewCookieStore(secret string) *CookieStore {
+	key := sha256.Sum256([]byte("livenest-session:" + secret))
+	block, _ := aes.NewCipher(key[:]) // a 32-byte key never fails
+	aead, _ := cipher.NewGCM(block)
+	return &CookieStore{aead: aead, weak: weakSecret(secret)}
+}
+
+// Load opens the session cookie of the request
+// A missing, tampered or expired cookie gives an empty session without an error
+func (s *CookieStore) Load(r *http.Request) (*liveview.Session, error) {
+	if s.weak && !s.AllowWeakSecret {
+		return nil, ErrWeakSecret
+	}
+	cookie, err := r.Cookie(s.name())
+	if err != nil {
+		return liveview.NewSession(), nil
+	}
+	data, ok := s.open(cookie.Value)
+	if !ok {
+		return liveview.NewSession(), nil
+	}
+	var state sessionState
+	if json.Unmarshal(data, &state) != nil || (state.Expires > 0 && time.Now().Unix() > state.Expires) {
+		return liveview.NewSession(), nil
+	}
+	return decodeSession(data)
+}
+
+// Save writes the session cookie; an empty session deletes it
+func (s *CookieStore) Save(w http.ResponseWriter, r *http.Request, session *liveview.Session) error {
+	if s.weak && !s.AllowWeakSecret {
+		return ErrWeakSecret
+	}
+	cookie := s.cookie()
+	state := sessionState{Values: session.Values(), Flashes: session.PeekFlashes()}
+	if len(state.Values) == 0 && len(state.Flashes) == 0 {
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+		return nil
+	}
+	if s.MaxAge > 0 {
+		state.Expires = time.Now().Add(s.MaxAge).Unix()
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("session: %w", err)
+	}
+	cookie.Value = s.seal(data)
+	if len(cookie.String()) > maxCookieSize {
+		return ErrSessionTooLarge
+	}
+	http.SetCookie(w, cookie)
+	return nil
+}
+
+// cookie returns the session cookie without its value
+func (s *CookieStore) cookie() *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     s.name(),
+		Path:     s.Path,
+		Domain:   s.Domain,
+		Secure:   s.Secure,
+		HttpOnly: true,
+		SameSite: s.SameSite,
+	}
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if cookie.SameSite == 0 {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	if s.MaxAge > 0 {
+		cookie.MaxAge = int(s.MaxAge / time.Second)
+	}
+	return cookie
+}
+
+// name returns the cookie name
+func (s *CookieStore) name() string {
+	if s.Name == "" {
+		return SessionCookieName
+	}
+	return s.Name
+}
+
+// seal encrypts data with a random nonce; the cookie name is authenticated with it, so
+// a value can't be moved to another cookie
+func (s *CookieStore) seal(data []byte) string {
+	nonce := make([]byte, s.aead.NonceSize(), s.aead.NonceSize()+len(data)+s.aead.Overhead())
+	rand.Read(nonce)
+	return base64.RawURLEncoding.EncodeToString(s.aead.Seal(nonce, nonce, data, []byte(s.name())))
+}
+
+// open decrypts a sealed value
+func (s *CookieStore) open(value string) ([]byte, bool) {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return nil, false
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	data, err := s.aead.Open(nil, nonce, ciphertext, []byte(s.name()))
+	return data, err == nil
+}
+
+// SessionMiddleware loads the session of each request from store and saves it, when
+// it changed or its ID renewal was requested, before the response is written
+// Handlers reach it with GetSession, and LiveView sockets rendered for the request
+// share it as Socket.Session. Failures are logged to slog's default logger; sessions
+// enabled with App.EnableSessions log to the app's logger
+func SessionMiddleware(store SessionStore) gin.HandlerFunc {
+	return sessionMiddleware(store, func() liveview.Logger { return slog.Default() })
+}
+
+// sessionMiddleware is SessionMiddleware logging to the logger returned by logger
+func sessionMiddleware(store SessionStore, logger func() liveview.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session, err := store.Load(c.Request)
+		if err != nil {
+			logger().Warn("Session load failed", "error", err)
+			session = liveview.NewSession()
+		}
+		loaded, _ := encodeSession(session)
+
+		c.Set(sessionKey, session)
+		c.Request = c.Request.WithContext(liveview.WithSession(c.Request.Context(), session))
+
+		w := &sessionWriter{ResponseWriter: c.Writer}
+		w.save = func() {
+			current, err := encodeSession(session)
+			if err == nil && bytes.Equal(current, loaded) && !session.RenewRequested() {
+				return
+			}
+			if err == nil {
+				err = store.Save(w.ResponseWriter, c.Request, session)
+			}
+			if err != nil {
+				logger().Error("Session save failed", "path", c.Request.URL.Path, "error", err)
+			}
+		}
+		c.Writer = w
+		c.Next()
+		w.saveOnce()
+	}
+}
+
+// GetSession returns the session SessionMiddleware loaded for the request, or nil
+func GetSession(c *gin.Context) *liveview.Session {
+	if session, ok := c.Get(sessionKey); ok {
+		return session.(*liveview.Session)
+	}
+	return nil
+}
+
+// EnableSessions loads and saves a session for every request registered afterwards and
+// for LiveView sockets; call it before registering routes
+// Without a store sessions are kept in a cookie sealed with the SecretKey, marked Secure
+// when the server serves HTTPS. Outside debug mode an empty or default SecretKey leaves
+// every request without a session, since anyone could forge one
+func (a *App) EnableSessions(store ...SessionStore) SessionStore {
+	var s SessionStore
+	if len(store) > 0 {
+		s = store[0]
+	} else {
+		cookies := NewCookieStore(a.config.SecretKey)
+		cookies.Secure = a.config.Server.CertFile != "" || len(a.config.Server.AutocertDomains) > 0
+		cookies.AllowWeakSecret = a.config.Debug
+		s = cookies
+	}
+	if weakSecret(a.config.SecretKey) && !a.config.Debug {
+		a.Logger().Error("Sessions disabled: set a random secret_key")
+	}
+
+	a.Router.Use(sessionMiddleware(s, a.Logger))
+	// The WebSocket route is registered before any middleware, so sockets read the
+	// page's session from the upgrade request themselves
+	a.lvHandler.SetSessionLoader(func(r *http.Request) *liveview.Session {
+		session, err := s.Load(r)
+		if err != nil {
+			a.Logger().Warn("Session load failed", "error", err)
+			return nil
+		}
+		return session
+	})
+	return s
+}
+
+// sessionWriter saves the session right before the response headers are written
+type sessionWriter struct {
+	gin.ResponseWriter
+	save  func()
+	saved bool
+}
+
+// saveOnce saves the session unless it was saved already
+func (w *sessionWriter) saveOnce() {
+	if !w.saved {
+		w.saved = true
+		w.save()
+	}
+}
+
+// WriteHeader saves the session, then writes the status
+func (w *sessionWriter) WriteHeader(code int) {
+	w.saveOnce()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// WriteHeaderNow saves the session, then writes the headers
+func (w *sessionWriter) WriteHeaderNow() {
+	w.saveOnce()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write saves the session, then writes the body
+func (w *sessionWriter) Write(data []byte) (int, error) {
+	w.saveOnce()
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString saves the session, then writes the body
+func (w *sessionWriter) WriteString(s string) (int, error) {
+	w.saveOnce()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Flush saves the session, then flushes the response
+func (w *sessionWriter) Flush() {
+	w.saveOnce()
+	w.ResponseWriter.Flush()
+}
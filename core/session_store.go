@@ -0,0 +1,224 @@
+package core
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/paulmanoni/livenest/liveview"
+	"github.com/paulmanoni/livenest/orm"
+)
+
+// defaultSessionMaxAge is how long server-side sessions last without MaxAge
+const defaultSessionMaxAge = 14 * 24 * time.Hour
+
+// SessionBackend keeps session data by ID for a ServerSessionStore, e.g. in Redis or a
+// database table, so every node of a deployment sees the same sessions
+type SessionBackend interface {
+	// Get returns the data stored for id, or nil when there is none or it expired
+	Get(ctx context.Context, id string) ([]byte, error)
+	// Set stores data for id until ttl has passed
+	Set(ctx context.Context, id string, data []byte, ttl time.Duration) error
+	// Delete removes the data of id
+	Delete(ctx context.Context, id string) error
+}
+
+// ServerSessionStore keeps session data in a backend and only a signed ID in the cookie
+// Unlike CookieStore, sessions can be of any size and are shared by every node using the
+// same backend. Each save extends the session by MaxAge
+type ServerSessionStore struct {
+	Backend  SessionBackend
+	Name     string        // cookie name (default SessionCookieName)
+	Path     string        // cookie path (default "/")
+	Domain   string        // cookie domain (default the request host)
+	MaxAge   time.Duration // lifetime after the last save (default 14 days)
+	Secure   bool          // send the cookie over HTTPS only
+	SameSite http.SameSite // default http.SameSiteLaxMode
+
+	// AllowWeakSecret accepts an empty or default secret, for development; the app's
+	// stores set it in debug mode. Otherwise such a store fails with ErrWeakSecret
+	AllowWeakSecret bool
+
+	key  []byte
+	weak bool
+}
+
+// NewServerSessionStore creates a store keeping sessions in backend, with IDs signed with
+// secret, e.g. the SecretKey
+func NewServerSessionStore(secret string, backend SessionBackend) *ServerSessionStore {
+	key := sha256.Sum256([]byte("livenest-session-id:" + secret))
+	return &ServerSessionStore{Backend: backend, key: key[:], weak: weakSecret(secret)}
+}
+
+// Load reads the session named by the request's cookie from the backend
+// A missing or forged cookie, or a session the backend no longer has, gives an empty
+// session; backend failures are returned
+func (s *ServerSessionStore) Load(r *http.Request) (*liveview.Session, error) {
+	if s.weak && !s.AllowWeakSecret {
+		return nil, ErrWeakSecret
+	}
+	id, ok := s.sessionID(r)
+	if !ok {
+		return liveview.NewSession(), nil
+	}
+	data, err := s.Backend.Get(r.Context(), id)
+	if err != nil {
+		return nil, fmt.Errorf("session: %w", err)
+	}
+	if data == nil {
+		return liveview.NewSession(), nil
+	}
+	session, err := decodeSession(data)
+	if err != nil {
+		// Data this version can't read starts over rather than failing every request
+		return liveview.NewSession(), nil
+	}
+	return session, nil
+}
+
+// Save stores the session in the backend and writes the cookie naming it
+// An empty session is deleted along with its cookie. A session whose ID renewal was
+// requested with Session.RenewID is moved to a new ID and its old entry deleted
+func (s *ServerSessionStore) Save(w http.ResponseWriter, r *http.Request, session *liveview.Session) error {
+	if s.weak && !s.AllowWeakSecret {
+		return ErrWeakSecret
+	}
+	cookie := s.cookie()
+	id, ok := s.sessionID(r)
+
+	data, err := encodeSession(session)
+	if err != nil {
+		return fmt.Errorf("session: %w", err)
+	}
+	if len(session.Values()) == 0 && len(session.PeekFlashes()) == 0 {
+		if ok {
+			if err := s.Backend.Delete(r.Context(), id); err != nil {
+				return fmt.Errorf("session: %w", err)
+			}
+		}
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+		return nil
+	}
+
+	if ok && session.RenewRequested() {
+		if err := s.Backend.Delete(r.Context(), id); err != nil {
+			return fmt.Errorf("session: %w", err)
+		}
+		ok = false
+	}
+	if !ok {
+		id = newSessionID()
+	}
+	if err := s.Backend.Set(r.Context(), id, data, s.maxAge()); err != nil {
+		return fmt.Errorf("session: %w", err)
+	}
+	cookie.Value = id + "." + s.sign(id)
+	http.SetCookie(w, cookie)
+	return nil
+}
+
+// sessionID returns the verified session ID of the request's cookie
+func (s *ServerSessionStore) sessionID(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(s.name())
+	if err != nil {
+		return "", false
+	}
+	id, sig, ok := strings.Cut(cookie.Value, ".")
+	if !ok || id == "" || !hmac.Equal([]byte(sig), []byte(s.sign(id))) {
+		return "", false
+	}
+	return id, true
+}
+
+// sign returns the signature of a session ID
+func (s *ServerSessionStore) sign(id string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cookie returns the session cookie without its value
+func (s *ServerSessionStore) cookie() *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     s.name(),
+		Path:     s.Path,
+		Domain:   s.Domain,
+		Secure:   s.Secure,
+		HttpOnly: true,
+		SameSite: s.SameSite,
+		MaxAge:   int(s.maxAge() / time.Second),
+	}
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if cookie.SameSite == 0 {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	return cookie
+}
+
+// name returns the cookie name
+func (s *ServerSessionStore) name() string {
+	if s.Name == "" {
+		return SessionCookieName
+	}
+	return s.Name
+}
+
+// maxAge returns the session lifetime
+func (s *ServerSessionStore) maxAge() time.Duration {
+	if s.MaxAge <= 0 {
+		return defaultSessionMaxAge
+	}
+	return s.MaxAge
+}
+
+// newSessionID returns a random session ID
+func newSessionID() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// EnableDBSessions creates the stored_sessions table and keeps sessions in it, so every
+// node sharing the database shares sessions and flashes; expired rows are deleted while
+// the app runs
+func (a *App) EnableDBSessions() (*ServerSessionStore, error) {
+	if a.DB == nil {
+		return nil, fmt.Errorf("no database connected")
+	}
+	if err := a.DB.AutoMigrate(&orm.StoredSession{}); err != nil {
+		return nil, err
+	}
+
+	a.sessions = orm.NewSessionTable(a.DB)
+	a.sessions.OnError = func(err error) {
+		a.Logger().Warn("Session cleanup failed", "error", err)
+	}
+	store := a.serverSessionStore(a.sessions)
+	a.EnableSessions(store)
+	return store, nil
+}
+
+// EnableRedisSessions keeps sessions in Redis through client, e.g. NewRedisClient or a
+// go-redis client adapted with RedisDoerFunc
+func (a *App) EnableRedisSessions(client RedisDoer) *ServerSessionStore {
+	store := a.serverSessionStore(NewRedisSessions(client))
+	a.EnableSessions(store)
+	return store
+}
+
+// serverSessionStore returns a store on backend signed with the SecretKey
+func (a *App) serverSessionStore(backend SessionBackend) *ServerSessionStore {
+	store := NewServerSessionStore(a.config.SecretKey, backend)
+	store.Secure = a.config.Server.CertFile != "" || len(a.config.Server.AutocertDomains) > 0
+	store.AllowWeakSecret = a.config.Debug
+	return store
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paulmanoni/livenest/liveview"
+)
+
+// Sockets lists the connected LiveView sockets
+func (a *App) Sockets() []liveview.SocketInfo {
+	return a.lvHandler.Sockets()
+}
+
+// DisconnectSocket unmounts a connected socket; its client doesn't reconnect it
+func (a *App) DisconnectSocket(id string) bool {
+	return a.lvHandler.Disconnect(id)
+}
+
+// DrainSockets asks every LiveView client to reconnect, in waves spread over a duration
+func (a *App) DrainSockets(ctx context.Context, over time.Duration, waves int) int {
+	return a.lvHandler.Drain(ctx, over, waves)
+}
+
+// EnableSocketAdmin mounts a JSON API for connected sockets under /debug/sockets
+// Like EnableProfiling it requires at least one auth middleware:
+//
+//	GET    /debug/sockets      lists sockets
+//	GET    /debug/sockets/:id  shows one socket
+//	DELETE /debug/sockets/:id  disconnects it
+func (a *App) EnableSocketAdmin(auth ...gin.HandlerFunc) {
+	if len(auth) == 0 {
+		a.Logger().Warn("Socket admin not mounted: EnableSocketAdmin requires an auth middleware")
+		return
+	}
+
+	group := a.Router.Group("/debug/sockets", auth...)
+	group.GET("", func(c *gin.Context) {
+		c.JSON(200, a.Sockets())
+	})
+	group.GET("/:id", func(c *gin.Context) {
+		info, ok := a.lvHandler.SocketInfo(c.Param("id"))
+		if !ok {
+			c.JSON(404, gin.H{"error": "Socket not found"})
+			return
+		}
+		c.JSON(200, info)
+	})
+	group.DELETE("/:id", func(c *gin.Context) {
+		if !a.DisconnectSocket(c.Param("id")) {
+			c.JSON(404, gin.H{"error": "Socket not found"})
+			return
+		}
+		c.Status(204)
+	})
+
+	a.Logger().Info("Socket admin mounted", "path", "/debug/sockets")
+}
@@ -0,0 +1,196 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fingerprinted matches a file name carrying a content hash, e.g. css/app.3fa2c1d8.css
+var fingerprinted = regexp.MustCompile(`^(.*)\.([0-9a-f]{8})(\.[^./]+)?$`)
+
+// StaticAssets serves a directory of static files under fingerprinted URLs
+// URL("app.css") returns /static/app.3fa2c1d8.css, named after the file's content, and
+// such URLs are served with far-future cache headers: a deploy that changes a file
+// changes its URL, so browsers and CDNs never keep a stale copy. Plain names are served
+// too, revalidated on every use
+type StaticAssets struct {
+	prefix string
+	fsys   fs.FS
+
+	mu     sync.Mutex
+	hashes map[string]fileHash // by slash-separated path relative to dir
+}
+
+// fileHash is the content hash of a file when it had a size and modification time
+type fileHash struct {
+	hash    string
+	size    int64
+	modTime time.Time
+}
+
+// NewStaticAssets hashes the files in dir for serving under prefix, e.g. "/static"
+// Files are hashed again when they change, so edits show up without a restart
+func NewStaticAssets(prefix, dir string) (*StaticAssets, error) {
+	return NewStaticAssetsFS(prefix, os.DirFS(dir))
+}
+
+// NewStaticAssetsFS hashes the files of fsys, e.g. an embed.FS shipped in the binary,
+// for serving under prefix
+func NewStaticAssetsFS(prefix string, fsys fs.FS) (*StaticAssets, error) {
+	s := &StaticAssets{prefix: "/" + strings.Trim(prefix, "/"), fsys: fsys, hashes: make(map[string]fileHash)}
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		s.hash(p)
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return s, nil
+}
+
+// URL returns the fingerprinted URL of a file, e.g. URL("css/app.css")
+// A file that doesn't exist keeps its plain URL, so the page still renders
+func (s *StaticAssets) URL(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	hash := s.hash(name)
+	if hash == "" {
+		return s.prefix + "/" + name
+	}
+	ext := path.Ext(name)
+	return s.prefix + "/" + strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// Funcs returns the template function "asset", which returns the URL of a file:
+// <link rel="stylesheet" href="{{asset "app.css"}}">
+func (s *StaticAssets) Funcs() template.FuncMap {
+	return template.FuncMap{"asset": s.URL}
+}
+
+// Register serves the files on router under the prefix
+func (s *StaticAssets) Register(router gin.IRoutes) {
+	router.GET(s.prefix+"/*filepath", s.serve)
+	router.HEAD(s.prefix+"/*filepath", s.serve)
+}
+
+// serve sends a file; fingerprinted URLs of its current content are cached for a year
+func (s *StaticAssets) serve(c *gin.Context) {
+	name := strings.TrimPrefix(path.Clean(c.Param("filepath")), "/")
+	immutable := false
+	if _, err := fs.Stat(s.fsys, name); err != nil {
+		m := fingerprinted.FindStringSubmatch(name)
+		if m == nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		// An outdated hash still gets the current file, e.g. from a page rendered
+		// before a deploy, but it mustn't be cached under the old URL
+		name = m[1] + m[3]
+		immutable = s.hash(name) == m[2]
+	}
+
+	f, err := http.FS(s.fsys).Open("/" + name)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	if immutable {
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		c.Header("Cache-Control", "no-cache")
+	}
+	if hash := s.hash(name); hash != "" {
+		c.Header("ETag", `"`+hash+`"`)
+	}
+	http.ServeContent(c.Writer, c.Request, name, info.ModTime(), f)
+}
+
+// hash returns the content hash of a file, computed again when it changed, or ""
+// when it can't be read
+func (s *StaticAssets) hash(name string) string {
+	info, err := fs.Stat(s.fsys, name)
+	if err != nil || info.IsDir() {
+		return ""
+	}
+	s.mu.Lock()
+	cached, ok := s.hashes[name]
+	s.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.hash
+	}
+
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	sum := hex.EncodeToString(h.Sum(nil))[:8]
+
+	s.mu.Lock()
+	s.hashes[name] = fileHash{hash: sum, size: info.Size(), modTime: info.ModTime()}
+	s.mu.Unlock()
+	return sum
+}
+
+// Static serves the files in dir under prefix with fingerprinted URLs and returns the
+// assets, whose Funcs add the "asset" helper to templates; an empty dir serves the
+// configured static_dir
+//
+//	assets := app.Static("/static", "")
+//	engine.AddFuncs(assets.Funcs())
+func (a *App) Static(prefix, dir string) *StaticAssets {
+	if dir == "" {
+		dir = a.config.StaticDir
+	}
+	return a.StaticFS(prefix, os.DirFS(dir))
+}
+
+// StaticFS serves the files of fsys under prefix like Static, e.g. an embed.FS so the
+// app ships as a single binary
+//
+//	//go:embed static
+//	var files embed.FS
+//
+//	sub, _ := fs.Sub(files, "static")
+//	app.StaticFS("/static", sub)
+func (a *App) StaticFS(prefix string, fsys fs.FS) *StaticAssets {
+	assets, err := NewStaticAssetsFS(prefix, fsys)
+	if err != nil {
+		a.Logger().Warn("Static files not hashed", "prefix", prefix, "error", err)
+		assets = &StaticAssets{prefix: "/" + strings.Trim(prefix, "/"), fsys: fsys, hashes: make(map[string]fileHash)}
+	}
+	assets.Register(a.Router)
+	a.assets = assets
+	return assets
+}
+
+// Assets returns the static assets served by Static, or nil before it is called
+func (a *App) Assets() *StaticAssets {
+	return a.assets
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"html/template"
+
+	"github.com/paulmanoni/livenest/liveview"
+	"github.com/paulmanoni/livenest/orm"
+)
+
+// StreamQuery streams the records of qs into the stream container with an id, batch by
+// batch, so a long list renders progressively without holding it in memory or in the
+// socket assigns; render turns a batch into rows and done, if set, runs once the last
+// batch is sent or the query fails
+// Batches are rendered off the connection goroutine and follow primary key order. The
+// key of each batch's last row is kept as the stream's cursor: after a reconnect the
+// client still shows the rows, and StreamQuery only sends the ones after it. Without a
+// cursor the container is emptied first; call socket.StreamReset(id) to start over
+//
+//	func (o *Orders) Mount(socket *liveview.Socket) error {
+//		core.StreamQuery(socket, "orders", app.Query().Model(&Order{}), 500, o.rows, nil)
+//		return nil
+//	}
+//
+// StreamQuery reports false when the socket has no live connection
+func StreamQuery[T any](socket *liveview.Socket, id string, qs orm.QuerySet, batchSize int, render func([]T) (template.HTML, error), done func(*liveview.Socket, error)) bool {
+	cursor := socket.StreamCursor(id)
+
+	return socket.StartStream("stream:"+id, func(ctx context.Context, push func(func(*liveview.Socket))) {
+		err := streamQuery(ctx, id, qs, cursor, batchSize, render, push)
+		if ctx.Err() != nil || done == nil {
+			return
+		}
+		push(func(s *liveview.Socket) {
+			done(s, err)
+		})
+	})
+}
+
+// streamQuery pushes the batches of a StreamQuery, resuming after cursor if set
+// A cursor that isn't a key of T, e.g. one edited in the browser, restarts the list
+func streamQuery[T any](ctx context.Context, id string, qs orm.QuerySet, cursor string, batchSize int, render func([]T) (template.HTML, error), push func(func(*liveview.Socket))) error {
+	resumed := false
+	if cursor != "" {
+		if after, err := orm.AfterKey(qs, new(T), cursor); err == nil {
+			qs, resumed = after, true
+		}
+	}
+	if !resumed {
+		push(func(s *liveview.Socket) {
+			s.StreamReset(id)
+		})
+	}
+
+	return orm.StreamAs(qs, batchSize, func(batch []T) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		html, err := render(batch)
+		if err != nil {
+			return err
+		}
+		last, err := orm.KeyCursor(&batch[len(batch)-1])
+		if err != nil {
+			return err
+		}
+		push(func(s *liveview.Socket) {
+			s.StreamAppendAt(id, html, last)
+		})
+		return nil
+	})
+}
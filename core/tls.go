@@ -0,0 +1,64 @@
+package core
+
+import (
+	"net"
+	"net/http"
+	"slices"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// RunTLS starts the HTTPS server with a certificate and its private key;
+// LiveView connections use WSS on HTTPS pages
+func (a *App) RunTLS(addr, certFile, keyFile string) error {
+	if err := a.start(); err != nil {
+		return err
+	}
+	a.Logger().Info("LiveNest server starting", "address", addr, "tls", true)
+	srv := a.HTTPServer(addr)
+	return a.serve(srv, func(ln net.Listener) error {
+		return srv.ServeTLS(ln, certFile, keyFile)
+	})
+}
+
+// runAutocert starts the HTTPS server with certificates issued by Let's Encrypt
+// for the configured domains and renewed before they expire
+func (a *App) runAutocert(addr string) error {
+	server := a.config.Server
+	cacheDir := server.AutocertCacheDir
+	if cacheDir == "" {
+		cacheDir = "certs"
+	}
+	httpAddr := server.AutocertHTTPAddr
+	if httpAddr == "" {
+		httpAddr = ":80"
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(server.AutocertDomains...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      server.AutocertEmail,
+	}
+
+	if err := a.start(); err != nil {
+		return err
+	}
+
+	// The HTTP listener answers ACME challenges and redirects everything else to HTTPS
+	go func() {
+		if err := http.ListenAndServe(httpAddr, manager.HTTPHandler(nil)); err != nil {
+			a.Logger().Error("ACME HTTP listener stopped", "address", httpAddr, "error", err)
+		}
+	}()
+
+	a.Logger().Info("LiveNest server starting", "address", addr, "tls", true, "domains", server.AutocertDomains)
+	srv := a.HTTPServer(addr)
+	srv.TLSConfig = manager.TLSConfig()
+	if server.DisableHTTP2 {
+		srv.TLSConfig.NextProtos = slices.DeleteFunc(srv.TLSConfig.NextProtos, func(p string) bool { return p == "h2" })
+	}
+	return a.serve(srv, func(ln net.Listener) error {
+		return srv.ServeTLS(ln, "", "")
+	})
+}
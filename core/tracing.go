@@ -0,0 +1,9 @@
+package core
+
+import "go.opentelemetry.io/otel/trace"
+
+// SetTracerProvider sets the OpenTelemetry provider of LiveView spans
+// By default the global provider set with otel.SetTracerProvider is used
+func (a *App) SetTracerProvider(tp trace.TracerProvider) {
+	a.lvHandler.SetTracerProvider(tp)
+}
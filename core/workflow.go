@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/paulmanoni/livenest/liveview"
+	"github.com/paulmanoni/livenest/orm"
+)
+
+// EnableWorkflows creates the workflow_runs table and returns the app's workflow engine,
+// which advances due runs while the app runs
+func (a *App) EnableWorkflows() (*orm.WorkflowEngine, error) {
+	if a.DB == nil {
+		return nil, fmt.Errorf("no database connected")
+	}
+	if err := a.DB.AutoMigrate(&orm.WorkflowRun{}); err != nil {
+		return nil, err
+	}
+
+	a.workflows = orm.NewWorkflowEngine(a.DB)
+	a.workflows.OnError = func(run orm.WorkflowRun, err error) {
+		if run.ID == "" {
+			a.Logger().Error("Workflow engine error", "error", err)
+			return
+		}
+		a.Logger().Warn("Workflow step failed", "run", run.ID, "workflow", run.Workflow, "step", run.StepName, "attempts", run.Attempts, "error", err)
+	}
+	return a.workflows, nil
+}
+
+// Workflows returns the workflow engine, or nil before EnableWorkflows
+func (a *App) Workflows() *orm.WorkflowEngine {
+	return a.workflows
+}
+
+// BindWorkflow assigns a workflow run to key and, on a live connection, re-renders
+// the component as the run progresses on any node; call it from Mount
+//
+//	func (c *Checkout) Mount(socket *liveview.Socket) error {
+//		return core.BindWorkflow(socket, app.Workflows(), socket.Params.Get("run"), "checkout")
+//	}
+func BindWorkflow(socket *liveview.Socket, engine *orm.WorkflowEngine, id, key string) error {
+	run, err := engine.Get(socket.Context(), id)
+	if err != nil {
+		return err
+	}
+	socket.Set(key, *run)
+	if run.Done() {
+		return nil
+	}
+
+	socket.StartStream("workflow:"+key, func(ctx context.Context, push func(func(*liveview.Socket))) {
+		engine.Watch(ctx, id, func(run orm.WorkflowRun) {
+			push(func(s *liveview.Socket) {
+				s.Set(key, run)
+			})
+		})
+	})
+	return nil
+}
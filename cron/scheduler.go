@@ -0,0 +1,186 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Task is the work run on a schedule; ctx is cancelled when the scheduler stops
+type Task func(ctx context.Context) error
+
+// Entry is a task of a scheduler
+type Entry struct {
+	ID       int
+	Spec     string
+	Schedule Schedule
+	Task     Task
+	Next     time.Time // when the task runs next
+	Prev     time.Time // when it last started, or zero
+	Running  bool      // a run is in progress; runs don't overlap, so a due one is skipped
+}
+
+// Scheduler runs tasks on their schedules
+// Every node of an app runs its own scheduler, so a task meant to run once across
+// nodes has to guard itself, e.g. with a database lock
+type Scheduler struct {
+	Location *time.Location               // time zone of the schedules (default time.Local); set it before adding tasks
+	OnError  func(entry Entry, err error) // called when a task fails or panics
+
+	mu      sync.Mutex
+	entries []*Entry
+	nextID  int
+	wake    chan struct{}
+}
+
+// New creates a scheduler without tasks
+func New() *Scheduler {
+	return &Scheduler{Location: time.Local, wake: make(chan struct{}, 1)}
+}
+
+// Add schedules a task with a cron expression or descriptor, see Parse
+func (s *Scheduler) Add(spec string, task Task) (int, error) {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return 0, err
+	}
+	return s.AddSchedule(spec, schedule, task), nil
+}
+
+// AddSchedule schedules a task with a Schedule; spec describes it in Entries
+func (s *Scheduler) AddSchedule(spec string, schedule Schedule, task Task) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	s.entries = append(s.entries, &Entry{
+		ID:       s.nextID,
+		Spec:     spec,
+		Schedule: schedule,
+		Task:     task,
+		Next:     schedule.Next(time.Now().In(s.location())),
+	})
+	s.signal()
+	return s.nextID
+}
+
+// Remove unschedules a task
+func (s *Scheduler) Remove(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, e := range s.entries {
+		if e.ID == id {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			break
+		}
+	}
+	s.signal()
+}
+
+// Entries returns the scheduled tasks, soonest first
+func (s *Scheduler) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]Entry, len(s.entries))
+	for i, e := range s.entries {
+		entries[i] = *e
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Next.Before(entries[j].Next) })
+	return entries
+}
+
+// Run runs tasks as they come due until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		timer := time.NewTimer(s.untilNext())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+
+		for _, e := range s.due(time.Now().In(s.location())) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.run(ctx, e)
+			}()
+		}
+	}
+}
+
+// untilNext returns how long until the soonest task is due
+func (s *Scheduler) untilNext() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wait := time.Hour
+	for _, e := range s.entries {
+		if !e.Next.IsZero() {
+			wait = min(wait, time.Until(e.Next))
+		}
+	}
+	return max(wait, 0)
+}
+
+// due moves the due tasks to their next time and returns those to start now
+func (s *Scheduler) due(now time.Time) []*Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []*Entry
+	for _, e := range s.entries {
+		if e.Next.IsZero() || e.Next.After(now) {
+			continue
+		}
+		e.Next = e.Schedule.Next(now)
+		if e.Running {
+			continue
+		}
+		e.Running = true
+		e.Prev = now
+		due = append(due, e)
+	}
+	return due
+}
+
+// run runs a task once, turning a panic into an error
+func (s *Scheduler) run(ctx context.Context, e *Entry) {
+	err := func() (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic: %v", p)
+			}
+		}()
+		return e.Task(ctx)
+	}()
+
+	s.mu.Lock()
+	e.Running = false
+	entry := *e
+	s.mu.Unlock()
+	if err != nil && ctx.Err() == nil && s.OnError != nil {
+		s.OnError(entry, err)
+	}
+}
+
+// location returns the time zone of the schedules
+func (s *Scheduler) location() *time.Location {
+	if s.Location == nil {
+		return time.Local
+	}
+	return s.Location
+}
+
+// signal wakes Run to recompute the next due time
+func (s *Scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default: // already due to wake
+	}
+}
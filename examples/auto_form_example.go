@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/paulmanoni/livenest/liveview"
 )
@@ -35,10 +38,16 @@ type ContactForm struct {
 
 func NewContactForm() *liveview.FormComponent[ContactForm] {
 	return liveview.NewFormComponent[ContactForm]("✉️ Contact Us").
+		WithSpamProtection(liveview.SpamProtection{MinFillTime: 3 * time.Second, MaxPerIP: 5}).
 		OnSubmit(func(socket *liveview.Socket, data *ContactForm) error {
 			fmt.Printf("Contact: %s <%s> - %s\n", data.Name, data.Email, data.Subject)
 			return nil
-		})
+		}).
+		EmitTo(liveview.SinkFunc(func(ctx context.Context, s liveview.Submission) error {
+			// Stand-in for publishing to a queue or calling a webhook
+			log.Printf("Contact submission emitted at %s", s.SubmittedAt.Format(time.RFC3339))
+			return nil
+		}))
 }
 
 // ProductReview with validation
@@ -47,6 +56,7 @@ type ProductReview struct {
 	Title     string `form:"label:Review Title;placeholder:Summarize your experience" validate:"required;min:3;max:100"`
 	Review    string `form:"label:Your Review;type:textarea;rows:6" validate:"required;min:20;max:2000"`
 	Recommend bool   `form:"label:I would recommend this product"`
+	Improve   string `form:"label:What would change your mind?;type:textarea;rows:3;visible_if:Recommend=false" validate:"max:500"`
 }
 
 func NewProductReview() *liveview.FormComponent[ProductReview] {
@@ -68,6 +78,27 @@ func NewLoginForm() *liveview.FormComponent[LoginForm] {
 		OnSubmit(func(socket *liveview.Socket, data *LoginForm) error {
 			fmt.Printf("Login: %s\n", data.Email)
 			// Your authentication logic here
+			socket.Redirect("/dashboard")
+			return nil
+		})
+}
+
+// OrderLine is a single row of OrderForm
+type OrderLine struct {
+	Product  string `form:"label:Product;placeholder:Widget" validate:"required"`
+	Quantity int    `form:"label:Qty;placeholder:1" validate:"required;min:1;max:99"`
+}
+
+// OrderForm - field array example with per-row validation
+type OrderForm struct {
+	Customer string      `form:"label:Customer;placeholder:Jane Doe" validate:"required"`
+	Lines    []OrderLine `form:"label:Order Lines;min_rows:1;max_rows:5"`
+}
+
+func NewOrderForm() *liveview.FormComponent[OrderForm] {
+	return liveview.NewFormComponent[OrderForm]("📦 New Order").
+		OnSubmit(func(socket *liveview.Socket, data *OrderForm) error {
+			fmt.Printf("Order for %s: %d lines\n", data.Customer, len(data.Lines))
 			return nil
 		})
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"html/template"
+	"strings"
 	"sync"
 	"time"
 
@@ -39,12 +40,15 @@ func (ch *ChatComponent) Mount(socket *liveview.Socket) error {
 		"newMessage": "",
 		"messages":   getChatMessages(),
 	})
+	// Pick up other users' messages while the page is connected
+	socket.EveryTick(3*time.Second, "refresh")
 	return nil
 }
 
 // HandleSend sends a new chat message
 func (ch *ChatComponent) HandleSend(socket *liveview.Socket, payload map[string]interface{}) error {
 	message, ok := payload["message"].(string)
+	message = strings.TrimSpace(message)
 	if !ok || message == "" {
 		return nil
 	}
@@ -114,7 +118,7 @@ func (ch *ChatComponent) Render(socket *liveview.Socket) (template.HTML, error)
 					</div>
 					<div class="message-content">%s</div>
 				</div>
-			`, messageClass, msg.Username, msg.Timestamp.Format("15:04"), msg.Message)
+			`, messageClass, template.HTMLEscapeString(msg.Username), msg.Timestamp.Format("15:04"), template.HTMLEscapeString(msg.Message))
 		}
 	}
 
@@ -122,13 +126,14 @@ func (ch *ChatComponent) Render(socket *liveview.Socket) (template.HTML, error)
 			</div>
 
 			<div class="chat-input">
-				<input
-					type="text"
-					id="messageInput"
-					placeholder="Type a message..."
-					lv-keyup="send"
-					lv-key="Enter"
-				/>
+				<form class="chat-form" lv-submit="send" lv-reset>
+					<input
+						type="text"
+						name="message"
+						placeholder="Type a message..."
+						autocomplete="off"
+					/>
+				</form>
 				<button lv-click="refresh" class="refresh-btn">🔄</button>
 				<button lv-click="clear" class="clear-btn">Clear</button>
 			</div>
@@ -208,6 +213,10 @@ func (ch *ChatComponent) Render(socket *liveview.Socket) (template.HTML, error)
 				border-top: 1px solid #e0e0e0;
 				gap: 10px;
 			}
+			.chat-form {
+				flex: 1;
+				display: flex;
+			}
 			.chat-input input {
 				flex: 1;
 				padding: 12px 15px;
@@ -242,32 +251,6 @@ func (ch *ChatComponent) Render(socket *liveview.Socket) (template.HTML, error)
 				background: #c0392b;
 			}
 		</style>
-
-		<script>
-			// Auto-scroll to bottom
-			const chatMessages = document.getElementById('chatMessages');
-			if (chatMessages) {
-				chatMessages.scrollTop = chatMessages.scrollHeight;
-			}
-
-			// Handle Enter key for sending messages
-			const messageInput = document.getElementById('messageInput');
-			if (messageInput) {
-				messageInput.addEventListener('keyup', function(e) {
-					if (e.key === 'Enter' && this.value.trim()) {
-						window.liveSocket.pushEvent('send', { message: this.value.trim() });
-						this.value = '';
-					}
-				});
-			}
-
-			// Auto-refresh every 3 seconds to get new messages
-			setInterval(() => {
-				if (window.liveSocket) {
-					window.liveSocket.pushEvent('refresh', {});
-				}
-			}, 3000);
-		</script>
 	`
 
 	return template.HTML(html), nil
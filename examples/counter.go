@@ -21,6 +21,19 @@ func (c *CounterComponent) Mount(socket *liveview.Socket) error {
 	return nil
 }
 
+// Describe documents the counter for the component catalog
+func (c *CounterComponent) Describe() liveview.ComponentDoc {
+	return liveview.ComponentDoc{
+		Description: "Increments, decrements and resets a number",
+		Events: []liveview.EventDoc{
+			{Name: "increment", Description: "Adds one to the count"},
+			{Name: "decrement", Description: "Subtracts one from the count"},
+			{Name: "reset", Description: "Sets the count back to zero"},
+		},
+		ExampleAssigns: map[string]interface{}{"count": 42},
+	}
+}
+
 // HandleIncrement handles the increment event
 func (c *CounterComponent) HandleIncrement(socket *liveview.Socket, payload map[string]interface{}) error {
 	count := socket.Assigns["count"].(int)
@@ -64,9 +77,9 @@ func (c *CounterComponent) Render(socket *liveview.Socket) (template.HTML, error
 				<h2>Count: %d</h2>
 			</div>
 			<div class="buttons">
-				<button lv-click="decrement">-</button>
-				<button lv-click="reset">Reset</button>
-				<button lv-click="increment">+</button>
+				<button lv-click="decrement" lv-window-keydown="decrement" lv-key="ArrowDown">-</button>
+				<button lv-click="reset" lv-window-keydown="reset" lv-key="Escape">Reset</button>
+				<button lv-click="increment" lv-window-keydown="increment" lv-key="ArrowUp">+</button>
 			</div>
 		</div>
 		<style>
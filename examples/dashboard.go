@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"html/template"
 	"math/rand"
+	"time"
 
 	"github.com/paulmanoni/livenest/liveview"
+	"github.com/paulmanoni/livenest/liveview/js"
 )
 
 // DashboardComponent demonstrates template file usage with subdirectories
@@ -12,14 +16,41 @@ type DashboardComponent struct {
 	liveview.TemplateComponent
 }
 
+// dashboardStats holds the figures shown on the dashboard
+type dashboardStats struct {
+	TotalUsers     int
+	ActiveSessions int
+	Revenue        float64
+}
+
+// dashboardCache shares the stats between the page render and the socket mount
+var dashboardCache = liveview.NewMountCache[dashboardStats](10 * time.Second)
+
+// loadDashboardStats stands in for an expensive set of queries
+func loadDashboardStats(ctx context.Context) (dashboardStats, error) {
+	return dashboardStats{TotalUsers: 1234, ActiveSessions: 89, Revenue: 45678.90}, nil
+}
+
 // Mount initializes the dashboard
 func (d *DashboardComponent) Mount(socket *liveview.Socket) error {
+	stats, err := dashboardCache.Get(socket, "dashboard", loadDashboardStats)
+	if err != nil {
+		return err
+	}
+
 	socket.Assign(map[string]interface{}{
 		"user_name":       "John Doe",
-		"total_users":     1234,
-		"active_sessions": 89,
-		"revenue":         45678.90,
+		"total_users":     stats.TotalUsers,
+		"active_sessions": stats.ActiveSessions,
+		"revenue":         stats.Revenue,
+		// Toggled on the client without a server round trip
+		"toggle_help": js.Toggle("#dashboard-help").ToggleClass("", "active"),
 	})
+	socket.SetTitle("Dashboard")
+	socket.PutMeta("description", "Live business metrics")
+	socket.PutOpenGraph(liveview.OpenGraph{Title: "LiveNest Dashboard", Type: "website"})
+	// Refresh the metrics while the page is connected
+	socket.EveryTick(5*time.Second, "refresh")
 	return nil
 }
 
@@ -31,6 +62,7 @@ func (d *DashboardComponent) HandleRefresh(socket *liveview.Socket, payload map[
 		"active_sessions": rand.Intn(200) + 50,
 		"revenue":         float64(rand.Intn(100000)) + 10000.50,
 	})
+	socket.SetTitle(fmt.Sprintf("Dashboard (%d active)", socket.Assigns["active_sessions"]))
 	return nil
 }
 
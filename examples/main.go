@@ -93,8 +93,14 @@ func main() {
 		AddComponent(NewLoginForm()).WithName("login").
 		Build()
 
+	app.NewHandler().
+		Path("/order").
+		AsLive().
+		AddComponent(NewOrderForm()).WithName("order").
+		Build()
+
 	// Serve static files
-	app.Router.Static("/static", "./static")
+	app.Static("/static", "./static")
 
 	// Start server
 	log.Println("Starting LiveNest example server...")
@@ -110,6 +116,7 @@ func main() {
 	log.Println("  http://localhost:8080/contact          - Contact Form (auto-generated)")
 	log.Println("  http://localhost:8080/review           - Product Review (auto-generated)")
 	log.Println("  http://localhost:8080/login            - Login Form (auto-generated)")
+	log.Println("  http://localhost:8080/order            - Order Form (field arrays)")
 	log.Println("  http://localhost:8080/component-tag    - <component> tag examples")
 	if err := app.Run(":8080"); err != nil {
 		log.Fatalf("Server error: %v", err)
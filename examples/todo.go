@@ -2,7 +2,7 @@ package main
 
 import (
 	"html/template"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/paulmanoni/livenest/liveview"
@@ -38,6 +38,7 @@ func (t *TodoListComponent) Mount(socket *liveview.Socket) error {
 // HandleAdd adds a new todo item
 func (t *TodoListComponent) HandleAdd(socket *liveview.Socket, payload map[string]interface{}) error {
 	text, ok := payload["text"].(string)
+	text = strings.TrimSpace(text)
 	if !ok || text == "" {
 		return nil
 	}
@@ -64,12 +65,11 @@ func (t *TodoListComponent) HandleAdd(socket *liveview.Socket, payload map[strin
 
 // HandleToggle toggles a todo's completed status
 func (t *TodoListComponent) HandleToggle(socket *liveview.Socket, payload map[string]interface{}) error {
-	id, ok := payload["id"].(string)
+	todoID, ok := liveview.Payload(payload).Int("id")
 	if !ok {
 		return nil
 	}
 
-	todoID, _ := strconv.Atoi(id)
 	todos := socket.Assigns["todos"].([]TodoItem)
 
 	for i := range todos {
@@ -87,12 +87,11 @@ func (t *TodoListComponent) HandleToggle(socket *liveview.Socket, payload map[st
 
 // HandleDelete removes a todo item
 func (t *TodoListComponent) HandleDelete(socket *liveview.Socket, payload map[string]interface{}) error {
-	id, ok := payload["id"].(float64)
+	todoID, ok := liveview.Payload(payload).Int("id")
 	if !ok {
 		return nil
 	}
 
-	todoID := int(id)
 	todos := socket.Assigns["todos"].([]TodoItem)
 	filtered := []TodoItem{}
 
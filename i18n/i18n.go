@@ -0,0 +1,246 @@
+// Package i18n translates an app's messages. A Catalog holds the messages of every
+// locale, loaded from JSON or TOML files named after their locale:
+//
+//	locales/en.json     {"greeting": "Hello, %s", "nav": {"home": "Home"}}
+//	locales/pt-BR.toml  greeting = "Olá, %s"
+//
+// Nested keys are joined with dots, e.g. "nav.home". Messages are fmt formats used
+// with the arguments given to Translate. A message missing in a locale is looked up
+// in its base language, e.g. "pt" for "pt-BR", then in the fallback locale, and
+// otherwise the key itself is shown.
+//
+// Negotiate picks the locale of a request from a locale cookie and its
+// Accept-Language header. A Localizer is a catalog bound to one locale; its Funcs add
+// the t template func.
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Catalog holds the messages of every locale
+// It is safe for concurrent use
+type Catalog struct {
+	mu         sync.RWMutex
+	fallback   string
+	messages   map[string]map[string]string // messages by locale, then key
+	locales    map[string]string            // locales by lower-case name
+	localizers map[string]*Localizer
+}
+
+// New creates an empty catalog whose messages fall back to the fallback locale, e.g. "en"
+func New(fallback string) *Catalog {
+	return &Catalog{
+		fallback:   normalize(fallback),
+		messages:   make(map[string]map[string]string),
+		locales:    make(map[string]string),
+		localizers: make(map[string]*Localizer),
+	}
+}
+
+// Fallback returns the fallback locale
+func (c *Catalog) Fallback() string {
+	return c.fallback
+}
+
+// Add adds messages of a locale; nested maps are flattened into dotted keys
+// Messages already in the catalog are replaced
+func (c *Catalog) Add(locale string, messages map[string]interface{}) {
+	locale = normalize(locale)
+	flat := make(map[string]string)
+	flatten("", messages, flat)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if existing, ok := c.locales[strings.ToLower(locale)]; ok {
+		locale = existing
+	} else {
+		c.locales[strings.ToLower(locale)] = locale
+		c.messages[locale] = make(map[string]string)
+	}
+	for key, msg := range flat {
+		c.messages[locale][key] = msg
+	}
+}
+
+// LoadFile adds the messages of a .json or .toml file named after its locale, e.g. "fr.json"
+func (c *Catalog) LoadFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return c.load(path.Base(strings.ReplaceAll(file, "\\", "/")), data)
+}
+
+// LoadDir adds the messages of every .json and .toml file in dir and its subdirectories
+func (c *Catalog) LoadDir(dir string) error {
+	return c.LoadFS(os.DirFS(dir))
+}
+
+// LoadFS adds the messages of every .json and .toml file in fsys, e.g. an embed.FS
+func (c *Catalog) LoadFS(fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if ext := path.Ext(name); ext != ".json" && ext != ".toml" {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		return c.load(path.Base(name), data)
+	})
+}
+
+// load adds the messages of a file's contents
+func (c *Catalog) load(name string, data []byte) error {
+	ext := path.Ext(name)
+	messages := make(map[string]interface{})
+	var err error
+	switch ext {
+	case ".json":
+		err = json.Unmarshal(data, &messages)
+	case ".toml":
+		err = toml.Unmarshal(data, &messages)
+	default:
+		return fmt.Errorf("i18n: %s: unsupported format", name)
+	}
+	if err != nil {
+		return fmt.Errorf("i18n: %s: %w", name, err)
+	}
+	c.Add(strings.TrimSuffix(name, ext), messages)
+	return nil
+}
+
+// Locales returns the locales with messages, sorted
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	locales := make([]string, 0, len(c.messages))
+	for locale := range c.messages {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Supported returns the catalog's locale for a requested one: the same locale, its base
+// language, e.g. "fr" for "fr-CA", or a regional variant of it, e.g. "pt-BR" for "pt"
+func (c *Catalog) Supported(locale string) (string, bool) {
+	tag := strings.ToLower(normalize(locale))
+	if tag == "" {
+		return "", false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if supported, ok := c.locales[tag]; ok {
+		return supported, true
+	}
+	base, _, _ := strings.Cut(tag, "-")
+	if supported, ok := c.locales[base]; ok {
+		return supported, true
+	}
+	// Regional variants are tried in a fixed order, so the same one is always picked
+	var variant string
+	for lower, supported := range c.locales {
+		if strings.HasPrefix(lower, base+"-") && (variant == "" || supported < variant) {
+			variant = supported
+		}
+	}
+	return variant, variant != ""
+}
+
+// Lookup returns the message of key in a locale, trying its base language and then the
+// fallback locale
+func (c *Catalog) Lookup(locale, key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	locale = normalize(locale)
+	base, _, _ := strings.Cut(locale, "-")
+	for _, l := range []string{locale, base, c.fallback} {
+		if msg, ok := c.messages[c.locales[strings.ToLower(l)]][key]; ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// Translate returns the message of key in a locale formatted with args, or the key
+// itself when no locale has it
+func (c *Catalog) Translate(locale, key string, args ...interface{}) string {
+	if msg, ok := c.Lookup(locale, key); ok {
+		return Format(msg, args...)
+	}
+	return Format(key, args...)
+}
+
+// Localizer returns the catalog bound to a locale; unsupported locales use the fallback
+// Localizers are cached, so there is one per supported locale
+func (c *Catalog) Localizer(locale string) *Localizer {
+	supported, ok := c.Supported(locale)
+	if !ok {
+		supported = c.fallback
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.localizers[supported]
+	if !ok {
+		l = &Localizer{catalog: c, locale: supported}
+		c.localizers[supported] = l
+	}
+	return l
+}
+
+// Format formats a message with args; a message without args is returned as is, so
+// messages may contain a literal % when they take none
+func Format(msg string, args ...interface{}) string {
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// normalize turns a locale into its usual form, e.g. "pt_br" into "pt-BR"
+func normalize(locale string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	for i, part := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(part)
+		case len(part) == 2:
+			parts[i] = strings.ToUpper(part)
+		case len(part) == 4:
+			// Scripts, e.g. "zh-Hant"
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// flatten copies nested messages into flat under dotted keys
+func flatten(prefix string, messages map[string]interface{}, flat map[string]string) {
+	for key, value := range messages {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case string:
+			flat[key] = v
+		case map[string]interface{}:
+			flatten(key, v, flat)
+		default:
+			flat[key] = fmt.Sprint(v)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package i18n
+
+import (
+	"html/template"
+	"reflect"
+)
+
+// AssignKey is the template data key of the Localizer a page or component renders with;
+// LiveView sockets assign it, with the locale under "locale"
+const AssignKey = "i18n"
+
+// Localizer translates messages into one locale
+// A nil Localizer returns keys untranslated, so code and templates work before the app
+// has translations
+type Localizer struct {
+	catalog *Catalog
+	locale  string
+}
+
+// Locale returns the locale
+func (l *Localizer) Locale() string {
+	if l == nil {
+		return ""
+	}
+	return l.locale
+}
+
+// T returns the message of key formatted with args, or the key itself when no locale
+// has it
+func (l *Localizer) T(key string, args ...interface{}) string {
+	if l == nil {
+		return Format(key, args...)
+	}
+	return l.catalog.Translate(l.locale, key, args...)
+}
+
+// Lookup returns the message of key, unformatted
+func (l *Localizer) Lookup(key string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	return l.catalog.Lookup(l.locale, key)
+}
+
+// Funcs returns the template funcs of the localizer:
+//
+//	{{t "nav.home"}}
+//	{{t "greeting" .user.Name}}
+//	<html lang="{{locale}}">
+func (l *Localizer) Funcs() template.FuncMap {
+	return template.FuncMap{
+		"t":      l.T,
+		"locale": l.Locale,
+	}
+}
+
+// FromData returns the Localizer in template data: a map such as socket assigns or gin.H
+// holding it under AssignKey, or a value with a Localizer method such as a LiveView
+// layout's page data; otherwise nil
+func FromData(data interface{}) *Localizer {
+	if d, ok := data.(interface{ Localizer() *Localizer }); ok {
+		return d.Localizer()
+	}
+	if m, ok := data.(map[string]interface{}); ok {
+		l, _ := m[AssignKey].(*Localizer)
+		return l
+	}
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil
+	}
+	value := v.MapIndex(reflect.ValueOf(AssignKey).Convert(v.Type().Key()))
+	if !value.IsValid() || !value.CanInterface() {
+		return nil
+	}
+	l, _ := value.Interface().(*Localizer)
+	return l
+}
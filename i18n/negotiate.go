@@ -0,0 +1,74 @@
+package i18n
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CookieName is the cookie holding a visitor's chosen locale
+const CookieName = "locale"
+
+// Negotiate returns the locale to use for a request: the one in its locale cookie when
+// the catalog supports it, otherwise the best match of its Accept-Language header,
+// otherwise the fallback locale
+func (c *Catalog) Negotiate(r *http.Request) string {
+	if r != nil {
+		if cookie, err := r.Cookie(CookieName); err == nil {
+			if locale, ok := c.Supported(cookie.Value); ok {
+				return locale
+			}
+		}
+		if locale, ok := c.Match(r.Header.Get("Accept-Language")); ok {
+			return locale
+		}
+	}
+	return c.fallback
+}
+
+// Match returns the supported locale best matching an Accept-Language header, e.g.
+// "fr-CH, fr;q=0.9, en;q=0.8", trying its languages by quality
+func (c *Catalog) Match(acceptLanguage string) (string, bool) {
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if locale, ok := c.Supported(tag); ok {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// parseAcceptLanguage returns the languages of an Accept-Language header by quality,
+// leaving out "*" and those with a quality of 0
+func parseAcceptLanguage(header string) []string {
+	type language struct {
+		tag string
+		q   float64
+	}
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			languages = append(languages, language{tag, q})
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool { return languages[i].q > languages[j].q })
+
+	tags := make([]string, len(languages))
+	for i, l := range languages {
+		tags[i] = l.tag
+	}
+	return tags
+}
@@ -0,0 +1,127 @@
+// Package jobs runs background work outside of requests, e.g. an import started from a
+// form, with retries and progress that LiveView components can follow as it changes.
+//
+// A Runner takes due jobs from a Queue and runs them with the Handler registered for
+// their kind. MemoryQueue keeps jobs in the process; orm.JobQueue keeps them in the
+// database, so they survive restarts and are shared by every node.
+//
+//	runner := jobs.NewRunner(jobs.NewMemoryQueue())
+//	runner.Register(jobs.Handler{Name: "import", Retries: 3, Run: func(ctx context.Context, job *jobs.Job) error {
+//		for i, row := range rows {
+//			job.Report(100*i/len(rows), "Importing")
+//		}
+//		return nil
+//	}})
+//	go runner.Run(ctx)
+//	job, err := runner.Enqueue(ctx, "import", payload)
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by Queue.Get for an unknown job
+var ErrNotFound = errors.New("jobs: job not found")
+
+// ErrLeaseLost is returned by Queue.Save and Queue.Renew when the job changed since the
+// caller last saw it, e.g. another node claimed it after the lease ran out
+var ErrLeaseLost = errors.New("jobs: job no longer owned")
+
+// Status is the state of a job
+type Status string
+
+const (
+	StatusQueued    Status = "queued"    // waiting to run, or for a retry after a failed attempt
+	StatusRunning   Status = "running"   // a node is running it
+	StatusSucceeded Status = "succeeded" // an attempt succeeded
+	StatusFailed    Status = "failed"    // every attempt failed
+)
+
+// Job is a unit of background work and its progress
+type Job struct {
+	ID          string          `gorm:"primaryKey;size:64" json:"id"`
+	Kind        string          `gorm:"size:255;index" json:"kind"` // name of the Handler that runs it
+	Status      Status          `gorm:"size:32;index" json:"status"`
+	Payload     json.RawMessage `gorm:"type:text" json:"payload"`
+	Result      json.RawMessage `gorm:"type:text" json:"result,omitempty"`
+	Progress    int             `json:"progress"`                           // percent done, 0 to 100
+	Message     string          `gorm:"type:text" json:"message,omitempty"` // what the job is doing, e.g. "Importing row 40 of 100"
+	Error       string          `gorm:"type:text" json:"error,omitempty"`   // error of the last failed attempt
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	Revision    int             `json:"revision"` // bumped by every change
+	RunAt       time.Time       `gorm:"index" json:"run_at"`
+	LockedUntil time.Time       `json:"-"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+
+	runner    *Runner     // runner of the attempt in progress, for Report
+	lastSaved time.Time   // when Report last saved the progress
+	mu        *sync.Mutex // serializes Report with the runner renewing the lease
+}
+
+// TableName keeps jobs in the jobs table
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// Decode unmarshals the job's payload into v
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// SetResult stores v as the job's result, e.g. the ID of what it created
+func (j *Job) SetResult(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	j.Result = data
+	return nil
+}
+
+// Done reports whether the job has finished, successfully or not
+func (j Job) Done() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// Report records the job's progress, percent done and what it is doing; watchers see it
+// right away. Progress is saved at most every ReportInterval of the runner, so it can be
+// reported for every item of a long loop. ErrLeaseLost means another node runs the job now
+func (j *Job) Report(percent int, message string) error {
+	if j.mu != nil {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+	}
+	j.Progress = min(max(percent, 0), 100)
+	j.Message = message
+	if j.runner == nil || time.Since(j.lastSaved) < j.runner.ReportInterval {
+		return nil
+	}
+	j.lastSaved = time.Now()
+	j.LockedUntil = j.lastSaved.Add(j.runner.Lease)
+	return j.runner.save(context.Background(), j)
+}
+
+// Queue stores jobs for a Runner
+// Implementations must be safe for concurrent use
+type Queue interface {
+	// Push adds a new job
+	Push(ctx context.Context, job *Job) error
+	// Claim leases the next due job to the caller until now+lease: a queued job whose
+	// RunAt has passed, or a running one whose lease expired because its node went away.
+	// The job is marked running with one more attempt. It returns nil when none is due
+	Claim(ctx context.Context, lease time.Duration) (*Job, error)
+	// Save stores the job's state and progress, bumping its revision, when the stored job is
+	// still at job.Revision; otherwise it returns ErrLeaseLost and changes nothing
+	Save(ctx context.Context, job *Job) error
+	// Renew extends the lease of a running job to now+lease, bumping its revision, when the
+	// stored job is still at job.Revision; otherwise it returns ErrLeaseLost
+	Renew(ctx context.Context, job *Job, lease time.Duration) error
+	// Get returns a job, or ErrNotFound
+	Get(ctx context.Context, id string) (*Job, error)
+}
@@ -0,0 +1,127 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryQueue keeps jobs in memory, for a single node; they are lost on restart
+// Finished jobs are kept for Retention so they can still be looked up
+type MemoryQueue struct {
+	Retention time.Duration // how long finished jobs are kept (default 1h)
+
+	mu        sync.Mutex
+	jobs      map[string]*Job
+	lastSweep time.Time
+}
+
+// NewMemoryQueue creates an empty queue
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{Retention: time.Hour, jobs: make(map[string]*Job)}
+}
+
+// Push adds a new job
+func (q *MemoryQueue) Push(ctx context.Context, job *Job) error {
+	now := time.Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job.CreatedAt, job.UpdatedAt = now, now
+	q.jobs[job.ID] = q.copy(job)
+	return nil
+}
+
+// Claim leases the due job that has waited longest
+func (q *MemoryQueue) Claim(ctx context.Context, lease time.Duration) (*Job, error) {
+	now := time.Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.sweep(now)
+
+	var next *Job
+	for _, job := range q.jobs {
+		due := job.Status == StatusQueued && !job.RunAt.After(now) ||
+			job.Status == StatusRunning && job.LockedUntil.Before(now)
+		if due && (next == nil || job.RunAt.Before(next.RunAt)) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+	next.Status = StatusRunning
+	next.Attempts++
+	next.LockedUntil = now.Add(lease)
+	next.Revision++
+	next.UpdatedAt = now
+	return q.copy(next), nil
+}
+
+// Save stores the job's state and progress unless it changed meanwhile
+func (q *MemoryQueue) Save(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stored, ok := q.jobs[job.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Revision != job.Revision {
+		return ErrLeaseLost
+	}
+	job.Revision++
+	job.UpdatedAt = time.Now()
+	q.jobs[job.ID] = q.copy(job)
+	return nil
+}
+
+// Renew extends the lease of a running job unless it changed meanwhile
+func (q *MemoryQueue) Renew(ctx context.Context, job *Job, lease time.Duration) error {
+	now := time.Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stored, ok := q.jobs[job.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Revision != job.Revision || stored.Status != StatusRunning {
+		return ErrLeaseLost
+	}
+	stored.LockedUntil = now.Add(lease)
+	stored.Revision++
+	stored.UpdatedAt = now
+	job.LockedUntil, job.Revision, job.UpdatedAt = stored.LockedUntil, stored.Revision, now
+	return nil
+}
+
+// Get returns a copy of a job
+func (q *MemoryQueue) Get(ctx context.Context, id string) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return q.copy(job), nil
+}
+
+// copy returns a copy of a job without its runner state
+func (q *MemoryQueue) copy(job *Job) *Job {
+	c := *job
+	c.runner = nil
+	c.lastSaved = time.Time{}
+	c.mu = nil
+	return &c
+}
+
+// sweep drops finished jobs past their retention, now and then; q.mu must be held
+func (q *MemoryQueue) sweep(now time.Time) {
+	if now.Sub(q.lastSweep) < time.Minute {
+		return
+	}
+	for id, job := range q.jobs {
+		if job.Done() && now.Sub(job.UpdatedAt) > q.Retention {
+			delete(q.jobs, id)
+		}
+	}
+	q.lastSweep = now
+}
@@ -0,0 +1,374 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Handler runs the jobs of a kind
+// Jobs run at least once: a node stopping mid-job leaves it to be run again once its
+// lease expires, so Run should be idempotent
+type Handler struct {
+	Name    string
+	Run     func(ctx context.Context, job *Job) error
+	Retries int           // extra attempts, with backoff, before the job fails
+	Timeout time.Duration // bound for an attempt (default the runner's Timeout)
+}
+
+// Runner runs the jobs of a queue
+type Runner struct {
+	Workers        int                      // jobs run at once (default 4)
+	Interval       time.Duration            // how often the queue is polled for due jobs and watched jobs (default 1s)
+	Lease          time.Duration            // how long a node owns a job before another may run it; renewed every third of it while the job runs (default 5m)
+	Timeout        time.Duration            // default bound for an attempt (default 30m)
+	MinBackoff     time.Duration            // delay before the first retry, doubled for each one (default 1s)
+	MaxBackoff     time.Duration            // upper bound for the retry delay (default 5m)
+	ReportInterval time.Duration            // least time between saves of a job's progress (default 250ms)
+	OnError        func(job Job, err error) // called for every failed attempt, and with a zero job or just its ID when the queue fails
+
+	queue    Queue
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	watches  map[string]map[chan struct{}]struct{}
+	wake     chan struct{}
+}
+
+// NewRunner creates a runner of the jobs in queue
+func NewRunner(queue Queue) *Runner {
+	return &Runner{
+		Workers:        4,
+		Interval:       time.Second,
+		Lease:          5 * time.Minute,
+		Timeout:        30 * time.Minute,
+		MinBackoff:     time.Second,
+		MaxBackoff:     5 * time.Minute,
+		ReportInterval: 250 * time.Millisecond,
+		queue:          queue,
+		handlers:       make(map[string]Handler),
+		watches:        make(map[string]map[chan struct{}]struct{}),
+		wake:           make(chan struct{}, 1),
+	}
+}
+
+// Queue returns the runner's queue
+func (r *Runner) Queue() Queue {
+	return r.queue
+}
+
+// Register adds the handler of a kind of job; every node running jobs must register
+// the same handlers
+func (r *Runner) Register(handler Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[handler.Name] = handler
+}
+
+// handler finds a registered handler
+func (r *Runner) handler(kind string) (Handler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	h, ok := r.handlers[kind]
+	return h, ok
+}
+
+// Enqueue adds a job of a registered kind with payload, to run as soon as a worker is free
+func (r *Runner) Enqueue(ctx context.Context, kind string, payload interface{}) (*Job, error) {
+	return r.EnqueueAt(ctx, time.Now(), kind, payload)
+}
+
+// EnqueueAt adds a job of a registered kind with payload, to run once at has passed
+func (r *Runner) EnqueueAt(ctx context.Context, at time.Time, kind string, payload interface{}) (*Job, error) {
+	handler, ok := r.handler(kind)
+	if !ok {
+		return nil, fmt.Errorf("job kind %q not registered", kind)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("job %s payload: %w", kind, err)
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	job := &Job{
+		ID:          hex.EncodeToString(id),
+		Kind:        kind,
+		Status:      StatusQueued,
+		Payload:     data,
+		MaxAttempts: handler.Retries + 1,
+		Revision:    1,
+		RunAt:       at,
+	}
+	if err := r.queue.Push(ctx, job); err != nil {
+		return nil, err
+	}
+	r.signal()
+	return job, nil
+}
+
+// Get returns a job
+func (r *Runner) Get(ctx context.Context, id string) (*Job, error) {
+	return r.queue.Get(ctx, id)
+}
+
+// Watch calls fn with the job every time it changes until it is done or ctx is cancelled
+// Changes made on this node are delivered right away, those of other nodes within Interval
+func (r *Runner) Watch(ctx context.Context, id string, fn func(Job)) error {
+	wake := r.subscribe(id)
+	defer r.unsubscribe(id, wake)
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	revision := 0
+	for {
+		job, err := r.queue.Get(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if err != nil && ctx.Err() == nil {
+			r.reportError(Job{ID: id}, err)
+		}
+		if err == nil && job.Revision > revision {
+			revision = job.Revision
+			fn(*job)
+			if job.Done() {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run runs due jobs, up to Workers at once, until ctx is cancelled
+// Attempts interrupted by the cancellation are queued again without counting
+func (r *Runner) Run(ctx context.Context) error {
+	workers := make(chan struct{}, max(r.Workers, 1))
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		r.claimDue(ctx, workers, &wg)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.wake:
+		case <-time.After(r.Interval):
+		}
+	}
+}
+
+// claimDue starts due jobs while there are free workers
+func (r *Runner) claimDue(ctx context.Context, workers chan struct{}, wg *sync.WaitGroup) {
+	for ctx.Err() == nil {
+		select {
+		case workers <- struct{}{}:
+		default:
+			return
+		}
+		job, err := r.queue.Claim(ctx, r.Lease)
+		if err != nil || job == nil {
+			<-workers
+			if err != nil && ctx.Err() == nil {
+				r.reportError(Job{}, err)
+			}
+			return
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.execute(ctx, job)
+			<-workers
+			r.signal() // a worker is free for the next due job
+		}()
+	}
+}
+
+// execute runs an attempt of a job and records its outcome
+func (r *Runner) execute(ctx context.Context, job *Job) {
+	r.notify(job.ID) // claimed: now running
+
+	handler, ok := r.handler(job.Kind)
+	var err error
+	if !ok {
+		err = fmt.Errorf("job kind %q not registered", job.Kind)
+	} else {
+		job.mu = new(sync.Mutex)
+		attempt, cancel := context.WithCancel(ctx)
+		lost := make(chan struct{})
+		stop := r.heartbeat(attempt, job, func() { close(lost); cancel() })
+		err = r.call(attempt, handler, job)
+		stop()
+		cancel()
+		select {
+		case <-lost:
+			// Another node runs the job now and owns its outcome
+			r.reportError(*job, ErrLeaseLost)
+			return
+		default:
+		}
+	}
+
+	// Saves outlive the runner's context, so a stopping node records where it got
+	save := context.WithoutCancel(ctx)
+	job.LockedUntil = time.Time{}
+	switch {
+	case err == nil:
+		job.Status = StatusSucceeded
+		job.Progress = 100
+		job.Error = ""
+	case ctx.Err() != nil:
+		// Stopped with the runner, not failed: run it again from the start
+		job.Status = StatusQueued
+		job.Attempts--
+		job.RunAt = time.Now()
+	default:
+		job.Error = err.Error()
+		if job.Attempts < job.MaxAttempts {
+			job.Status = StatusQueued
+			job.RunAt = time.Now().Add(r.backoff(job.Attempts))
+		} else {
+			job.Status = StatusFailed
+		}
+		r.reportError(*job, err)
+	}
+	if err := r.save(save, job); err != nil {
+		r.reportError(*job, err)
+	}
+}
+
+// heartbeat renews the lease of a running job every third of Lease, so a handler may
+// run longer than the lease without reporting progress. lost is called when the queue
+// says another node took the job over; the returned func stops renewing
+func (r *Runner) heartbeat(ctx context.Context, job *Job, lost func()) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(max(r.Lease/3, time.Millisecond))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			job.mu.Lock()
+			err := r.queue.Renew(ctx, job, r.Lease)
+			job.mu.Unlock()
+			switch {
+			case errors.Is(err, ErrLeaseLost), errors.Is(err, ErrNotFound):
+				lost()
+				return
+			case err != nil && ctx.Err() == nil:
+				// The handler may be changing the job, so only its ID is passed on
+				r.reportError(Job{ID: job.ID, Kind: job.Kind}, err)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// call runs an attempt within the handler's timeout, turning a panic into an error
+func (r *Runner) call(ctx context.Context, handler Handler, job *Job) (err error) {
+	timeout := handler.Timeout
+	if timeout <= 0 {
+		timeout = r.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	job.runner = r
+	defer func() {
+		job.runner = nil
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return handler.Run(ctx, job)
+}
+
+// save stores a job and tells its watchers on this node
+func (r *Runner) save(ctx context.Context, job *Job) error {
+	if err := r.queue.Save(ctx, job); err != nil {
+		return err
+	}
+	r.notify(job.ID)
+	return nil
+}
+
+// backoff returns the delay before the next attempt of a job that failed attempts times
+func (r *Runner) backoff(attempts int) time.Duration {
+	delay := r.MinBackoff << (attempts - 1)
+	if delay <= 0 || delay > r.MaxBackoff {
+		delay = r.MaxBackoff
+	}
+	return delay
+}
+
+// signal wakes Run to claim due jobs
+func (r *Runner) signal() {
+	select {
+	case r.wake <- struct{}{}:
+	default: // already due to claim
+	}
+}
+
+// subscribe registers a watcher of a job
+func (r *Runner) subscribe(id string) chan struct{} {
+	wake := make(chan struct{}, 1)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.watches[id] == nil {
+		r.watches[id] = make(map[chan struct{}]struct{})
+	}
+	r.watches[id][wake] = struct{}{}
+	return wake
+}
+
+// unsubscribe removes a watcher of a job
+func (r *Runner) unsubscribe(id string, wake chan struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.watches[id], wake)
+	if len(r.watches[id]) == 0 {
+		delete(r.watches, id)
+	}
+}
+
+// notify wakes the watchers of a job
+func (r *Runner) notify(id string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for wake := range r.watches[id] {
+		select {
+		case wake <- struct{}{}:
+		default: // already due to read
+		}
+	}
+}
+
+// reportError hands an error to OnError
+func (r *Runner) reportError(job Job, err error) {
+	if r.OnError != nil {
+		r.OnError(job, err)
+	}
+}
@@ -0,0 +1,114 @@
+package liveview
+
+// AppAssignFunc computes an app-level assign for a single socket
+// Use it for values that depend on the connection, such as the current user
+type AppAssignFunc func(socket *Socket) interface{}
+
+// SetAppAssign sets an app-level assign shared by every component
+// Connected sockets receive the new value and re-render
+func (h *Handler) SetAppAssign(key string, value interface{}) {
+	h.SetAppAssigns(map[string]interface{}{key: value})
+}
+
+// SetAppAssigns sets several app-level assigns at once
+// Values may be AppAssignFunc to compute them per socket
+func (h *Handler) SetAppAssigns(assigns map[string]interface{}) {
+	h.mu.Lock()
+	for k, v := range assigns {
+		h.appAssigns[k] = v
+	}
+	h.mu.Unlock()
+
+	h.pushAppAssigns(assigns)
+}
+
+// RefreshAppAssigns recomputes AppAssignFunc values on every connected socket
+// Call it when the data behind a per-socket assign changed (e.g. an unread count)
+func (h *Handler) RefreshAppAssigns(keys ...string) {
+	h.mu.RLock()
+	assigns := make(map[string]interface{}, len(keys))
+	for _, k := range keys {
+		if v, ok := h.appAssigns[k]; ok {
+			assigns[k] = v
+		}
+	}
+	h.mu.RUnlock()
+
+	h.pushAppAssigns(assigns)
+}
+
+// AppAssigns returns a copy of the app-level assigns
+func (h *Handler) AppAssigns() map[string]interface{} {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	assigns := make(map[string]interface{}, len(h.appAssigns))
+	for k, v := range h.appAssigns {
+		assigns[k] = v
+	}
+	return assigns
+}
+
+// assignAppState copies the app-level assigns into a socket
+func (h *Handler) assignAppState(socket *Socket) {
+	applyAppAssigns(socket, h.AppAssigns())
+}
+
+// pushAppAssigns applies assigns to every connected socket without waiting for them
+// Assigns a socket hasn't applied yet are merged, so a slow connection gets the latest
+// values in one update instead of holding up the caller and every other socket.
+// Components whose output doesn't depend on them produce an empty diff and send nothing
+func (h *Handler) pushAppAssigns(assigns map[string]interface{}) {
+	if len(assigns) == 0 {
+		return
+	}
+
+	h.mu.RLock()
+	sockets := make([]*Socket, 0, len(h.sockets))
+	for _, socket := range h.sockets {
+		sockets = append(sockets, socket)
+	}
+	h.mu.RUnlock()
+
+	for _, socket := range sockets {
+		socket.queueAppAssigns(assigns)
+	}
+}
+
+// queueAppAssigns merges assigns into the socket's pending app assigns and, unless an
+// update is already waiting, queues one applying them
+func (s *Socket) queueAppAssigns(assigns map[string]interface{}) {
+	s.appMu.Lock()
+	waiting := s.appPending != nil
+	if !waiting {
+		s.appPending = make(map[string]interface{}, len(assigns))
+	}
+	for k, v := range assigns {
+		s.appPending[k] = v
+	}
+	s.appMu.Unlock()
+	if waiting {
+		return
+	}
+
+	apply := func() {
+		s.appMu.Lock()
+		pending := s.appPending
+		s.appPending = nil
+		s.appMu.Unlock()
+		applyAppAssigns(s, pending)
+	}
+	if !s.tryEnqueue(apply) {
+		go s.enqueue(apply)
+	}
+}
+
+// applyAppAssigns sets assigns on a socket, resolving AppAssignFunc values
+func applyAppAssigns(socket *Socket, assigns map[string]interface{}) {
+	for k, v := range assigns {
+		if fn, ok := v.(AppAssignFunc); ok {
+			v = fn(socket)
+		}
+		socket.Assigns[k] = v
+	}
+}
@@ -0,0 +1,208 @@
+package liveview
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Asset kinds
+const (
+	AssetJS  = "js"
+	AssetCSS = "css"
+)
+
+// AssetPath is the URL prefix bundles are served under
+const AssetPath = "/livenest/assets/"
+
+// Asset is a JavaScript or CSS dependency of a component
+// Path names a file read when the bundle is built; Source is inline content used instead
+type Asset struct {
+	Kind   string
+	Path   string
+	Source string
+	Scope  Scope // prefixes the class selectors of a stylesheet, see Scope.CSS
+}
+
+// JSAsset declares a JavaScript file
+func JSAsset(path string) Asset {
+	return Asset{Kind: AssetJS, Path: path}
+}
+
+// CSSAsset declares a stylesheet file
+func CSSAsset(path string) Asset {
+	return Asset{Kind: AssetCSS, Path: path}
+}
+
+// InlineJS declares JavaScript given as source
+func InlineJS(source string) Asset {
+	return Asset{Kind: AssetJS, Source: source}
+}
+
+// InlineCSS declares CSS given as source
+func InlineCSS(source string) Asset {
+	return Asset{Kind: AssetCSS, Source: source}
+}
+
+// AssetComponent is an optional interface for components that need JavaScript or CSS
+// on their page, e.g. for a chart or an editor. The page only loads the assets of the
+// components on its route
+//
+//	func (c *Chart) Assets() []liveview.Asset {
+//		return []liveview.Asset{liveview.JSAsset("assets/chart.js"), liveview.CSSAsset("assets/chart.css")}
+//	}
+type AssetComponent interface {
+	Assets() []Asset
+}
+
+// assetBundle is the combined JavaScript and CSS of a page
+// Files are named after their content hash, so they can be cached forever
+type assetBundle struct {
+	js  string // file name of the script bundle, empty without scripts
+	css string // file name of the stylesheet bundle, empty without styles
+}
+
+// SetAssetFS reads the files of JSAsset and CSSAsset from fsys instead of the OS, e.g.
+// an embed.FS shipped in the binary; paths are relative to its root
+func (h *Handler) SetAssetFS(fsys fs.FS) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.assetFS = fsys
+}
+
+// readAsset reads an asset file from the asset file system or the OS
+func (h *Handler) readAsset(path string) ([]byte, error) {
+	h.mu.RLock()
+	fsys := h.assetFS
+	h.mu.RUnlock()
+	if fsys != nil {
+		return fs.ReadFile(fsys, path)
+	}
+	return os.ReadFile(path)
+}
+
+// RegisterAssets adds assets to a registered component, next to those it declares
+func (h *Handler) RegisterAssets(componentName string, assets ...Asset) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.assets == nil {
+		h.assets = make(map[string][]Asset)
+	}
+	h.assets[componentName] = append(h.assets[componentName], assets...)
+}
+
+// BundleAssets combines the assets of components, usually those of one route, into the
+// bundle loaded by the page of the component page
+// Assets shared by several components are included once, in the order first declared;
+// files are read now, so call it again after they change
+func (h *Handler) BundleAssets(page string, componentNames ...string) error {
+	var js, css bytes.Buffer
+	seen := make(map[Asset]bool)
+
+	for _, name := range componentNames {
+		h.mu.RLock()
+		component := h.components[name]
+		assets := append([]Asset(nil), h.assets[name]...)
+		h.mu.RUnlock()
+		if declared, ok := component.(AssetComponent); ok {
+			assets = append(declared.Assets(), assets...)
+		}
+
+		for _, asset := range assets {
+			if seen[asset] {
+				continue
+			}
+			seen[asset] = true
+
+			content := []byte(asset.Source)
+			if asset.Path != "" {
+				var err error
+				if content, err = h.readAsset(asset.Path); err != nil {
+					return fmt.Errorf("asset of %s: %w", name, err)
+				}
+			}
+			switch asset.Kind {
+			case AssetJS:
+				// Scripts are separated so one missing a semicolon can't join the next
+				js.Write(content)
+				js.WriteString("\n;\n")
+			case AssetCSS:
+				if asset.Scope != "" {
+					content = []byte(scopeCSS(string(content), asset.Scope))
+				}
+				css.Write(content)
+				css.WriteString("\n")
+			default:
+				return fmt.Errorf("asset of %s: unknown kind %q", name, asset.Kind)
+			}
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.assetFiles == nil {
+		h.assetFiles = make(map[string][]byte)
+	}
+	if h.bundles == nil {
+		h.bundles = make(map[string]assetBundle)
+	}
+	bundle := assetBundle{
+		js:  h.addAssetFile(js.Bytes(), ".js"),
+		css: h.addAssetFile(css.Bytes(), ".css"),
+	}
+	h.bundles[page] = bundle
+	return nil
+}
+
+// addAssetFile stores bundle content under a name derived from its hash
+func (h *Handler) addAssetFile(content []byte, ext string) string {
+	if len(content) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(content)
+	name := hex.EncodeToString(sum[:8]) + ext
+	h.assetFiles[name] = content
+	return name
+}
+
+// HandleAsset serves a bundle built by BundleAssets
+func (h *Handler) HandleAsset(c *gin.Context) {
+	name := c.Param("file")
+	h.mu.RLock()
+	content, ok := h.assetFiles[name]
+	h.mu.RUnlock()
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	contentType := "application/javascript"
+	if strings.HasSuffix(name, ".css") {
+		contentType = "text/css; charset=utf-8"
+	}
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Data(http.StatusOK, contentType, content)
+}
+
+// assetTags returns the tags loading the bundle of a page's scripts and styles
+func (h *Handler) assetTags(page string, socket *Socket) (scripts, styles template.HTML) {
+	h.mu.RLock()
+	bundle := h.bundles[page]
+	h.mu.RUnlock()
+
+	if bundle.css != "" {
+		styles = template.HTML(`<link rel="stylesheet" href="` + AssetPath + bundle.css + `">`)
+	}
+	if bundle.js != "" {
+		scripts = template.HTML(`<script src="` + AssetPath + bundle.js + `" defer` + string(socket.NonceAttr()) + `></script>`)
+	}
+	return scripts, styles
+}
@@ -0,0 +1,108 @@
+package liveview
+
+import "context"
+
+// asyncTask is a running StartAsync task
+type asyncTask struct {
+	cancel context.CancelFunc
+}
+
+// Context returns the context of the socket's connection
+// It is cancelled when the WebSocket connection closes
+func (s *Socket) Context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// StartAsync runs fn on its own goroutine and applies its result to the socket
+// Starting another task with the same name cancels the previous one, and all
+// tasks are cancelled when the connection closes. The function returned by fn
+// runs on the connection goroutine, after which the component re-renders; it
+// is skipped if the task was cancelled in the meantime.
+// StartAsync reports false when the socket has no live connection
+func (s *Socket) StartAsync(name string, fn func(ctx context.Context) func(*Socket)) bool {
+	if s.updates == nil {
+		return false
+	}
+
+	s.CancelAsync(name)
+
+	ctx, cancel := context.WithCancel(s.Context())
+	task := &asyncTask{cancel: cancel}
+	if s.tasks == nil {
+		s.tasks = make(map[string]*asyncTask)
+	}
+	s.tasks[name] = task
+
+	go func() {
+		apply := fn(ctx)
+		s.enqueue(func() {
+			if s.tasks[name] == task {
+				delete(s.tasks, name)
+			}
+			if ctx.Err() == nil && apply != nil {
+				apply(s)
+			}
+			cancel()
+		})
+	}()
+
+	return true
+}
+
+// CancelAsync cancels the running task with a name
+func (s *Socket) CancelAsync(name string) {
+	if task, ok := s.tasks[name]; ok {
+		task.cancel()
+		delete(s.tasks, name)
+	}
+}
+
+// AsyncRunning reports whether a task with a name is running
+func (s *Socket) AsyncRunning(name string) bool {
+	_, ok := s.tasks[name]
+	return ok
+}
+
+// StartStream runs fn on its own goroutine for as long as the connection lasts and
+// lets it push any number of updates; each one runs on the connection goroutine
+// and re-renders the component, e.g. to follow a feed or an event stream
+// Like StartAsync tasks, streams are named, replaced, cancelled with CancelAsync
+// and stopped when the connection closes
+// StartStream reports false when the socket has no live connection
+func (s *Socket) StartStream(name string, fn func(ctx context.Context, push func(func(*Socket)))) bool {
+	if s.updates == nil {
+		return false
+	}
+
+	s.CancelAsync(name)
+
+	ctx, cancel := context.WithCancel(s.Context())
+	task := &asyncTask{cancel: cancel}
+	if s.tasks == nil {
+		s.tasks = make(map[string]*asyncTask)
+	}
+	s.tasks[name] = task
+
+	push := func(apply func(*Socket)) {
+		s.enqueue(func() {
+			if ctx.Err() == nil {
+				apply(s)
+			}
+		})
+	}
+
+	go func() {
+		fn(ctx, push)
+		s.enqueue(func() {
+			if s.tasks[name] == task {
+				delete(s.tasks, name)
+			}
+			cancel()
+		})
+	}()
+
+	return true
+}
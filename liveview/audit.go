@@ -0,0 +1,202 @@
+package liveview
+
+import (
+	"html/template"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// AuditFinding is an interaction that doesn't work without JavaScript
+type AuditFinding struct {
+	Element   string // short description of the element, e.g. <button class="btn"> "Save"
+	Attribute string // the lv-* binding that needs the live runtime
+	Message   string // what happens without JavaScript and how to provide a fallback
+}
+
+// SetNoJSAudit serves LiveView pages without the live runtime and lists, on the page
+// and in the log, the interactions that have no fallback for browsers without
+// JavaScript or crawlers. Meant for development only
+func (h *Handler) SetNoJSAudit(enabled bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.noJSAudit = enabled
+}
+
+// isNoJSAudit reports whether pages are served in audit mode
+func (h *Handler) isNoJSAudit() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.noJSAudit
+}
+
+// AuditNoJS inspects rendered component HTML for interactions that only work with
+// the live runtime. postable tells whether the page accepts plain form POSTs
+func AuditNoJS(content template.HTML, postable bool) []AuditFinding {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(string(content)), body)
+	if err != nil {
+		return nil
+	}
+
+	var findings []AuditFinding
+	var walk func(n *html.Node, form *html.Node)
+	walk = func(n *html.Node, form *html.Node) {
+		if n.Type == html.ElementNode {
+			if n.DataAtom == atom.Form {
+				form = n
+			}
+			findings = append(findings, auditElement(n, form, postable)...)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, form)
+		}
+	}
+	for _, n := range nodes {
+		walk(n, nil)
+	}
+	return findings
+}
+
+// auditElement checks the lv-* bindings of one element
+func auditElement(n, form *html.Node, postable bool) []AuditFinding {
+	var findings []AuditFinding
+	report := func(attr, message string) {
+		findings = append(findings, AuditFinding{Element: describeElement(n), Attribute: attr, Message: message})
+	}
+
+	for _, a := range n.Attr {
+		switch {
+		case a.Key == "lv-click":
+			if n.DataAtom == atom.A && hasAttr(n, "href") && !strings.HasPrefix(attrValue(n, "href"), "#") {
+				continue // the link is followed without JavaScript
+			}
+			if form != nil && isSubmitButton(n) {
+				continue // the form is submitted without JavaScript
+			}
+			report(a.Key, "does nothing without JavaScript; use a link with an href or a button in a form")
+		case a.Key == "lv-submit":
+			if !strings.EqualFold(attrValue(n, "method"), "post") {
+				report(a.Key, `submits as a GET without JavaScript; add method="post" and handle the POST`)
+			} else if !postable {
+				report(a.Key, "is posted to a page that doesn't handle POST; implement FormPoster on the component")
+			}
+		case a.Key == "lv-change":
+			if form == nil {
+				report(a.Key, "changes are only sent live; put the control in a form with a submit button")
+			}
+		case a.Key == "lv-keydown", a.Key == "lv-keyup":
+			report(a.Key, "keyboard shortcuts need JavaScript; make sure the action is also reachable with a link or button")
+		case strings.HasPrefix(a.Key, "lv-viewport-"):
+			report(a.Key, "infinite scrolling needs JavaScript; add a link to the next page")
+		case strings.HasPrefix(a.Key, "lv-window-"), strings.HasPrefix(a.Key, "lv-document-"):
+			report(a.Key, "window and document events need JavaScript")
+		}
+	}
+	return findings
+}
+
+// isSubmitButton reports whether an element submits its form when clicked
+func isSubmitButton(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Button:
+		t := strings.ToLower(attrValue(n, "type"))
+		return t == "" || t == "submit"
+	case atom.Input:
+		t := strings.ToLower(attrValue(n, "type"))
+		return t == "submit" || t == "image"
+	}
+	return false
+}
+
+// attrValue returns the value of an attribute, or ""
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasAttr reports whether an element has an attribute, even an empty one
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// describeElement formats an element as its tag with id or class and its text
+func describeElement(n *html.Node) string {
+	var b strings.Builder
+	b.WriteString("<" + n.Data)
+	if id := attrValue(n, "id"); id != "" {
+		b.WriteString(` id="` + id + `"`)
+	} else if class := attrValue(n, "class"); class != "" {
+		b.WriteString(` class="` + class + `"`)
+	}
+	b.WriteString(">")
+
+	text := strings.Join(strings.Fields(nodeText(n)), " ")
+	if len(text) > 40 {
+		text = text[:40] + "..."
+	}
+	if text != "" {
+		b.WriteString(` "` + text + `"`)
+	}
+	return b.String()
+}
+
+// nodeText returns the text content of a node
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(nodeText(c))
+	}
+	return b.String()
+}
+
+// auditReportTemplate renders the findings below the component in audit mode
+var auditReportTemplate = template.Must(template.New("audit").Parse(`
+<aside id="lv-nojs-audit">
+<style{{if .Nonce}} nonce="{{.Nonce}}"{{end}}>
+#lv-nojs-audit { margin-top: 24px; padding: 16px; border: 2px dashed #d97706; border-radius: 8px; background: #fffbeb; font: 14px/1.5 sans-serif; color: #78350f; }
+#lv-nojs-audit code { background: #fef3c7; padding: 0 4px; border-radius: 3px; }
+</style>
+<strong>No-JavaScript audit</strong>: the live runtime is disabled.
+{{- if .Findings}}
+<ul>
+{{- range .Findings}}
+<li><code>{{.Element}}</code> <code>{{.Attribute}}</code> {{.Message}}</li>
+{{- end}}
+</ul>
+{{- else}}
+<p>Every interaction has a fallback.</p>
+{{- end}}
+</aside>`))
+
+// auditPage logs the findings for a page and renders them as a report
+func auditPage(logger Logger, componentName string, content template.HTML, postable bool, nonce string) template.HTML {
+	findings := AuditNoJS(content, postable)
+	for _, f := range findings {
+		logger.Warn("No-JS audit finding", "component", componentName, "element", f.Element, "attribute", f.Attribute, "message", f.Message)
+	}
+
+	var b strings.Builder
+	data := struct {
+		Nonce    string
+		Findings []AuditFinding
+	}{nonce, findings}
+	if err := auditReportTemplate.Execute(&b, data); err != nil {
+		logger.Error("No-JS audit error", "error", err)
+		return ""
+	}
+	return template.HTML(b.String())
+}
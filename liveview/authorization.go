@@ -0,0 +1,56 @@
+package liveview
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnauthorized is returned when a socket is not allowed to mount or drive a component
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Authorizer is an optional interface for components that restrict access
+// Authorize is called before Mount and before each event
+type Authorizer interface {
+	Authorize(socket *Socket) error
+}
+
+// Policy decides whether a socket may use a component
+// event is empty when the policy is checked before Mount
+type Policy func(socket *Socket, event string) error
+
+// RegisterPolicy adds a policy for a registered component name
+// Policies run in registration order, after the component's own Authorize method
+func (h *Handler) RegisterPolicy(name string, policy Policy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.policies[name] = append(h.policies[name], policy)
+}
+
+// authorize runs the component's Authorizer and all policies registered for name
+func (h *Handler) authorize(name string, component Component, socket *Socket, event string) error {
+	if authorizer, ok := component.(Authorizer); ok {
+		if err := authorizer.Authorize(socket); err != nil {
+			return wrapUnauthorized(err)
+		}
+	}
+
+	h.mu.RLock()
+	policies := h.policies[name]
+	h.mu.RUnlock()
+
+	for _, policy := range policies {
+		if err := policy(socket, event); err != nil {
+			return wrapUnauthorized(err)
+		}
+	}
+
+	return nil
+}
+
+// wrapUnauthorized makes sure authorization errors match ErrUnauthorized
+func wrapUnauthorized(err error) error {
+	if errors.Is(err, ErrUnauthorized) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrUnauthorized, err)
+}
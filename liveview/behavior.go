@@ -0,0 +1,94 @@
+package liveview
+
+import (
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Behavior is a reusable piece of component functionality
+// Behaviors contribute assigns when the component mounts and event handlers
+// that run alongside the component's own Handle* methods
+//
+// Embed a behavior in a component struct to attach it automatically, or
+// register it for a component name with Handler.RegisterBehavior
+type Behavior interface {
+	// MountBehavior is called before the component's Mount
+	MountBehavior(socket *Socket) error
+
+	// BehaviorHooks returns the event handlers contributed by the behavior
+	BehaviorHooks() []EventHook
+}
+
+// RegisterBehavior attaches a behavior to a registered component name
+func (h *Handler) RegisterBehavior(name string, behavior Behavior) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.behaviors[name] = append(h.behaviors[name], behavior)
+}
+
+// behaviorsFor returns the embedded and registered behaviors of a component
+func (h *Handler) behaviorsFor(name string, component Component) []Behavior {
+	behaviors := embeddedBehaviors(component)
+
+	h.mu.RLock()
+	behaviors = append(behaviors, h.behaviors[name]...)
+	h.mu.RUnlock()
+
+	return behaviors
+}
+
+// mount injects app-level assigns and runs the mount hooks, then runs behavior mounts
+// followed by the component's Mount
+// c is the context of the entry point, nil when there is none such as in a replay
+func (h *Handler) mount(name string, component Component, socket *Socket, c *gin.Context) error {
+	c = mountContext(c, socket)
+	socket.negotiateLocale()
+	h.assignAppState(socket)
+	if err := h.runMountHooks(c, socket); err != nil {
+		return err
+	}
+	h.assignRouteDefaults(name, c, socket)
+
+	for _, behavior := range h.behaviorsFor(name, component) {
+		if err := behavior.MountBehavior(socket); err != nil {
+			return err
+		}
+	}
+	return mountComponent(component, socket)
+}
+
+// embeddedBehaviors finds behaviors embedded in a component struct
+func embeddedBehaviors(component interface{}) []Behavior {
+	v := reflect.ValueOf(component)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	v = v.Elem()
+
+	var behaviors []Behavior
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.Anonymous || !f.IsExported() {
+			continue
+		}
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				continue
+			}
+			if b, ok := field.Interface().(Behavior); ok {
+				behaviors = append(behaviors, b)
+			}
+			continue
+		}
+
+		if b, ok := field.Addr().Interface().(Behavior); ok {
+			behaviors = append(behaviors, b)
+		}
+	}
+
+	return behaviors
+}
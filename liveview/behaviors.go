@@ -0,0 +1,398 @@
+package liveview
+
+import (
+	"fmt"
+	"time"
+)
+
+// Pagination is the page state assigned by the Paginated behavior
+type Pagination struct {
+	Page    int
+	PerPage int
+	Total   int // total row count; -1 until SetTotal records it
+}
+
+// Offset returns the number of rows to skip for the current page
+func (p Pagination) Offset() int {
+	if p.Page < 1 {
+		return 0
+	}
+	return (p.Page - 1) * p.PerPage
+}
+
+// Pages returns the total number of pages
+// While the total is unknown it counts the pages up to the current one
+func (p Pagination) Pages() int {
+	if p.Total < 0 {
+		return max(p.Page, 1)
+	}
+	if p.PerPage <= 0 || p.Total == 0 {
+		return 1
+	}
+	return (p.Total + p.PerPage - 1) / p.PerPage
+}
+
+// HasPrev reports whether there is a previous page
+func (p Pagination) HasPrev() bool {
+	return p.Page > 1
+}
+
+// HasNext reports whether there is a next page, which is always the case while the
+// total is unknown
+func (p Pagination) HasNext() bool {
+	return p.Total < 0 || p.Page < p.Pages()
+}
+
+// Range returns all page numbers, for rendering page links
+func (p Pagination) Range() []int {
+	pages := make([]int, p.Pages())
+	for i := range pages {
+		pages[i] = i + 1
+	}
+	return pages
+}
+
+// Paginated adds page navigation to list components
+// It assigns a Pagination under Key and handles the "page" (payload "page"),
+// "nextPage" and "prevPage" events before the component's own handlers run.
+// Pages are only bounded once SetTotal records the row count; until then "nextPage"
+// always moves on, e.g. for lists whose count is too costly to query
+type Paginated struct {
+	PerPage int    // rows per page (default 20)
+	Key     string // assign key (default "pagination")
+}
+
+func (p *Paginated) key() string {
+	if p.Key == "" {
+		return "pagination"
+	}
+	return p.Key
+}
+
+// MountBehavior assigns the first page
+func (p *Paginated) MountBehavior(socket *Socket) error {
+	perPage := p.PerPage
+	if perPage <= 0 {
+		perPage = 20
+	}
+	socket.Set(p.key(), Pagination{Page: 1, PerPage: perPage, Total: -1})
+	return nil
+}
+
+// BehaviorHooks handles page navigation events
+func (p *Paginated) BehaviorHooks() []EventHook {
+	return []EventHook{
+		{Event: "page", Priority: -10, Handle: func(socket *Socket, event string, payload map[string]interface{}) error {
+			page, ok := Payload(payload).Int("page")
+			if !ok {
+				return fmt.Errorf("page not provided")
+			}
+			p.setPage(socket, page)
+			return nil
+		}},
+		{Event: "nextPage", Priority: -10, Handle: func(socket *Socket, event string, payload map[string]interface{}) error {
+			p.setPage(socket, p.Pagination(socket).Page+1)
+			return nil
+		}},
+		{Event: "prevPage", Priority: -10, Handle: func(socket *Socket, event string, payload map[string]interface{}) error {
+			p.setPage(socket, p.Pagination(socket).Page-1)
+			return nil
+		}},
+	}
+}
+
+// Pagination returns the current page state of a socket
+func (p *Paginated) Pagination(socket *Socket) Pagination {
+	pagination, _ := socket.Assigns[p.key()].(Pagination)
+	return pagination
+}
+
+// SetTotal records the total row count so page bounds can be enforced
+func (p *Paginated) SetTotal(socket *Socket, total int) {
+	pagination := p.Pagination(socket)
+	pagination.Total = total
+	socket.Set(p.key(), pagination)
+	p.setPage(socket, pagination.Page)
+}
+
+// setPage moves to a page, clamped to the valid range once the total is known
+func (p *Paginated) setPage(socket *Socket, page int) {
+	pagination := p.Pagination(socket)
+	if pagination.Total >= 0 && page > pagination.Pages() {
+		page = pagination.Pages()
+	}
+	if page < 1 {
+		page = 1
+	}
+	pagination.Page = page
+	socket.Set(p.key(), pagination)
+}
+
+// Sort is the sort state assigned by the Sortable behavior
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// OrderBy returns an ORDER BY clause such as "name desc", or "" when Field isn't a plain
+// column name, so it is safe to pass to a query
+func (s Sort) OrderBy() string {
+	if !isIdentifier(s.Field) {
+		return ""
+	}
+	if s.Desc {
+		return s.Field + " desc"
+	}
+	return s.Field + " asc"
+}
+
+// Sortable adds column sorting to list components
+// It assigns a Sort under Key and handles the "sort" event (payload "field", and "dir"
+// of "asc" or "desc"), toggling the direction when the same field is chosen twice
+// without a dir. Only Fields can be sorted on, as the field ends up in ORDER BY
+type Sortable struct {
+	Fields  []string // allowed sort fields; none are allowed when empty
+	Default string   // initial sort field
+	Key     string   // assign key (default "sort")
+}
+
+func (s *Sortable) key() string {
+	if s.Key == "" {
+		return "sort"
+	}
+	return s.Key
+}
+
+// MountBehavior assigns the default sort
+func (s *Sortable) MountBehavior(socket *Socket) error {
+	socket.Set(s.key(), Sort{Field: s.Default})
+	return nil
+}
+
+// BehaviorHooks handles the sort event
+func (s *Sortable) BehaviorHooks() []EventHook {
+	return []EventHook{
+		{Event: "sort", Priority: -10, Handle: func(socket *Socket, event string, payload map[string]interface{}) error {
+			field, _ := payload["field"].(string)
+			if !s.allowed(field) {
+				return fmt.Errorf("cannot sort by %q", field)
+			}
+
+			current := s.Sort(socket)
+			if current.Field == field {
+				current.Desc = !current.Desc
+			} else {
+				current = Sort{Field: field}
+			}
+			if dir, ok := payload["dir"]; ok {
+				switch dir {
+				case "asc":
+					current.Desc = false
+				case "desc":
+					current.Desc = true
+				default:
+					return fmt.Errorf("invalid sort direction %v", dir)
+				}
+			}
+			socket.Set(s.key(), current)
+			return nil
+		}},
+	}
+}
+
+// Sort returns the current sort state of a socket
+func (s *Sortable) Sort(socket *Socket) Sort {
+	sort, _ := socket.Assigns[s.key()].(Sort)
+	return sort
+}
+
+// allowed reports whether a field may be sorted on
+func (s *Sortable) allowed(field string) bool {
+	if !isIdentifier(field) {
+		return false
+	}
+	for _, f := range s.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdentifier reports whether s is a plain column name such as "created_at" or "users.name"
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9', r == '.':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ScrollState is the list state assigned by the InfiniteScroll behavior
+type ScrollState[T any] struct {
+	Items   []T
+	HasMore bool
+}
+
+// InfiniteScroll loads list rows page by page as the user scrolls
+// It assigns a ScrollState under Key with the first page on mount and appends
+// the next page on the "loadMore" event. Render the rows inside an element with
+// lv-viewport-bottom="loadMore" to load more when the last row becomes visible
+type InfiniteScroll[T any] struct {
+	PerPage int    // rows per load (default 20)
+	Key     string // assign key (default "scroll")
+
+	// Load returns up to limit rows starting at offset
+	Load func(socket *Socket, offset, limit int) ([]T, error)
+}
+
+func (s *InfiniteScroll[T]) key() string {
+	if s.Key == "" {
+		return "scroll"
+	}
+	return s.Key
+}
+
+func (s *InfiniteScroll[T]) perPage() int {
+	if s.PerPage <= 0 {
+		return 20
+	}
+	return s.PerPage
+}
+
+// MountBehavior loads the first page
+func (s *InfiniteScroll[T]) MountBehavior(socket *Socket) error {
+	return s.Reset(socket)
+}
+
+// BehaviorHooks handles the "loadMore" event
+func (s *InfiniteScroll[T]) BehaviorHooks() []EventHook {
+	return []EventHook{
+		{Event: "loadMore", Priority: -10, Handle: func(socket *Socket, event string, payload map[string]interface{}) error {
+			return s.LoadMore(socket)
+		}},
+	}
+}
+
+// State returns the current list state of a socket
+func (s *InfiniteScroll[T]) State(socket *Socket) ScrollState[T] {
+	state, _ := socket.Assigns[s.key()].(ScrollState[T])
+	return state
+}
+
+// Reset reloads the list from the first row, e.g. after a filter changed
+func (s *InfiniteScroll[T]) Reset(socket *Socket) error {
+	socket.Set(s.key(), ScrollState[T]{HasMore: true})
+	return s.LoadMore(socket)
+}
+
+// LoadMore appends the next page of rows
+func (s *InfiniteScroll[T]) LoadMore(socket *Socket) error {
+	state := s.State(socket)
+	if !state.HasMore || s.Load == nil {
+		return nil
+	}
+
+	// Ask for one extra row to know whether another page exists
+	limit := s.perPage()
+	rows, err := s.Load(socket, len(state.Items), limit+1)
+	if err != nil {
+		return err
+	}
+
+	state.HasMore = len(rows) > limit
+	if state.HasMore {
+		rows = rows[:limit]
+	}
+	state.Items = append(state.Items[:len(state.Items):len(state.Items)], rows...)
+	socket.Set(s.key(), state)
+	return nil
+}
+
+// Flashes exposes pending flash messages to templates for inline rendering
+// After every event it assigns a copy of the session flashes under Key
+// and it handles the "clearFlash" event (optional payload "key" or "type")
+type Flashes struct {
+	Key string // assign key (default "flashes")
+}
+
+func (f *Flashes) key() string {
+	if f.Key == "" {
+		return "flashes"
+	}
+	return f.Key
+}
+
+// MountBehavior assigns an empty flash list
+func (f *Flashes) MountBehavior(socket *Socket) error {
+	socket.Set(f.key(), []Flash{})
+	return nil
+}
+
+// BehaviorHooks keeps the flash assign in sync and handles clearing
+func (f *Flashes) BehaviorHooks() []EventHook {
+	return []EventHook{
+		{Event: "clearFlash", Priority: -10, Handle: func(socket *Socket, event string, payload map[string]interface{}) error {
+			if key, ok := payload["key"].(string); ok && key != "" {
+				socket.Session.DeleteFlashKey(key)
+			} else if kind, ok := payload["type"].(string); ok && kind != "" {
+				socket.Session.DeleteFlashes(kind)
+			} else {
+				socket.Session.ClearFlashes()
+			}
+			return nil
+		}},
+		{Event: AnyEvent, Priority: 100, Handle: func(socket *Socket, event string, payload map[string]interface{}) error {
+			socket.Set(f.key(), socket.Session.PeekFlashes())
+			return nil
+		}},
+	}
+}
+
+// AuditEntry records a single handled event
+type AuditEntry struct {
+	Time     time.Time
+	SocketID string
+	Event    string
+	Payload  map[string]interface{}
+}
+
+// Audited records every event a component receives before it is handled
+type Audited struct {
+	Log func(entry AuditEntry) // defaults to the standard logger
+}
+
+// MountBehavior does nothing; auditing only applies to events
+func (a *Audited) MountBehavior(socket *Socket) error {
+	return nil
+}
+
+// BehaviorHooks records every event before any other handler
+func (a *Audited) BehaviorHooks() []EventHook {
+	return []EventHook{
+		{Event: AnyEvent, Priority: -100, Handle: func(socket *Socket, event string, payload map[string]interface{}) error {
+			entry := AuditEntry{
+				Time:     time.Now(),
+				SocketID: socket.ID,
+				Event:    event,
+				Payload:  payload,
+			}
+			if a.Log != nil {
+				a.Log(entry)
+			} else {
+				socket.log().Info("Audit", "socket", entry.SocketID, "event", entry.Event, "payload", entry.Payload)
+			}
+			return nil
+		}},
+	}
+}
@@ -0,0 +1,99 @@
+package liveview
+
+import (
+	"time"
+)
+
+// BudgetedComponent is an optional interface for components that declare how long
+// their events are expected to take, including the re-render. AnyEvent sets the
+// budget of events without their own
+//
+//	func (c *Search) EventBudgets() map[string]time.Duration {
+//		return map[string]time.Duration{"search": 50 * time.Millisecond, liveview.AnyEvent: 20 * time.Millisecond}
+//	}
+type BudgetedComponent interface {
+	EventBudgets() map[string]time.Duration
+}
+
+// BudgetReport describes an event that took longer than its budget
+type BudgetReport struct {
+	Component string
+	Event     string
+	SocketID  string
+	Duration  time.Duration
+	Budget    time.Duration
+}
+
+// SetEventBudget sets the latency budget of an event of a registered component
+// Use AnyEvent to set a default for all of its events. Budgets declared by the
+// component with EventBudgets take precedence
+func (h *Handler) SetEventBudget(componentName, event string, budget time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.budgets == nil {
+		h.budgets = make(map[string]map[string]time.Duration)
+	}
+	if h.budgets[componentName] == nil {
+		h.budgets[componentName] = make(map[string]time.Duration)
+	}
+	h.budgets[componentName][event] = budget
+}
+
+// OnBudgetExceeded registers a function called for every event over its budget,
+// e.g. to feed an alerting or metrics system. It runs on the connection goroutine
+func (h *Handler) OnBudgetExceeded(fn func(BudgetReport)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.budgetReporters = append(h.budgetReporters, fn)
+}
+
+// budgetFor returns the latency budget of an event, or 0 when it has none
+func (h *Handler) budgetFor(componentName string, component Component, event string) time.Duration {
+	if bc, ok := component.(BudgetedComponent); ok {
+		budgets := bc.EventBudgets()
+		if budget, ok := budgets[event]; ok {
+			return budget
+		}
+		if budget, ok := budgets[AnyEvent]; ok {
+			return budget
+		}
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	budgets := h.budgets[componentName]
+	if budget, ok := budgets[event]; ok {
+		return budget
+	}
+	return budgets[AnyEvent]
+}
+
+// checkBudget logs, counts and reports an event that took longer than its budget
+func (h *Handler) checkBudget(componentName string, component Component, socket *Socket, event string, start time.Time) {
+	budget := h.budgetFor(componentName, component, event)
+	if budget <= 0 {
+		return
+	}
+
+	elapsed := time.Since(start)
+	if elapsed <= budget {
+		return
+	}
+
+	report := BudgetReport{
+		Component: componentName,
+		Event:     event,
+		SocketID:  socket.ID,
+		Duration:  elapsed,
+		Budget:    budget,
+	}
+	h.log().Warn("Event budget exceeded", "component", componentName, "event", event, "elapsed", elapsed.Round(time.Microsecond), "budget", budget, "socket", socket.ID)
+	h.counters.recordBudgetMiss(componentName + "/" + event)
+
+	h.mu.RLock()
+	reporters := h.budgetReporters
+	h.mu.RUnlock()
+	for _, fn := range reporters {
+		fn(report)
+	}
+}
@@ -1,8 +1,15 @@
 package liveview
 
 import (
+	"context"
 	"html/template"
 	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/paulmanoni/livenest/i18n"
+	"github.com/paulmanoni/livenest/jobs"
 )
 
 // Component represents a LiveView component
@@ -25,7 +32,45 @@ type Socket struct {
 	ComponentID  string
 	Session      *Session
 	Assigns      map[string]interface{}
-	previousHTML string // Track previous render for diffing
+	Request      *http.Request     // Request that opened the socket (page load or WebSocket upgrade)
+	RequestID    string            // ID of the page request the socket was rendered for, or of its WebSocket upgrade
+	Locale       string            // Locale the socket renders in, see Handler.SetTranslations
+	Nonce        string            // CSP nonce of the page this socket renders into
+	Params       url.Values        // Query parameters of the page URL
+	RenderMode   RenderMode        // RenderPrint renders the printable variant, see Printing
+	page         pageMeta          // Document title and meta tags
+	toasts       []Toast           // Toasts waiting to be sent
+	toastSeq     int               // Sequence for generated toast IDs
+	previousHTML string            // Track previous render for diffing
+	updates      chan socketUpdate // Server-side updates run on the connection goroutine
+	closed       chan struct{}     // Closed when the WebSocket connection ends
+	ctx          context.Context
+	tasks        map[string]*asyncTask                                    // Running StartAsync tasks by name
+	queries      *queryLog                                                // Queries recorded for the debug overlay
+	redirect     *redirect                                                // Navigation requested by the current event
+	ticks        map[string]context.CancelFunc                            // Running EveryTick timers by event
+	dispatch     func(event string, payload map[string]interface{}) error // Runs server-sent events through the component's handlers
+	eventCtx     context.Context                                          // Context of the event being handled
+	timedOut     bool                                                     // An event handler timed out; the socket is abandoned
+	kicked       bool                                                     // Disconnect was called; the socket is unmounted
+	meta         *socketMeta                                              // Connection metadata listed by Handler.Sockets
+	traceCtx     context.Context                                          // Context of the current trace span while mounting or handling an event
+	logger       Logger                                                   // Logger of the handler that created the socket
+	services     func(name string) *ServiceClient                         // Looks up the handler's service clients
+	streams      []streamOp                                               // Stream container changes waiting to be sent
+	cursors      map[string]string                                        // Last cursor delivered to each stream container or subscription
+	sentCursors  map[string]string                                        // Subscription cursors waiting to be sent
+	print        bool                                                     // Print was called during the current event
+	statics      []string                                                 // Markup of the last split render the client has
+	dynamics     []string                                                 // Text of the last split render the client has
+	pipeline     string                                                   // Render pipeline of the update being sent, for the metrics
+	throttles    *topicThrottles                                          // Throttle rules of the handler's broadcast topics
+	translations *i18n.Catalog                                            // Messages of the handler's locales
+	jobs         *jobs.Runner                                             // Runner of the handler's background jobs, see MonitorJob
+	appMu        sync.Mutex                                               // Guards appPending, which is filled off the connection goroutine
+	appPending   map[string]interface{}                                   // App assigns waiting for the connection goroutine, see pushAppAssigns
+	formKey      []byte                                                   // Signs values that forms post back without JavaScript
+	csrfToken    string                                                   // CSRF token rendered into forms that post without JavaScript
 }
 
 // NewSocket creates a new socket
@@ -34,6 +79,7 @@ func NewSocket(id string) *Socket {
 		ID:          id,
 		ComponentID: generateComponentID(),
 		Assigns:     make(map[string]interface{}),
+		Params:      make(url.Values),
 		Session:     NewSession(),
 	}
 }
@@ -48,6 +94,33 @@ func generateComponentID() string {
 	return "lv-" + string(b)
 }
 
+// tryEnqueue is enqueue without waiting: it reports false when the connection's queue is full
+func (s *Socket) tryEnqueue(fn func()) bool {
+	if s.updates == nil {
+		return false
+	}
+	select {
+	case s.updates <- socketUpdate{socket: s, fn: fn}:
+		return true
+	default:
+		return false
+	}
+}
+
+// enqueue runs fn on the socket's connection goroutine and re-renders afterwards
+// It reports false when the socket has no live WebSocket connection
+func (s *Socket) enqueue(fn func()) bool {
+	if s.updates == nil {
+		return false
+	}
+	select {
+	case s.updates <- socketUpdate{socket: s, fn: fn}:
+		return true
+	case <-s.closed:
+		return false
+	}
+}
+
 // Assign sets multiple values in the socket assigns from a map
 func (s *Socket) Assign(assigns map[string]interface{}) {
 	for k, v := range assigns {
@@ -71,6 +144,11 @@ func (s *Socket) PutFlash(key, message string) {
 	s.Session.PutFlash(key, message)
 }
 
+// AddFlash queues a flash message with an optional deduplication key
+func (s *Socket) AddFlash(flash Flash) {
+	s.Session.AddFlash(flash)
+}
+
 // GetFlash retrieves and clears a flash message
 func (s *Socket) GetFlash(key string) (string, bool) {
 	return s.Session.GetFlash(key)
@@ -30,9 +30,16 @@ class LiveNestComponent extends HTMLElement {
             this.setAttribute('id', componentId);
         }
 
+        // Components of a route group are served under its prefix, e.g. base="/admin"
+        const base = (this.getAttribute('base') || '').replace(/\/$/, '');
+
         // Fetch initial component HTML from server
         try {
-            const response = await fetch('/livenest/component/' + componentName);
+            let url = base + '/livenest/component/' + componentName;
+            if (liveNestNonce) {
+                url += '?nonce=' + encodeURIComponent(liveNestNonce);
+            }
+            const response = await fetch(url);
             if (!response.ok) {
                 throw new Error('Component not found: ' + componentName);
             }
@@ -45,13 +52,15 @@ class LiveNestComponent extends HTMLElement {
             container.dataset.component = componentName;
             container.dataset.socketId = data.socket_id;
             container.dataset.componentId = data.component_id;
+            if (base) {
+                container.dataset.wsPath = base + '/live/ws';
+            }
             container.innerHTML = data.html;
 
             this.shadowRoot.appendChild(container);
 
-            // Initialize LiveView WebSocket connection
-            this.liveview = new LiveViewSocket(componentName, data.socket_id);
-            this.liveview.container = container;
+            // Join the page's shared LiveView WebSocket
+            this.liveview = new LiveViewSocket(componentName, data.socket_id, container);
             this.liveview.connect();
 
             // Dispatch loaded event
@@ -66,9 +75,9 @@ class LiveNestComponent extends HTMLElement {
     }
 
     disconnectedCallback() {
-        // Clean up WebSocket connection
-        if (this.liveview && this.liveview.ws) {
-            this.liveview.ws.close();
+        // Leave the shared WebSocket; other components keep using it
+        if (this.liveview) {
+            this.liveview.disconnect();
         }
     }
 
@@ -90,7 +99,7 @@ class LiveNestComponent extends HTMLElement {
     // Send event to component
     sendEvent(eventName, payload = {}) {
         if (this.liveview) {
-            this.liveview.sendEvent(eventName, payload);
+            this.liveview.pushEvent(eventName, payload);
         }
     }
 
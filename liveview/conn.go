@@ -0,0 +1,485 @@
+package liveview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/paulmanoni/livenest/protocol"
+)
+
+// Control events sent by the client on a shared socket
+const (
+	joinEvent  = protocol.EventJoin  // mount a component; payload {component, socket_id}
+	leaveEvent = protocol.EventLeave // unmount the component of the message topic
+)
+
+// controlHandlers handle the client messages that aren't events, by message type
+var controlHandlers = map[string]func(lc *liveConn, msg Message){
+	protocol.TypeJoin:      (*liveConn).handleJoin,
+	protocol.TypeLeave:     (*liveConn).handleLeave,
+	protocol.TypeHeartbeat: (*liveConn).handleHeartbeat,
+}
+
+// liveConn is a WebSocket connection carrying one or more mounted components
+type liveConn struct {
+	h       *Handler
+	conn    *websocket.Conn
+	request *http.Request
+	session *Session // shared by the components of the connection
+	params  url.Values
+	nonce   string
+	group   string       // prefix of the route group the connection came through
+	gin     *gin.Context // context of the upgrade request, valid while the connection runs
+	ctx     context.Context
+	cancel  context.CancelFunc
+	updates chan socketUpdate // server-side updates of every mounted socket
+	closed  chan struct{}     // closed when the connection ends
+	views   map[string]*liveView
+}
+
+// liveView is a component mounted on a connection under a topic
+type liveView struct {
+	topic     string
+	name      string
+	component Component
+	socket    *Socket
+	cancel    context.CancelFunc
+	recording *Recording       // session being recorded, if any
+	save      func(*Recording) // receives the recording when the view leaves
+}
+
+// socketUpdate is a server-side update queued with Socket.enqueue
+type socketUpdate struct {
+	socket *Socket
+	fn     func()
+}
+
+// newLiveConn prepares a connection for the upgraded request
+func (h *Handler) newLiveConn(c *gin.Context, conn *websocket.Conn) *liveConn {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	ctx = h.labelConn(ctx)
+	lc := &liveConn{
+		h:       h,
+		conn:    conn,
+		request: c.Request,
+		session: h.requestSession(c.Request),
+		params:  pageParams(c.Query("params")),
+		nonce:   c.Query("nonce"),
+		group:   requestGroup(c),
+		gin:     c,
+		ctx:     ctx,
+		cancel:  cancel,
+		updates: make(chan socketUpdate, 16),
+		closed:  make(chan struct{}),
+		views:   make(map[string]*liveView),
+	}
+	h.mu.Lock()
+	h.conns[lc] = struct{}{}
+	h.mu.Unlock()
+	return lc
+}
+
+// join authorizes, mounts and renders a component under topic
+func (lc *liveConn) join(topic, componentName, socketID, nonce string, cursors map[string]string) (err error) {
+	h := lc.h
+
+	h.mu.RLock()
+	component, exists := h.components[componentName]
+	h.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("component %q not found", componentName)
+	}
+	if err := h.checkGroup(componentName, lc.group); err != nil {
+		return err
+	}
+
+	// A container joining again replaces its previous mount
+	lc.leave(topic)
+
+	if nonce == "" {
+		nonce = lc.nonce
+	}
+
+	// Create socket
+	socket := h.newSocket(socketID)
+	socket.RequestID = requestID(lc.request)
+	// A join of a server render keeps its component ID, so element IDs derived from it
+	// match, and the ID of the page request, so its logs trace back to the page load
+	if pending, ok := h.pending.claim(socketID, componentName); ok {
+		socket.ComponentID = pending.componentID
+		if pending.requestID != "" {
+			socket.RequestID = pending.requestID
+		}
+	}
+	socket.Request = lc.request
+	socket.Session = lc.session
+	socket.Nonce = nonce
+	socket.Params = lc.params
+	socket.cursors = cursors
+	socket.updates = lc.updates
+	socket.closed = lc.closed
+	socket.dispatch = func(event string, payload map[string]interface{}) error {
+		return h.runEvent(componentName, component, event, payload, socket)
+	}
+
+	ctx, cancel := context.WithCancel(lc.ctx)
+	if h.isDebug() {
+		socket.queries = &queryLog{}
+		ctx = context.WithValue(ctx, queryLogKey{}, socket.queries)
+	}
+	socket.ctx = ctx
+
+	// Authorizing, mounting and the first render are traced as one span
+	end := h.startSpan("liveview.mount", componentName, "", socket)
+	defer func() { end(err) }()
+
+	// Check authorization before mounting
+	if err := h.authorize(componentName, component, socket, ""); err != nil {
+		cancel()
+		socket.log().Warn("Component mount rejected", "component", componentName, "error", err)
+		return err
+	}
+
+	// Mount component and render it for the first time
+	start := time.Now()
+	var html template.HTML
+	h.profiled(lc.ctx, componentName, "mount", func() {
+		if err = h.mount(componentName, component, socket, lc.gin); err != nil {
+			if !errors.Is(err, ErrHalt) {
+				socket.log().Error("Component mount error", "component", componentName, "error", err)
+			}
+			return
+		}
+		if html, err = h.renderComponent(componentName, "mount", component, socket); err != nil {
+			socket.log().Error("Render error", "component", componentName, "error", err)
+		}
+	})
+	if err != nil {
+		cancel()
+		// A mount hook that halted may have asked to navigate elsewhere
+		if errors.Is(err, ErrHalt) && socket.redirect != nil {
+			data := make(map[string]interface{})
+			h.addRedirectToData(socket, data)
+			h.sendMessage(lc.conn, topic, "render", data)
+		}
+		return err
+	}
+
+	htmlStr := string(html)
+	socket.previousHTML = htmlStr // Store for future diffs
+
+	renderData := map[string]interface{}{
+		"html": htmlStr,
+	}
+	h.addFlashToData(socket, renderData)
+	h.addTitleToData(socket, renderData)
+	h.addToastsToData(socket, renderData)
+	if !h.featuresFor(componentName, component).UseStreams {
+		h.dropStreams(componentName, socket)
+	}
+	h.addStreamsToData(socket, renderData)
+	h.addRedirectToData(socket, renderData)
+	h.addPrintToData(socket, renderData)
+	h.addDebugToData(socket, "mount", time.Since(start), renderData)
+
+	size, err := h.writeFrame(lc.conn, topic, "render", renderData)
+	if err != nil {
+		cancel()
+		socket.log().Error("Send error", "component", componentName, "error", err)
+		return err
+	}
+	h.checkPayload(componentName, "mount", socket, size)
+
+	// Store socket
+	view := &liveView{topic: topic, name: componentName, component: component, socket: socket, cancel: cancel}
+	if save := h.recorderFor(componentName); save != nil {
+		view.recording = &Recording{Component: componentName, Params: lc.params, RecordedAt: start}
+		view.save = save
+	}
+	lc.views[topic] = view
+	socket.meta = newSocketMeta(socket, componentName, topic)
+	h.mu.Lock()
+	h.sockets[socket.ID] = socket
+	h.mu.Unlock()
+	h.trackSocket(socket)
+	socket.log().Debug("Socket joined", "socket", socket.ID, "component", componentName, "connection_request_id", requestID(lc.request))
+
+	return nil
+}
+
+// leave unmounts the component of a topic
+func (lc *liveConn) leave(topic string) {
+	view, ok := lc.views[topic]
+	if !ok {
+		return
+	}
+	delete(lc.views, topic)
+	view.cancel()
+
+	if view.recording != nil && len(view.recording.Events) > 0 {
+		go view.save(view.recording)
+	}
+
+	lc.h.mu.Lock()
+	if lc.h.sockets[view.socket.ID] == view.socket {
+		delete(lc.h.sockets, view.socket.ID)
+	}
+	lc.h.mu.Unlock()
+	untrackSocket(view.socket)
+}
+
+// viewFor finds the mounted view of a socket
+func (lc *liveConn) viewFor(socket *Socket) *liveView {
+	for _, view := range lc.views {
+		if view.socket == socket {
+			return view
+		}
+	}
+	return nil
+}
+
+// handleJoin mounts the component requested by a join message
+// Failures are reported to the container as an error frame
+func (lc *liveConn) handleJoin(msg Message) {
+	payload := Payload(msg.Payload)
+	name, _ := payload.String("component")
+	socketID, _ := payload.String("socket_id")
+	nonce, _ := payload.String("nonce")
+	cursors := streamCursors(payload["cursors"])
+
+	// A panicking mount fails the join instead of the whole server
+	defer func() {
+		if r := recover(); r != nil {
+			p := recoverPanic(r)
+			lc.h.log().Error("Component mount panic", "component", name, "panic", r, "stack", p.Stack)
+			frame := lc.h.errorFrame(joinEvent, p)
+			frame["reason"] = "join_failed"
+			lc.h.sendMessage(lc.conn, msg.Topic, "error", frame)
+			lc.reply(msg, protocol.StatusError)
+		}
+	}()
+
+	if err := lc.join(msg.Topic, name, socketID, nonce, cursors); err != nil {
+		if IsUnavailable(err) {
+			lc.h.sendMessage(lc.conn, msg.Topic, "error", lc.h.errorFrame(joinEvent, err))
+			lc.reply(msg, protocol.StatusError)
+			return
+		}
+		reason := "join_failed"
+		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrHalt) {
+			reason = "unauthorized"
+		}
+		lc.h.sendMessage(lc.conn, msg.Topic, "error", map[string]interface{}{
+			"event":   joinEvent,
+			"reason":  reason,
+			"message": err.Error(),
+		})
+		lc.reply(msg, protocol.StatusError)
+		return
+	}
+	lc.reply(msg, protocol.StatusOK)
+}
+
+// handleLeave unmounts the component of a leave message's topic
+func (lc *liveConn) handleLeave(msg Message) {
+	lc.leave(msg.Topic)
+	lc.reply(msg, protocol.StatusOK)
+}
+
+// handleHeartbeat answers a heartbeat, so the client knows the connection still works
+func (lc *liveConn) handleHeartbeat(msg Message) {
+	lc.reply(msg, protocol.StatusOK)
+}
+
+// reply acknowledges a control message that carries a ref
+func (lc *liveConn) reply(msg Message, status string) {
+	if msg.Ref == "" {
+		return
+	}
+	lc.h.sendMessage(lc.conn, msg.Topic, protocol.TypeReply, map[string]interface{}{
+		"ref":    msg.Ref,
+		"status": status,
+	})
+}
+
+// run dispatches client events and server-side updates until the connection closes
+func (lc *liveConn) run() {
+	h := lc.h
+
+	// Read client messages on their own goroutine so server-side updates can interleave
+	incoming := make(chan Message)
+	go h.readMessages(lc.conn, incoming, lc.closed)
+
+	// Listen for events and server-side updates
+	for running := true; running; {
+		var view *liveView
+		var renderData map[string]interface{}
+		var event string
+		var start time.Time
+
+		select {
+		case msg, ok := <-incoming:
+			if !ok {
+				running = false
+				continue
+			}
+			start = time.Now()
+
+			kind := protocol.MessageKind(msg.Type, msg.Event)
+			if msg.rejected == nil {
+				msg.rejected = checkEnvelope(kind, msg)
+			}
+			if msg.rejected != nil {
+				lc.reject(msg)
+				continue
+			}
+
+			if handle, ok := controlHandlers[kind]; ok {
+				handle(lc, msg)
+				continue
+			}
+
+			view = lc.views[msg.Topic]
+			if view == nil {
+				h.log().Warn("Event for unknown topic", "event", msg.Event, "topic", msg.Topic)
+				continue
+			}
+
+			if view.recording != nil {
+				view.recording.record(msg)
+			}
+			view.socket.meta.touch(msg.Event)
+
+			event = msg.Event
+			h.profiled(lc.ctx, view.name, msg.Event, func() {
+				renderData = h.processEvent(lc.conn, view.name, view.component, view.socket, msg)
+			})
+
+			// Echo the ref so the client can clear its loading state
+			if msg.Ref != "" {
+				renderData["ref"] = msg.Ref
+			}
+
+		case update := <-lc.updates:
+			start = time.Now()
+			// Updates for components that left in the meantime are dropped
+			view = lc.viewFor(update.socket)
+			if view == nil {
+				continue
+			}
+			renderData = lc.applyUpdate(view, update)
+		}
+
+		// A handler that timed out may still be running, so its socket is dropped
+		// and the client mounts the component again
+		if view.socket.timedOut {
+			h.sendMessage(lc.conn, view.topic, "error", h.errorFrame(event, ErrEventTimeout))
+			lc.leave(view.topic)
+			continue
+		}
+
+		// Disconnect unmounts the socket; the client doesn't join it again
+		if view.socket.kicked {
+			h.sendMessage(lc.conn, view.topic, "error", map[string]interface{}{
+				"reason":  "disconnected",
+				"message": "Disconnected by the server",
+			})
+			lc.leave(view.topic)
+			continue
+		}
+
+		h.addDebugToData(view.socket, event, time.Since(start), renderData)
+
+		// If nothing changed and there is no ref to acknowledge, skip sending
+		if len(renderData) == 0 {
+			continue
+		}
+
+		size, err := h.writeFrame(lc.conn, view.topic, "render", renderData)
+		if err != nil {
+			view.socket.log().Error("Send error", "component", view.name, "error", err)
+			running = false
+		}
+		if pipeline := view.socket.pipeline; pipeline != "" {
+			h.metrics.observeRender(view.name, pipeline, size)
+			view.socket.pipeline = ""
+		}
+		if event == "" {
+			h.checkPayload(view.name, "update", view.socket, size)
+		} else {
+			h.checkPayload(view.name, event, view.socket, size)
+		}
+	}
+}
+
+// checkEnvelope rejects messages of an unknown type or protocol version
+func checkEnvelope(kind string, msg Message) error {
+	if msg.Version != "" && msg.Version != protocol.Version {
+		return fmt.Errorf("unsupported protocol version %q", msg.Version)
+	}
+	if _, ok := controlHandlers[kind]; !ok && kind != protocol.TypeEvent {
+		return fmt.Errorf("unknown message type %q", kind)
+	}
+	return nil
+}
+
+// reject reports a malformed message or one over the limits with a protocol_error frame,
+// then acknowledges its ref so the client clears the event's loading state
+func (lc *liveConn) reject(msg Message) {
+	lc.h.log().Warn("Message rejected", "topic", msg.Topic, "event", msg.Event, "error", msg.rejected)
+	lc.h.sendMessage(lc.conn, msg.Topic, "error", map[string]interface{}{
+		"event":   msg.Event,
+		"reason":  "protocol_error",
+		"message": msg.rejected.Error(),
+	})
+	if protocol.MessageKind(msg.Type, msg.Event) != protocol.TypeEvent {
+		lc.reply(msg, protocol.StatusError)
+	} else if msg.Ref != "" {
+		lc.h.sendMessage(lc.conn, msg.Topic, "render", map[string]interface{}{"ref": msg.Ref})
+	}
+}
+
+// applyUpdate runs a server-side update and re-renders
+// A panic is logged and reported to the client; the connection keeps running
+func (lc *liveConn) applyUpdate(view *liveView, update socketUpdate) (renderData map[string]interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			p := recoverPanic(r)
+			lc.h.log().Error("Server update panic", "component", view.name, "panic", r, "stack", p.Stack)
+			lc.h.sendMessage(lc.conn, view.topic, "error", lc.h.errorFrame("", p))
+			renderData = make(map[string]interface{})
+		}
+	}()
+
+	end := lc.h.startSpan("liveview.update", view.name, "", view.socket)
+	defer func() { end(nil) }()
+
+	lc.h.profiled(lc.ctx, view.name, "update", func() {
+		update.fn()
+		if view.socket.timedOut || view.socket.kicked {
+			return
+		}
+		renderData = lc.h.renderUpdate(view.name, "update", view.component, view.socket)
+	})
+	return renderData
+}
+
+// close unmounts every component and stops pending updates
+func (lc *liveConn) close() {
+	for topic := range lc.views {
+		lc.leave(topic)
+	}
+	close(lc.closed)
+	lc.cancel()
+
+	lc.h.mu.Lock()
+	delete(lc.h.conns, lc)
+	lc.h.mu.Unlock()
+}
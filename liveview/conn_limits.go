@@ -0,0 +1,184 @@
+package liveview
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// CloseTryAgainLater is the WebSocket close code sent when the server is at capacity
+const CloseTryAgainLater = 1013
+
+// ConnectionLimits bounds the WebSocket connections a handler accepts, so a single
+// client can't exhaust goroutines and memory. Zero fields disable a limit
+type ConnectionLimits struct {
+	ConnectsPerMinute int                        // new connections per client in a sliding minute
+	MaxPerClient      int                        // concurrent connections per client
+	MaxConnections    int                        // concurrent connections in total
+	KeyFunc           func(*http.Request) string // identifies the client; defaults to the remote IP
+}
+
+// connLimiter applies ConnectionLimits and tracks connects and open connections
+type connLimiter struct {
+	config    ConnectionLimits
+	mu        sync.Mutex
+	connects  map[string][]time.Time
+	open      map[string]int
+	total     int
+	lastSweep time.Time
+}
+
+// connVerdict is the outcome of admitting a connection
+type connVerdict int
+
+const (
+	connAdmitted connVerdict = iota
+	connRateLimited
+	connClientLimit
+	connServerFull
+)
+
+// SetConnectionLimits sets the limits on WebSocket connections
+// A client over its connect rate or concurrent connections gets 429 Too Many Requests
+// before the upgrade. When the server is at MaxConnections the upgrade completes and the
+// connection is closed with 1013 (try again later), which the client retries with backoff
+func (h *Handler) SetConnectionLimits(limits ConnectionLimits) {
+	if limits.KeyFunc == nil {
+		limits.KeyFunc = RemoteIP
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.connLimiter = &connLimiter{
+		config:   limits,
+		connects: make(map[string][]time.Time),
+		open:     make(map[string]int),
+	}
+}
+
+// admitConnection applies the connection limits to a WebSocket request
+// When it returns false the request was answered; otherwise release must be called once
+// the connection closes
+func (h *Handler) admitConnection(c *gin.Context) (release func(), ok bool) {
+	h.mu.RLock()
+	limiter := h.connLimiter
+	h.mu.RUnlock()
+	if limiter == nil {
+		return func() {}, true
+	}
+
+	key := limiter.config.KeyFunc(c.Request)
+	verdict := limiter.acquire(key)
+	if verdict == connAdmitted {
+		return func() { limiter.release(key) }, true
+	}
+
+	h.counters.rejectedConns.Add(1)
+	h.log().Warn("WebSocket connection rejected", "client", key, "reason", verdict.String())
+	switch verdict {
+	case connServerFull:
+		conn, err := h.upgrade(c)
+		if err == nil {
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(CloseTryAgainLater, "server at capacity"))
+			conn.Close()
+		}
+	case connRateLimited:
+		c.Header("Retry-After", strconv.Itoa(int(limiter.retryAfter(key)/time.Second)+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many connections"})
+	default:
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many connections"})
+	}
+	return nil, false
+}
+
+// String names a verdict for logs
+func (v connVerdict) String() string {
+	switch v {
+	case connRateLimited:
+		return "connect rate"
+	case connClientLimit:
+		return "connections per client"
+	case connServerFull:
+		return "server at capacity"
+	default:
+		return "admitted"
+	}
+}
+
+// acquire records a connect for key and admits it if every limit allows
+func (l *connLimiter) acquire(key string) connVerdict {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-time.Minute)
+
+	// Drop clients that haven't connected for a minute
+	if now.Sub(l.lastSweep) > time.Minute {
+		for k, times := range l.connects {
+			if len(times) == 0 || times[len(times)-1].Before(cutoff) {
+				delete(l.connects, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	if l.config.ConnectsPerMinute > 0 {
+		recent := l.connects[key][:0]
+		for _, t := range l.connects[key] {
+			if t.After(cutoff) {
+				recent = append(recent, t)
+			}
+		}
+		if len(recent) >= l.config.ConnectsPerMinute {
+			l.connects[key] = recent
+			return connRateLimited
+		}
+		// Attempts count even if another limit turns them away
+		l.connects[key] = append(recent, now)
+	}
+	if l.config.MaxPerClient > 0 && l.open[key] >= l.config.MaxPerClient {
+		return connClientLimit
+	}
+	if l.config.MaxConnections > 0 && l.total >= l.config.MaxConnections {
+		return connServerFull
+	}
+
+	l.open[key]++
+	l.total++
+	return connAdmitted
+}
+
+// release records a closed connection
+func (l *connLimiter) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.total--
+	if l.open[key]--; l.open[key] <= 0 {
+		delete(l.open, key)
+	}
+}
+
+// retryAfter returns when the oldest connect of key leaves the window
+func (l *connLimiter) retryAfter(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if times := l.connects[key]; len(times) > 0 {
+		return time.Until(times[0].Add(time.Minute))
+	}
+	return time.Minute
+}
+
+// RemoteIP returns the IP of a request's peer, ignoring forwarded headers
+// Apps behind a proxy should set ConnectionLimits.KeyFunc to read the forwarded address they trust
+func RemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
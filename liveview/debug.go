@@ -0,0 +1,105 @@
+package liveview
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SetDebug enables the debug overlay
+// In debug mode every reply carries the handled event, its duration and the
+// database queries recorded while handling it
+func (h *Handler) SetDebug(enabled bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.debug = enabled
+}
+
+// isDebug reports whether the debug overlay is enabled
+func (h *Handler) isDebug() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.debug
+}
+
+// QueryRecord is a database query executed while a socket handled an event
+type QueryRecord struct {
+	SQL      string
+	Duration time.Duration
+	Rows     int64 // rows affected or returned; -1 when unknown
+	Error    string
+}
+
+// queryLog collects the queries of a socket until the next reply
+type queryLog struct {
+	mu      sync.Mutex
+	queries []QueryRecord
+}
+
+// queryLogKey is the context key of a socket's query log
+type queryLogKey struct{}
+
+// IsRecordingQueries reports whether queries run with ctx are shown in the debug overlay
+// Database loggers use it to skip formatting SQL nobody will see
+func IsRecordingQueries(ctx context.Context) bool {
+	_, ok := ctx.Value(queryLogKey{}).(*queryLog)
+	return ok
+}
+
+// RecordQuery adds a query to the debug overlay of the socket that owns ctx
+// Queries only reach the overlay when they run with socket.Context(), e.g. db.WithContext(socket.Context())
+func RecordQuery(ctx context.Context, query QueryRecord) {
+	log, ok := ctx.Value(queryLogKey{}).(*queryLog)
+	if !ok {
+		return
+	}
+	log.mu.Lock()
+	log.queries = append(log.queries, query)
+	log.mu.Unlock()
+}
+
+// take returns and clears the recorded queries
+func (l *queryLog) take() []QueryRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	queries := l.queries
+	l.queries = nil
+	return queries
+}
+
+// debugQuery is a recorded query as sent to the overlay
+type debugQuery struct {
+	SQL   string  `json:"sql"`
+	MS    float64 `json:"ms"`
+	Rows  int64   `json:"rows"`
+	Error string  `json:"error,omitempty"`
+}
+
+// addDebugToData adds the debug overlay frame for an event to render data
+// Server-side updates have no event and are only reported when they ran queries
+func (h *Handler) addDebugToData(socket *Socket, event string, elapsed time.Duration, data map[string]interface{}) {
+	if socket.queries == nil {
+		return
+	}
+
+	queries := socket.queries.take()
+	if event == "" && len(queries) == 0 {
+		return
+	}
+
+	frame := make([]debugQuery, len(queries))
+	for i, q := range queries {
+		frame[i] = debugQuery{SQL: q.SQL, MS: milliseconds(q.Duration), Rows: q.Rows, Error: q.Error}
+	}
+
+	data["debug"] = map[string]interface{}{
+		"event":   event,
+		"ms":      milliseconds(elapsed),
+		"queries": frame,
+	}
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
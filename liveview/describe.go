@@ -0,0 +1,98 @@
+package liveview
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Describer is an optional interface for components that document themselves
+// The returned metadata is surfaced in the component catalog
+type Describer interface {
+	Describe() ComponentDoc
+}
+
+// ComponentDoc describes a component for discoverability
+type ComponentDoc struct {
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description,omitempty"`
+	Events         []EventDoc             `json:"events"`
+	Params         []ParamDoc             `json:"params,omitempty"`
+	ExampleAssigns map[string]interface{} `json:"example_assigns,omitempty"`
+}
+
+// EventDoc describes an event handled by a component
+type EventDoc struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Payload     []ParamDoc `json:"payload,omitempty"`
+}
+
+// ParamDoc describes a mount parameter or payload key
+type ParamDoc struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Required    bool   `json:"required,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// DescribeComponent builds the documentation for a component
+// Declared metadata from Describe() is merged with events discovered from Handle* methods
+func DescribeComponent(name string, component Component) ComponentDoc {
+	var doc ComponentDoc
+	if describer, ok := component.(Describer); ok {
+		doc = describer.Describe()
+	}
+	doc.Name = name
+
+	declared := make(map[string]bool)
+	for _, event := range doc.Events {
+		declared[event.Name] = true
+	}
+
+	for _, event := range discoverEvents(component) {
+		if !declared[event] {
+			doc.Events = append(doc.Events, EventDoc{Name: event})
+		}
+	}
+
+	sort.Slice(doc.Events, func(i, j int) bool {
+		return doc.Events[i].Name < doc.Events[j].Name
+	})
+
+	return doc
+}
+
+// Catalog returns documentation for every registered component, sorted by name
+func (h *Handler) Catalog() []ComponentDoc {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	docs := make([]ComponentDoc, 0, len(h.components))
+	for name, component := range h.components {
+		docs = append(docs, DescribeComponent(name, component))
+	}
+
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].Name < docs[j].Name
+	})
+
+	return docs
+}
+
+// discoverEvents finds event names from Handle* methods using reflection
+func discoverEvents(component interface{}) []string {
+	var events []string
+	for method := range eventTableFor(reflect.TypeOf(component)).exact {
+		events = append(events, toEventName(strings.TrimPrefix(method, "Handle")))
+	}
+	return events
+}
+
+// toEventName converts a method suffix to an event name (e.g., "ClearCompleted" -> "clearCompleted")
+func toEventName(s string) string {
+	if s == "" {
+		return ""
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,64 @@
+package liveview
+
+import (
+	"context"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// CloseServiceRestart is the WebSocket close code sent to connections drained for a restart
+const CloseServiceRestart = 1012
+
+// Drain asks every connected client to reconnect, e.g. to the process that took over the
+// listener during an upgrade. Connections are closed with 1012 (service restart) in waves
+// spread evenly over the duration, so clients don't all reconnect at once; components are
+// unmounted as usual. It returns the number of connections closed when all are, or
+// earlier when ctx is cancelled
+// Connections opened while it runs aren't drained, so stop accepting them first
+func (h *Handler) Drain(ctx context.Context, over time.Duration, waves int) int {
+	h.mu.RLock()
+	conns := make([]*liveConn, 0, len(h.conns))
+	for lc := range h.conns {
+		conns = append(conns, lc)
+	}
+	h.mu.RUnlock()
+	if len(conns) == 0 {
+		return 0
+	}
+
+	if waves < 1 {
+		waves = 1
+	}
+	if waves > len(conns) {
+		waves = len(conns)
+	}
+	h.log().Info("Draining LiveView connections", "connections", len(conns), "waves", waves, "over", over)
+
+	closed := 0
+	for wave := 0; wave < waves; wave++ {
+		if wave > 0 {
+			select {
+			case <-ctx.Done():
+				return closed
+			case <-time.After(over / time.Duration(waves)):
+			}
+		}
+		// Waves split the connections as evenly as they can
+		end := len(conns) * (wave + 1) / waves
+		for _, lc := range conns[closed:end] {
+			lc.restart()
+		}
+		closed = end
+	}
+	return closed
+}
+
+// restart closes the connection with CloseServiceRestart so the client reconnects
+// WriteControl and Close are safe next to the connection goroutine's writes; the read
+// loop then fails and the connection unmounts its components
+func (lc *liveConn) restart() {
+	msg := websocket.FormatCloseMessage(CloseServiceRestart, "server restarting")
+	lc.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	lc.conn.Close()
+}
@@ -0,0 +1,72 @@
+package liveview
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// genericErrorMessage is sent for failed events outside debug mode so internals don't leak
+const genericErrorMessage = "Something went wrong. Please try again."
+
+// PanicError is a panic recovered while handling an event or rendering
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error describes the recovered value
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// recoverPanic turns a recovered value into a PanicError with the current stack
+func recoverPanic(value interface{}) *PanicError {
+	// Panics handed back from a handler goroutine already carry their stack
+	if p, ok := value.(*PanicError); ok {
+		return p
+	}
+	return &PanicError{Value: value, Stack: debug.Stack()}
+}
+
+// errorFrame builds the error message sent to the client for a failed event
+// In debug mode it carries the real message, the wrapped causes and, for panics, the stack
+func (h *Handler) errorFrame(event string, err error) map[string]interface{} {
+	frame := map[string]interface{}{
+		"event":   event,
+		"reason":  "handler_error",
+		"message": genericErrorMessage,
+	}
+
+	var p *PanicError
+	if errors.As(err, &p) {
+		frame["reason"] = "panic"
+	}
+	if errors.Is(err, ErrEventTimeout) {
+		frame["reason"] = "timeout"
+	}
+	if IsUnavailable(err) {
+		frame["reason"] = "unavailable"
+		frame["message"] = unavailableMessage
+		frame["retry_after_ms"] = retryAfter(err).Milliseconds()
+	}
+
+	if !h.isDebug() {
+		return frame
+	}
+
+	frame["debug"] = true
+	frame["message"] = err.Error()
+	if p != nil {
+		frame["stack"] = string(p.Stack)
+	}
+
+	var causes []string
+	for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(cause) {
+		causes = append(causes, cause.Error())
+	}
+	if len(causes) > 0 {
+		frame["causes"] = causes
+	}
+	return frame
+}
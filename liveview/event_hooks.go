@@ -0,0 +1,118 @@
+package liveview
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrHalt stops the remaining handlers of an event without reporting an error
+var ErrHalt = errors.New("halt event handling")
+
+// AnyEvent matches every event when used as EventHook.Event
+const AnyEvent = "*"
+
+// DefaultPriority is the priority of the component's own Handle* method
+// Hooks with a lower priority run before it, higher ones after it
+const DefaultPriority = 0
+
+// EventHook handles an event alongside the component's own handler
+type EventHook struct {
+	Event    string // event name, or AnyEvent
+	Priority int    // lower runs first
+	Handle   func(socket *Socket, event string, payload map[string]interface{}) error
+}
+
+// EventHooker is an optional interface for components that contribute extra event handlers
+type EventHooker interface {
+	EventHooks() []EventHook
+}
+
+// RegisterHook attaches an event hook to a registered component name
+func (h *Handler) RegisterHook(name string, hook EventHook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks[name] = append(h.hooks[name], hook)
+}
+
+// hooksFor collects the hooks of a component that match an event
+func (h *Handler) hooksFor(name string, component Component, event string) []EventHook {
+	h.mu.RLock()
+	registered := h.hooks[name]
+	h.mu.RUnlock()
+
+	var all []EventHook
+	if hooker, ok := component.(EventHooker); ok {
+		all = append(all, hooker.EventHooks()...)
+	}
+	for _, behavior := range h.behaviorsFor(name, component) {
+		all = append(all, behavior.BehaviorHooks()...)
+	}
+	all = append(all, registered...)
+
+	key := normalizeEventName(event)
+	var matched []EventHook
+	for _, hook := range all {
+		if hook.Event == AnyEvent || normalizeEventName(hook.Event) == key {
+			matched = append(matched, hook)
+		}
+	}
+	return matched
+}
+
+// handleEvent runs the component handler and all matching hooks in priority order
+// Execution stops at the first error; ErrHalt stops it without an error
+func (h *Handler) handleEvent(name string, component Component, event string, payload map[string]interface{}, socket *Socket) error {
+	hooks := h.hooksFor(name, component, event)
+	if len(hooks) == 0 {
+		return dispatchEvent(component, event, payload, socket)
+	}
+
+	type step struct {
+		hook      *EventHook
+		priority  int
+		component bool
+	}
+
+	steps := make([]step, 0, len(hooks)+1)
+	for i := range hooks {
+		steps = append(steps, step{hook: &hooks[i], priority: hooks[i].Priority})
+	}
+	steps = append(steps, step{priority: DefaultPriority, component: true})
+
+	// Stable sort keeps registration order for equal priorities
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].priority < steps[j].priority
+	})
+
+	handled := false
+	var unknownErr error
+	for _, s := range steps {
+		var err error
+		if s.component {
+			err = dispatchEvent(component, event, payload, socket)
+			if errors.Is(err, ErrUnknownEvent) {
+				unknownErr = err
+				continue
+			}
+		} else {
+			err = s.hook.Handle(socket, event, payload)
+		}
+
+		// Catch-all hooks don't make an event known on their own
+		if s.component || s.hook.Event != AnyEvent {
+			handled = true
+		}
+
+		if errors.Is(err, ErrHalt) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if !handled && unknownErr != nil {
+		return unknownErr
+	}
+	return nil
+}
@@ -1,11 +1,17 @@
 package liveview
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
+	"unicode"
 )
 
+// ErrUnknownEvent is returned when a component has no handler for an event
+var ErrUnknownEvent = errors.New("no handler found for event")
+
 // BaseComponent provides a base for LiveView components
 // Embedding this is optional - the framework automatically routes events to Handle* methods
 type BaseComponent struct{}
@@ -14,18 +20,31 @@ type BaseComponent struct{}
 // automatically routes events using reflection on the component instance
 
 // RouteEvent is a standalone helper that routes events to Handle* methods on any component
+// Event names may be camelCase, snake_case or kebab-case and are matched case-insensitively:
+// "clearCompleted", "clear_completed" and "clear-completed" all route to HandleClearCompleted
 func RouteEvent(component interface{}, event string, payload map[string]interface{}, socket *Socket) error {
 	// Convert event name to method name (e.g., "increment" -> "HandleIncrement")
-	methodName := "Handle" + toTitle(event)
+	methodName := "Handle" + toCamelCase(event)
 
-	// Get the component's value
-	val := reflect.ValueOf(component)
-	method := val.MethodByName(methodName)
+	table := eventTableFor(reflect.TypeOf(component))
+	key := normalizeEventName(event)
 
-	if !method.IsValid() {
-		return fmt.Errorf("no handler found for event: %s (expected method: %s)", event, methodName)
+	// Prefer an exact method name match, then fall back to the normalized lookup
+	name := methodName
+	if !table.exact[methodName] {
+		if methods, ambiguous := table.collisions[key]; ambiguous {
+			return fmt.Errorf("ambiguous event %s: matches %s", event, strings.Join(methods, ", "))
+		}
+		name = table.methods[key]
+	}
+	if name == "" {
+		return fmt.Errorf("%w: %s (expected method: %s)", ErrUnknownEvent, event, methodName)
 	}
 
+	// Get the component's value
+	val := reflect.ValueOf(component)
+	method := val.MethodByName(name)
+
 	// Prepare arguments
 	args := []reflect.Value{
 		reflect.ValueOf(socket),
@@ -45,10 +64,107 @@ func RouteEvent(component interface{}, event string, payload map[string]interfac
 	return nil
 }
 
-// toTitle converts first character to uppercase
-func toTitle(s string) string {
-	if s == "" {
-		return ""
+// eventTable maps event names to the Handle* methods of a component type
+type eventTable struct {
+	exact      map[string]bool     // valid handler method names
+	methods    map[string]string   // normalized event name -> method name
+	collisions map[string][]string // normalized event name -> conflicting method names
+}
+
+// eventTables caches event tables per component type
+var eventTables sync.Map
+
+// eventTableFor returns the cached event table for a component type
+func eventTableFor(t reflect.Type) *eventTable {
+	if cached, ok := eventTables.Load(t); ok {
+		return cached.(*eventTable)
+	}
+
+	table := &eventTable{
+		exact:      make(map[string]bool),
+		methods:    make(map[string]string),
+		collisions: make(map[string][]string),
+	}
+
+	socketType := reflect.TypeOf((*Socket)(nil))
+	payloadType := reflect.TypeOf(map[string]interface{}(nil))
+
+	for i := 0; i < t.NumMethod(); i++ {
+		method := t.Method(i)
+		if !strings.HasPrefix(method.Name, "Handle") || method.Name == "Handle" {
+			continue
+		}
+
+		// Method type includes the receiver as the first input
+		mt := method.Type
+		if mt.NumIn() != 3 || mt.In(1) != socketType || mt.In(2) != payloadType {
+			continue
+		}
+
+		table.exact[method.Name] = true
+		key := normalizeEventName(strings.TrimPrefix(method.Name, "Handle"))
+		if existing, ok := table.methods[key]; ok {
+			if len(table.collisions[key]) == 0 {
+				table.collisions[key] = []string{existing}
+			}
+			table.collisions[key] = append(table.collisions[key], method.Name)
+			continue
+		}
+		table.methods[key] = method.Name
+	}
+
+	cached, _ := eventTables.LoadOrStore(t, table)
+	return cached.(*eventTable)
+}
+
+// EventCollisions returns Handle* methods of a component that map to the same event name
+// For example HandleFooBar and HandleFoobar both answer to "foo_bar"
+func EventCollisions(component interface{}) map[string][]string {
+	return eventTableFor(reflect.TypeOf(component)).collisions
+}
+
+// normalizeEventName lowercases a name and strips word separators
+func normalizeEventName(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r == '_' || r == '-' || r == ' ' || r == ':' || r == '.' {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// toCamelCase converts snake_case, kebab-case and camelCase names to CamelCase
+func toCamelCase(s string) string {
+	var b strings.Builder
+	upperNext := true
+	for _, r := range s {
+		if r == '_' || r == '-' || r == ' ' || r == ':' || r == '.' {
+			upperNext = true
+			continue
+		}
+		if upperNext {
+			r = unicode.ToUpper(r)
+			upperNext = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// dispatchEvent routes an event to a Handle* method, falling back to the EventHandler interface
+func dispatchEvent(component Component, event string, payload map[string]interface{}, socket *Socket) error {
+	err := RouteEvent(component, event, payload, socket)
+	if err == nil || !errors.Is(err, ErrUnknownEvent) {
+		return err
+	}
+
+	if handler, ok := component.(ContextEventHandler); ok {
+		return handler.HandleEventContext(socket.EventContext(), event, payload, socket)
+	}
+	if handler, ok := component.(EventHandler); ok {
+		return handler.HandleEvent(event, payload, socket)
 	}
-	return strings.ToUpper(s[:1]) + s[1:]
+	return err
 }
@@ -0,0 +1,122 @@
+package liveview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrEventTimeout is returned for events whose handler ran past the event timeout
+var ErrEventTimeout = errors.New("event timed out")
+
+// ContextMounter is an optional interface for components that mount with a context
+// The context belongs to the connection and is cancelled when it closes; on the first
+// HTTP render it is the request's context. MountContext is called instead of Mount
+type ContextMounter interface {
+	MountContext(ctx context.Context, socket *Socket) error
+}
+
+// ContextEventHandler is an optional interface for handling events with a context
+// The context is cancelled when the connection closes or the event timeout passes,
+// so database calls and outgoing requests made with it stop with the event
+type ContextEventHandler interface {
+	HandleEventContext(ctx context.Context, event string, payload map[string]interface{}, socket *Socket) error
+}
+
+// TimedComponent is an optional interface for components that set their own event
+// timeouts. AnyEvent sets the timeout of events without their own
+type TimedComponent interface {
+	EventTimeouts() map[string]time.Duration
+}
+
+// SetEventTimeout sets how long an event handler may run before the event fails
+// A handler that times out is abandoned and the client mounts the component again,
+// so a hung handler can't block the connection. 0 disables the timeout
+func (h *Handler) SetEventTimeout(timeout time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.eventTimeout = timeout
+}
+
+// timeoutFor returns the timeout of an event, or 0 when it has none
+func (h *Handler) timeoutFor(component Component, event string) time.Duration {
+	if tc, ok := component.(TimedComponent); ok {
+		timeouts := tc.EventTimeouts()
+		if timeout, ok := timeouts[event]; ok {
+			return timeout
+		}
+		if timeout, ok := timeouts[AnyEvent]; ok {
+			return timeout
+		}
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.eventTimeout
+}
+
+// EventContext returns the context of the event being handled
+// It is cancelled when the connection closes or the event times out, and carries the
+// event's trace span. Outside an event it is the socket's Context, with the mount
+// span while mounting
+func (s *Socket) EventContext() context.Context {
+	if s.eventCtx != nil {
+		return s.eventCtx
+	}
+	return s.traceContext()
+}
+
+// runEvent handles an event under its timeout
+// The handler runs on its own goroutine; when it times out, the socket is marked as
+// abandoned and ErrEventTimeout is returned while the handler finishes on its own
+func (h *Handler) runEvent(name string, component Component, event string, payload map[string]interface{}, socket *Socket) (err error) {
+	h.counters.events.Add(1)
+	defer func() { h.metrics.observeEvent(name, event, err) }()
+
+	timeout := h.timeoutFor(component, event)
+	if timeout <= 0 {
+		ctx, cancel := context.WithCancel(socket.traceContext())
+		defer cancel()
+		socket.eventCtx = ctx
+		defer func() { socket.eventCtx = nil }()
+		return h.handleEvent(name, component, event, payload, socket)
+	}
+
+	ctx, cancel := context.WithTimeout(socket.traceContext(), timeout)
+	defer cancel()
+	socket.eventCtx = ctx
+
+	done := make(chan error, 1)
+	go func() {
+		// Panics are handed back so they are reported like any other
+		defer func() {
+			if r := recover(); r != nil {
+				done <- recoverPanic(r)
+			}
+		}()
+		done <- h.handleEvent(name, component, event, payload, socket)
+	}()
+
+	select {
+	case err := <-done:
+		socket.eventCtx = nil
+		var p *PanicError
+		if errors.As(err, &p) {
+			panic(p)
+		}
+		return err
+	case <-ctx.Done():
+		socket.timedOut = true
+		h.counters.timedOutEvents.Add(1)
+		return fmt.Errorf("%w after %s: %s", ErrEventTimeout, timeout, event)
+	}
+}
+
+// mountComponent calls MountContext when the component has it, or Mount
+func mountComponent(component Component, socket *Socket) error {
+	if cm, ok := component.(ContextMounter); ok {
+		return cm.MountContext(socket.traceContext(), socket)
+	}
+	return component.Mount(socket)
+}
@@ -0,0 +1,173 @@
+package liveview
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RenderFeatures selects how the updates of a component are sent, so pages can move to a
+// new render pipeline one at a time and compare their payload sizes, which the metrics
+// record by component and pipeline
+type RenderFeatures struct {
+	UseDiff        bool // send the changes since the previous render; otherwise the full HTML
+	UseStreams     bool // send stream operations; otherwise they are dropped, for pages that render their lists in full
+	UseStaticSplit bool // send the text of a render apart from its markup, and only the changed text while the markup stays the same
+}
+
+// DefaultRenderFeatures are the features of components without their own
+var DefaultRenderFeatures = RenderFeatures{UseDiff: true, UseStreams: true}
+
+// Render pipelines, as labelled in the metrics
+const (
+	PipelineHTML  = "html"
+	PipelineDiff  = "diff"
+	PipelineSplit = "split"
+)
+
+// FeaturedComponent is an optional interface for components that choose their render
+// features
+//
+//	func (c *Feed) RenderFeatures() liveview.RenderFeatures {
+//		return liveview.RenderFeatures{UseDiff: true, UseStaticSplit: true}
+//	}
+type FeaturedComponent interface {
+	RenderFeatures() RenderFeatures
+}
+
+// SetRenderFeatures sets the render features of a registered component
+// Features declared by the component with RenderFeatures take precedence
+func (h *Handler) SetRenderFeatures(name string, features RenderFeatures) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.features == nil {
+		h.features = make(map[string]RenderFeatures)
+	}
+	h.features[name] = features
+}
+
+// featuresFor returns the render features of a component
+func (h *Handler) featuresFor(name string, component Component) RenderFeatures {
+	if fc, ok := component.(FeaturedComponent); ok {
+		return fc.RenderFeatures()
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if features, ok := h.features[name]; ok {
+		return features
+	}
+	return DefaultRenderFeatures
+}
+
+// pipeline returns the pipeline updates go through with these features
+func (f RenderFeatures) pipeline() string {
+	switch {
+	case f.UseStaticSplit:
+		return PipelineSplit
+	case f.UseDiff:
+		return PipelineDiff
+	default:
+		return PipelineHTML
+	}
+}
+
+// addSplitToData adds a render split into statics and dynamics to render data
+// The statics are sent when they changed, or for the first split render of a socket, with
+// every dynamic; otherwise only the dynamics that changed are, by index
+func addSplitToData(socket *Socket, html string, data map[string]interface{}) {
+	statics, dynamics := splitStatics(html)
+	split := make(map[string]interface{})
+	changed := make(map[string]string)
+	if !slices.Equal(statics, socket.statics) {
+		split["s"] = statics
+		for i, dynamic := range dynamics {
+			changed[strconv.Itoa(i)] = dynamic
+		}
+	} else {
+		for i, dynamic := range dynamics {
+			if dynamic != socket.dynamics[i] {
+				changed[strconv.Itoa(i)] = dynamic
+			}
+		}
+		if len(changed) == 0 {
+			return
+		}
+	}
+	split["d"] = changed
+	socket.statics, socket.dynamics = statics, dynamics
+	data["split"] = split
+}
+
+// splitStatics splits rendered HTML into its markup, the statics, and the text between,
+// the dynamics: statics[0] + dynamics[0] + statics[1] + ... + statics[n] is the HTML
+// Script and style contents count as text
+func splitStatics(html string) (statics, dynamics []string) {
+	var markup strings.Builder
+	rawText := ""
+	for i := 0; i < len(html); {
+		if html[i] != '<' || rawText != "" && !hasPrefixFold(html[i:], "</"+rawText) {
+			// Text up to the next tag
+			end := i + 1
+			for end < len(html) && (html[end] != '<' || rawText != "" && !hasPrefixFold(html[end:], "</"+rawText)) {
+				end++
+			}
+			statics = append(statics, markup.String())
+			markup.Reset()
+			dynamics = append(dynamics, html[i:end])
+			i = end
+			continue
+		}
+
+		end := tagEnd(html, i)
+		tag := html[i:end]
+		markup.WriteString(tag)
+		switch {
+		case rawText != "":
+			rawText = ""
+		case strings.HasSuffix(tag, "/>"):
+		case hasPrefixFold(tag, "<script"):
+			rawText = "script"
+		case hasPrefixFold(tag, "<style"):
+			rawText = "style"
+		}
+		i = end
+	}
+	statics = append(statics, markup.String())
+	return statics, dynamics
+}
+
+// tagEnd returns the offset just after the tag, comment or doctype opened at i
+func tagEnd(html string, i int) int {
+	if strings.HasPrefix(html[i:], "<!--") {
+		if end := strings.Index(html[i+4:], "-->"); end >= 0 {
+			return i + 4 + end + 3
+		}
+		return len(html)
+	}
+	var quote byte
+	for j := i + 1; j < len(html); j++ {
+		switch c := html[j]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return j + 1
+		}
+	}
+	return len(html)
+}
+
+// hasPrefixFold reports whether s begins with prefix, ignoring ASCII case
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// dropStreams discards the stream operations of a component without UseStreams
+func (h *Handler) dropStreams(componentName string, socket *Socket) {
+	if ops := socket.takeStreams(); len(ops) > 0 {
+		h.log().Warn("Stream operations dropped, UseStreams is off", "component", componentName, "socket", socket.ID, "operations", len(ops))
+	}
+}
@@ -0,0 +1,85 @@
+package liveview
+
+import (
+	"html/template"
+	"strings"
+)
+
+// defaultFlashPartial renders a single flash
+// The client relies on the lv-flash, lv-flash-close and data-ttl hooks to dismiss flashes
+var defaultFlashPartial = template.Must(template.New("flash").Parse(
+	`<div class="lv-flash lv-flash-{{.Type}}"{{if .Key}} data-flash-key="{{.Key}}"{{end}} data-ttl="{{.TTLMillis}}" role="alert">` +
+		`<span class="lv-flash-message">{{.Message}}</span>` +
+		`<button type="button" class="lv-flash-close" aria-label="Close">&times;</button></div>`))
+
+// FlashView is the data passed to the flash partial
+type FlashView struct {
+	Flash
+	TTLMillis int64 // display time in milliseconds; 0 keeps the flash until dismissed
+}
+
+// SetFlashPartial sets the template used to render each flash, on the page and in live updates
+// It is executed with a FlashView. Keep the lv-flash class, a .lv-flash-close button and the
+// data-ttl attribute so the client can dismiss flashes
+func (h *Handler) SetFlashPartial(partial *template.Template) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.flashPartial = partial
+}
+
+// renderFlash renders a flash with the configured partial
+func (h *Handler) renderFlash(flash Flash) (template.HTML, error) {
+	h.mu.RLock()
+	partial := h.flashPartial
+	h.mu.RUnlock()
+	if partial == nil {
+		partial = defaultFlashPartial
+	}
+
+	var buf strings.Builder
+	view := FlashView{Flash: flash, TTLMillis: flash.ttl().Milliseconds()}
+	if err := partial.Execute(&buf, view); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// flashFrames prepares pending flashes for a live update
+// Each frame carries the rendered partial; clients fall back to building their own markup
+func (h *Handler) flashFrames(flashes []Flash) []map[string]interface{} {
+	frames := make([]map[string]interface{}, 0, len(flashes))
+	for _, flash := range flashes {
+		frame := map[string]interface{}{
+			"type":    flash.Type,
+			"message": flash.Message,
+			"ttl":     flash.ttl().Milliseconds(),
+		}
+		if flash.Key != "" {
+			frame["key"] = flash.Key
+		}
+		if html, err := h.renderFlash(flash); err != nil {
+			h.log().Error("Flash render error", "error", err)
+		} else {
+			frame["html"] = string(html)
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// flashesHTML renders the pending flashes of a socket for the initial page
+// The stack is always present so live flashes have a place to go
+func (h *Handler) flashesHTML(socket *Socket) template.HTML {
+	var buf strings.Builder
+	buf.WriteString(`<div id="lv-flashes" class="lv-flashes">`)
+	for _, flash := range socket.Session.TakeFlashes() {
+		html, err := h.renderFlash(flash)
+		if err != nil {
+			h.log().Error("Flash render error", "error", err)
+			continue
+		}
+		buf.WriteString(string(html))
+	}
+	buf.WriteString(`</div>`)
+	return template.HTML(buf.String())
+}
@@ -0,0 +1,302 @@
+package liveview
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Field arrays
+//
+// A []struct field of a form renders as repeatable rows:
+//
+//	Items []LineItem `form:"label:Items;min_rows:1;max_rows:10"`
+//
+// Row inputs are named Items.<index>.<Column> and their errors use the same keys,
+// e.g. errors["Items.2.Price"]. Count errors are reported under the field name.
+
+// rowFieldName returns the input name and error key of a column in a row
+func rowFieldName(fieldName string, index int, column string) string {
+	return fmt.Sprintf("%s.%d.%s", fieldName, index, column)
+}
+
+// isRowsType reports whether t is rendered as a field array
+func isRowsType(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct
+}
+
+// arrayCountValidator checks the number of rows against min_rows and max_rows
+func arrayCountValidator[T any](fieldName, label string, minRows, maxRows int) func(*T) error {
+	return func(data *T) error {
+		n := reflect.ValueOf(data).Elem().FieldByName(fieldName).Len()
+		if minRows > 0 && n < minRows {
+			return validationError("validation.min_rows", "%s requires at least %d entries", label, minRows)
+		}
+		if maxRows > 0 && n > maxRows {
+			return validationError("validation.max_rows", "%s allows at most %d entries", label, maxRows)
+		}
+		return nil
+	}
+}
+
+// arrayRowValidator validates every row of a field array with the row type's validate tags
+func arrayRowValidator[T any](fieldName string, elem reflect.Type) func(*T) map[string]error {
+	rules := make(map[string][]func(interface{}) error)
+	for i := 0; i < elem.NumField(); i++ {
+		column := elem.Field(i)
+		if !column.IsExported() {
+			continue
+		}
+		if tag := column.Tag.Get("validate"); tag != "" {
+			rules[column.Name] = parseValidationRules(tag, column.Name, column.Type)
+		}
+	}
+
+	return func(data *T) map[string]error {
+		errors := make(map[string]error)
+		rows := reflect.ValueOf(data).Elem().FieldByName(fieldName)
+		for i := 0; i < rows.Len(); i++ {
+			row := rows.Index(i)
+			for column, columnRules := range rules {
+				value := row.FieldByName(column).Interface()
+				for _, rule := range columnRules {
+					if err := rule(value); err != nil {
+						errors[rowFieldName(fieldName, i, column)] = err
+						break
+					}
+				}
+			}
+		}
+		return errors
+	}
+}
+
+// setFormValue sets a top-level field or, for names like Items.2.Price, a column of a row
+func setFormValue(data interface{}, name string, value interface{}) error {
+	parts := strings.SplitN(name, ".", 3)
+	if len(parts) != 3 {
+		return setFieldValue(data, name, value)
+	}
+
+	index, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return fmt.Errorf("invalid row index in %s", name)
+	}
+
+	rows := reflect.ValueOf(data).Elem().FieldByName(parts[0])
+	if !rows.IsValid() || !isRowsType(rows.Type()) {
+		return fmt.Errorf("field %s is not a list", parts[0])
+	}
+	if index < 0 || index >= rows.Len() {
+		return fmt.Errorf("row %d of %s not found", index, parts[0])
+	}
+
+	cloneRows(rows)
+	return setFieldValue(rows.Index(index).Addr().Interface(), parts[2], value)
+}
+
+// cloneRows gives a slice field its own backing array
+// Form data is copied by value into assigns and async checks, so rows must not be changed in place
+func cloneRows(rows reflect.Value) {
+	clone := reflect.MakeSlice(rows.Type(), rows.Len(), rows.Len())
+	reflect.Copy(clone, rows)
+	rows.Set(clone)
+}
+
+// arrayFields returns the field arrays of the form
+func (fc *FormComponent[T]) arrayFields() []field {
+	var zero T
+	var arrays []field
+	for _, f := range parseStructTags(zero) {
+		if f.Type == "array" {
+			arrays = append(arrays, f)
+		}
+	}
+	return arrays
+}
+
+// initRows fills every field array up to its minimum rows and assigns fresh row keys
+func (fc *FormComponent[T]) initRows(socket *Socket, formData *T) {
+	keys := make(map[string][]string)
+	for _, f := range fc.arrayFields() {
+		rows := reflect.ValueOf(formData).Elem().FieldByName(f.Name)
+		for rows.Len() < f.MinRows {
+			rows.Set(reflect.Append(rows, reflect.Zero(rows.Type().Elem())))
+		}
+		for i := 0; i < rows.Len(); i++ {
+			keys[f.Name] = append(keys[f.Name], nextRowKey(socket))
+		}
+	}
+	socket.Set("rowKeys", keys)
+}
+
+// nextRowKey returns a key that identifies a row for as long as it exists
+// Keys keep element ids stable when rows before them are removed
+func nextRowKey(socket *Socket) string {
+	seq, _ := socket.Assigns["rowSeq"].(int)
+	seq++
+	socket.Set("rowSeq", seq)
+	return "r" + strconv.Itoa(seq)
+}
+
+// rowKeysFor returns the row keys of a field array
+func rowKeysFor(socket *Socket, fieldName string) (map[string][]string, []string) {
+	keys, ok := socket.Assigns["rowKeys"].(map[string][]string)
+	if !ok {
+		keys = make(map[string][]string)
+		socket.Set("rowKeys", keys)
+	}
+	return keys, keys[fieldName]
+}
+
+// findArrayField looks up a field array by name
+func (fc *FormComponent[T]) findArrayField(name string) (field, bool) {
+	for _, f := range fc.arrayFields() {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// HandleAddRow appends an empty row to a field array
+// Payload: {field: "Items"}
+func (fc *FormComponent[T]) HandleAddRow(socket *Socket, payload map[string]interface{}) error {
+	name, _ := Payload(payload).String("field")
+	f, ok := fc.findArrayField(name)
+	if !ok {
+		return fmt.Errorf("list field not found")
+	}
+
+	formData, _ := socket.Assigns["formData"].(T)
+	rows := reflect.ValueOf(&formData).Elem().FieldByName(f.Name)
+	if f.MaxRows > 0 && rows.Len() >= f.MaxRows {
+		return nil
+	}
+
+	cloneRows(rows)
+	rows.Set(reflect.Append(rows, reflect.Zero(rows.Type().Elem())))
+
+	keys, rowKeys := rowKeysFor(socket, f.Name)
+	keys[f.Name] = append(rowKeys, nextRowKey(socket))
+
+	fc.assignRows(socket, f.Name, formData)
+	return nil
+}
+
+// HandleRemoveRow removes a row from a field array
+// Payload: {field: "Items", index: 2}
+func (fc *FormComponent[T]) HandleRemoveRow(socket *Socket, payload map[string]interface{}) error {
+	p := Payload(payload)
+	name, _ := p.String("field")
+	f, ok := fc.findArrayField(name)
+	if !ok {
+		return fmt.Errorf("list field not found")
+	}
+	index, ok := p.Int("index")
+	if !ok {
+		return fmt.Errorf("row index not provided")
+	}
+
+	formData, _ := socket.Assigns["formData"].(T)
+	rows := reflect.ValueOf(&formData).Elem().FieldByName(f.Name)
+	if index < 0 || index >= rows.Len() || rows.Len() <= f.MinRows {
+		return nil
+	}
+
+	cloneRows(rows)
+	rows.Set(reflect.AppendSlice(rows.Slice(0, index), rows.Slice(index+1, rows.Len())))
+
+	keys, rowKeys := rowKeysFor(socket, f.Name)
+	if index < len(rowKeys) {
+		keys[f.Name] = append(rowKeys[:index:index], rowKeys[index+1:]...)
+	}
+
+	// Errors of later rows move up with their rows
+	if errors, ok := socket.Assigns["errors"].(map[string]string); ok {
+		shiftRowErrors(errors, f.Name, index)
+	}
+
+	fc.assignRows(socket, f.Name, formData)
+	return nil
+}
+
+// assignRows stores form data after the rows of a field array changed and rechecks the row count
+func (fc *FormComponent[T]) assignRows(socket *Socket, fieldName string, formData T) {
+	errors, ok := socket.Assigns["errors"].(map[string]string)
+	if !ok {
+		errors = make(map[string]string)
+	}
+	if fc.validator != nil {
+		if err := fc.validator.ValidateField(fieldName, &formData); err != nil {
+			errors[fieldName] = socket.errorMessage(err)
+		} else {
+			delete(errors, fieldName)
+		}
+	}
+
+	socket.Assign(map[string]interface{}{
+		"formData": formData,
+		"errors":   errors,
+	})
+}
+
+// shiftRowErrors drops the errors of a removed row and renumbers the errors of the rows after it
+func shiftRowErrors(errors map[string]string, fieldName string, removed int) {
+	prefix := fieldName + "."
+	shifted := make(map[string]string)
+	for key, msg := range errors {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(key, prefix), ".", 2)
+		index, err := strconv.Atoi(parts[0])
+		if err != nil || len(parts) != 2 || index < removed {
+			continue
+		}
+		delete(errors, key)
+		if index > removed {
+			shifted[rowFieldName(fieldName, index-1, parts[1])] = msg
+		}
+	}
+	for key, msg := range shifted {
+		errors[key] = msg
+	}
+}
+
+// newArrayView prepares a field array for rendering
+func (fc *FormComponent[T]) newArrayView(f field, formData T, assigns map[string]interface{}, errors map[string]string) *arrayView {
+	view := &arrayView{
+		Name:     f.Name,
+		Label:    f.Label,
+		Required: f.MinRows > 0,
+		Error:    errors[f.Name],
+	}
+
+	allKeys, _ := assigns["rowKeys"].(map[string][]string)
+	keys := allKeys[f.Name]
+
+	rows := reflect.ValueOf(formData).FieldByName(f.Name)
+	for i := 0; i < rows.Len(); i++ {
+		key := strconv.Itoa(i)
+		if i < len(keys) {
+			key = keys[i]
+		}
+
+		row := rowView{ID: f.Name + "-" + key, Index: i}
+		for _, column := range f.Columns {
+			name := rowFieldName(f.Name, i, column.Name)
+			fv := newFieldView(column, getFieldValue(rows.Index(i).Interface(), column.Name), errors[name])
+			fv.Name = name
+			fv.ID = row.ID + "-" + column.Name
+			fv.Debounce = fc.debounce
+			row.Fields = append(row.Fields, fv)
+		}
+		view.Rows = append(view.Rows, row)
+	}
+
+	view.CanAdd = f.MaxRows == 0 || rows.Len() < f.MaxRows
+	view.CanRemove = rows.Len() > f.MinRows
+	return view
+}
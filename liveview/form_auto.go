@@ -1,11 +1,13 @@
 package liveview
 
 import (
+	"context"
 	"fmt"
 	"html/template"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // FormComponent automatically generates forms from struct tags
@@ -13,11 +15,22 @@ import (
 type FormComponent[T any] struct {
 	validator  *FormValidator[T]
 	onSubmit   func(*Socket, *T) error
+	onUpdate   func(*Socket, *T, []string) error
+	initial    func(*Socket) (T, error)
+	fromQuery  bool
+	spam       *spamGuard
+	sinks      []SubmissionSink
 	title      string
 	submitText string
 	showReset  bool
+	debounce   int
+	successKey string // translation key of successMsg
+	successMsg string // shown after a successful submit instead of the default message
 }
 
+// DefaultFormDebounce is the default debounce in milliseconds for generated form inputs
+const DefaultFormDebounce = 300
+
 // Ensure FormComponent implements Component and EventHandler
 var _ Component = (*FormComponent[struct{}])(nil)
 var _ EventHandler = (*FormComponent[struct{}])(nil)
@@ -29,6 +42,7 @@ func NewFormComponent[T any](title string) *FormComponent[T] {
 		title:      title,
 		submitText: "Submit",
 		showReset:  true,
+		debounce:   DefaultFormDebounce,
 	}
 	return comp
 }
@@ -39,25 +53,197 @@ func (fc *FormComponent[T]) OnSubmit(handler func(*Socket, *T) error) *FormCompo
 	return fc
 }
 
+// OnUpdate sets a submit handler for edit forms that also receives the changed field names
+// When set it is called instead of the OnSubmit handler
+func (fc *FormComponent[T]) OnUpdate(handler func(*Socket, *T, []string) error) *FormComponent[T] {
+	fc.onUpdate = handler
+	return fc
+}
+
+// WithInitial sets how the form data is loaded on mount, e.g. from a database record in edit mode
+// Changes are tracked against the loaded values and Reset returns to them
+func (fc *FormComponent[T]) WithInitial(initial func(*Socket) (T, error)) *FormComponent[T] {
+	fc.initial = initial
+	return fc
+}
+
+// PrefillFromQuery fills fields from matching page query parameters on mount, e.g. ?Email=a@b.c
+// Parameter names match field names case-insensitively and override WithInitial values
+func (fc *FormComponent[T]) PrefillFromQuery() *FormComponent[T] {
+	fc.fromQuery = true
+	return fc
+}
+
+// WithDebounce sets how long inputs wait after the last keystroke before sending a change
+// A value of 0 sends a change event per keystroke
+func (fc *FormComponent[T]) WithDebounce(ms int) *FormComponent[T] {
+	fc.debounce = ms
+	return fc
+}
+
+// WithSubmitText sets the label of the submit button
+func (fc *FormComponent[T]) WithSubmitText(text string) *FormComponent[T] {
+	fc.submitText = text
+	return fc
+}
+
+// WithoutReset hides the reset button, and the button to submit again after a submit
+func (fc *FormComponent[T]) WithoutReset() *FormComponent[T] {
+	fc.showReset = false
+	return fc
+}
+
+// WithSuccessMessage sets the flash and the text shown after a successful submit,
+// translated under key when the app's translations have it
+func (fc *FormComponent[T]) WithSuccessMessage(key, message string) *FormComponent[T] {
+	fc.successKey = key
+	fc.successMsg = message
+	return fc
+}
+
+// AddRule adds a validation rule for a field that depends on other fields, e.g. a
+// confirmation matching a password; it runs after the rules of the field's tags
+func (fc *FormComponent[T]) AddRule(field string, rule func(*T) error) *FormComponent[T] {
+	if fc.validator == nil {
+		fc.validator = NewFormValidator[T]()
+	}
+	previous := fc.validator.validators[field]
+	fc.validator.AddFieldValidator(field, func(data *T) error {
+		if previous != nil {
+			if err := previous(data); err != nil {
+				return err
+			}
+		}
+		return rule(data)
+	})
+	return fc
+}
+
+// successMessage returns the message shown after a successful submit, or "" for the default
+func (fc *FormComponent[T]) successMessage(socket *Socket) string {
+	if fc.successMsg == "" {
+		return ""
+	}
+	return socket.translate(fc.successKey, fc.successMsg)
+}
+
+// AddAsyncRule adds a validation rule that runs off the event loop, e.g. a username availability check
+// A newer change to the field cancels the running check
+func (fc *FormComponent[T]) AddAsyncRule(field string, rule AsyncRule[T]) *FormComponent[T] {
+	if fc.validator == nil {
+		fc.validator = NewFormValidator[T]()
+	}
+	fc.validator.AddAsyncRule(field, rule)
+	return fc
+}
+
+// VisibleIf shows a field only while visible returns true, e.g. a State field for US addresses
+// Hidden fields are not rendered or validated, and are cleared on submit
+func (fc *FormComponent[T]) VisibleIf(field string, visible func(*T) bool) *FormComponent[T] {
+	if fc.validator == nil {
+		fc.validator = NewFormValidator[T]()
+	}
+	fc.validator.SetCondition(field, visible)
+	return fc
+}
+
+// isVisible reports whether a field is shown for the given form data
+func (fc *FormComponent[T]) isVisible(field string, formData *T) bool {
+	return fc.validator == nil || fc.validator.IsActive(field, formData)
+}
+
 // Mount initializes the form component
 func (fc *FormComponent[T]) Mount(socket *Socket) error {
-	var formData T
+	initial, err := fc.loadInitial(socket)
+	if err != nil {
+		return err
+	}
+	fc.initRows(socket, &initial)
+
+	formData, err := fc.prefillFromQuery(socket, initial)
+	if err != nil {
+		return err
+	}
+
 	socket.Assign(map[string]interface{}{
-		"formData":  formData,
-		"errors":    make(map[string]string),
-		"submitted": false,
+		"formData":     formData,
+		"initialData":  initial,
+		"formLoadedAt": time.Now(),
+		"errors":       make(map[string]string),
+		"validating":   make(map[string]bool),
+		"validated":    make(map[string]bool),
+		"submitted":    false,
 	})
 	return nil
 }
 
+// loadInitial returns the form data from WithInitial, or the zero value
+func (fc *FormComponent[T]) loadInitial(socket *Socket) (T, error) {
+	var formData T
+	if fc.initial == nil {
+		return formData, nil
+	}
+	return fc.initial(socket)
+}
+
+// prefillFromQuery copies matching page query parameters into the form data
+// Prefilled values count as changes, since they differ from what was loaded
+func (fc *FormComponent[T]) prefillFromQuery(socket *Socket, formData T) (T, error) {
+	if !fc.fromQuery {
+		return formData, nil
+	}
+
+	for _, f := range parseStructTags(formData) {
+		if f.Type == "array" {
+			continue
+		}
+		for key, values := range socket.Params {
+			if strings.EqualFold(key, f.Name) && len(values) > 0 {
+				if err := setFieldValue(&formData, f.Name, values[0]); err != nil {
+					return formData, err
+				}
+			}
+		}
+	}
+	return formData, nil
+}
+
+// ChangedFields returns the names of the fields that differ from the values the form was loaded with
+func (fc *FormComponent[T]) ChangedFields(socket *Socket) []string {
+	formData, _ := socket.Assigns["formData"].(T)
+	initial, _ := socket.Assigns["initialData"].(T)
+	return changedFields(initial, formData)
+}
+
+// changedFields compares two values of a form struct field by field
+func changedFields(before, after interface{}) []string {
+	var changed []string
+	for _, f := range parseStructTags(after) {
+		if f.Name == "" {
+			continue
+		}
+		if !reflect.DeepEqual(getFieldValue(before, f.Name), getFieldValue(after, f.Name)) {
+			changed = append(changed, f.Name)
+		}
+	}
+	return changed
+}
+
 // HandleChange handles input changes with live validation
+// The payload is either {field, value} or a serialized form with _target naming the changed field
 func (fc *FormComponent[T]) HandleChange(socket *Socket, payload map[string]interface{}) error {
 	field, ok := payload["field"].(string)
+	value := payload["value"]
 	if !ok {
+		field, ok = payload["_target"].(string)
+		value = payload[field]
+	}
+	if !ok || field == "" {
 		return fmt.Errorf("field name not provided")
 	}
-
-	value := payload["value"]
+	if field == fc.honeypotField() {
+		return nil
+	}
 
 	// Get current form data
 	formData, ok := socket.Assigns["formData"].(T)
@@ -72,16 +258,30 @@ func (fc *FormComponent[T]) HandleChange(socket *Socket, payload map[string]inte
 	}
 
 	// Update the field value
-	if err := setFieldValue(&formData, field, value); err != nil {
+	if err := setFormValue(&formData, field, value); err != nil {
 		return err
 	}
 
 	// Validate the specific field
+	socket.CancelAsync(asyncValidationTask(field))
+	fc.setValidationState(socket, field, false, false)
 	if fc.validator != nil {
 		if err := fc.validator.ValidateField(field, &formData); err != nil {
-			errors[field] = err.Error()
+			errors[field] = socket.errorMessage(err)
 		} else {
 			delete(errors, field)
+			if fc.validator.HasAsyncRules(field) && fc.isVisible(field, &formData) {
+				fc.startAsyncValidation(socket, field, formData)
+			}
+		}
+	}
+
+	// The change may have hidden other fields; drop their errors and pending checks
+	for _, f := range parseStructTags(formData) {
+		if !fc.isVisible(f.Name, &formData) {
+			delete(errors, f.Name)
+			socket.CancelAsync(asyncValidationTask(f.Name))
+			fc.setValidationState(socket, f.Name, false, false)
 		}
 	}
 
@@ -93,6 +293,59 @@ func (fc *FormComponent[T]) HandleChange(socket *Socket, payload map[string]inte
 	return nil
 }
 
+// startAsyncValidation runs a field's async rules on a copy of the form data
+// The result is applied as a targeted update of the field's error and state
+func (fc *FormComponent[T]) startAsyncValidation(socket *Socket, field string, formData T) {
+	started := socket.StartAsync(asyncValidationTask(field), func(ctx context.Context) func(*Socket) {
+		err := fc.validator.ValidateFieldAsync(ctx, field, &formData)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return func(socket *Socket) {
+			errors, ok := socket.Assigns["errors"].(map[string]string)
+			if !ok {
+				errors = make(map[string]string)
+			}
+			if err != nil {
+				errors[field] = socket.errorMessage(err)
+			} else {
+				delete(errors, field)
+			}
+			socket.Set("errors", errors)
+			fc.setValidationState(socket, field, false, err == nil)
+		}
+	})
+	// The result arrives on the connection goroutine, so the spinner is shown before it
+	if started {
+		fc.setValidationState(socket, field, true, false)
+	}
+}
+
+// setValidationState records whether a field's async check is running or has passed
+func (fc *FormComponent[T]) setValidationState(socket *Socket, field string, validating, validated bool) {
+	for key, value := range map[string]bool{"validating": validating, "validated": validated} {
+		state, ok := socket.Assigns[key].(map[string]bool)
+		if !ok {
+			state = make(map[string]bool)
+			socket.Set(key, state)
+		}
+		if value {
+			state[field] = true
+		} else {
+			delete(state, field)
+		}
+	}
+}
+
+// asyncValidationTask names the StartAsync task validating a field
+func asyncValidationTask(field string) string {
+	return "validate:" + field
+}
+
+// submitValidationTask names the StartAsync task running the async rules of a submission
+const submitValidationTask = "validate-submit"
+
 // HandleSubmit handles form submission
 func (fc *FormComponent[T]) HandleSubmit(socket *Socket, payload map[string]interface{}) error {
 	formData, ok := socket.Assigns["formData"].(T)
@@ -100,49 +353,168 @@ func (fc *FormComponent[T]) HandleSubmit(socket *Socket, payload map[string]inte
 		return fmt.Errorf("form data not found")
 	}
 
-	// Validate all fields
+	// Apply the submitted values in case a debounced change hasn't arrived yet
+	fields := parseStructTags(formData)
+	for _, f := range fields {
+		if f.Type == "array" {
+			rows := reflect.ValueOf(formData).FieldByName(f.Name).Len()
+			for i := 0; i < rows; i++ {
+				for _, column := range f.Columns {
+					name := rowFieldName(f.Name, i, column.Name)
+					if value, ok := payload[name]; ok {
+						if err := setFormValue(&formData, name, value); err != nil {
+							return err
+						}
+					}
+				}
+			}
+			continue
+		}
+		if value, ok := payload[f.Name]; ok {
+			if err := setFieldValue(&formData, f.Name, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Hidden fields are cleared so values entered before they were hidden aren't saved
+	for _, f := range fields {
+		if !fc.isVisible(f.Name, &formData) {
+			clearFieldValue(&formData, f.Name)
+		}
+	}
+
+	// Validate all fields; async rules run off the connection goroutine, which would
+	// otherwise hold up every other view on the connection
 	var errors map[string]string
 	if fc.validator != nil {
-		errors = fc.validator.Validate(&formData)
+		errors = fc.validator.validate(&formData, socket.errorMessage)
+		fc.cancelAsyncValidation(socket)
+		if fc.startSubmitValidation(socket, formData, errors, payload) {
+			return nil
+		}
+		// Without a live connection, e.g. a plain POST, the request waits for them
+		fc.validator.validateAsync(socket.Context(), &formData, errors, socket.errorMessage)
 	} else {
 		errors = make(map[string]string)
 	}
 
+	return fc.finishSubmit(socket, formData, errors, payload)
+}
+
+// startSubmitValidation runs the async rules of a submitted form as a StartAsync task and
+// finishes the submission once they pass. It reports false when there is nothing to run or
+// the socket has no live connection
+func (fc *FormComponent[T]) startSubmitValidation(socket *Socket, formData T, errors map[string]string, payload map[string]interface{}) bool {
+	fields := fc.validator.pendingAsync(&formData, errors)
+	if len(fields) == 0 {
+		return false
+	}
+
+	started := socket.StartAsync(submitValidationTask, func(ctx context.Context) func(*Socket) {
+		failed := fc.validator.runAsyncRules(ctx, &formData, fields)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return func(socket *Socket) {
+			for _, field := range fields {
+				fc.setValidationState(socket, field, false, failed[field] == nil)
+			}
+			for field, err := range failed {
+				errors[field] = socket.errorMessage(err)
+			}
+			if err := fc.finishSubmit(socket, formData, errors, payload); err != nil {
+				socket.log().Error("Form submit error", "error", err)
+			}
+		}
+	})
+	if !started {
+		return false
+	}
+	for _, field := range fields {
+		fc.setValidationState(socket, field, true, false)
+	}
+	return true
+}
+
+// finishSubmit saves a validated submission, or shows its errors
+func (fc *FormComponent[T]) finishSubmit(socket *Socket, formData T, errors map[string]string, payload map[string]interface{}) error {
 	if len(errors) > 0 {
 		socket.Assign(map[string]interface{}{
-			"errors": errors,
+			"formData": formData,
+			"errors":   errors,
 		})
-		socket.PutFlash("error", "Please fix the errors below")
+		socket.PutFlash("error", socket.translate("form.invalid", "Please fix the errors below"))
 		return nil
 	}
 
-	// Call custom submit handler
-	if fc.onSubmit != nil {
-		if err := fc.onSubmit(socket, &formData); err != nil {
-			socket.PutFlash("error", err.Error())
+	if fc.spam != nil {
+		if verdict := fc.spam.check(socket, payload); verdict != spamOK {
+			fc.rejectSpam(socket, verdict)
 			return nil
 		}
 	}
 
-	// Form is valid and submitted
+	// Call custom submit handler
+	var err error
+	var changed []string
+	if fc.onUpdate != nil {
+		initial, _ := socket.Assigns["initialData"].(T)
+		changed = changedFields(initial, formData)
+		err = fc.onUpdate(socket, &formData, changed)
+	} else if fc.onSubmit != nil {
+		err = fc.onSubmit(socket, &formData)
+	}
+	if err != nil {
+		socket.PutFlash("error", err.Error())
+		return nil
+	}
+
+	fc.emit(socket, formData, changed)
+
+	// Form is valid and submitted; the saved values are the new baseline for changes
 	socket.Assign(map[string]interface{}{
-		"submitted": true,
-		"errors":    make(map[string]string),
+		"formData":    formData,
+		"initialData": formData,
+		"submitted":   true,
+		"errors":      make(map[string]string),
 	})
 
-	socket.PutFlash("success", "Form submitted successfully!")
+	message := fc.successMessage(socket)
+	if message == "" {
+		message = socket.translate("form.submitted", "Form submitted successfully!")
+	}
+	socket.PutFlash("success", message)
 	return nil
 }
 
-// HandleReset resets the form
+// cancelAsyncValidation stops every running async check, including a pending submission
+func (fc *FormComponent[T]) cancelAsyncValidation(socket *Socket) {
+	socket.CancelAsync(submitValidationTask)
+	for field := range fc.validator.asyncRules {
+		socket.CancelAsync(asyncValidationTask(field))
+	}
+	socket.Assign(map[string]interface{}{
+		"validating": make(map[string]bool),
+		"validated":  make(map[string]bool),
+	})
+}
+
+// HandleReset resets the form to the values it was loaded with
 func (fc *FormComponent[T]) HandleReset(socket *Socket, payload map[string]interface{}) error {
-	var formData T
+	formData, _ := socket.Assigns["initialData"].(T)
+	if fc.validator != nil {
+		fc.cancelAsyncValidation(socket)
+	}
+	fc.initRows(socket, &formData)
 	socket.Assign(map[string]interface{}{
-		"formData":  formData,
-		"errors":    make(map[string]string),
-		"submitted": false,
+		"formLoadedAt": time.Now(),
+		"formData":     formData,
+		"errors":       make(map[string]string),
+		"submitted":    false,
 	})
-	socket.PutFlash("info", "Form reset")
+	socket.PutFlash("info", socket.translate("form.reset", "Form reset"))
 	return nil
 }
 
@@ -150,7 +522,7 @@ func (fc *FormComponent[T]) HandleReset(socket *Socket, payload map[string]inter
 func (fc *FormComponent[T]) Render(socket *Socket) (template.HTML, error) {
 	var zero T
 	fields := parseStructTags(zero)
-	return fc.buildHTML(fields, socket.Assigns), nil
+	return fc.buildHTML(fields, socket)
 }
 
 // HandleEvent handles all form events
@@ -162,8 +534,12 @@ func (fc *FormComponent[T]) HandleEvent(event string, payload map[string]interfa
 		return fc.HandleSubmit(socket, payload)
 	case "reset":
 		return fc.HandleReset(socket, payload)
+	case "addRow":
+		return fc.HandleAddRow(socket, payload)
+	case "removeRow":
+		return fc.HandleRemoveRow(socket, payload)
 	default:
-		return fmt.Errorf("unknown event: %s", event)
+		return fmt.Errorf("%w: %s", ErrUnknownEvent, event)
 	}
 }
 
@@ -177,125 +553,57 @@ type field struct {
 	Min         interface{}
 	Max         interface{}
 	Rows        int
+	VisibleIf   string
+	MinRows     int     // field arrays only
+	MaxRows     int     // field arrays only
+	Columns     []field // row fields of a field array
 }
 
 // buildHTML generates the complete HTML form
-func (fc *FormComponent[T]) buildHTML(fields []field, assigns map[string]interface{}) template.HTML {
-	var html strings.Builder
-
+func (fc *FormComponent[T]) buildHTML(fields []field, socket *Socket) (template.HTML, error) {
+	assigns := socket.Assigns
 	submitted, _ := assigns["submitted"].(bool)
-	formData := assigns["formData"]
+	formData, _ := assigns["formData"].(T)
 	errors, _ := assigns["errors"].(map[string]string)
-
-	html.WriteString(`<div class="form-container">`)
-	html.WriteString(fmt.Sprintf(`<h1>%s</h1>`, fc.title))
-
-	if submitted {
-		html.WriteString(`<div class="success-message">
-			<h2>✅ Form Submitted Successfully!</h2>
-			<p>Thank you for your submission.</p>
-			<button lv-click="reset" class="btn btn-primary">Submit Another</button>
-		</div>`)
-	} else {
-		html.WriteString(`<form class="contact-form">`)
-
-		for _, field := range fields {
-			html.WriteString(fc.buildField(field, formData, errors))
-		}
-
-		html.WriteString(`<div class="form-actions">`)
-		html.WriteString(fmt.Sprintf(`<button type="button" lv-click="submit" class="btn btn-primary">%s</button>`, fc.submitText))
-		if fc.showReset {
-			html.WriteString(`<button type="button" lv-click="reset" class="btn btn-secondary">Reset</button>`)
-		}
-		html.WriteString(`</div></form>`)
-	}
-
-	html.WriteString(`</div>`)
-	html.WriteString(buildCSS())
-	html.WriteString(buildScript())
-
-	return template.HTML(html.String())
-}
-
-// buildField generates HTML for a single field
-func (fc *FormComponent[T]) buildField(f field, formData interface{}, errors map[string]string) string {
-	var html strings.Builder
-
-	isCheckbox := f.Type == "checkbox"
-	groupClass := "form-group"
-	if isCheckbox {
-		groupClass += " checkbox-group"
-	}
-
-	html.WriteString(fmt.Sprintf(`<div class="%s">`, groupClass))
-
-	fieldValue := getFieldValue(formData, f.Name)
-	hasError := errors[f.Name] != ""
-	errorClass := ""
-	if hasError {
-		errorClass = "error"
-	}
-
-	if !isCheckbox {
-		required := ""
-		if f.Required {
-			required = " *"
-		}
-		html.WriteString(fmt.Sprintf(`<label for="%s">%s%s</label>`, f.Name, f.Label, required))
+	validating, _ := assigns["validating"].(map[string]bool)
+	validated, _ := assigns["validated"].(map[string]bool)
+	loadedAt, _ := assigns["formLoadedAt"].(time.Time)
+
+	view := formView{
+		Title:      fc.title,
+		SubmitText: fc.submitText,
+		ShowReset:  fc.showReset,
+		Submitted:  submitted,
+		Success:    fc.successMessage(socket),
+		Honeypot:   fc.honeypotField(),
+		LoadedAt:   signFormValue(socket.formKey, purposeLoadedAt, strconv.FormatInt(loadedAt.UnixMilli(), 10)),
+		CSRF:       socket.csrfToken,
 	}
-
-	switch f.Type {
-	case "textarea":
-		rows := f.Rows
-		if rows == 0 {
-			rows = 5
-		}
-		html.WriteString(fmt.Sprintf(
-			`<textarea id="%s" rows="%d" data-field="%s" class="form-input %s" placeholder="%s">%v</textarea>`,
-			f.Name, rows, f.Name, errorClass, f.Placeholder, fieldValue,
-		))
-
-	case "checkbox":
-		checked := ""
-		if boolVal, ok := fieldValue.(bool); ok && boolVal {
-			checked = " checked"
-		}
-		html.WriteString(`<label>`)
-		html.WriteString(fmt.Sprintf(
-			`<input type="checkbox" id="%s"%s data-field="%s" />`,
-			f.Name, checked, f.Name,
-		))
-		required := ""
-		if f.Required {
-			required = " *"
-		}
-		html.WriteString(fmt.Sprintf(`%s%s`, f.Label, required))
-		html.WriteString(`</label>`)
-
-	default:
-		attrs := fmt.Sprintf(
-			`type="%s" id="%s" value="%v" data-field="%s" name="%s" class="form-input %s" placeholder="%s"`,
-			f.Type, f.Name, fieldValue, f.Name, f.Name, errorClass, f.Placeholder,
-		)
-
-		if f.Min != nil {
-			attrs += fmt.Sprintf(` min="%v"`, f.Min)
+	for _, f := range fields {
+		if !fc.isVisible(f.Name, &formData) {
+			continue
 		}
-		if f.Max != nil {
-			attrs += fmt.Sprintf(` max="%v"`, f.Max)
+		if f.Type == "array" {
+			view.Fields = append(view.Fields, fieldView{field: f, Array: fc.newArrayView(f, formData, assigns, errors)})
+			continue
 		}
-
-		html.WriteString(fmt.Sprintf(`<input %s />`, attrs))
+		fv := newFieldView(f, getFieldValue(formData, f.Name), errors[f.Name])
+		fv.Debounce = fc.debounce
+		fv.Validating = validating[f.Name]
+		fv.Validated = validated[f.Name]
+		view.Fields = append(view.Fields, fv)
 	}
 
-	if hasError {
-		html.WriteString(fmt.Sprintf(`<span class="error-message">%s</span>`, errors[f.Name]))
+	form, err := renderForm(view)
+	if err != nil {
+		return "", err
 	}
 
-	html.WriteString(`</div>`)
+	var html strings.Builder
+	html.WriteString(form)
+	html.WriteString(buildCSS(socket.NonceAttr()))
 
-	return html.String()
+	return template.HTML(html.String()), nil
 }
 
 // getFieldValue gets the value of a field from the form data
@@ -322,8 +630,8 @@ func getFieldValue(formData interface{}, fieldName string) interface{} {
 }
 
 // buildCSS generates the default CSS
-func buildCSS() string {
-	return `<style>
+func buildCSS(nonce template.HTMLAttr) string {
+	return `<style` + string(nonce) + `>
     .form-container {
         max-width: 600px;
         margin: 40px auto;
@@ -368,11 +676,72 @@ func buildCSS() string {
     .form-input.error {
         border-color: #e74c3c;
     }
+    .field-status {
+        display: inline-block;
+        width: 12px;
+        height: 12px;
+        margin-left: 8px;
+        vertical-align: middle;
+    }
+    .form-group.lv-pending .field-status,
+    .form-group.validating .field-status {
+        border: 2px solid #e0e0e0;
+        border-top-color: #3498db;
+        border-radius: 50%;
+        animation: field-spin 0.8s linear infinite;
+        animation-delay: 0.15s;
+    }
+    .form-group.lv-resolved:not(.has-error):not(.validating) .field-status::after,
+    .form-group.validated .field-status::after {
+        content: "✓";
+        color: #27ae60;
+        font-size: 12px;
+    }
+    @keyframes field-spin {
+        to { transform: rotate(360deg); }
+    }
     .error-message {
         color: #e74c3c;
         font-size: 13px;
         font-weight: 500;
     }
+    .array-group {
+        border: 2px solid #e0e0e0;
+        border-radius: 5px;
+        padding: 15px;
+    }
+    .array-group legend {
+        font-weight: 600;
+        color: #34495e;
+        font-size: 14px;
+        padding: 0 5px;
+    }
+    .array-row {
+        display: flex;
+        flex-wrap: wrap;
+        align-items: flex-end;
+        gap: 10px;
+        padding-bottom: 10px;
+        margin-bottom: 10px;
+        border-bottom: 1px solid #f0f0f0;
+    }
+    .array-row .form-group {
+        flex: 1;
+        min-width: 120px;
+    }
+    .array-row .array-remove,
+    .array-group .array-add {
+        flex: none;
+        padding: 8px 14px;
+        font-size: 14px;
+    }
+    .lv-hp {
+        position: absolute;
+        left: -10000px;
+        width: 1px;
+        height: 1px;
+        overflow: hidden;
+    }
     .checkbox-group label {
         display: flex;
         align-items: center;
@@ -399,6 +768,8 @@ func buildCSS() string {
         font-weight: 600;
         cursor: pointer;
         transition: background-color 0.3s;
+        text-align: center;
+        text-decoration: none;
     }
     .btn-primary {
         background: #3498db;
@@ -434,35 +805,6 @@ func buildCSS() string {
 </style>`
 }
 
-// buildScript generates the JavaScript for form handling
-func buildScript() string {
-	// With morphdom, event listeners are preserved, so we only need to attach once
-	return `<script>
-	(function() {
-		// Check if listeners already attached (avoid duplicates)
-		if (window.__formListenersAttached) return;
-		window.__formListenersAttached = true;
-
-		// Use event delegation for efficiency and to handle dynamically added inputs
-		document.addEventListener('input', function(e) {
-			const field = e.target.getAttribute('data-field');
-			if (field && window.liveSocket) {
-				const value = e.target.type === 'checkbox' ? e.target.checked.toString() : e.target.value;
-				window.liveSocket.pushEvent('change', { field, value });
-			}
-		});
-
-		document.addEventListener('change', function(e) {
-			const field = e.target.getAttribute('data-field');
-			if (field && window.liveSocket) {
-				const value = e.target.type === 'checkbox' ? e.target.checked.toString() : e.target.value;
-				window.liveSocket.pushEvent('change', { field, value });
-			}
-		});
-	})();
-	</script>`
-}
-
 // parseStructTags parses struct tags to build form fields
 func parseStructTags(data interface{}) []field {
 	fields := make([]field, 0)
@@ -496,6 +838,12 @@ func parseStructTags(data interface{}) []field {
 			parseValidateTag(&f, validateTag)
 		}
 
+		// []struct fields render as repeatable rows
+		if isRowsType(structField.Type) {
+			f.Type = "array"
+			f.Columns = parseStructTags(reflect.New(structField.Type.Elem()).Elem().Interface())
+		}
+
 		// Infer type from field type if not specified
 		if f.Type == "text" {
 			f.Type = inferFieldType(structField.Type)
@@ -531,6 +879,16 @@ func parseFormTag(f *field, tag string) {
 			if rows, err := strconv.Atoi(value); err == nil {
 				f.Rows = rows
 			}
+		case "min_rows":
+			if n, err := strconv.Atoi(value); err == nil {
+				f.MinRows = n
+			}
+		case "max_rows":
+			if n, err := strconv.Atoi(value); err == nil {
+				f.MaxRows = n
+			}
+		case "visible_if":
+			f.VisibleIf = value
 		case "-":
 			// Skip this field
 			f.Name = ""
@@ -598,6 +956,29 @@ func buildValidatorFromTags[T any]() *FormValidator[T] {
 			continue
 		}
 
+		if formTag := structField.Tag.Get("form"); formTag != "" {
+			var f field
+			parseFormTag(&f, formTag)
+			if f.VisibleIf != "" {
+				validator.SetCondition(structField.Name, parseVisibleIf[T](f.VisibleIf))
+			}
+		}
+
+		// Field arrays validate their row count and each row's own tags
+		if isRowsType(structField.Type) {
+			var f field
+			parseFormTag(&f, structField.Tag.Get("form"))
+			label := structField.Name
+			if f.Label != "" {
+				label = f.Label
+			}
+			if f.MinRows > 0 || f.MaxRows > 0 {
+				validator.AddFieldValidator(structField.Name, arrayCountValidator[T](structField.Name, label, f.MinRows, f.MaxRows))
+			}
+			validator.addRowRules(structField.Name, arrayRowValidator[T](structField.Name, structField.Type.Elem()))
+			continue
+		}
+
 		validateTag := structField.Tag.Get("validate")
 		if validateTag == "" {
 			continue
@@ -628,6 +1009,37 @@ func buildValidatorFromTags[T any]() *FormValidator[T] {
 	return validator
 }
 
+// parseVisibleIf parses a visibility rule from the form tag
+// Format: visible_if:Country=US, visible_if:Country!=US, visible_if:Country=US|CA or visible_if:Subscribe
+// A bare field name is true when the field is set (non-zero)
+func parseVisibleIf[T any](expr string) func(*T) bool {
+	name, values, negate := expr, []string(nil), false
+	if i := strings.Index(expr, "!="); i >= 0 {
+		name, values, negate = expr[:i], strings.Split(expr[i+2:], "|"), true
+	} else if i := strings.Index(expr, "="); i >= 0 {
+		name, values = expr[:i], strings.Split(expr[i+1:], "|")
+	}
+	name = strings.TrimSpace(name)
+
+	return func(data *T) bool {
+		field := reflect.ValueOf(data).Elem().FieldByName(name)
+		if !field.IsValid() {
+			return true
+		}
+		if values == nil {
+			return !field.IsZero()
+		}
+
+		current := fmt.Sprintf("%v", field.Interface())
+		for _, value := range values {
+			if current == strings.TrimSpace(value) {
+				return !negate
+			}
+		}
+		return negate
+	}
+}
+
 // parseValidationRules parses validation rules from tag
 func parseValidationRules(tag string, fieldName string, fieldType reflect.Type) []func(interface{}) error {
 	rules := make([]func(interface{}) error, 0)
@@ -638,7 +1050,14 @@ func parseValidationRules(tag string, fieldName string, fieldType reflect.Type)
 
 		if part == "required" {
 			rules = append(rules, func(val interface{}) error {
-				return Required(fieldName)(val.(string))
+				// Non-string fields such as row quantities are required to be non-zero
+				if str, ok := val.(string); ok {
+					return Required(fieldName)(str)
+				}
+				if reflect.ValueOf(val).IsZero() {
+					return Required(fieldName)("")
+				}
+				return nil
 			})
 		} else if strings.HasPrefix(part, "min:") {
 			minStr := strings.TrimPrefix(part, "min:")
@@ -680,6 +1099,14 @@ func parseValidationRules(tag string, fieldName string, fieldType reflect.Type)
 	return rules
 }
 
+// clearFieldValue resets a field to its zero value
+func clearFieldValue(data interface{}, fieldName string) {
+	field := reflect.ValueOf(data).Elem().FieldByName(fieldName)
+	if field.IsValid() && field.CanSet() {
+		field.Set(reflect.Zero(field.Type()))
+	}
+}
+
 // setFieldValue sets a field value using reflection
 func setFieldValue(data interface{}, fieldName string, value interface{}) error {
 	v := reflect.ValueOf(data).Elem()
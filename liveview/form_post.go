@@ -0,0 +1,247 @@
+package liveview
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loadedAtField is the hidden input carrying when a form was rendered, for the
+// spam fill-time check of plain POST submissions
+const loadedAtField = "lv-loaded-at"
+
+// csrfField is the hidden input carrying the CSRF token of plain POST submissions,
+// checked against the csrfCookie of the browser that loaded the form
+const (
+	csrfField  = "lv-csrf"
+	csrfCookie = "lv_csrf"
+)
+
+// Purposes of signed form values, so one can't stand in for the other
+const (
+	purposeLoadedAt = "loaded-at"
+	purposeCSRF     = "csrf"
+)
+
+// FormPoster is implemented by components whose forms also work as a plain HTML POST
+// PostEvent turns the posted form into the event and payload the component handles,
+// so browsers without JavaScript go through the same pipeline as connected clients
+type FormPoster interface {
+	PostEvent(socket *Socket, form url.Values) (string, map[string]interface{})
+}
+
+// Ensure FormComponent implements FormPoster
+var _ FormPoster = (*FormComponent[struct{}])(nil)
+
+// HandlePost handles a full-page form POST to a component, e.g. when JavaScript is disabled
+// The component is mounted, the posted event runs with the usual authorization and hooks,
+// and the page is rendered again with errors and flashes. Redirects become a 303
+func (h *Handler) HandlePost(componentName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.mu.RLock()
+		component, exists := h.components[componentName]
+		h.mu.RUnlock()
+
+		if !exists {
+			c.JSON(404, gin.H{"error": "Component not found"})
+			return
+		}
+
+		poster, ok := component.(FormPoster)
+		if !ok {
+			c.JSON(405, gin.H{"error": "Method not allowed"})
+			return
+		}
+
+		if err := c.Request.ParseForm(); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid form"})
+			return
+		}
+
+		// The POST runs OnSubmit, so other sites must not be able to send it
+		if !sameOrigin(c.Request) || !h.validCSRF(c) {
+			c.JSON(403, gin.H{"error": "Forbidden"})
+			return
+		}
+		c.Request.PostForm.Del(csrfField)
+
+		socket := h.newSocket("")
+		socket.Request = c.Request
+		socket.Session = h.requestSession(c.Request)
+		socket.Params = c.Request.URL.Query()
+		socket.Nonce = GenerateNonce()
+		socket.ctx = c.Request.Context()
+		socket.csrfToken = h.csrfToken(c)
+
+		if err := h.authorize(componentName, component, socket, ""); err != nil {
+			c.JSON(403, gin.H{"error": "Forbidden"})
+			return
+		}
+
+		if err := h.mount(componentName, component, socket, c); err != nil {
+			c.JSON(500, gin.H{"error": "Mount failed"})
+			return
+		}
+
+		event, payload := poster.PostEvent(socket, c.Request.PostForm)
+		if err := h.authorize(componentName, component, socket, event); err != nil {
+			c.JSON(403, gin.H{"error": "Forbidden"})
+			return
+		}
+		if err := h.runEvent(componentName, component, event, payload, socket); err != nil {
+			h.log().Error("Form POST error", "component", componentName, "error", err)
+			// The abandoned handler may still be changing the socket, so it isn't rendered
+			if socket.timedOut {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Request timed out"})
+				return
+			}
+			socket.PutFlash(FlashError, "Something went wrong, please try again")
+		}
+
+		// Redirect after a successful POST so reloading doesn't submit again
+		if r := socket.takeRedirect(); r != nil {
+			c.Redirect(http.StatusSeeOther, r.to)
+			return
+		}
+
+		h.servePage(c, componentName, component, socket)
+	}
+}
+
+// PostEvent converts a plain POST of the generated form into a submit event
+// Unchecked checkboxes are not posted by browsers, so they are submitted as false
+func (fc *FormComponent[T]) PostEvent(socket *Socket, form url.Values) (string, map[string]interface{}) {
+	payload := make(map[string]interface{}, len(form))
+	for name, values := range form {
+		if len(values) == 1 {
+			payload[name] = values[0]
+		} else {
+			payload[name] = values
+		}
+	}
+
+	formData, _ := socket.Assigns["formData"].(T)
+	for _, f := range parseStructTags(formData) {
+		switch f.Type {
+		case "checkbox":
+			payload[f.Name] = strconv.FormatBool(form.Has(f.Name))
+		case "array":
+			rows := reflect.ValueOf(formData).FieldByName(f.Name).Len()
+			for i := 0; i < rows; i++ {
+				for _, column := range f.Columns {
+					if column.Type == "checkbox" {
+						name := rowFieldName(f.Name, i, column.Name)
+						payload[name] = strconv.FormatBool(form.Has(name))
+					}
+				}
+			}
+		}
+	}
+
+	// The fill-time check measures from when the posted form was rendered. The time is
+	// signed, so a forged one fails and leaves the mount time, which is too recent to pass
+	delete(payload, loadedAtField)
+	if value, ok := verifyFormValue(socket.formKey, purposeLoadedAt, form.Get(loadedAtField)); ok {
+		if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+			if loadedAt := time.UnixMilli(ms); loadedAt.Before(time.Now()) {
+				socket.Set("formLoadedAt", loadedAt)
+			}
+		}
+	}
+
+	return "submit", payload
+}
+
+// SetFormSecret sets the secret signing the CSRF tokens and timestamps of forms posted
+// without JavaScript, e.g. the app's SecretKey, so every node of a cluster accepts them
+// Without it a random key is used, which only the current process accepts
+func (h *Handler) SetFormSecret(secret string) {
+	key := sha256.Sum256([]byte("livenest-forms:" + secret))
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.formKey = key[:]
+}
+
+// formSigningKey returns the key signing form values
+func (h *Handler) formSigningKey() []byte {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.formKey
+}
+
+// csrfToken returns the CSRF token of a browser, issuing it a CSRF cookie when it has none
+// The token is the cookie's value signed, so a cookie planted by another site is useless
+func (h *Handler) csrfToken(c *gin.Context) string {
+	value, err := c.Cookie(csrfCookie)
+	if err != nil || len(value) < 32 {
+		value = base64.RawURLEncoding.EncodeToString(randomKey())
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     csrfCookie,
+			Value:    value,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   c.Request.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	return signFormValue(h.formSigningKey(), purposeCSRF, value)
+}
+
+// validCSRF reports whether a posted form carries the CSRF token of the browser's cookie
+func (h *Handler) validCSRF(c *gin.Context) bool {
+	cookie, err := c.Cookie(csrfCookie)
+	if err != nil || cookie == "" {
+		return false
+	}
+	value, ok := verifyFormValue(h.formSigningKey(), purposeCSRF, c.Request.PostForm.Get(csrfField))
+	return ok && hmac.Equal([]byte(value), []byte(cookie))
+}
+
+// sameOrigin reports whether a request may come from a page of this site: its Origin,
+// or its Referer when the browser leaves Origin out, must be on the request's host
+// Requests with neither pass, as not every client sends them
+func sameOrigin(r *http.Request) bool {
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+	if source == "" {
+		return true
+	}
+	u, err := url.Parse(source)
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// signFormValue appends a signature to a value a form posts back
+func signFormValue(key []byte, purpose, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose + ":" + value))
+	return value + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyFormValue returns the value of a signed form value, reporting false when the
+// signature doesn't match
+func verifyFormValue(key []byte, purpose, signed string) (string, bool) {
+	i := strings.LastIndex(signed, ".")
+	if i < 0 {
+		return "", false
+	}
+	value := signed[:i]
+	return value, hmac.Equal([]byte(signFormValue(key, purpose, value)), []byte(signed))
+}
+
+// randomKey returns 32 random bytes
+func randomKey() []byte {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return b
+}
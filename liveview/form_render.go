@@ -0,0 +1,160 @@
+package liveview
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// formTemplates renders auto-generated forms
+// All user-controlled values (field values, error messages, labels) go through
+// html/template so they are escaped according to their context
+var formTemplates = template.Must(template.New("form").Parse(`
+{{- define "form" -}}
+<div class="form-container">
+<h1>{{.Title}}</h1>
+{{- if .Submitted}}
+<div class="success-message">
+	{{- if .Success}}
+	<p>{{.Success}}</p>
+	{{- else}}
+	<h2>✅ Form Submitted Successfully!</h2>
+	<p>Thank you for your submission.</p>
+	{{- end}}
+	{{- if .ShowReset}}
+	<a href="" lv-click="reset" class="btn btn-primary">Submit Another</a>
+	{{- end}}
+</div>
+{{- else}}
+<form class="contact-form" method="post" lv-change="change" lv-submit="submit">
+{{- if .CSRF}}
+<input type="hidden" name="lv-csrf" value="{{.CSRF}}" />
+{{- end}}
+{{- range .Fields}}{{if .Array}}{{template "array" .Array}}{{else}}{{template "field" .}}{{end}}{{end}}
+{{- if .Honeypot}}
+<div class="lv-hp" aria-hidden="true"><label for="lv-hp-{{.Honeypot}}">Leave this field empty</label><input type="text" id="lv-hp-{{.Honeypot}}" name="{{.Honeypot}}" tabindex="-1" autocomplete="off" /></div>
+<input type="hidden" name="lv-loaded-at" value="{{.LoadedAt}}" />
+{{- end}}
+<div class="form-actions">
+<button type="submit" class="btn btn-primary">{{.SubmitText}}</button>
+{{- if .ShowReset}}<a href="" lv-click="reset" class="btn btn-secondary">Reset</a>{{end}}
+</div>
+</form>
+{{- end}}
+</div>
+{{- end}}
+
+{{- define "field" -}}
+<div class="form-group{{if eq .Type "checkbox"}} checkbox-group{{end}}{{if .Error}} has-error{{end}}{{if .Validating}} validating{{end}}{{if .Validated}} validated{{end}}" lv-feedback-for="{{.Name}}">
+{{- if eq .Type "checkbox"}}
+<label><input type="checkbox" id="{{.ID}}" name="{{.Name}}"{{if .Checked}} checked{{end}} />{{.Label}}{{if .Required}} *{{end}}</label>
+{{- else}}
+<label for="{{.ID}}">{{.Label}}{{if .Required}} *{{end}}<span class="field-status" aria-hidden="true"></span></label>
+{{- if eq .Type "textarea"}}
+<textarea id="{{.ID}}" name="{{.Name}}" rows="{{.Rows}}"{{if .Debounce}} lv-debounce="{{.Debounce}}"{{end}} class="form-input {{.ErrorClass}}" placeholder="{{.Placeholder}}">{{.Value}}</textarea>
+{{- else}}
+<input type="{{.Type}}" id="{{.ID}}" value="{{.Value}}" name="{{.Name}}"{{if .Debounce}} lv-debounce="{{.Debounce}}"{{end}} class="form-input {{.ErrorClass}}" placeholder="{{.Placeholder}}"{{if .Min}} min="{{.Min}}"{{end}}{{if .Max}} max="{{.Max}}"{{end}} />
+{{- end}}
+{{- end}}
+{{- if .Error}}<span class="error-message">{{.Error}}</span>{{end}}
+</div>
+{{- end}}
+
+{{- define "array" -}}
+<fieldset class="form-group array-group{{if .Error}} has-error{{end}}" id="{{.Name}}">
+<legend>{{.Label}}{{if .Required}} *{{end}}</legend>
+{{- range .Rows}}
+<div class="array-row" id="{{.ID}}">
+{{- range .Fields}}{{template "field" .}}{{end}}
+{{- if $.CanRemove}}<button type="button" class="btn btn-secondary array-remove" lv-click="removeRow" lv-value-field="{{$.Name}}" lv-value-index="{{.Index}}">Remove</button>{{end}}
+</div>
+{{- end}}
+{{- if .CanAdd}}<button type="button" class="btn btn-secondary array-add" lv-click="addRow" lv-value-field="{{.Name}}">Add another</button>{{end}}
+{{- if .Error}}<span class="error-message">{{.Error}}</span>{{end}}
+</fieldset>
+{{- end}}
+`))
+
+// formView is the data passed to the form template
+type formView struct {
+	Title      string
+	SubmitText string
+	ShowReset  bool
+	Submitted  bool
+	Success    string // text shown after a submit instead of the default
+	Honeypot   string // name of the hidden anti-spam input, if any
+	LoadedAt   string // signed unix milliseconds the form was loaded, posted back without JavaScript
+	CSRF       string // token checked when the form is posted without JavaScript
+	Fields     []fieldView
+}
+
+// fieldView is the data passed to the field template
+type fieldView struct {
+	field
+	ID         string // element id; row fields use their row key so ids survive removals
+	Value      string
+	Checked    bool
+	Error      string
+	ErrorClass string
+	Min        string
+	Max        string
+	Debounce   int
+	Validating bool // an async rule is checking the field
+	Validated  bool // async rules passed for the current value
+	Array      *arrayView
+}
+
+// arrayView is the data passed to the array template
+type arrayView struct {
+	Name      string
+	Label     string
+	Required  bool
+	Error     string
+	Rows      []rowView
+	CanAdd    bool
+	CanRemove bool
+}
+
+// rowView is a single row of a field array
+type rowView struct {
+	ID     string
+	Index  int
+	Fields []fieldView
+}
+
+// newFieldView prepares a field for rendering
+func newFieldView(f field, value interface{}, err string) fieldView {
+	view := fieldView{
+		field: f,
+		ID:    f.Name,
+		Value: fmt.Sprintf("%v", value),
+		Error: err,
+	}
+
+	if view.Error != "" {
+		view.ErrorClass = "error"
+	}
+	if b, ok := value.(bool); ok {
+		view.Checked = b
+	}
+	if view.Rows == 0 {
+		view.Rows = 5
+	}
+	if f.Min != nil {
+		view.Min = fmt.Sprintf("%v", f.Min)
+	}
+	if f.Max != nil {
+		view.Max = fmt.Sprintf("%v", f.Max)
+	}
+
+	return view
+}
+
+// renderForm executes the form template
+func renderForm(view formView) (string, error) {
+	var buf strings.Builder
+	if err := formTemplates.ExecuteTemplate(&buf, "form", view); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
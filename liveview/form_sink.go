@@ -0,0 +1,129 @@
+package liveview
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultSinkTimeout bounds how long a sink may take to accept a submission
+const DefaultSinkTimeout = 30 * time.Second
+
+// Submission is a successful form submission published to sinks
+type Submission struct {
+	Form        string      `json:"form"`              // title of the form
+	Data        interface{} `json:"data"`              // the submitted struct
+	Changed     []string    `json:"changed,omitempty"` // changed fields, for forms with an OnUpdate handler
+	SubmittedAt time.Time   `json:"submitted_at"`
+}
+
+// SubmissionSink receives successful form submissions for downstream processing
+type SubmissionSink interface {
+	Emit(ctx context.Context, submission Submission) error
+}
+
+// SinkFunc adapts a function to a SubmissionSink, e.g. to publish to a PubSub topic or enqueue a job
+type SinkFunc func(ctx context.Context, submission Submission) error
+
+// Emit calls f
+func (f SinkFunc) Emit(ctx context.Context, submission Submission) error {
+	return f(ctx, submission)
+}
+
+// ChannelSink sends submissions to a channel read by a background worker
+// Submissions are dropped with an error when the channel is full
+func ChannelSink(ch chan<- Submission) SubmissionSink {
+	return SinkFunc(func(ctx context.Context, submission Submission) error {
+		select {
+		case ch <- submission:
+			return nil
+		default:
+			return fmt.Errorf("submission channel is full")
+		}
+	})
+}
+
+// WebhookSink posts submissions as JSON to a URL
+type WebhookSink struct {
+	URL     string
+	Secret  string            // signs the body with HMAC-SHA256 in the X-LiveNest-Signature header when set
+	Headers map[string]string // extra request headers, e.g. Authorization
+	Client  *http.Client      // defaults to http.DefaultClient; the request is bounded by the sink timeout
+}
+
+// NewWebhookSink creates a webhook sink for url
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{URL: url}
+}
+
+// Emit posts the submission and fails on a non-2xx response
+func (w *WebhookSink) Emit(ctx context.Context, submission Submission) error {
+	body, err := json.Marshal(submission)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range w.Headers {
+		req.Header.Set(key, value)
+	}
+	if w.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.Secret))
+		mac.Write(body)
+		req.Header.Set("X-LiveNest-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned %s", w.URL, resp.Status)
+	}
+	return nil
+}
+
+// EmitTo publishes successful submissions to sinks in addition to the submit handler
+// Sinks run in the background once the submit handler succeeded; their errors are logged
+func (fc *FormComponent[T]) EmitTo(sinks ...SubmissionSink) *FormComponent[T] {
+	fc.sinks = append(fc.sinks, sinks...)
+	return fc
+}
+
+// emit hands a submission to every sink
+// Sinks outlive the socket so a submission is delivered even if the user navigates away
+func (fc *FormComponent[T]) emit(socket *Socket, formData T, changed []string) {
+	submission := Submission{
+		Form:        fc.title,
+		Data:        formData,
+		Changed:     changed,
+		SubmittedAt: time.Now(),
+	}
+
+	logger := socket.log()
+	for _, sink := range fc.sinks {
+		go func(sink SubmissionSink) {
+			ctx, cancel := context.WithTimeout(context.Background(), DefaultSinkTimeout)
+			defer cancel()
+			if err := sink.Emit(ctx, submission); err != nil {
+				logger.Error("Form submission sink error", "form", fc.title, "error", err)
+			}
+		}(sink)
+	}
+}
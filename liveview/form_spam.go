@@ -0,0 +1,143 @@
+package liveview
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultHoneypotField is the name of the hidden honeypot input
+const DefaultHoneypotField = "website"
+
+// SpamProtection configures the built-in anti-spam checks of a FormComponent
+type SpamProtection struct {
+	Honeypot    string               // Name of a hidden input that only bots fill in; defaults to "website"
+	MinFillTime time.Duration        // Submissions faster than this after the form loaded are rejected
+	MaxPerIP    int                  // Accepted submissions per client in Window; 0 disables throttling
+	Window      time.Duration        // Throttling window; defaults to one hour
+	KeyFunc     func(*Socket) string // Identifies the client for throttling; defaults to the remote IP
+}
+
+// spamGuard applies SpamProtection and keeps the per-client submission history
+type spamGuard struct {
+	config    SpamProtection
+	mu        sync.Mutex
+	history   map[string][]time.Time
+	lastSweep time.Time
+}
+
+// newSpamGuard fills in defaults for a spam protection config
+func newSpamGuard(config SpamProtection) *spamGuard {
+	if config.Honeypot == "" {
+		config.Honeypot = DefaultHoneypotField
+	}
+	if config.Window <= 0 {
+		config.Window = time.Hour
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = clientIP
+	}
+	return &spamGuard{config: config, history: make(map[string][]time.Time)}
+}
+
+// WithSpamProtection adds a honeypot field, a minimum fill time and optional per-client throttling
+// Submissions caught by the honeypot look successful to the sender but never reach the submit handler
+func (fc *FormComponent[T]) WithSpamProtection(config SpamProtection) *FormComponent[T] {
+	fc.spam = newSpamGuard(config)
+	return fc
+}
+
+// honeypotField returns the honeypot input name, or "" without spam protection
+func (fc *FormComponent[T]) honeypotField() string {
+	if fc.spam == nil {
+		return ""
+	}
+	return fc.spam.config.Honeypot
+}
+
+// spamVerdict is the outcome of checking a submission
+type spamVerdict int
+
+const (
+	spamOK spamVerdict = iota
+	spamHoneypot
+	spamTooFast
+	spamThrottled
+)
+
+// check inspects a submission that already passed validation
+func (g *spamGuard) check(socket *Socket, payload map[string]interface{}) spamVerdict {
+	if value, _ := payload[g.config.Honeypot].(string); value != "" {
+		return spamHoneypot
+	}
+
+	if g.config.MinFillTime > 0 {
+		if loadedAt, ok := socket.Assigns["formLoadedAt"].(time.Time); ok && time.Since(loadedAt) < g.config.MinFillTime {
+			return spamTooFast
+		}
+	}
+
+	if g.config.MaxPerIP > 0 && !g.allow(g.config.KeyFunc(socket)) {
+		return spamThrottled
+	}
+
+	return spamOK
+}
+
+// allow records a submission for key and reports whether it is within MaxPerIP
+func (g *spamGuard) allow(key string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-g.config.Window)
+
+	// Drop clients that have been quiet for a whole window
+	if now.Sub(g.lastSweep) > g.config.Window {
+		for k, times := range g.history {
+			if len(times) == 0 || times[len(times)-1].Before(cutoff) {
+				delete(g.history, k)
+			}
+		}
+		g.lastSweep = now
+	}
+
+	recent := g.history[key][:0]
+	for _, t := range g.history[key] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= g.config.MaxPerIP {
+		g.history[key] = recent
+		return false
+	}
+	g.history[key] = append(recent, now)
+	return true
+}
+
+// rejectSpam reports a rejected submission to the sender
+// Honeypot hits pretend to succeed so bots get no signal
+func (fc *FormComponent[T]) rejectSpam(socket *Socket, verdict spamVerdict) {
+	switch verdict {
+	case spamHoneypot:
+		socket.log().Info("Form submission rejected", "reason", "honeypot filled")
+		socket.Set("submitted", true)
+		socket.PutFlash("success", "Form submitted successfully!")
+	case spamTooFast:
+		socket.log().Info("Form submission rejected", "reason", "submitted too quickly")
+		socket.PutFlash("error", "Please take a moment to review the form before submitting")
+	case spamThrottled:
+		socket.log().Info("Form submission rejected", "reason", "too many submissions")
+		socket.PutFlash("error", "Too many submissions, please try again later")
+	}
+}
+
+// clientIP returns the remote IP of the request that opened the socket
+// Apps behind a proxy should set SpamProtection.KeyFunc to read the forwarded address they trust
+func clientIP(socket *Socket) string {
+	if socket.Request == nil {
+		return ""
+	}
+	return RemoteIP(socket.Request)
+}
@@ -1,10 +1,13 @@
 package liveview
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // ValidationRule represents a validation rule for a field
@@ -38,11 +41,30 @@ func (v *FieldValidator[T]) Validate(value T) error {
 	return nil
 }
 
+// ValidationError is the error of a built-in rule
+// Forms show it translated into the socket's locale under Key, e.g. "validation.required",
+// formatted with Args; Message is the English text shown without a translation
+type ValidationError struct {
+	Key     string
+	Args    []interface{}
+	Message string
+}
+
+// Error returns the English message
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// validationError returns the error of a built-in rule
+func validationError(key, format string, args ...interface{}) error {
+	return &ValidationError{Key: key, Args: args, Message: fmt.Sprintf(format, args...)}
+}
+
 // Common validation rules for strings
 func Required(fieldName string) ValidationRule[string] {
 	return func(value string) error {
 		if strings.TrimSpace(value) == "" {
-			return fmt.Errorf("%s is required", fieldName)
+			return validationError("validation.required", "%s is required", fieldName)
 		}
 		return nil
 	}
@@ -51,7 +73,7 @@ func Required(fieldName string) ValidationRule[string] {
 func MinLength(min int) ValidationRule[string] {
 	return func(value string) error {
 		if len(value) < min {
-			return fmt.Errorf("must be at least %d characters", min)
+			return validationError("validation.min_length", "must be at least %d characters", min)
 		}
 		return nil
 	}
@@ -60,7 +82,7 @@ func MinLength(min int) ValidationRule[string] {
 func MaxLength(max int) ValidationRule[string] {
 	return func(value string) error {
 		if len(value) > max {
-			return fmt.Errorf("must be at most %d characters", max)
+			return validationError("validation.max_length", "must be at most %d characters", max)
 		}
 		return nil
 	}
@@ -70,17 +92,18 @@ func Email() ValidationRule[string] {
 	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 	return func(value string) error {
 		if !emailRegex.MatchString(value) {
-			return fmt.Errorf("invalid email format")
+			return validationError("validation.email", "invalid email format")
 		}
 		return nil
 	}
 }
 
+// Pattern requires a value to match pattern; message may be a translation key
 func Pattern(pattern string, message string) ValidationRule[string] {
 	regex := regexp.MustCompile(pattern)
 	return func(value string) error {
 		if !regex.MatchString(value) {
-			return fmt.Errorf("%s", message)
+			return &ValidationError{Key: message, Message: message}
 		}
 		return nil
 	}
@@ -90,7 +113,7 @@ func Numeric() ValidationRule[string] {
 	return func(value string) error {
 		value = strings.TrimSpace(value)
 		if _, err := strconv.ParseFloat(value, 64); err != nil {
-			return fmt.Errorf("must be a number")
+			return validationError("validation.numeric", "must be a number")
 		}
 		return nil
 	}
@@ -100,10 +123,10 @@ func Min(min float64) ValidationRule[string] {
 	return func(value string) error {
 		num, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
 		if err != nil {
-			return fmt.Errorf("must be a number")
+			return validationError("validation.numeric", "must be a number")
 		}
 		if num < min {
-			return fmt.Errorf("must be at least %.2f", min)
+			return validationError("validation.min", "must be at least %.2f", min)
 		}
 		return nil
 	}
@@ -113,37 +136,159 @@ func Max(max float64) ValidationRule[string] {
 	return func(value string) error {
 		num, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
 		if err != nil {
-			return fmt.Errorf("must be a number")
+			return validationError("validation.numeric", "must be a number")
 		}
 		if num > max {
-			return fmt.Errorf("must be at most %.2f", max)
+			return validationError("validation.max", "must be at most %.2f", max)
 		}
 		return nil
 	}
 }
 
-// Validation rule for booleans
+// Validation rule for booleans; message may be a translation key
 func MustBeTrue(message string) ValidationRule[bool] {
 	return func(value bool) error {
 		if !value {
-			return fmt.Errorf("%s", message)
+			return &ValidationError{Key: message, Message: message}
 		}
 		return nil
 	}
 }
 
+// AsyncRule validates a field against a slow source such as a database or API
+// The context is cancelled when newer input for the field arrives
+type AsyncRule[T any] func(ctx context.Context, data *T) error
+
 // FormValidator manages validation for all form fields
 type FormValidator[T any] struct {
-	validators map[string]func(*T) error
+	validators    map[string]func(*T) error
+	asyncRules    map[string][]AsyncRule[T]
+	rowRules      map[string]func(*T) map[string]error
+	conditions    map[string]func(*T) bool
+	asyncDebounce time.Duration
 }
 
 // NewFormValidator creates a new form validator
 func NewFormValidator[T any]() *FormValidator[T] {
 	return &FormValidator[T]{
 		validators: make(map[string]func(*T) error),
+		asyncRules: make(map[string][]AsyncRule[T]),
+		rowRules:   make(map[string]func(*T) map[string]error),
+		conditions: make(map[string]func(*T) bool),
 	}
 }
 
+// AddRowValidator adds a validator for the rows of a list field
+// It returns errors keyed by row, e.g. "Items.2.Price"
+func (fv *FormValidator[T]) AddRowValidator(fieldName string, validator func(*T) map[string]string) *FormValidator[T] {
+	return fv.addRowRules(fieldName, func(data *T) map[string]error {
+		errs := make(map[string]error)
+		for key, msg := range validator(data) {
+			errs[key] = errors.New(msg)
+		}
+		return errs
+	})
+}
+
+// addRowRules adds a row validator returning errors, so built-in rules can be translated
+func (fv *FormValidator[T]) addRowRules(fieldName string, validator func(*T) map[string]error) *FormValidator[T] {
+	fv.rowRules[fieldName] = validator
+	return fv
+}
+
+// SetCondition makes a field conditional, e.g. a State field that only applies when Country is "US"
+// Fields whose condition is false are not validated
+func (fv *FormValidator[T]) SetCondition(fieldName string, cond func(*T) bool) *FormValidator[T] {
+	fv.conditions[fieldName] = cond
+	return fv
+}
+
+// IsActive reports whether a field's condition holds for data
+// Fields without a condition are always active
+func (fv *FormValidator[T]) IsActive(fieldName string, data *T) bool {
+	cond, ok := fv.conditions[fieldName]
+	return !ok || cond(data)
+}
+
+// AddAsyncRule adds an async validation rule for a field
+// Async rules run after the field's synchronous rules pass
+func (fv *FormValidator[T]) AddAsyncRule(fieldName string, rule AsyncRule[T]) *FormValidator[T] {
+	fv.asyncRules[fieldName] = append(fv.asyncRules[fieldName], rule)
+	return fv
+}
+
+// SetAsyncDebounce sets how long async rules wait for newer input before they start
+func (fv *FormValidator[T]) SetAsyncDebounce(d time.Duration) *FormValidator[T] {
+	fv.asyncDebounce = d
+	return fv
+}
+
+// HasAsyncRules reports whether a field has async rules
+func (fv *FormValidator[T]) HasAsyncRules(fieldName string) bool {
+	return len(fv.asyncRules[fieldName]) > 0
+}
+
+// ValidateFieldAsync runs the async rules of a field after the debounce delay
+// It returns the context error if ctx is cancelled first
+func (fv *FormValidator[T]) ValidateFieldAsync(ctx context.Context, fieldName string, data *T) error {
+	if fv.asyncDebounce > 0 {
+		timer := time.NewTimer(fv.asyncDebounce)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for _, rule := range fv.asyncRules[fieldName] {
+		if err := rule(ctx, data); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// ValidateAsync runs the async rules of every field without a debounce
+// Fields already present in errors are skipped
+func (fv *FormValidator[T]) ValidateAsync(ctx context.Context, data *T, errors map[string]string) {
+	fv.validateAsync(ctx, data, errors, error.Error)
+}
+
+// validateAsync runs the async rules of every field, turning errors into messages with msg
+func (fv *FormValidator[T]) validateAsync(ctx context.Context, data *T, errors map[string]string, msg func(error) string) {
+	for fieldName, err := range fv.runAsyncRules(ctx, data, fv.pendingAsync(data, errors)) {
+		errors[fieldName] = msg(err)
+	}
+}
+
+// pendingAsync returns the active fields with async rules that aren't in errors yet
+func (fv *FormValidator[T]) pendingAsync(data *T, errors map[string]string) []string {
+	var fields []string
+	for fieldName := range fv.asyncRules {
+		if _, failed := errors[fieldName]; failed || !fv.IsActive(fieldName, data) {
+			continue
+		}
+		fields = append(fields, fieldName)
+	}
+	return fields
+}
+
+// runAsyncRules runs the async rules of fields and returns the first error of each
+// It only reads data, so it may run off the connection goroutine on a copy of the form
+func (fv *FormValidator[T]) runAsyncRules(ctx context.Context, data *T, fields []string) map[string]error {
+	failed := make(map[string]error)
+	for _, fieldName := range fields {
+		for _, rule := range fv.asyncRules[fieldName] {
+			if err := rule(ctx, data); err != nil {
+				failed[fieldName] = err
+				break
+			}
+		}
+	}
+	return failed
+}
+
 // AddFieldValidator adds a field validator
 func (fv *FormValidator[T]) AddFieldValidator(fieldName string, validator func(*T) error) *FormValidator[T] {
 	fv.validators[fieldName] = validator
@@ -152,18 +297,44 @@ func (fv *FormValidator[T]) AddFieldValidator(fieldName string, validator func(*
 
 // Validate validates the entire form
 func (fv *FormValidator[T]) Validate(data *T) map[string]string {
+	return fv.validate(data, error.Error)
+}
+
+// validate validates the entire form, turning errors into messages with msg
+func (fv *FormValidator[T]) validate(data *T, msg func(error) string) map[string]string {
 	errors := make(map[string]string)
 	for fieldName, validator := range fv.validators {
+		if !fv.IsActive(fieldName, data) {
+			continue
+		}
 		if err := validator(data); err != nil {
-			errors[fieldName] = err.Error()
+			errors[fieldName] = msg(err)
+		}
+	}
+	for fieldName, validator := range fv.rowRules {
+		if !fv.IsActive(fieldName, data) {
+			continue
+		}
+		for key, err := range validator(data) {
+			errors[key] = msg(err)
 		}
 	}
 	return errors
 }
 
 // ValidateField validates a single field
+// Row fields such as "Items.2.Price" are checked with the list's row validator
 func (fv *FormValidator[T]) ValidateField(fieldName string, data *T) error {
-	if validator, ok := fv.validators[fieldName]; ok {
+	if list, _, ok := strings.Cut(fieldName, "."); ok {
+		if validator, ok := fv.rowRules[list]; ok && fv.IsActive(list, data) {
+			if err, failed := validator(data)[fieldName]; failed {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if validator, ok := fv.validators[fieldName]; ok && fv.IsActive(fieldName, data) {
 		return validator(data)
 	}
 	return nil
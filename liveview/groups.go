@@ -0,0 +1,55 @@
+package liveview
+
+import (
+	"fmt"
+	"html/template"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paulmanoni/livenest/protocol"
+)
+
+// GroupContextKey is the gin context key holding the prefix of the route group a request
+// came through; the group's middleware sets it
+const GroupContextKey = "livenest.group"
+
+// RegisterGroup places a registered component in the route group with prefix, e.g.
+// "/admin". It is then only served through the group's routes, so the group's middleware
+// guards its page, its component tag and its WebSocket: joins through another
+// WebSocket endpoint are refused as unauthorized
+func (h *Handler) RegisterGroup(name, prefix string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.groups == nil {
+		h.groups = make(map[string]string)
+	}
+	h.groups[name] = prefix
+}
+
+// groupOf returns the prefix of the group a component is served in, "" for the root
+func (h *Handler) groupOf(name string) string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.groups[name]
+}
+
+// checkGroup refuses a component requested outside its route group
+func (h *Handler) checkGroup(name, group string) error {
+	if want := h.groupOf(name); want != group {
+		return fmt.Errorf("%w: component %q is served under %q", ErrUnauthorized, name, want+"/")
+	}
+	return nil
+}
+
+// requestGroup returns the route group a request came through
+func requestGroup(c *gin.Context) string {
+	return c.GetString(GroupContextKey)
+}
+
+// socketPathAttr points the page's containers to the WebSocket of the component's group
+func (h *Handler) socketPathAttr(name string) string {
+	group := h.groupOf(name)
+	if group == "" {
+		return ""
+	}
+	return ` data-ws-path="` + template.HTMLEscapeString(group+protocol.Path) + `"`
+}
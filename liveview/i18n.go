@@ -0,0 +1,124 @@
+package liveview
+
+import (
+	"context"
+	"errors"
+
+	"github.com/paulmanoni/livenest/i18n"
+)
+
+// LocaleSessionKey is the session key of a visitor's chosen locale, which sockets prefer
+// over the locale cookie and Accept-Language header
+const LocaleSessionKey = "locale"
+
+// localeContextKey is the context key of a request's locale
+type localeContextKey struct{}
+
+// WithLocale returns a copy of ctx carrying the locale chosen for its request, which the
+// sockets the request renders use over the session, cookie and Accept-Language header
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeContextKey{}, locale)
+}
+
+// LocaleFrom returns the locale carried by ctx, or ""
+func LocaleFrom(ctx context.Context) string {
+	locale, _ := ctx.Value(localeContextKey{}).(string)
+	return locale
+}
+
+// SetTranslations sets the catalog sockets translate with. Each socket picks its locale
+// when it mounts, from its request's context, the session, the locale cookie or the Accept-Language header of
+// the request that opened it, and assigns "locale" and the i18n.Localizer templates
+// translate with: {{t "nav.home"}}
+func (h *Handler) SetTranslations(catalog *i18n.Catalog) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.translations = catalog
+}
+
+// Translations returns the catalog set with SetTranslations, or nil
+func (h *Handler) Translations() *i18n.Catalog {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.translations
+}
+
+// Localizer returns the socket's localizer, which is nil, returning keys untranslated,
+// when the handler has no translations
+func (s *Socket) Localizer() *i18n.Localizer {
+	if s.translations == nil {
+		return nil
+	}
+	return s.translations.Localizer(s.Locale)
+}
+
+// T returns the message of key in the socket's locale formatted with args
+func (s *Socket) T(key string, args ...interface{}) string {
+	return s.Localizer().T(key, args...)
+}
+
+// SetLocale switches the socket to a locale, e.g. from a language menu, and stores it in
+// the session; unsupported locales switch to the catalog's fallback
+// The socket re-renders in the new locale, but texts already assigned stay as they are
+func (s *Socket) SetLocale(locale string) {
+	if s.translations == nil {
+		return
+	}
+	s.Locale = s.translations.Localizer(locale).Locale()
+	s.Session.Put(LocaleSessionKey, s.Locale)
+	s.assignLocale()
+}
+
+// negotiateLocale picks the socket's locale when it mounts, unless it has one
+func (s *Socket) negotiateLocale() {
+	if s.translations == nil {
+		return
+	}
+	if s.Locale == "" && s.Request != nil {
+		s.Locale, _ = s.translations.Supported(LocaleFrom(s.Request.Context()))
+	}
+	if s.Locale == "" {
+		if chosen, ok := s.Session.Get(LocaleSessionKey); ok {
+			if locale, ok := chosen.(string); ok {
+				s.Locale, _ = s.translations.Supported(locale)
+			}
+		}
+	}
+	if s.Locale == "" {
+		s.Locale = s.translations.Negotiate(s.Request)
+	}
+	s.assignLocale()
+}
+
+// assignLocale assigns the socket's locale and localizer for its templates
+func (s *Socket) assignLocale() {
+	localizer := s.Localizer()
+	s.Assigns["locale"] = localizer.Locale()
+	s.Assigns[i18n.AssignKey] = localizer
+}
+
+// translate returns the message of key in the socket's locale, or def when no locale
+// has it
+func (s *Socket) translate(key, def string) string {
+	if msg, ok := s.Localizer().Lookup(key); ok {
+		return msg
+	}
+	return def
+}
+
+// errorMessage returns the message of a validation error in the socket's locale
+// Built-in rules are translated under their ValidationError key; the text of other
+// errors is looked up as a key, so a rule may return errors.New("validation.taken")
+func (s *Socket) errorMessage(err error) string {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		if msg, ok := s.Localizer().Lookup(verr.Key); ok {
+			return i18n.Format(msg, verr.Args...)
+		}
+		return verr.Message
+	}
+	if msg, ok := s.Localizer().Lookup(err.Error()); ok {
+		return msg
+	}
+	return err.Error()
+}
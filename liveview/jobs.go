@@ -0,0 +1,66 @@
+package liveview
+
+import (
+	"context"
+	"errors"
+
+	"github.com/paulmanoni/livenest/jobs"
+)
+
+// ErrNoJobRunner is returned by MonitorJob when the handler has no job runner
+var ErrNoJobRunner = errors.New("liveview: no job runner, see Handler.SetJobs")
+
+// SetJobs sets the runner whose jobs sockets follow with MonitorJob
+func (h *Handler) SetJobs(runner *jobs.Runner) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.jobs = runner
+}
+
+// Jobs returns the runner set with SetJobs, or nil
+func (h *Handler) Jobs() *jobs.Runner {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.jobs
+}
+
+// MonitorJob assigns a background job under key ("job" by default) and, on a live
+// connection, re-renders the component as the job progresses on any node, until it is
+// done; templates show {{.job.Progress}}, {{.job.Message}} and {{.job.Status}}
+// Call it from Mount, or from the event that started the job:
+//
+//	func (c *Import) HandleSubmit(socket *liveview.Socket, payload map[string]interface{}) error {
+//		job, err := c.Jobs.Enqueue(socket.Context(), "import", payload)
+//		if err != nil {
+//			return err
+//		}
+//		return socket.MonitorJob(job.ID)
+//	}
+func (s *Socket) MonitorJob(id string, key ...string) error {
+	if s.jobs == nil {
+		return ErrNoJobRunner
+	}
+	name := "job"
+	if len(key) > 0 {
+		name = key[0]
+	}
+
+	job, err := s.jobs.Get(s.Context(), id)
+	if err != nil {
+		return err
+	}
+	s.Set(name, *job)
+	if job.Done() {
+		return nil
+	}
+
+	runner := s.jobs
+	s.StartStream("job:"+name, func(ctx context.Context, push func(func(*Socket))) {
+		runner.Watch(ctx, id, func(job jobs.Job) {
+			push(func(s *Socket) {
+				s.Set(name, job)
+			})
+		})
+	})
+	return nil
+}
@@ -0,0 +1,152 @@
+// Package js builds client-side commands that run in the browser without a server round trip
+//
+// Commands serialize to JSON and are used in place of an event name in lv-click,
+// lv-submit, lv-keydown and the window bindings:
+//
+//	socket.Set("openModal", js.Show("#modal").AddClass("body", "modal-open"))
+//
+//	<button lv-click="{{.openModal}}">Open</button>
+//
+// An empty selector targets the element that triggered the command.
+package js
+
+import "encoding/json"
+
+// Command is a single client-side operation
+type Command struct {
+	Op     string                 `json:"op"`
+	Target string                 `json:"to,omitempty"`
+	Args   map[string]interface{} `json:"args,omitempty"`
+}
+
+// Commands is a sequence of client-side operations run in order
+type Commands []Command
+
+// Show shows the elements matching selector
+func Show(selector string) Commands { return Commands{}.Show(selector) }
+
+// Hide hides the elements matching selector
+func Hide(selector string) Commands { return Commands{}.Hide(selector) }
+
+// Toggle shows hidden and hides visible elements matching selector
+func Toggle(selector string) Commands { return Commands{}.Toggle(selector) }
+
+// AddClass adds space-separated classes to the elements matching selector
+func AddClass(selector, classes string) Commands { return Commands{}.AddClass(selector, classes) }
+
+// RemoveClass removes space-separated classes from the elements matching selector
+func RemoveClass(selector, classes string) Commands {
+	return Commands{}.RemoveClass(selector, classes)
+}
+
+// ToggleClass toggles space-separated classes on the elements matching selector
+func ToggleClass(selector, classes string) Commands {
+	return Commands{}.ToggleClass(selector, classes)
+}
+
+// SetAttr sets an attribute on the elements matching selector
+func SetAttr(selector, name, value string) Commands { return Commands{}.SetAttr(selector, name, value) }
+
+// RemoveAttr removes an attribute from the elements matching selector
+func RemoveAttr(selector, name string) Commands { return Commands{}.RemoveAttr(selector, name) }
+
+// Focus focuses the first element matching selector
+func Focus(selector string) Commands { return Commands{}.Focus(selector) }
+
+// Push sends an event to the server, like a plain lv-click
+func Push(event string, payload map[string]interface{}) Commands {
+	return Commands{}.Push(event, payload)
+}
+
+// Dispatch dispatches a DOM CustomEvent on the elements matching selector
+func Dispatch(selector, event string, detail map[string]interface{}) Commands {
+	return Commands{}.Dispatch(selector, event, detail)
+}
+
+// Show shows the elements matching selector
+func (c Commands) Show(selector string) Commands {
+	return c.add("show", selector, nil)
+}
+
+// ShowAs shows the elements matching selector with a specific CSS display value (e.g. "flex")
+func (c Commands) ShowAs(selector, display string) Commands {
+	return c.add("show", selector, map[string]interface{}{"display": display})
+}
+
+// Hide hides the elements matching selector
+func (c Commands) Hide(selector string) Commands {
+	return c.add("hide", selector, nil)
+}
+
+// Toggle shows hidden and hides visible elements matching selector
+func (c Commands) Toggle(selector string) Commands {
+	return c.add("toggle", selector, nil)
+}
+
+// AddClass adds space-separated classes to the elements matching selector
+func (c Commands) AddClass(selector, classes string) Commands {
+	return c.add("add_class", selector, map[string]interface{}{"names": classes})
+}
+
+// RemoveClass removes space-separated classes from the elements matching selector
+func (c Commands) RemoveClass(selector, classes string) Commands {
+	return c.add("remove_class", selector, map[string]interface{}{"names": classes})
+}
+
+// ToggleClass toggles space-separated classes on the elements matching selector
+func (c Commands) ToggleClass(selector, classes string) Commands {
+	return c.add("toggle_class", selector, map[string]interface{}{"names": classes})
+}
+
+// SetAttr sets an attribute on the elements matching selector
+func (c Commands) SetAttr(selector, name, value string) Commands {
+	return c.add("set_attr", selector, map[string]interface{}{"name": name, "value": value})
+}
+
+// RemoveAttr removes an attribute from the elements matching selector
+func (c Commands) RemoveAttr(selector, name string) Commands {
+	return c.add("remove_attr", selector, map[string]interface{}{"name": name})
+}
+
+// Focus focuses the first element matching selector
+func (c Commands) Focus(selector string) Commands {
+	return c.add("focus", selector, nil)
+}
+
+// Push sends an event to the server
+// The payload is merged with the lv-value-* attributes of the triggering element
+func (c Commands) Push(event string, payload map[string]interface{}) Commands {
+	args := map[string]interface{}{"event": event}
+	if payload != nil {
+		args["payload"] = payload
+	}
+	return c.add("push", "", args)
+}
+
+// Dispatch dispatches a DOM CustomEvent on the elements matching selector
+func (c Commands) Dispatch(selector, event string, detail map[string]interface{}) Commands {
+	args := map[string]interface{}{"event": event}
+	if detail != nil {
+		args["detail"] = detail
+	}
+	return c.add("dispatch", selector, args)
+}
+
+// String returns the JSON form used in lv-* attributes
+func (c Commands) String() string {
+	if c == nil {
+		c = Commands{}
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "[]"
+	}
+	return string(b)
+}
+
+// add returns a copy of c with a command appended, so partial chains can be shared
+func (c Commands) add(op, selector string, args map[string]interface{}) Commands {
+	next := make(Commands, len(c), len(c)+1)
+	copy(next, c)
+	return append(next, Command{Op: op, Target: selector, Args: args})
+}
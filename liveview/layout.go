@@ -0,0 +1,167 @@
+package liveview
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/paulmanoni/livenest/i18n"
+)
+
+// Layout renders the HTML page around a LiveView component
+type Layout interface {
+	RenderLayout(w io.Writer, page PageData) error
+}
+
+// LayoutFunc adapts a function to the Layout interface
+type LayoutFunc func(w io.Writer, page PageData) error
+
+// RenderLayout calls f(w, page)
+func (f LayoutFunc) RenderLayout(w io.Writer, page PageData) error {
+	return f(w, page)
+}
+
+// PageData holds the slots available to a layout
+type PageData struct {
+	Title         string
+	ComponentName string
+	SocketID      string
+	ComponentID   string
+	Nonce         string
+	Locale        string // locale of the page, see Handler.SetTranslations
+
+	// Content is the rendered component HTML on its own
+	Content template.HTML
+
+	// LiveView is the component HTML inside the container the client connects to
+	LiveView template.HTML
+
+	// Assets holds the script tags required by the LiveView client
+	Assets template.HTML
+
+	// Meta holds the meta tags set with Socket.PutMeta and Socket.PutOpenGraph
+	Meta template.HTML
+
+	// Flashes holds the flashes put during mount, rendered with the flash partial
+	Flashes template.HTML
+
+	localizer *i18n.Localizer
+}
+
+// Localizer returns the page's localizer, so layout templates can use {{t "key"}}
+func (p PageData) Localizer() *i18n.Localizer {
+	return p.localizer
+}
+
+// newPageData prepares the layout slots for an initial render
+// containerAttrs are extra pre-escaped attributes for the LiveView container
+func (h *Handler) newPageData(componentName string, content template.HTML, socketID string, socket *Socket, containerAttrs string) PageData {
+	nonce := socket.NonceAttr()
+
+	title := socket.Title()
+	if title == "" {
+		title = "LiveNest - " + componentName
+	}
+
+	return PageData{
+		Title:         title,
+		ComponentName: componentName,
+		SocketID:      socketID,
+		ComponentID:   socket.ComponentID,
+		Nonce:         socket.Nonce,
+		Locale:        socket.Locale,
+		localizer:     socket.Localizer(),
+		Content:       content,
+		LiveView:      containerHTML("liveview", componentName, socketID, socket.ComponentID, containerAttrs, content),
+		Assets:        template.HTML(`<script src="` + h.liveViewJSURL() + `"` + string(nonce) + `></script>`),
+		Meta:          renderMetaTags(socket.MetaTags()),
+	}
+}
+
+// containerHTML wraps rendered component HTML in a container the client connects to
+func containerHTML(id, componentName, socketID, componentID, attrs string, content template.HTML) template.HTML {
+	return template.HTML(`<div id="` + template.HTMLEscapeString(id) +
+		`" data-component="` + template.HTMLEscapeString(componentName) +
+		`" data-socket-id="` + template.HTMLEscapeString(socketID) +
+		`" data-component-id="` + template.HTMLEscapeString(componentID) + `"` + attrs + `>` + string(content) + `</div>`)
+}
+
+// TemplateRenderer is implemented by template engines that can render a named template
+// template.Engine satisfies this interface
+type TemplateRenderer interface {
+	RenderTo(w io.Writer, name string, data interface{}) error
+}
+
+// NewTemplateLayout creates a layout that renders a named template with PageData
+// Use {{.Title}}, {{.Meta}}, {{.Assets}}, {{.Flashes}} and {{.LiveView}} in the template to place the slots
+func NewTemplateLayout(renderer TemplateRenderer, name string) Layout {
+	return LayoutFunc(func(w io.Writer, page PageData) error {
+		return renderer.RenderTo(w, name, page)
+	})
+}
+
+// SetLayout sets the default layout for all LiveView pages
+func (h *Handler) SetLayout(layout Layout) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.layout = layout
+}
+
+// RegisterLayout sets the layout for a registered component name
+func (h *Handler) RegisterLayout(name string, layout Layout) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.layouts[name] = layout
+}
+
+// layoutFor returns the layout for a component, falling back to the default
+func (h *Handler) layoutFor(name string) Layout {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if layout, ok := h.layouts[name]; ok {
+		return layout
+	}
+	return h.layout
+}
+
+// defaultLayoutTemplate is the built-in page wrapper
+var defaultLayoutTemplate = template.Must(template.New("layout").Parse(`<!DOCTYPE html>
+<html lang="{{if .Locale}}{{.Locale}}{{else}}en{{end}}">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Title}}</title>
+    {{.Meta}}
+    <style{{if .Nonce}} nonce="{{.Nonce}}"{{end}}>
+        body {
+            margin: 0;
+            padding: 0;
+            font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, "Helvetica Neue", Arial, sans-serif;
+            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
+            min-height: 100vh;
+            display: flex;
+            justify-content: center;
+            align-items: center;
+        }
+        .liveview-container {
+            background: white;
+            border-radius: 15px;
+            padding: 40px;
+            box-shadow: 0 20px 60px rgba(0, 0, 0, 0.3);
+        }
+    </style>
+    {{.Assets}}
+</head>
+<body>
+    {{.Flashes}}
+    <div class="liveview-container">
+        {{.LiveView}}
+    </div>
+</body>
+</html>`))
+
+// DefaultLayout returns the built-in layout
+func DefaultLayout() Layout {
+	return LayoutFunc(func(w io.Writer, page PageData) error {
+		return defaultLayoutTemplate.Execute(w, page)
+	})
+}
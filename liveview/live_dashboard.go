@@ -0,0 +1,355 @@
+package liveview
+
+import (
+	"fmt"
+	"html/template"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LiveDashboard is a LiveView of the handler's runtime: connected sockets, event
+// rate, render latency, memory, goroutines, registered components and broadcast throttles
+// It exposes internals, so mount it behind authentication, e.g. with core's
+// App.EnableLiveDashboard
+type LiveDashboard struct {
+	handler  *Handler
+	interval time.Duration
+	mu       sync.Mutex
+	checks   []namedCheck
+	tables   []DashboardTable
+}
+
+// DashboardCheck reports a status shown on the LiveDashboard, e.g. pending migrations
+// ok false highlights the status as a problem
+type DashboardCheck func() (status string, ok bool)
+
+// DashboardTable is an extra table on the LiveDashboard, e.g. index suggestions
+// Rows is called on every refresh
+type DashboardTable struct {
+	Title   string
+	Columns []string
+	Rows    func() [][]string
+}
+
+// namedCheck is a check added with AddCheck
+type namedCheck struct {
+	name  string
+	check DashboardCheck
+}
+
+// NewLiveDashboard creates a dashboard of h refreshing every two seconds
+func NewLiveDashboard(h *Handler) *LiveDashboard {
+	return &LiveDashboard{handler: h, interval: 2 * time.Second}
+}
+
+// SetRefreshInterval changes how often connected dashboards refresh
+func (d *LiveDashboard) SetRefreshInterval(interval time.Duration) {
+	d.interval = interval
+}
+
+// AddCheck shows the status of check on the dashboard under name
+func (d *LiveDashboard) AddCheck(name string, check DashboardCheck) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.checks = append(d.checks, namedCheck{name, check})
+}
+
+// AddTable shows an extra table on the dashboard, below the checks
+func (d *LiveDashboard) AddTable(table DashboardTable) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tables = append(d.tables, table)
+}
+
+// dashboardSample is a stats reading, kept per socket to compute rates between refreshes
+type dashboardSample struct {
+	at    time.Time
+	stats HandlerStats
+}
+
+// dashboardComponent is a row of the components table
+type dashboardComponent struct {
+	Name    string
+	Events  int
+	Sockets int
+}
+
+// dashboardCheck is a row of the checks table
+type dashboardCheck struct {
+	Name   string
+	Status string
+	OK     bool
+}
+
+// dashboardTableView is an extra table with its rows read
+type dashboardTableView struct {
+	Title   string
+	Columns []string
+	Rows    [][]string
+}
+
+// dashboardView is the data rendered by the dashboard template
+type dashboardView struct {
+	Nonce         string
+	Interval      string
+	EventsPerSec  string
+	RenderLatency string
+	Renders       uint64
+	Events        uint64
+	UnknownEvents uint64
+	SlowEvents    uint64
+	TimedOut      uint64
+	HeapAlloc     string
+	HeapSys       string
+	NumGC         uint32
+	Goroutines    int
+	Sockets       []SocketInfo
+	Components    []dashboardComponent
+	Checks        []dashboardCheck
+	Tables        []dashboardTableView
+	Throttles     []ThrottleInfo
+}
+
+// Mount takes the first reading and starts the refresh tick
+func (d *LiveDashboard) Mount(socket *Socket) error {
+	socket.Set("sample", dashboardSample{at: time.Now(), stats: d.handler.Stats()})
+	socket.Set("rates", [2]string{"-", "-"})
+	socket.EveryTick(d.interval, "refresh")
+	return nil
+}
+
+// HandleRefresh computes the event rate and render latency since the previous reading
+func (d *LiveDashboard) HandleRefresh(socket *Socket, payload map[string]interface{}) error {
+	now := dashboardSample{at: time.Now(), stats: d.handler.Stats()}
+	if prev, ok := socket.Assigns["sample"].(dashboardSample); ok {
+		elapsed := now.at.Sub(prev.at).Seconds()
+		events := now.stats.Events - prev.stats.Events
+		renders := now.stats.Renders - prev.stats.Renders
+
+		rates := [2]string{"-", "-"}
+		if elapsed > 0 {
+			rates[0] = fmt.Sprintf("%.1f", float64(events)/elapsed)
+		}
+		if renders > 0 {
+			latency := (now.stats.RenderTime - prev.stats.RenderTime) / time.Duration(renders)
+			rates[1] = latency.Round(time.Microsecond).String()
+		}
+		socket.Set("rates", rates)
+	}
+	socket.Set("sample", now)
+	return nil
+}
+
+// HandleDisconnect disconnects the socket of a sockets table row
+func (d *LiveDashboard) HandleDisconnect(socket *Socket, payload map[string]interface{}) error {
+	id, _ := Payload(payload).String("id")
+	if id == socket.ID || !d.handler.Disconnect(id) {
+		return nil
+	}
+	socket.PutFlash("info", "Disconnected "+id)
+	return nil
+}
+
+// HandleThrottle sets the throttle of a topic from the throttles form
+func (d *LiveDashboard) HandleThrottle(socket *Socket, payload map[string]interface{}) error {
+	p := Payload(payload)
+	topic, _ := p.String("topic")
+	topic = strings.TrimSpace(topic)
+	rate, ok := p.Float("rate")
+	if topic == "" || !ok || rate <= 0 {
+		socket.PutFlash("error", "Enter a topic and a rate above zero")
+		return nil
+	}
+	mode, _ := p.String("mode")
+	d.handler.ThrottleTopic(topic, Throttle{Rate: rate, Coalesce: mode == "coalesce"})
+	socket.PutFlash("info", "Throttled "+topic)
+	return nil
+}
+
+// HandleUnthrottle removes the throttle of a throttles table row
+func (d *LiveDashboard) HandleUnthrottle(socket *Socket, payload map[string]interface{}) error {
+	topic, _ := Payload(payload).String("topic")
+	d.handler.UnthrottleTopic(topic)
+	socket.PutFlash("info", "Removed the throttle of "+topic)
+	return nil
+}
+
+// Render reads the runtime figures and renders the dashboard
+func (d *LiveDashboard) Render(socket *Socket) (template.HTML, error) {
+	sample, _ := socket.Assigns["sample"].(dashboardSample)
+	rates, _ := socket.Assigns["rates"].([2]string)
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	view := dashboardView{
+		Nonce:         socket.Nonce,
+		Interval:      d.interval.String(),
+		EventsPerSec:  rates[0],
+		RenderLatency: rates[1],
+		Renders:       sample.stats.Renders,
+		Events:        sample.stats.Events,
+		UnknownEvents: sample.stats.UnknownEvents,
+		SlowEvents:    sample.stats.BudgetExceeded,
+		TimedOut:      sample.stats.TimedOutEvents,
+		HeapAlloc:     formatBytes(mem.HeapAlloc),
+		HeapSys:       formatBytes(mem.HeapSys),
+		NumGC:         mem.NumGC,
+		Goroutines:    runtime.NumGoroutine(),
+		Sockets:       d.handler.Sockets(),
+		Throttles:     d.handler.Throttles(),
+	}
+
+	counts := make(map[string]int)
+	for _, info := range view.Sockets {
+		counts[info.Component]++
+	}
+	for _, doc := range d.handler.Catalog() {
+		view.Components = append(view.Components, dashboardComponent{
+			Name:    doc.Name,
+			Events:  len(doc.Events),
+			Sockets: counts[doc.Name],
+		})
+	}
+	sort.SliceStable(view.Components, func(i, j int) bool {
+		return view.Components[i].Sockets > view.Components[j].Sockets
+	})
+
+	d.mu.Lock()
+	checks, tables := d.checks, d.tables
+	d.mu.Unlock()
+	for _, c := range checks {
+		status, ok := c.check()
+		view.Checks = append(view.Checks, dashboardCheck{Name: c.name, Status: status, OK: ok})
+	}
+	for _, t := range tables {
+		view.Tables = append(view.Tables, dashboardTableView{Title: t.Title, Columns: t.Columns, Rows: t.Rows()})
+	}
+
+	var b strings.Builder
+	if err := dashboardTemplate.Execute(&b, view); err != nil {
+		return "", err
+	}
+	return template.HTML(b.String()), nil
+}
+
+// formatBytes formats a byte count in binary units
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// dashboardTemplate renders the LiveDashboard
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`
+<div id="lv-dashboard">
+<style{{if .Nonce}} nonce="{{.Nonce}}"{{end}}>
+#lv-dashboard { font: 14px/1.5 sans-serif; color: #1f2937; max-width: 1100px; margin: 0 auto; padding: 24px; }
+#lv-dashboard .cards { display: grid; grid-template-columns: repeat(auto-fill, minmax(160px, 1fr)); gap: 12px; margin-bottom: 24px; }
+#lv-dashboard .card { border: 1px solid #e5e7eb; border-radius: 8px; padding: 12px 16px; }
+#lv-dashboard .card span { display: block; font-size: 12px; color: #6b7280; }
+#lv-dashboard .card strong { font-size: 22px; }
+#lv-dashboard table { width: 100%; border-collapse: collapse; margin-bottom: 24px; }
+#lv-dashboard th, #lv-dashboard td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #e5e7eb; }
+#lv-dashboard th { font-size: 12px; color: #6b7280; }
+#lv-dashboard button { font-size: 12px; }
+#lv-dashboard form { margin: -12px 0 24px; }
+#lv-dashboard .failing { color: #b91c1c; font-weight: bold; }
+</style>
+<h1>LiveNest Dashboard</h1>
+<p>Refreshes every {{.Interval}}.</p>
+<div class="cards">
+<div class="card"><span>Sockets</span><strong>{{len .Sockets}}</strong></div>
+<div class="card"><span>Events/sec</span><strong>{{.EventsPerSec}}</strong></div>
+<div class="card"><span>Render latency</span><strong>{{.RenderLatency}}</strong></div>
+<div class="card"><span>Goroutines</span><strong>{{.Goroutines}}</strong></div>
+<div class="card"><span>Heap in use</span><strong>{{.HeapAlloc}}</strong></div>
+<div class="card"><span>Heap reserved</span><strong>{{.HeapSys}}</strong></div>
+<div class="card"><span>GC cycles</span><strong>{{.NumGC}}</strong></div>
+</div>
+<div class="cards">
+<div class="card"><span>Events</span><strong>{{.Events}}</strong></div>
+<div class="card"><span>Renders</span><strong>{{.Renders}}</strong></div>
+<div class="card"><span>Unknown events</span><strong>{{.UnknownEvents}}</strong></div>
+<div class="card"><span>Over budget</span><strong>{{.SlowEvents}}</strong></div>
+<div class="card"><span>Timed out</span><strong>{{.TimedOut}}</strong></div>
+</div>
+{{- if .Checks}}
+<h2>Checks</h2>
+<table>
+<tbody>
+{{- range .Checks}}
+<tr><td>{{.Name}}</td><td{{if not .OK}} class="failing"{{end}}>{{.Status}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- range .Tables}}
+<h2>{{.Title}}</h2>
+<table>
+<thead><tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{- range .Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{- else}}
+<tr><td colspan="{{len .Columns}}">Nothing yet</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+<h2>Components</h2>
+<table>
+<thead><tr><th>Name</th><th>Events</th><th>Sockets</th></tr></thead>
+<tbody>
+{{- range .Components}}
+<tr><td>{{.Name}}</td><td>{{.Events}}</td><td>{{.Sockets}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+<h2>Broadcast throttles</h2>
+<table>
+<thead><tr><th>Topic</th><th>Rate per socket</th><th>Over the rate</th><th>Delivered</th><th>Dropped</th><th>Coalesced</th><th></th></tr></thead>
+<tbody>
+{{- range .Throttles}}
+<tr>
+<td>{{.Topic}}</td><td>{{.Throttle.Rate}}/s</td><td>{{if .Throttle.Coalesce}}latest wins{{else}}dropped{{end}}</td>
+<td>{{.Delivered}}</td><td>{{.Dropped}}</td><td>{{.Coalesced}}</td>
+<td><button lv-click="unthrottle" lv-value-topic="{{.Topic}}">Remove</button></td>
+</tr>
+{{- else}}
+<tr><td colspan="7">No throttled topics</td></tr>
+{{- end}}
+</tbody>
+</table>
+<form lv-submit="throttle" lv-reset>
+<input name="topic" placeholder="prices.* or a topic" required>
+<input name="rate" type="number" min="0.1" step="any" placeholder="messages/sec" required>
+<select name="mode"><option value="coalesce">Latest wins</option><option value="drop">Drop</option></select>
+<button type="submit">Throttle</button>
+</form>
+<h2>Sockets</h2>
+<table>
+<thead><tr><th>ID</th><th>Component</th><th>Remote address</th><th>Request ID</th><th>Connected</th><th>Events</th><th>Last event</th><th></th></tr></thead>
+<tbody>
+{{- range .Sockets}}
+<tr>
+<td>{{.ID}}</td><td>{{.Component}}</td><td>{{.RemoteAddr}}</td><td>{{.RequestID}}</td>
+<td>{{.ConnectedAt.Format "15:04:05"}}</td><td>{{.Events}}</td><td>{{.LastEvent}}</td>
+<td><button lv-click="disconnect" lv-value-id="{{.ID}}">Disconnect</button></td>
+</tr>
+{{- else}}
+<tr><td colspan="8">No sockets connected</td></tr>
+{{- end}}
+</tbody>
+</table>
+</div>`))
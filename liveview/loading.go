@@ -0,0 +1,24 @@
+package liveview
+
+import (
+	"strconv"
+	"time"
+)
+
+// SetLoadingTimeout sets how long an event may be in flight before the client
+// shows its loading indicator. Zero keeps the client default
+func (h *Handler) SetLoadingTimeout(timeout time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.loadingTimeout = timeout
+}
+
+// loadingTimeoutAttr returns the container attribute that configures the client loading timeout
+func (h *Handler) loadingTimeoutAttr() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.loadingTimeout <= 0 {
+		return ""
+	}
+	return ` data-loading-timeout="` + strconv.FormatInt(h.loadingTimeout.Milliseconds(), 10) + `"`
+}
@@ -0,0 +1,54 @@
+package liveview
+
+import "log/slog"
+
+// Logger receives LiveView log records as a message and key-value pairs
+// *slog.Logger implements it; the default is slog.Default(), so handlers configured
+// with slog.SetDefault, e.g. for JSON output, apply
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// SetLogger sets the logger of the handler and the sockets it creates
+func (h *Handler) SetLogger(logger Logger) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.logger = logger
+}
+
+// log returns the handler's logger
+func (h *Handler) log() Logger {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.logger == nil {
+		return slog.Default()
+	}
+	return h.logger
+}
+
+// newSocket creates a socket that logs to the handler's logger
+func (h *Handler) newSocket(id string) *Socket {
+	socket := NewSocket(id)
+	socket.logger = h.log()
+	socket.services = h.Service
+	socket.throttles = h.throttles
+	socket.translations = h.Translations()
+	socket.jobs = h.Jobs()
+	socket.formKey = h.formSigningKey()
+	return socket
+}
+
+// log returns the socket's logger, which adds the socket's request ID to its records
+func (s *Socket) log() Logger {
+	logger := s.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if s.RequestID != "" {
+		return argsLogger{logger: logger, args: []any{"request_id", s.RequestID}}
+	}
+	return logger
+}
@@ -0,0 +1,102 @@
+package liveview
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// MessageLimits bounds the client messages a connection accepts
+// Zero fields keep the defaults
+type MessageLimits struct {
+	MaxBytes int // largest message in bytes; larger ones close the connection with 1009 (default 1 MiB)
+	MaxKeys  int // object keys in a message, at any depth (default 1000)
+	MaxDepth int // nesting of objects and arrays in a message, the envelope included (default 32)
+}
+
+// Default message limits
+const (
+	defaultMaxMessageBytes = 1 << 20
+	defaultMaxMessageKeys  = 1000
+	defaultMaxMessageDepth = 32
+)
+
+// ErrMessageTooComplex is reported for messages over the key or depth limit
+var ErrMessageTooComplex = errors.New("message exceeds the payload limits")
+
+// SetMessageLimits sets the limits of client messages
+// A message over the key or depth limit is rejected with a protocol_error frame before it
+// is decoded; the connection stays open. An oversized message can't be read without
+// buffering it, so it closes the connection with status 1009 (message too big)
+func (h *Handler) SetMessageLimits(limits MessageLimits) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.messageLimits = limits
+}
+
+// limits returns the message limits with defaults filled in
+func (h *Handler) limits() MessageLimits {
+	h.mu.RLock()
+	limits := h.messageLimits
+	h.mu.RUnlock()
+	if limits.MaxBytes <= 0 {
+		limits.MaxBytes = defaultMaxMessageBytes
+	}
+	if limits.MaxKeys <= 0 {
+		limits.MaxKeys = defaultMaxMessageKeys
+	}
+	if limits.MaxDepth <= 0 {
+		limits.MaxDepth = defaultMaxMessageDepth
+	}
+	return limits
+}
+
+// checkComplexity scans a JSON message for its key count and nesting depth without
+// decoding it, so a hostile message can't make the decoder allocate a deep structure
+func checkComplexity(data []byte, limits MessageLimits) error {
+	keys, depth := 0, 0
+	inString, escaped := false, false
+	for _, b := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+			continue
+		}
+		switch b {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > limits.MaxDepth {
+				return fmt.Errorf("%w: nested deeper than %d", ErrMessageTooComplex, limits.MaxDepth)
+			}
+		case '}', ']':
+			depth--
+		case ':':
+			keys++
+			if keys > limits.MaxKeys {
+				return fmt.Errorf("%w: more than %d keys", ErrMessageTooComplex, limits.MaxKeys)
+			}
+		}
+	}
+	return nil
+}
+
+// rejectedMessage addresses the protocol error of a message over the limits to its
+// container, decoding only the envelope
+func rejectedMessage(data []byte, err error) Message {
+	var envelope struct {
+		Type  string `json:"type"`
+		Event string `json:"event"`
+		Ref   string `json:"ref"`
+		Topic string `json:"topic"`
+	}
+	json.Unmarshal(data, &envelope)
+	return Message{Type: envelope.Type, Event: envelope.Event, Ref: envelope.Ref, Topic: envelope.Topic, rejected: err}
+}
@@ -0,0 +1,225 @@
+package liveview
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Histogram buckets of the exported metrics
+var (
+	durationBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+	sizeBuckets     = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576}
+	serviceBuckets  = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+)
+
+// histogram is a Prometheus histogram with fixed buckets
+type histogram struct {
+	buckets []float64
+	counts  []uint64 // per bucket, not cumulative; the last one counts values above every bucket
+	sum     float64
+	count   uint64
+}
+
+// newHistogram creates a histogram with the given upper bounds
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets)+1)}
+}
+
+// observe adds a value
+func (h *histogram) observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+	h.counts[i]++
+	h.sum += v
+	h.count++
+}
+
+// eventKey labels the events counter
+type eventKey struct {
+	component string
+	event     string
+}
+
+// renderKey labels the render payload histograms
+type renderKey struct {
+	component string
+	pipeline  string
+}
+
+// metrics holds the Prometheus metrics of a handler
+// Event names come from clients, so unknown events share the "unknown" label
+type metrics struct {
+	mu         sync.Mutex
+	events     map[eventKey]uint64
+	render     *histogram
+	diff       *histogram
+	payloadIn  *histogram
+	payloadOut *histogram
+	renders    map[renderKey]*histogram
+}
+
+// newMetrics creates empty metrics
+func newMetrics() *metrics {
+	return &metrics{
+		events:     make(map[eventKey]uint64),
+		render:     newHistogram(durationBuckets),
+		diff:       newHistogram(durationBuckets),
+		payloadIn:  newHistogram(sizeBuckets),
+		payloadOut: newHistogram(sizeBuckets),
+		renders:    make(map[renderKey]*histogram),
+	}
+}
+
+// observeEvent counts a handled event
+func (m *metrics) observeEvent(componentName, event string, err error) {
+	if errors.Is(err, ErrUnknownEvent) {
+		event = "unknown"
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events[eventKey{componentName, event}]++
+}
+
+// observe adds a value to one of the histograms
+func (m *metrics) observe(h *histogram, v float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h.observe(v)
+}
+
+// observeRender adds the size of an update sent through a render pipeline
+func (m *metrics) observeRender(componentName, pipeline string, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := renderKey{componentName, pipeline}
+	if m.renders[key] == nil {
+		m.renders[key] = newHistogram(sizeBuckets)
+	}
+	m.renders[key].observe(float64(size))
+}
+
+// observeDuration adds a duration in seconds to one of the histograms
+func (m *metrics) observeDuration(h *histogram, d time.Duration) {
+	m.observe(h, d.Seconds())
+}
+
+// WriteMetrics writes the handler's metrics in the Prometheus text format
+func (h *Handler) WriteMetrics(w io.Writer) error {
+	h.mu.RLock()
+	sockets := len(h.sockets)
+	h.mu.RUnlock()
+	stats := h.Stats()
+
+	bw := bufio.NewWriter(w)
+	writeMetric(bw, "livenest_sockets", "gauge", "Connected LiveView sockets.", float64(sockets))
+	writeMetric(bw, "livenest_unknown_events_total", "counter", "Events without a handler.", float64(stats.UnknownEvents))
+	writeMetric(bw, "livenest_budget_exceeded_total", "counter", "Events slower than their latency budget.", float64(stats.BudgetExceeded))
+	writeMetric(bw, "livenest_timed_out_events_total", "counter", "Events that timed out.", float64(stats.TimedOutEvents))
+	writeMetric(bw, "livenest_rejected_connections_total", "counter", "WebSocket connections over the connection limits.", float64(stats.RejectedConns))
+	writeMetric(bw, "livenest_pending_sockets", "gauge", "Server renders waiting for their WebSocket join.", float64(stats.PendingSockets))
+	writeMetric(bw, "livenest_expired_sockets_total", "counter", "Server renders not joined before their TTL.", float64(stats.ExpiredSockets))
+
+	m := h.metrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]eventKey, 0, len(m.events))
+	for key := range m.events {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].component != keys[j].component {
+			return keys[i].component < keys[j].component
+		}
+		return keys[i].event < keys[j].event
+	})
+	fmt.Fprintf(bw, "# HELP livenest_events_total Events handled by component and event.\n# TYPE livenest_events_total counter\n")
+	for _, key := range keys {
+		fmt.Fprintf(bw, "livenest_events_total{component=%s,event=%s} %d\n",
+			quoteLabel(key.component), quoteLabel(key.event), m.events[key])
+	}
+
+	writeHistogram(bw, "livenest_render_duration_seconds", "Component render time.", m.render)
+	writeHistogram(bw, "livenest_diff_duration_seconds", "Time spent diffing renders.", m.diff)
+	fmt.Fprintf(bw, "# HELP livenest_message_size_bytes WebSocket message sizes.\n# TYPE livenest_message_size_bytes histogram\n")
+	writeHistogramSeries(bw, "livenest_message_size_bytes", `direction="in"`, m.payloadIn)
+	writeHistogramSeries(bw, "livenest_message_size_bytes", `direction="out"`, m.payloadOut)
+
+	renders := make([]renderKey, 0, len(m.renders))
+	for key := range m.renders {
+		renders = append(renders, key)
+	}
+	sort.Slice(renders, func(i, j int) bool {
+		if renders[i].component != renders[j].component {
+			return renders[i].component < renders[j].component
+		}
+		return renders[i].pipeline < renders[j].pipeline
+	})
+	fmt.Fprintf(bw, "# HELP livenest_render_payload_bytes Update sizes by component and render pipeline.\n# TYPE livenest_render_payload_bytes histogram\n")
+	for _, key := range renders {
+		labels := "component=" + quoteLabel(key.component) + ",pipeline=" + quoteLabel(key.pipeline)
+		writeHistogramSeries(bw, "livenest_render_payload_bytes", labels, m.renders[key])
+	}
+	h.writeServiceMetrics(bw)
+
+	return bw.Flush()
+}
+
+// MetricsHandler serves the handler's metrics for Prometheus to scrape
+func (h *Handler) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := h.WriteMetrics(w); err != nil {
+			h.log().Error("Metrics error", "error", err)
+		}
+	})
+}
+
+// writeMetric writes a single-value metric
+func writeMetric(w io.Writer, name, typ, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, help, name, typ, name, formatValue(value))
+}
+
+// writeHistogram writes an unlabelled histogram with its help and type
+func writeHistogram(w io.Writer, name, help string, h *histogram) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	writeHistogramSeries(w, name, "", h)
+}
+
+// writeHistogramSeries writes the cumulative buckets, sum and count of a histogram
+func writeHistogramSeries(w io.Writer, name, labels string, h *histogram) {
+	prefix := ""
+	if labels != "" {
+		prefix = labels + ","
+	}
+	var cumulative uint64
+	for i, bound := range h.buckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{%sle=\"%s\"} %d\n", name, prefix, formatValue(bound), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, prefix, h.count)
+
+	suffix := ""
+	if labels != "" {
+		suffix = "{" + labels + "}"
+	}
+	fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n", name, suffix, formatValue(h.sum), name, suffix, h.count)
+}
+
+// formatValue formats a sample value
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// quoteLabel quotes a label value, escaping as the text format requires
+func quoteLabel(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return `"` + value + `"`
+}
@@ -0,0 +1,289 @@
+package liveview
+
+import (
+	"sort"
+	"strings"
+)
+
+// minifyJS strips comments, indentation and blank lines from JavaScript and joins lines
+// where no semicolon could be inserted. Strings, template literals and regular
+// expressions are copied as they are. It returns the minified source and the "mappings"
+// of a source map pointing each output line back to the line it came from
+func minifyJS(src string) (string, string) {
+	m := jsMinifier{src: src}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			m.lineStarts = append(m.lineStarts, i+1)
+		}
+	}
+	m.run()
+	return m.out.String(), m.mappings.String()
+}
+
+// jsMinifier holds the state of minifyJS
+type jsMinifier struct {
+	src        string
+	lineStarts []int // offsets of the lines after the first
+	out        strings.Builder
+
+	last     byte   // last byte written outside strings, 0 at the start
+	lastWord string // identifier ending at last, to tell a regular expression from a division
+	sep      byte   // whitespace pending before the next token: 0, ' ' or '\n'
+
+	// templates holds, for each template literal whose ${...} is being copied, the
+	// braces open inside the expression
+	templates []int
+
+	lineStart  bool // the next byte written starts an output line
+	mappings   strings.Builder
+	prevSource [2]int // source line and column of the previous mapping
+}
+
+// run minifies the whole source
+func (m *jsMinifier) run() {
+	src := m.src
+	m.lineStart = true
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			if c == '\n' {
+				m.sep = '\n'
+			} else if m.sep == 0 {
+				m.sep = ' '
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				i = len(src)
+				break
+			}
+			if strings.Contains(src[i:i+end+4], "\n") {
+				m.sep = '\n'
+			} else if m.sep == 0 {
+				m.sep = ' '
+			}
+			i += end + 3
+		case c == '\'' || c == '"':
+			i = m.copyString(i)
+		case c == '`':
+			i = m.copyTemplate(i + 1)
+		case c == '/' && m.regexAllowed():
+			if end := m.regexEnd(i); end > 0 {
+				m.token(i)
+				m.write(src[i+1:end+1], i+1)
+				m.last, m.lastWord = '/', ""
+				i = end
+				break
+			}
+			m.token(i)
+		case c == '{' && len(m.templates) > 0:
+			m.templates[len(m.templates)-1]++
+			m.token(i)
+		case c == '}' && len(m.templates) > 0:
+			top := len(m.templates) - 1
+			if m.templates[top] == 0 {
+				// The end of a ${...} expression resumes its template literal
+				m.templates = m.templates[:top]
+				m.sep = 0
+				m.write("}", i)
+				i = m.copyTemplate(i + 1)
+				break
+			}
+			m.templates[top]--
+			m.token(i)
+		default:
+			m.token(i)
+		}
+	}
+}
+
+// token writes the byte at i outside strings, with the pending whitespace it needs
+func (m *jsMinifier) token(i int) {
+	c := m.src[i]
+	switch m.sep {
+	case '\n':
+		if m.last != 0 && !strings.ContainsRune(";{,([", rune(m.last)) && !strings.ContainsRune(")]},;", rune(c)) {
+			m.write("\n", i)
+		} else if m.needsSpace(c) {
+			m.write(" ", i)
+		}
+	case ' ':
+		if m.needsSpace(c) {
+			m.write(" ", i)
+		}
+	}
+	m.sep = 0
+	m.write(string(c), i)
+
+	if isIdentByte(c) {
+		if !isIdentByte(m.last) {
+			m.lastWord = ""
+		}
+		m.lastWord += string(c)
+	} else {
+		m.lastWord = ""
+	}
+	m.last = c
+}
+
+// needsSpace reports whether whitespace between the last byte and c must be kept
+func (m *jsMinifier) needsSpace(c byte) bool {
+	if isIdentByte(m.last) && isIdentByte(c) {
+		return true
+	}
+	// a + +b, a - -b and a / /re/ change meaning without the space
+	return m.last == c && (c == '+' || c == '-' || c == '/')
+}
+
+// regexAllowed reports whether a slash at this point starts a regular expression
+func (m *jsMinifier) regexAllowed() bool {
+	if m.last == 0 || strings.ContainsRune("(,=:[!&|?{};+-*%<>~^", rune(m.last)) {
+		return true
+	}
+	switch m.lastWord {
+	case "return", "typeof", "case", "do", "else", "in", "instanceof", "new", "delete", "void", "throw", "yield", "await":
+		return true
+	}
+	return false
+}
+
+// regexEnd returns the index of the slash closing a regular expression opened at i,
+// or 0 when the line ends first, in which case the slash is a division after all
+func (m *jsMinifier) regexEnd(i int) int {
+	src := m.src
+	inClass := false
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case '\n':
+			return 0
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '/':
+			if !inClass {
+				// Copy the flags with the literal
+				for j+1 < len(src) && isIdentByte(src[j+1]) {
+					j++
+				}
+				return j
+			}
+		}
+	}
+	return 0
+}
+
+// copyString copies the string literal opened at i and returns the index of its quote
+func (m *jsMinifier) copyString(i int) int {
+	src := m.src
+	quote := src[i]
+	end := i + 1
+	for ; end < len(src) && src[end] != quote; end++ {
+		if src[end] == '\\' {
+			end++
+		}
+	}
+	if end >= len(src) {
+		end = len(src) - 1
+	}
+	m.token(i)
+	m.write(src[i+1:end+1], i+1)
+	m.last, m.lastWord = quote, ""
+	return end
+}
+
+// copyTemplate copies a template literal from i, just after its backtick or the end of
+// an expression, up to the closing backtick or the next ${, and returns the index of
+// the last byte copied
+func (m *jsMinifier) copyTemplate(i int) int {
+	src := m.src
+	if i > 0 && src[i-1] == '`' {
+		m.token(i - 1)
+	}
+	start := i
+	for ; i < len(src); i++ {
+		switch {
+		case src[i] == '\\':
+			i++
+		case src[i] == '`':
+			m.write(src[start:i+1], start)
+			m.last, m.lastWord = '`', ""
+			return i
+		case src[i] == '$' && i+1 < len(src) && src[i+1] == '{':
+			m.write(src[start:i+2], start)
+			m.templates = append(m.templates, 0)
+			m.last, m.lastWord = '{', ""
+			return i + 1
+		}
+	}
+	m.write(src[start:], start)
+	return len(src) - 1
+}
+
+// write appends s, copied from offset pos of the source, and maps each output line it
+// starts back to its source position
+func (m *jsMinifier) write(s string, pos int) {
+	for len(s) > 0 {
+		if m.lineStart {
+			m.addMapping(pos)
+			m.lineStart = false
+		}
+		nl := strings.IndexByte(s, '\n')
+		if nl < 0 {
+			m.out.WriteString(s)
+			return
+		}
+		m.out.WriteString(s[:nl+1])
+		m.mappings.WriteByte(';')
+		m.lineStart = true
+		s = s[nl+1:]
+		pos += nl + 1
+	}
+}
+
+// addMapping adds the segment mapping the start of an output line to offset pos
+func (m *jsMinifier) addMapping(pos int) {
+	line := sort.Search(len(m.lineStarts), func(k int) bool { return m.lineStarts[k] > pos })
+	col := pos
+	if line > 0 {
+		col = pos - m.lineStarts[line-1]
+	}
+	writeVLQ(&m.mappings, 0)
+	writeVLQ(&m.mappings, 0)
+	writeVLQ(&m.mappings, line-m.prevSource[0])
+	writeVLQ(&m.mappings, col-m.prevSource[1])
+	m.prevSource = [2]int{line, col}
+}
+
+// writeVLQ writes a source map Base64 VLQ number
+func writeVLQ(b *strings.Builder, n int) {
+	const digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	v := n << 1
+	if n < 0 {
+		v = -n<<1 | 1
+	}
+	for {
+		digit := v & 31
+		v >>= 5
+		if v > 0 {
+			digit |= 32
+		}
+		b.WriteByte(digits[digit])
+		if v == 0 {
+			return
+		}
+	}
+}
+
+// isIdentByte reports whether c can be part of an identifier or number
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
@@ -0,0 +1,130 @@
+package liveview
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MountCache shares expensive mount data between sockets for a short time
+// Entries are keyed by a name and the page's query parameters. Concurrent loads of the
+// same key run once, so an HTTP pre-render and the socket mount that follows it, or a
+// burst of visitors opening the same dashboard, cause a single query
+//
+//	var statsCache = liveview.NewMountCache[Stats](10 * time.Second)
+//
+//	func (d *Dashboard) Mount(socket *liveview.Socket) error {
+//		stats, err := statsCache.Get(socket, "dashboard", loadStats)
+//		...
+//	}
+type MountCache[T any] struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	entries   map[string]*mountEntry[T]
+	lastSweep time.Time
+}
+
+// mountEntry is a cached value, or a load in progress while done is open
+type mountEntry[T any] struct {
+	done    chan struct{}
+	value   T
+	err     error
+	expires time.Time
+}
+
+// NewMountCache creates a cache whose entries are reused for ttl
+func NewMountCache[T any](ttl time.Duration) *MountCache[T] {
+	return &MountCache[T]{ttl: ttl, entries: make(map[string]*mountEntry[T])}
+}
+
+// Get returns the cached value for name and the socket's query parameters, calling
+// load when there is none. Errors are not cached. load gets a context that outlives
+// the socket that started it, since other sockets may be waiting for the result.
+// Sockets waiting for a load that fails get the same error
+func (c *MountCache[T]) Get(socket *Socket, name string, load func(context.Context) (T, error)) (T, error) {
+	key := name
+	if len(socket.Params) > 0 {
+		key += "?" + socket.Params.Encode()
+	}
+
+	c.mu.Lock()
+	c.sweep()
+	if entry, ok := c.entries[key]; ok && !entry.expired() {
+		c.mu.Unlock()
+		select {
+		case <-entry.done:
+			return entry.value, entry.err
+		case <-socket.Context().Done():
+			var zero T
+			return zero, socket.Context().Err()
+		}
+	}
+
+	entry := &mountEntry[T]{done: make(chan struct{})}
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	c.load(key, entry, socket, load)
+	return entry.value, entry.err
+}
+
+// load fills an entry and wakes the sockets waiting for it
+// Failed loads are handed to the waiting sockets but not cached
+func (c *MountCache[T]) load(key string, entry *mountEntry[T], socket *Socket, load func(context.Context) (T, error)) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			entry.err = fmt.Errorf("mount cache load panicked: %v", r)
+		}
+
+		c.mu.Lock()
+		if c.entries[key] == entry {
+			if entry.err != nil {
+				delete(c.entries, key)
+			} else {
+				entry.expires = time.Now().Add(c.ttl)
+			}
+		}
+		c.mu.Unlock()
+		close(entry.done)
+
+		// The socket that ran the load fails like any panicking mount
+		if r != nil {
+			panic(r)
+		}
+	}()
+	entry.value, entry.err = load(context.WithoutCancel(socket.Context()))
+}
+
+// Invalidate drops every cached entry of name, e.g. after the underlying data changed
+// Loads in progress finish for the sockets waiting on them but aren't cached
+func (c *MountCache[T]) Invalidate(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if key == name || strings.HasPrefix(key, name+"?") {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// expired reports whether a loaded entry is past its TTL; the caller holds the cache lock
+func (e *mountEntry[T]) expired() bool {
+	return !e.expires.IsZero() && time.Now().After(e.expires)
+}
+
+// sweep drops expired entries once per TTL; the caller holds c.mu
+func (c *MountCache[T]) sweep() {
+	now := time.Now()
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	for key, entry := range c.entries {
+		if entry.expired() {
+			delete(c.entries, key)
+		}
+	}
+	c.lastSweep = now
+}
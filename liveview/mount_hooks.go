@@ -0,0 +1,54 @@
+package liveview
+
+import "github.com/gin-gonic/gin"
+
+// MountHook runs before every component mounts, on the first page load and again when its
+// WebSocket joins, e.g. to assign the current user, locale or tenant in one place
+// c wraps the request like an AssignFunc's and carries the values the route's middleware
+// set with c.Set; it must not be used to write a response. Return ErrUnauthorized to
+// refuse the mount, or call socket.Redirect and return ErrHalt to send the visitor
+// elsewhere:
+//
+//	h.OnMount(func(socket *liveview.Socket, c *gin.Context) error {
+//		user, ok := c.Get("user")
+//		if !ok {
+//			socket.Redirect("/login")
+//			return liveview.ErrHalt
+//		}
+//		socket.Assigns["current_user"] = user
+//		return nil
+//	})
+type MountHook func(socket *Socket, c *gin.Context) error
+
+// OnMount adds a hook run before every component mounts, in registration order
+// Hooks run after authorization and the app assigns, and before the route's default
+// assigns, so those can build on what the hooks assigned
+func (h *Handler) OnMount(hook MountHook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.mountHooks = append(h.mountHooks, hook)
+}
+
+// runMountHooks runs the mount hooks on a socket, stopping at the first error
+func (h *Handler) runMountHooks(c *gin.Context, socket *Socket) error {
+	h.mu.RLock()
+	hooks := h.mountHooks
+	h.mu.RUnlock()
+	for _, hook := range hooks {
+		if err := hook(socket, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mountContext returns the context mount hooks and assign funcs see: a copy of the entry
+// point's context, if any, with the socket's request carrying the page's query parameters
+func mountContext(c *gin.Context, socket *Socket) *gin.Context {
+	if c == nil {
+		return &gin.Context{Request: routeRequest(socket)}
+	}
+	cp := c.Copy()
+	cp.Request = routeRequest(socket)
+	return cp
+}
@@ -0,0 +1,109 @@
+package liveview
+
+import (
+	"html/template"
+	"strings"
+)
+
+// MetaTag is a <meta> tag rendered into the page head
+// Name is used for name="..." tags and Property for Open Graph property="..." tags
+type MetaTag struct {
+	Name     string
+	Property string
+	Content  string
+}
+
+// OpenGraph holds common Open Graph properties
+type OpenGraph struct {
+	Title       string
+	Description string
+	Image       string
+	URL         string
+	Type        string
+	SiteName    string
+}
+
+// pageMeta tracks the document title and meta tags of a socket
+type pageMeta struct {
+	title        string
+	titleChanged bool
+	tags         []MetaTag
+}
+
+// SetTitle sets the document title
+// It is used in the initial page render and updated live on connected sockets
+func (s *Socket) SetTitle(title string) {
+	if s.page.title == title {
+		return
+	}
+	s.page.title = title
+	s.page.titleChanged = true
+}
+
+// Title returns the document title set with SetTitle
+func (s *Socket) Title() string {
+	return s.page.title
+}
+
+// PutMeta sets a name="..." meta tag, replacing any previous value
+func (s *Socket) PutMeta(name, content string) {
+	s.putMetaTag(MetaTag{Name: name, Content: content})
+}
+
+// PutOpenGraph sets the Open Graph properties that are not empty
+func (s *Socket) PutOpenGraph(og OpenGraph) {
+	props := []struct{ key, value string }{
+		{"og:title", og.Title},
+		{"og:description", og.Description},
+		{"og:image", og.Image},
+		{"og:url", og.URL},
+		{"og:type", og.Type},
+		{"og:site_name", og.SiteName},
+	}
+	for _, p := range props {
+		if p.value != "" {
+			s.putMetaTag(MetaTag{Property: p.key, Content: p.value})
+		}
+	}
+}
+
+// MetaTags returns the meta tags set on the socket in insertion order
+func (s *Socket) MetaTags() []MetaTag {
+	return s.page.tags
+}
+
+// putMetaTag adds or replaces a meta tag with the same name or property
+func (s *Socket) putMetaTag(tag MetaTag) {
+	for i, existing := range s.page.tags {
+		if existing.Name == tag.Name && existing.Property == tag.Property {
+			s.page.tags[i] = tag
+			return
+		}
+	}
+	s.page.tags = append(s.page.tags, tag)
+}
+
+// takeTitleChange returns the new title if it changed since the last call
+func (s *Socket) takeTitleChange() (string, bool) {
+	if !s.page.titleChanged {
+		return "", false
+	}
+	s.page.titleChanged = false
+	return s.page.title, true
+}
+
+// renderMetaTags renders meta tags as escaped HTML
+func renderMetaTags(tags []MetaTag) template.HTML {
+	var b strings.Builder
+	for _, tag := range tags {
+		b.WriteString(`<meta `)
+		if tag.Property != "" {
+			b.WriteString(`property="` + template.HTMLEscapeString(tag.Property) + `"`)
+		} else {
+			b.WriteString(`name="` + template.HTMLEscapeString(tag.Name) + `"`)
+		}
+		b.WriteString(` content="` + template.HTMLEscapeString(tag.Content) + `">`)
+		b.WriteString("\n")
+	}
+	return template.HTML(b.String())
+}
@@ -0,0 +1,83 @@
+package liveview
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Payload wraps an event payload with typed accessors
+// Values from lv-value-* attributes arrive as strings while JSON numbers arrive
+// as float64; the accessors accept either so handlers don't have to care:
+//
+//	id, ok := liveview.Payload(payload).Int("id")
+type Payload map[string]interface{}
+
+// String returns a value as a string, formatting numbers and booleans
+func (p Payload) String(key string) (string, bool) {
+	switch v := p[key].(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(v), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case nil:
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// Int returns a value as an int, parsing strings and truncating whole floats
+func (p Payload) Int(key string) (int, bool) {
+	switch v := p[key].(type) {
+	case float64:
+		if v != float64(int(v)) {
+			return 0, false
+		}
+		return int(v), true
+	case int:
+		return v, true
+	case string:
+		n, err := strconv.Atoi(v)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// Float returns a value as a float64, parsing strings
+func (p Payload) Float(key string) (float64, bool) {
+	switch v := p[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// Bool returns a value as a bool, parsing "true"/"false" style strings
+func (p Payload) Bool(key string) (bool, bool) {
+	switch v := p[key].(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	default:
+		return false, false
+	}
+}
+
+// Has reports whether the payload contains a key
+func (p Payload) Has(key string) bool {
+	_, ok := p[key]
+	return ok
+}
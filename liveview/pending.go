@@ -0,0 +1,99 @@
+package liveview
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultPendingSocketTTL is how long a pre-rendered socket waits for its WebSocket join
+const DefaultPendingSocketTTL = time.Minute
+
+// pendingSocket is a socket ID handed out with a server render, waiting for the
+// browser to join with it
+type pendingSocket struct {
+	component   string
+	componentID string
+	requestID   string // of the page request, so the socket can be traced from the page load
+	expires     time.Time
+}
+
+// pendingSockets tracks the socket IDs of server renders until they are joined
+// Pages that are never connected, e.g. fetched by crawlers or closed before the script
+// loads, leave entries behind that expire after the TTL
+type pendingSockets struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]pendingSocket
+	expired   uint64
+	lastSweep time.Time
+}
+
+// newPendingSockets returns an empty table
+func newPendingSockets() *pendingSockets {
+	return &pendingSockets{ttl: DefaultPendingSocketTTL, entries: make(map[string]pendingSocket)}
+}
+
+// add records the socket ID of a server render, dropping expired entries now and then
+func (p *pendingSockets) add(socketID, componentName string, socket *Socket) {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if now.Sub(p.lastSweep) > p.ttl/4 {
+		p.sweep(now)
+	}
+	p.entries[socketID] = pendingSocket{component: componentName, componentID: socket.ComponentID, requestID: socket.RequestID, expires: now.Add(p.ttl)}
+}
+
+// claim removes the entry of a socket ID and returns it when it is still valid for the
+// component; a join with an unknown ID, e.g. after a restart, mounts as before
+func (p *pendingSockets) claim(socketID, componentName string) (pendingSocket, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.entries[socketID]
+	if !ok {
+		return pendingSocket{}, false
+	}
+	delete(p.entries, socketID)
+	if entry.component != componentName || time.Now().After(entry.expires) {
+		p.expired++
+		return pendingSocket{}, false
+	}
+	return entry, true
+}
+
+// sweep drops expired entries; p.mu must be held
+func (p *pendingSockets) sweep(now time.Time) {
+	for id, entry := range p.entries {
+		if now.After(entry.expires) {
+			delete(p.entries, id)
+			p.expired++
+		}
+	}
+	p.lastSweep = now
+}
+
+// stats returns the number of waiting entries and of those that expired unclaimed
+func (p *pendingSockets) stats() (pending int, expired uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sweep(time.Now())
+	return len(p.entries), p.expired
+}
+
+// SetPendingSocketTTL sets how long the socket ID of a server-rendered page is kept for
+// its WebSocket join; unclaimed IDs are dropped after it. Defaults to DefaultPendingSocketTTL
+func (h *Handler) SetPendingSocketTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultPendingSocketTTL
+	}
+	h.pending.mu.Lock()
+	defer h.pending.mu.Unlock()
+	h.pending.ttl = ttl
+}
+
+// issueSocketID generates the socket ID of a server render and keeps it for the join
+func (h *Handler) issueSocketID(componentName string, socket *Socket) string {
+	socketID := generateSocketID()
+	h.pending.add(socketID, componentName, socket)
+	return socketID
+}
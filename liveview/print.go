@@ -0,0 +1,135 @@
+package liveview
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RenderMode selects the variant a component renders
+type RenderMode string
+
+// Render modes
+const (
+	RenderScreen RenderMode = ""      // the interactive page
+	RenderPrint  RenderMode = "print" // a printable variant without the live runtime or page styles
+)
+
+// PrintParam is the query parameter that serves a page in print mode, e.g. /invoice/7?print=1
+const PrintParam = "print"
+
+// PrintingAssign is the assign set to true while a socket renders in print mode, so
+// templates can write {{if .printing}}
+const PrintingAssign = "printing"
+
+// Printing reports whether the socket renders its printable variant
+func (s *Socket) Printing() bool {
+	return s.RenderMode == RenderPrint
+}
+
+// Print opens the browser's print dialog for the printable variant of the current page
+// once the current event is handled. The page is loaded again with ?print=1 in a hidden
+// frame, so state the component keeps outside the URL isn't part of the printout
+func (s *Socket) Print() {
+	s.print = true
+}
+
+// addPrintToData adds a pending print request to render data
+func (h *Handler) addPrintToData(socket *Socket, data map[string]interface{}) {
+	if socket.print {
+		socket.print = false
+		data["print"] = true
+	}
+}
+
+// syncPrintingAssign exposes the render mode to templates before a render
+func syncPrintingAssign(socket *Socket) {
+	if socket.Printing() {
+		socket.Assigns[PrintingAssign] = true
+	} else {
+		delete(socket.Assigns, PrintingAssign)
+	}
+}
+
+// printRequested reports whether a page request asks for the printable variant
+func printRequested(c *gin.Context) bool {
+	switch c.Query(PrintParam) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
+
+// SetPrintLayout sets the layout of pages served in print mode
+// It receives the same PageData, but LiveView, Assets and Flashes are empty: the page
+// is static and carries none of the app's stylesheets
+func (h *Handler) SetPrintLayout(layout Layout) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.printLayout = layout
+}
+
+// servePrintPage renders a mounted component as a static printable page
+func (h *Handler) servePrintPage(c *gin.Context, componentName string, component Component, socket *Socket) {
+	html, err := h.renderComponent(componentName, "mount", component, socket)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Render failed"})
+		return
+	}
+
+	page := h.newPageData(componentName, html, "", socket, "")
+	page.LiveView = ""
+	page.Assets = ""
+
+	h.mu.RLock()
+	layout := h.printLayout
+	strictCSP := h.strictCSP
+	h.mu.RUnlock()
+	if layout == nil {
+		layout = DefaultPrintLayout()
+	}
+	if strictCSP {
+		c.Header("Content-Security-Policy", strictCSPHeader(socket.Nonce))
+	}
+	// Printouts of private data shouldn't be indexed or cached by proxies
+	c.Header("X-Robots-Tag", "noindex")
+	c.Header("Cache-Control", "private, no-store")
+
+	var buf bytes.Buffer
+	if err := layout.RenderLayout(&buf, page); err != nil {
+		h.log().Error("Print layout error", "component", componentName, "error", err)
+		c.JSON(500, gin.H{"error": "Render failed"})
+		return
+	}
+	c.Data(200, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// defaultPrintLayoutTemplate is the built-in printable page: black on white, no scripts
+var defaultPrintLayoutTemplate = template.Must(template.New("print").Parse(`<!DOCTYPE html>
+<html lang="{{if .Locale}}{{.Locale}}{{else}}en{{end}}">
+<head>
+    <meta charset="UTF-8">
+    <meta name="robots" content="noindex">
+    <title>{{.Title}}</title>
+    <style{{if .Nonce}} nonce="{{.Nonce}}"{{end}}>
+        body { margin: 0 auto; max-width: 50rem; padding: 1rem; font: 11pt/1.4 Georgia, "Times New Roman", serif; color: #000; background: #fff; }
+        table { border-collapse: collapse; width: 100%; }
+        th, td { border-bottom: 1px solid #999; padding: 4pt 6pt; text-align: left; }
+        a { color: inherit; }
+        button, input[type=submit], .lv-print-hide { display: none !important; }
+        @page { margin: 15mm; }
+    </style>
+</head>
+<body>
+{{.Content}}
+</body>
+</html>`))
+
+// DefaultPrintLayout returns the built-in print layout
+func DefaultPrintLayout() Layout {
+	return LayoutFunc(func(w io.Writer, page PageData) error {
+		return defaultPrintLayoutTemplate.Execute(w, page)
+	})
+}
@@ -0,0 +1,61 @@
+package liveview
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// socketProfile is a pprof profile of the connected sockets, recorded where they were mounted
+// It is served as /debug/pprof/livenest.sockets when profiling endpoints are mounted
+var socketProfile = pprof.NewProfile("livenest.sockets")
+
+// SetProfiling enables profiler labels and the livenest.sockets profile
+// With profiling on, connection goroutines are labelled livenest=conn and CPU samples
+// taken while handling an event or rendering carry component and event labels, so
+// `go tool pprof -tagfocus` can narrow a profile to one component's hot path
+func (h *Handler) SetProfiling(enabled bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.profiling = enabled
+}
+
+// isProfiling reports whether profiler labels are enabled
+func (h *Handler) isProfiling() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.profiling
+}
+
+// labelConn labels the current connection goroutine for goroutine profiles
+// It returns the labelled context, which later labels are added to
+func (h *Handler) labelConn(ctx context.Context) context.Context {
+	if !h.isProfiling() {
+		return ctx
+	}
+	ctx = pprof.WithLabels(ctx, pprof.Labels("livenest", "conn"))
+	pprof.SetGoroutineLabels(ctx)
+	return ctx
+}
+
+// profiled runs fn with profiler labels naming the component and event
+func (h *Handler) profiled(ctx context.Context, componentName, event string, fn func()) {
+	if !h.isProfiling() {
+		fn()
+		return
+	}
+	pprof.Do(ctx, pprof.Labels("livenest.component", componentName, "livenest.event", event), func(context.Context) {
+		fn()
+	})
+}
+
+// trackSocket adds a mounted socket to the livenest.sockets profile
+func (h *Handler) trackSocket(socket *Socket) {
+	if h.isProfiling() {
+		socketProfile.Add(socket, 2)
+	}
+}
+
+// untrackSocket removes a socket from the livenest.sockets profile
+func untrackSocket(socket *Socket) {
+	socketProfile.Remove(socket)
+}
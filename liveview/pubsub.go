@@ -0,0 +1,69 @@
+package liveview
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/paulmanoni/livenest/pubsub"
+)
+
+// subscriptionCursor prefixes the cursors of subscriptions, apart from stream containers
+const subscriptionCursor = "pubsub:"
+
+// Subscribe calls fn with the messages published on topic for as long as the connection
+// lasts; fn runs on the connection goroutine and the component re-renders after it
+// Topics throttled with Handler.ThrottleTopic deliver at most their rate of messages
+// The client keeps the sequence number of the last message it was sent, so after a
+// reconnect the messages published meanwhile are replayed first, as long as the bus still
+// retains them. Call it from Mount; like StartStream it reports false without a live
+// connection, and CancelAsync("pubsub:"+topic) unsubscribes
+//
+//	socket.Subscribe(app.PubSub(), "orders", func(s *liveview.Socket, msg pubsub.Message) {
+//		var order Order
+//		msg.Decode(&order)
+//		s.StreamAppend("orders", renderOrder(order))
+//	})
+func (s *Socket) Subscribe(ps pubsub.PubSub, topic string, fn func(*Socket, pubsub.Message)) bool {
+	key := subscriptionCursor + topic
+	after := ps.Seq()
+	// A cursor ahead of the bus is from before the bus lost its messages, e.g. an in-memory
+	// bus after a restart, so only new messages are delivered
+	if cursor, err := strconv.ParseUint(s.cursors[key], 10, 64); err == nil && cursor <= after {
+		after = cursor
+	}
+
+	throttles := s.throttles
+	started := s.StartStream(key, func(ctx context.Context, push func(func(*Socket))) {
+		deliver := func(msg pubsub.Message) {
+			push(func(s *Socket) {
+				fn(s, msg)
+				s.sendCursor(key, strconv.FormatUint(msg.Seq, 10))
+			})
+		}
+		// Throttle rules of the topic apply to every message, as they are when it arrives
+		if throttles != nil {
+			throttle := &subscriptionThrottle{rules: throttles, topic: topic, deliver: deliver}
+			defer throttle.stop()
+			deliver = throttle.receive
+		}
+		ps.Subscribe(ctx, topic, after, deliver)
+	})
+	if started {
+		// The client learns the position it joined at, so messages published before
+		// the first one it receives are replayed too
+		s.sendCursor(key, strconv.FormatUint(after, 10))
+	}
+	return started
+}
+
+// sendCursor records a subscription cursor and queues it for the client
+func (s *Socket) sendCursor(key, cursor string) {
+	if s.cursors == nil {
+		s.cursors = make(map[string]string)
+	}
+	s.cursors[key] = cursor
+	if s.sentCursors == nil {
+		s.sentCursors = make(map[string]string)
+	}
+	s.sentCursors[key] = cursor
+}
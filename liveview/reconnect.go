@@ -0,0 +1,39 @@
+package liveview
+
+import (
+	"strconv"
+	"time"
+)
+
+// ReconnectPolicy controls how the client reconnects after its WebSocket drops
+// Delays grow exponentially from MinDelay to MaxDelay with random jitter; zero values keep the client defaults
+type ReconnectPolicy struct {
+	MinDelay    time.Duration // Delay before the first retry (client default 500ms)
+	MaxDelay    time.Duration // Upper bound for the delay between retries (client default 30s)
+	MaxAttempts int           // Retries before the client gives up; 0 retries forever
+}
+
+// SetReconnectPolicy sets the reconnect backoff used by the client
+func (h *Handler) SetReconnectPolicy(policy ReconnectPolicy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.reconnect = policy
+}
+
+// reconnectAttrs returns the container attributes that configure client reconnects
+func (h *Handler) reconnectAttrs() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var attrs string
+	if h.reconnect.MinDelay > 0 {
+		attrs += ` data-reconnect-min="` + strconv.FormatInt(h.reconnect.MinDelay.Milliseconds(), 10) + `"`
+	}
+	if h.reconnect.MaxDelay > 0 {
+		attrs += ` data-reconnect-max="` + strconv.FormatInt(h.reconnect.MaxDelay.Milliseconds(), 10) + `"`
+	}
+	if h.reconnect.MaxAttempts > 0 {
+		attrs += ` data-reconnect-attempts="` + strconv.Itoa(h.reconnect.MaxAttempts) + `"`
+	}
+	return attrs
+}
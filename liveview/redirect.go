@@ -0,0 +1,61 @@
+package liveview
+
+import (
+	"net/url"
+	"strings"
+)
+
+// redirect is a navigation requested by a handler
+type redirect struct {
+	to       string
+	external bool
+}
+
+// Redirect navigates the browser to a path of this site once the current event is handled
+// Only local paths such as "/dashboard" are accepted, so a redirect built from user input
+// can't send people to another site; use ExternalRedirect for that. Flashes set in the
+// same event are shown on the page redirected to
+func (s *Socket) Redirect(to string) {
+	if !isLocalPath(to) {
+		s.log().Warn("Redirect ignored: not a local path", "to", to)
+		return
+	}
+	s.redirect = &redirect{to: to}
+}
+
+// ExternalRedirect navigates the browser to an absolute http or https URL, e.g. a payment provider
+func (s *Socket) ExternalRedirect(to string) {
+	u, err := url.Parse(to)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		s.log().Warn("External redirect ignored: not an absolute http(s) URL", "to", to)
+		return
+	}
+	s.redirect = &redirect{to: to, external: true}
+}
+
+// takeRedirect returns and clears the pending redirect
+func (s *Socket) takeRedirect() *redirect {
+	r := s.redirect
+	s.redirect = nil
+	return r
+}
+
+// isLocalPath reports whether to is a path on this site
+// Protocol-relative URLs ("//host"), backslash tricks ("/\host") and control characters
+// are rejected; browsers drop tabs and newlines, which turns "/\t/host" into "//host"
+func isLocalPath(to string) bool {
+	if strings.IndexFunc(to, func(r rune) bool { return r < 0x20 || r == 0x7f }) >= 0 {
+		return false
+	}
+	return strings.HasPrefix(to, "/") && !strings.HasPrefix(to, "//") && !strings.HasPrefix(to, "/\\")
+}
+
+// addRedirectToData adds a pending redirect to render data
+func (h *Handler) addRedirectToData(socket *Socket, data map[string]interface{}) {
+	if r := socket.takeRedirect(); r != nil {
+		data["redirect"] = map[string]interface{}{
+			"to":       r.to,
+			"external": r.external,
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package liveview
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session recording and replay
+//
+// A recording captures the events a real user sent to a component. Replaying it
+// across many simulated sockets benchmarks the component's event and render path
+// in-process, without browsers or network.
+
+// RecordedEvent is a client event captured from a live session
+type RecordedEvent struct {
+	Event   string                 `json:"event"`
+	Payload map[string]interface{} `json:"payload"`
+	Offset  time.Duration          `json:"offset"` // time since the component was mounted
+}
+
+// Recording is a captured user session of one component
+// Payloads are stored as sent, including anything the user typed
+type Recording struct {
+	Component  string          `json:"component"`
+	Params     url.Values      `json:"params,omitempty"`
+	RecordedAt time.Time       `json:"recorded_at"`
+	Events     []RecordedEvent `json:"events"`
+}
+
+// RecordSessions captures the sessions of a registered component
+// save is called on its own goroutine with every session that sent events, once it ends
+func (h *Handler) RecordSessions(componentName string, save func(*Recording)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.recorders == nil {
+		h.recorders = make(map[string]func(*Recording))
+	}
+	h.recorders[componentName] = save
+}
+
+// recorderFor returns the save function for a component's sessions, if recording
+func (h *Handler) recorderFor(componentName string) func(*Recording) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.recorders[componentName]
+}
+
+// record appends a client event to a recording in progress
+func (r *Recording) record(msg Message) {
+	r.Events = append(r.Events, RecordedEvent{
+		Event:   msg.Event,
+		Payload: msg.Payload,
+		Offset:  time.Since(r.RecordedAt),
+	})
+}
+
+// SaveRecordingsTo returns a save function for RecordSessions that writes each
+// recording as a JSON file to dir
+func SaveRecordingsTo(dir string) func(*Recording) {
+	return func(rec *Recording) {
+		name := fmt.Sprintf("%s-%d.json", rec.Component, rec.RecordedAt.UnixNano())
+		data, err := json.MarshalIndent(rec, "", "  ")
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, name), data, 0o600)
+		}
+		if err != nil {
+			slog.Error("Session recording error", "component", rec.Component, "error", err)
+		}
+	}
+}
+
+// LoadRecording reads a recording written by SaveRecordingsTo
+func LoadRecording(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// ReplayOptions configures a replay benchmark
+type ReplayOptions struct {
+	Sockets int     // simulated sockets replaying the session concurrently; defaults to 1
+	Speed   float64 // 1 keeps the recorded pacing, 2 replays twice as fast; 0 sends events back to back
+}
+
+// LatencyStats summarizes measured durations
+type LatencyStats struct {
+	Count int
+	Mean  time.Duration
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// ReplayReport is the result of a replay benchmark
+type ReplayReport struct {
+	Component string
+	Sockets   int
+	Events    int // events replayed across all sockets
+	Errors    int // failed mounts and events
+	Duration  time.Duration
+	Mount     LatencyStats            // mount and first render
+	Overall   LatencyStats            // every event, including its re-render
+	ByEvent   map[string]LatencyStats // per event name
+}
+
+// EventsPerSecond returns the replayed event throughput
+func (r ReplayReport) EventsPerSecond() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Events) / r.Duration.Seconds()
+}
+
+// String formats the report as a table
+func (r ReplayReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d sockets, %d events, %d errors in %s (%.0f events/s)\n",
+		r.Component, r.Sockets, r.Events, r.Errors, r.Duration.Round(time.Millisecond), r.EventsPerSecond())
+	fmt.Fprintf(&b, "%-20s %8s %10s %10s %10s %10s\n", "", "count", "p50", "p95", "p99", "max")
+	row := func(name string, s LatencyStats) {
+		fmt.Fprintf(&b, "%-20s %8d %10s %10s %10s %10s\n", name, s.Count, s.P50, s.P95, s.P99, s.Max)
+	}
+	row("mount", r.Mount)
+	row("all events", r.Overall)
+
+	names := make([]string, 0, len(r.ByEvent))
+	for name := range r.ByEvent {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		row(name, r.ByEvent[name])
+	}
+	return b.String()
+}
+
+// Replay replays a recording across simulated sockets and measures every mount and event
+// Each socket mounts the component, then sends the recorded events in order and re-renders
+// after each one, like a connected browser would. Authorization policies and event hooks run
+// as usual; the simulated requests come from distinct loopback addresses
+func (h *Handler) Replay(ctx context.Context, rec *Recording, opts ReplayOptions) (ReplayReport, error) {
+	h.mu.RLock()
+	component, exists := h.components[rec.Component]
+	h.mu.RUnlock()
+	if !exists {
+		return ReplayReport{}, fmt.Errorf("component %q not found", rec.Component)
+	}
+
+	if opts.Sockets <= 0 {
+		opts.Sockets = 1
+	}
+
+	var (
+		mu      sync.Mutex
+		mounts  []time.Duration
+		all     []time.Duration
+		byEvent = make(map[string][]time.Duration)
+		errors  int
+		wg      sync.WaitGroup
+	)
+
+	start := time.Now()
+	for i := 0; i < opts.Sockets; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					h.log().Error("Replay panic", "socket", i, "panic", r)
+					mu.Lock()
+					errors++
+					mu.Unlock()
+				}
+			}()
+			result := h.replaySocket(ctx, rec, component, i, opts.Speed)
+
+			mu.Lock()
+			defer mu.Unlock()
+			errors += result.errors
+			if result.mounted {
+				mounts = append(mounts, result.mount)
+			}
+			for _, e := range result.events {
+				all = append(all, e.took)
+				byEvent[e.name] = append(byEvent[e.name], e.took)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	report := ReplayReport{
+		Component: rec.Component,
+		Sockets:   opts.Sockets,
+		Events:    len(all),
+		Errors:    errors,
+		Duration:  time.Since(start),
+		Mount:     latencyStats(mounts),
+		Overall:   latencyStats(all),
+		ByEvent:   make(map[string]LatencyStats, len(byEvent)),
+	}
+	for name, durations := range byEvent {
+		report.ByEvent[name] = latencyStats(durations)
+	}
+	return report, ctx.Err()
+}
+
+// replayResult holds the measurements of one simulated socket
+type replayResult struct {
+	mounted bool
+	mount   time.Duration
+	events  []replayedEvent
+	errors  int
+}
+
+// replayedEvent is the measured duration of one replayed event
+type replayedEvent struct {
+	name string
+	took time.Duration
+}
+
+// replaySocket mounts a simulated socket and sends it the recorded events
+func (h *Handler) replaySocket(ctx context.Context, rec *Recording, component Component, index int, speed float64) replayResult {
+	var result replayResult
+
+	socket := h.newSocket(fmt.Sprintf("replay-%d", index))
+	socket.Params = rec.Params
+	socket.Request = &http.Request{
+		Method:     http.MethodGet,
+		URL:        &url.URL{Path: "/", RawQuery: rec.Params.Encode()},
+		Header:     make(http.Header),
+		RemoteAddr: fmt.Sprintf("127.%d.%d.%d:0", index>>16&0xff, index>>8&0xff, index&0xff),
+	}
+	socketCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	socket.ctx = socketCtx
+
+	began := time.Now()
+	if err := h.authorize(rec.Component, component, socket, ""); err != nil {
+		result.errors++
+		return result
+	}
+	if err := h.mount(rec.Component, component, socket, nil); err != nil {
+		result.errors++
+		return result
+	}
+	html, err := component.Render(socket)
+	if err != nil {
+		result.errors++
+		return result
+	}
+	socket.previousHTML = string(html)
+	result.mounted = true
+	result.mount = time.Since(began)
+
+	for _, event := range rec.Events {
+		if speed > 0 {
+			wait := time.Duration(float64(event.Offset)/speed) - time.Since(began)
+			if wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					return result
+				}
+			}
+		}
+		if ctx.Err() != nil {
+			return result
+		}
+
+		// Handlers may modify the payload, so each socket gets its own copy
+		payload := make(map[string]interface{}, len(event.Payload))
+		for k, v := range event.Payload {
+			payload[k] = v
+		}
+
+		eventStart := time.Now()
+		err := h.authorize(rec.Component, component, socket, event.Event)
+		if err == nil {
+			err = h.runEvent(rec.Component, component, event.Event, payload, socket)
+		}
+		if err == nil {
+			h.renderUpdate(rec.Component, event.Event, component, socket)
+		}
+		result.events = append(result.events, replayedEvent{name: event.Event, took: time.Since(eventStart)})
+		if err != nil {
+			result.errors++
+		}
+	}
+	return result
+}
+
+// latencyStats computes percentiles of a set of durations
+func latencyStats(durations []time.Duration) LatencyStats {
+	if len(durations) == 0 {
+		return LatencyStats{}
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	percentile := func(p float64) time.Duration {
+		return sorted[int(float64(len(sorted)-1)*p)]
+	}
+	return LatencyStats{
+		Count: len(sorted),
+		Mean:  total / time.Duration(len(sorted)),
+		P50:   percentile(0.50),
+		P95:   percentile(0.95),
+		P99:   percentile(0.99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
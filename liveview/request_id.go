@@ -0,0 +1,56 @@
+package liveview
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+// requestIDContextKey is the context key of a request's ID
+type requestIDContextKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID of its request, so the sockets the
+// request opens log it and a visitor can be traced from the page load to its WebSocket
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFrom returns the request ID carried by ctx, or ""
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// requestID returns the ID of a request, or ""
+func requestID(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	return RequestIDFrom(r.Context())
+}
+
+// argsLogger adds key-value pairs to every record of a logger
+type argsLogger struct {
+	logger Logger
+	args   []any
+}
+
+// Debug logs at debug level with the logger's pairs
+func (l argsLogger) Debug(msg string, args ...any) {
+	l.logger.Debug(msg, slices.Concat(args, l.args)...)
+}
+
+// Info logs at info level with the logger's pairs
+func (l argsLogger) Info(msg string, args ...any) {
+	l.logger.Info(msg, slices.Concat(args, l.args)...)
+}
+
+// Warn logs at warn level with the logger's pairs
+func (l argsLogger) Warn(msg string, args ...any) {
+	l.logger.Warn(msg, slices.Concat(args, l.args)...)
+}
+
+// Error logs at error level with the logger's pairs
+func (l argsLogger) Error(msg string, args ...any) {
+	l.logger.Error(msg, slices.Concat(args, l.args)...)
+}
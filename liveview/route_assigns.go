@@ -0,0 +1,52 @@
+package liveview
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AssignFunc returns default assigns for a socket of a route, e.g. the current user or
+// feature flags, computed from the request
+// On the first page load c wraps the page request; when the WebSocket joins, it wraps the
+// upgrade request, with the page's query parameters, so c.Query reads the same values.
+// Either way it carries the values the route's middleware set with c.Set
+type AssignFunc func(c *gin.Context) map[string]interface{}
+
+// RegisterAssigns adds default assigns for a registered component name
+// They are set before Mount, in registration order, after the app assigns, so Mount can
+// still override them
+func (h *Handler) RegisterAssigns(name string, fn AssignFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.routeAssigns == nil {
+		h.routeAssigns = make(map[string][]AssignFunc)
+	}
+	h.routeAssigns[name] = append(h.routeAssigns[name], fn)
+}
+
+// assignRouteDefaults sets the default assigns registered for name on a socket
+func (h *Handler) assignRouteDefaults(name string, c *gin.Context, socket *Socket) {
+	h.mu.RLock()
+	funcs := h.routeAssigns[name]
+	h.mu.RUnlock()
+
+	for _, fn := range funcs {
+		for key, value := range fn(c) {
+			socket.Assigns[key] = value
+		}
+	}
+}
+
+// routeRequest returns the request assign funcs see: the socket's request carrying the
+// page's query parameters
+func routeRequest(socket *Socket) *http.Request {
+	r := socket.Request
+	if r == nil {
+		r = (&http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/"}, Header: make(http.Header)}).WithContext(socket.Context())
+	}
+	r = r.Clone(r.Context())
+	r.URL.RawQuery = socket.Params.Encode()
+	return r
+}
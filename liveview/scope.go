@@ -0,0 +1,190 @@
+package liveview
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"reflect"
+	"strings"
+)
+
+// Scope prefixes the CSS class names of a component with a hash of its identity, so
+// generic names like .message or .btn in its stylesheet can't clash with other
+// components on the page. Templates write {{scope "message"}} instead of the class name,
+// and the component declares its stylesheet with Scope.CSS, which rewrites the class
+// selectors the same way when the bundle is built
+//
+//	var chatScope = liveview.ScopeOf(&ChatComponent{})
+//
+//	func (c *ChatComponent) Assets() []liveview.Asset {
+//		return []liveview.Asset{chatScope.CSS("assets/chat.css")}
+//	}
+//
+//	tmpl := template.Must(template.New("chat").Funcs(chatScope.Funcs()).Parse(`<p class="{{scope "message"}}">...`))
+type Scope string
+
+// NewScope returns the scope of a name; the same name always gives the same scope
+func NewScope(name string) Scope {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return Scope(fmt.Sprintf("lv%06x", h.Sum32()&0xffffff))
+}
+
+// ScopeOf returns the scope of a component's type, unique across packages
+func ScopeOf(component interface{}) Scope {
+	t := reflect.TypeOf(component)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return NewScope(t.PkgPath() + "." + t.Name())
+}
+
+// Class returns the scoped form of space-separated class names
+func (s Scope) Class(names string) string {
+	fields := strings.Fields(names)
+	for i, name := range fields {
+		fields[i] = string(s) + "-" + name
+	}
+	return strings.Join(fields, " ")
+}
+
+// Funcs returns the template helper "scope", which scopes class names like Class
+func (s Scope) Funcs() template.FuncMap {
+	return template.FuncMap{"scope": s.Class}
+}
+
+// CSS declares a stylesheet file whose class selectors are scoped
+// Selectors wrapped in :global(...) are left as they are
+func (s Scope) CSS(path string) Asset {
+	return Asset{Kind: AssetCSS, Path: path, Scope: s}
+}
+
+// InlineCSS declares CSS given as source whose class selectors are scoped
+func (s Scope) InlineCSS(source string) Asset {
+	return Asset{Kind: AssetCSS, Source: source, Scope: s}
+}
+
+// scopeCSS rewrites the class selectors of a stylesheet; declarations, at-rule preludes,
+// comments and strings are copied as they are
+func scopeCSS(css string, scope Scope) string {
+	var out strings.Builder
+	// blocks holds, for each open brace, whether it contains rules rather than declarations
+	blocks := []bool{true}
+	start := 0 // start of the pending prelude
+	for i := 0; i < len(css); i++ {
+		switch c := css[i]; {
+		case c == '/' && i+1 < len(css) && css[i+1] == '*':
+			end := strings.Index(css[i+2:], "*/")
+			if end < 0 {
+				i = len(css) - 1
+			} else {
+				i += end + 3
+			}
+		case c == '"' || c == '\'':
+			i = skipCSSString(css, i)
+		case c == '{':
+			prelude := css[start:i]
+			inRules := blocks[len(blocks)-1]
+			if inRules && !strings.HasPrefix(strings.TrimSpace(prelude), "@") {
+				out.WriteString(scopeSelector(prelude, scope))
+				blocks = append(blocks, false)
+			} else {
+				out.WriteString(prelude)
+				// @media, @supports and @layer hold rules; @font-face, @page and
+				// keyframe steps hold declarations, which have no classes to scope
+				name := strings.TrimSpace(prelude)
+				blocks = append(blocks, inRules && (strings.HasPrefix(name, "@media") ||
+					strings.HasPrefix(name, "@supports") || strings.HasPrefix(name, "@layer") ||
+					strings.HasPrefix(name, "@container")))
+			}
+			out.WriteByte('{')
+			start = i + 1
+		case c == '}':
+			out.WriteString(css[start : i+1])
+			if len(blocks) > 1 {
+				blocks = blocks[:len(blocks)-1]
+			}
+			start = i + 1
+		case c == ';' && blocks[len(blocks)-1]:
+			// A statement at-rule such as @import
+			out.WriteString(css[start : i+1])
+			start = i + 1
+		}
+	}
+	out.WriteString(css[start:])
+	return out.String()
+}
+
+// scopeSelector prefixes the class names of a selector list, except inside :global(...),
+// attribute selectors, strings and comments
+func scopeSelector(selector string, scope Scope) string {
+	var out strings.Builder
+	for i := 0; i < len(selector); i++ {
+		c := selector[i]
+		switch {
+		case strings.HasPrefix(selector[i:], ":global("):
+			end := matchingParen(selector, i+len(":global"))
+			out.WriteString(selector[i+len(":global(") : end])
+			i = end
+		case strings.HasPrefix(selector[i:], "/*"):
+			end := strings.Index(selector[i:], "*/")
+			if end < 0 {
+				end = len(selector) - i - 2
+			}
+			out.WriteString(selector[i : i+end+2])
+			i += end + 1
+		case c == '[':
+			end := strings.IndexByte(selector[i:], ']')
+			if end < 0 {
+				end = len(selector) - i - 1
+			}
+			out.WriteString(selector[i : i+end+1])
+			i += end
+		case c == '"' || c == '\'':
+			end := skipCSSString(selector, i)
+			out.WriteString(selector[i : end+1])
+			i = end
+		case c == '.' && i+1 < len(selector) && isClassStart(selector[i+1]):
+			out.WriteString("." + string(scope) + "-")
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+// skipCSSString returns the index of the quote closing the string opened at i
+func skipCSSString(s string, i int) int {
+	quote := s[i]
+	for i++; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case quote:
+			return i
+		}
+	}
+	return len(s) - 1
+}
+
+// matchingParen returns the index of the parenthesis closing the one at open
+func matchingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+// isClassStart reports whether c can start a class name
+func isClassStart(c byte) bool {
+	return c == '_' || c == '-' || c == '\\' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
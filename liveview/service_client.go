@@ -0,0 +1,476 @@
+package liveview
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrServiceUnavailable matches the error returned while a service's circuit breaker is open
+var ErrServiceUnavailable = errors.New("service unavailable")
+
+// ServiceUnavailableError is returned without calling a service whose breaker is open
+// Components failing with it show the unavailable state or a warning flash instead of an error
+type ServiceUnavailableError struct {
+	Service string
+	Retry   time.Duration // time until the breaker lets a trial call through
+	Cause   error         // failure that opened the breaker
+}
+
+// Error describes the outage
+func (e *ServiceUnavailableError) Error() string {
+	return fmt.Sprintf("service %s unavailable: %v", e.Service, e.Cause)
+}
+
+// Is matches ErrServiceUnavailable
+func (e *ServiceUnavailableError) Is(target error) bool {
+	return target == ErrServiceUnavailable
+}
+
+// Unwrap returns the failure that opened the breaker
+func (e *ServiceUnavailableError) Unwrap() error {
+	return e.Cause
+}
+
+// Unavailable marks the error as a temporary outage for IsUnavailable
+func (e *ServiceUnavailableError) Unavailable() bool {
+	return true
+}
+
+// RetryAfter suggests when to try again
+func (e *ServiceUnavailableError) RetryAfter() time.Duration {
+	return e.Retry
+}
+
+// ServiceStatusError is an HTTP response with a server error or 429 status;
+// it counts as a failed call
+type ServiceStatusError struct {
+	Service    string
+	StatusCode int
+}
+
+// Error describes the status
+func (e *ServiceStatusError) Error() string {
+	return fmt.Sprintf("service %s responded %d %s", e.Service, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// ServiceClient calls an external API with a timeout per attempt, retries with
+// backoff and a circuit breaker, and records metrics for /metrics
+// After Threshold consecutive failures the breaker opens and calls fail right away
+// with a ServiceUnavailableError; after Cooldown one trial call is let through and
+// closes the breaker again if it succeeds. Register clients on the handler and
+// reach them from components with socket.Service(name)
+type ServiceClient struct {
+	Name       string
+	BaseURL    string           // prefix of relative request URLs
+	HTTPClient *http.Client     // client for HTTP calls (default http.DefaultClient)
+	Timeout    time.Duration    // bound for one attempt (default 5s)
+	Retries    int              // extra attempts after a retryable failure (default 2)
+	MinDelay   time.Duration    // delay before the first retry, doubled for each one (default 100ms)
+	MaxDelay   time.Duration    // upper bound for the retry delay (default 2s)
+	Threshold  int              // consecutive failures that open the breaker (default 5)
+	Cooldown   time.Duration    // how long the breaker stays open before a trial call (default 30s)
+	Retryable  func(error) bool // whether a failure is retried (default everything but cancellation)
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool  // a trial call is in flight while half-open
+	lastErr   error // failure that opened the breaker
+
+	calls    atomic.Int64
+	failed   atomic.Int64
+	retried  atomic.Int64
+	rejected atomic.Int64
+	latency  *histogram
+}
+
+// NewServiceClient creates a client for a named service
+func NewServiceClient(name, baseURL string) *ServiceClient {
+	return &ServiceClient{
+		Name:      name,
+		BaseURL:   baseURL,
+		Timeout:   5 * time.Second,
+		Retries:   2,
+		MinDelay:  100 * time.Millisecond,
+		MaxDelay:  2 * time.Second,
+		Threshold: 5,
+		Cooldown:  30 * time.Second,
+		latency:   newHistogram(serviceBuckets),
+	}
+}
+
+// ServiceStats counts the calls of a service client
+type ServiceStats struct {
+	Service  string `json:"service"`
+	Calls    int64  `json:"calls"`    // calls made, not counting retries
+	Failed   int64  `json:"failed"`   // calls that failed after their retries
+	Retried  int64  `json:"retried"`  // retries made
+	Rejected int64  `json:"rejected"` // calls failed fast by the open breaker
+	Open     bool   `json:"open"`     // whether the breaker is open
+}
+
+// Stats returns the client's counters
+func (c *ServiceClient) Stats() ServiceStats {
+	c.mu.Lock()
+	open := time.Now().Before(c.openUntil) || c.trial
+	c.mu.Unlock()
+	return ServiceStats{
+		Service:  c.Name,
+		Calls:    c.calls.Load(),
+		Failed:   c.failed.Load(),
+		Retried:  c.retried.Load(),
+		Rejected: c.rejected.Load(),
+		Open:     open,
+	}
+}
+
+// Call runs op under the client's timeout, retries and breaker, e.g. a gRPC call:
+//
+//	err := client.Call(ctx, func(ctx context.Context) error {
+//		resp, err = payments.Charge(ctx, req)
+//		return err
+//	})
+func (c *ServiceClient) Call(ctx context.Context, op func(ctx context.Context) error) error {
+	if err := c.allow(); err != nil {
+		c.rejected.Add(1)
+		return err
+	}
+	c.calls.Add(1)
+
+	start := time.Now()
+	err := c.attempt(ctx, op)
+	for attempt := 0; err != nil && attempt < c.Retries && c.retryable(ctx, err); attempt++ {
+		c.retried.Add(1)
+		select {
+		case <-ctx.Done():
+			return c.record(start, ctx.Err())
+		case <-time.After(c.backoff(attempt)):
+		}
+		err = c.attempt(ctx, op)
+	}
+	return c.record(start, err)
+}
+
+// attempt runs op once within the timeout
+func (c *ServiceClient) attempt(ctx context.Context, op func(ctx context.Context) error) error {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	return op(ctx)
+}
+
+// Do sends an HTTP request; server errors and 429 responses count as failures
+// Only requests with a replayable body (or none) are retried, and only for
+// idempotent methods
+func (c *ServiceClient) Do(req *http.Request) (*http.Response, error) {
+	if c.BaseURL != "" && !req.URL.IsAbs() {
+		u, err := url.Parse(strings.TrimSuffix(c.BaseURL, "/") + "/" + strings.TrimPrefix(req.URL.String(), "/"))
+		if err != nil {
+			return nil, err
+		}
+		req.URL, req.Host = u, u.Host
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var resp *http.Response
+	err := c.Call(req.Context(), func(ctx context.Context) error {
+		attempt := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return err
+			}
+			attempt.Body = body
+		}
+		r, err := client.Do(attempt)
+		if err == nil && (r.StatusCode >= 500 || r.StatusCode == http.StatusTooManyRequests) {
+			io.Copy(io.Discard, r.Body)
+			r.Body.Close()
+			err = &ServiceStatusError{Service: c.Name, StatusCode: r.StatusCode}
+		}
+		if err != nil {
+			if !idempotent(req) {
+				return permanent{err}
+			}
+			return err
+		}
+		// The attempt's context ends when it returns, so the body is read here
+		data, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		resp = r
+		return nil
+	})
+	if p, ok := err.(permanent); ok {
+		err = p.error
+	}
+	return resp, err
+}
+
+// Get fetches a URL, relative to BaseURL
+func (c *ServiceClient) Get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// GetJSON fetches a URL and decodes its JSON body into v; other 4xx statuses are errors
+func (c *ServiceClient) GetJSON(ctx context.Context, rawURL string, v interface{}) error {
+	resp, err := c.Get(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return &ServiceStatusError{Service: c.Name, StatusCode: resp.StatusCode}
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// permanent marks a failure that must not be retried
+type permanent struct{ error }
+
+// Unwrap returns the failure
+func (p permanent) Unwrap() error {
+	return p.error
+}
+
+// idempotent reports whether a request can be sent again safely
+func idempotent(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryable reports whether a failed attempt is tried again
+func (c *ServiceClient) retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if _, ok := err.(permanent); ok {
+		return false
+	}
+	if c.Retryable != nil {
+		return c.Retryable(err)
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// backoff returns the jittered delay before retry attempt+1
+func (c *ServiceClient) backoff(attempt int) time.Duration {
+	delay := c.MinDelay << attempt
+	if delay <= 0 || delay > c.MaxDelay {
+		delay = c.MaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// allow lets a call through unless the breaker is open
+// Once the cooldown has passed, a single trial call is let through
+func (c *ServiceClient) allow() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.openUntil.IsZero() {
+		return nil
+	}
+	now := time.Now()
+	if now.Before(c.openUntil) || c.trial {
+		retry := c.openUntil.Sub(now)
+		if retry <= 0 {
+			retry = c.Cooldown
+		}
+		return &ServiceUnavailableError{Service: c.Name, Retry: retry, Cause: c.lastErr}
+	}
+	c.trial = true
+	return nil
+}
+
+// record updates the metrics and the breaker with the outcome of a call and returns its error
+// Cancelled calls don't count for or against the service
+func (c *ServiceClient) record(start time.Time, err error) error {
+	if err != nil {
+		c.failed.Add(1)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.latency == nil {
+		c.latency = newHistogram(serviceBuckets)
+	}
+	c.latency.observe(time.Since(start).Seconds())
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		c.trial = false
+		return err
+	case err == nil:
+		c.failures = 0
+		c.openUntil = time.Time{}
+		c.trial = false
+		return nil
+	}
+	c.failures++
+	if c.trial || (c.Threshold > 0 && c.failures >= c.Threshold) {
+		c.openUntil = time.Now().Add(c.Cooldown)
+		c.lastErr = err
+		c.trial = false
+	}
+	return err
+}
+
+// RegisterService adds a service client that components reach with socket.Service
+func (h *Handler) RegisterService(client *ServiceClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.services == nil {
+		h.services = make(map[string]*ServiceClient)
+	}
+	h.services[client.Name] = client
+}
+
+// Service returns a registered service client, or nil
+func (h *Handler) Service(name string) *ServiceClient {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.services[name]
+}
+
+// serviceClients returns the registered clients
+func (h *Handler) serviceClients() []*ServiceClient {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	clients := make([]*ServiceClient, 0, len(h.services))
+	for _, client := range h.services {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// SocketService is a service client bound to a socket
+// Its calls use the socket's EventContext, so they stop when the event times out or
+// the connection closes, and failures are logged with the socket's logger
+type SocketService struct {
+	name   string
+	client *ServiceClient
+	socket *Socket
+}
+
+// Service returns the registered service client of a name, bound to the socket
+//
+//	var rates Rates
+//	if err := socket.Service("fx").GetJSON("/rates", &rates); err != nil {
+//		return err // an open breaker shows as a temporary outage
+//	}
+func (s *Socket) Service(name string) SocketService {
+	var client *ServiceClient
+	if s.services != nil {
+		client = s.services(name)
+	}
+	return SocketService{name: name, client: client, socket: s}
+}
+
+// Call runs op through the service client
+func (b SocketService) Call(op func(ctx context.Context) error) error {
+	if b.client == nil {
+		return fmt.Errorf("service %q not registered", b.name)
+	}
+	return b.logged(b.client.Call(b.socket.EventContext(), op))
+}
+
+// Do sends an HTTP request through the service client
+func (b SocketService) Do(req *http.Request) (*http.Response, error) {
+	if b.client == nil {
+		return nil, fmt.Errorf("service %q not registered", b.name)
+	}
+	resp, err := b.client.Do(req.WithContext(b.socket.EventContext()))
+	return resp, b.logged(err)
+}
+
+// Get fetches a URL through the service client
+func (b SocketService) Get(rawURL string) (*http.Response, error) {
+	if b.client == nil {
+		return nil, fmt.Errorf("service %q not registered", b.name)
+	}
+	resp, err := b.client.Get(b.socket.EventContext(), rawURL)
+	return resp, b.logged(err)
+}
+
+// GetJSON fetches a URL through the service client and decodes its JSON body into v
+func (b SocketService) GetJSON(rawURL string, v interface{}) error {
+	if b.client == nil {
+		return fmt.Errorf("service %q not registered", b.name)
+	}
+	return b.logged(b.client.GetJSON(b.socket.EventContext(), rawURL, v))
+}
+
+// logged logs a failed call
+func (b SocketService) logged(err error) error {
+	if err != nil && !errors.Is(err, context.Canceled) {
+		b.socket.log().Warn("Service call failed", "service", b.name, "error", err)
+	}
+	return err
+}
+
+// writeServiceMetrics writes the counters, breaker state and latency of every service client
+func (h *Handler) writeServiceMetrics(w io.Writer) {
+	clients := h.serviceClients()
+	if len(clients) == 0 {
+		return
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].Name < clients[j].Name })
+
+	fmt.Fprintf(w, "# HELP livenest_service_calls_total Service client calls by outcome.\n# TYPE livenest_service_calls_total counter\n")
+	for _, c := range clients {
+		stats := c.Stats()
+		label := quoteLabel(c.Name)
+		fmt.Fprintf(w, "livenest_service_calls_total{service=%s,outcome=\"ok\"} %d\n", label, stats.Calls-stats.Failed)
+		fmt.Fprintf(w, "livenest_service_calls_total{service=%s,outcome=\"error\"} %d\n", label, stats.Failed)
+		fmt.Fprintf(w, "livenest_service_calls_total{service=%s,outcome=\"rejected\"} %d\n", label, stats.Rejected)
+	}
+	fmt.Fprintf(w, "# HELP livenest_service_retries_total Service client retries.\n# TYPE livenest_service_retries_total counter\n")
+	for _, c := range clients {
+		fmt.Fprintf(w, "livenest_service_retries_total{service=%s} %d\n", quoteLabel(c.Name), c.retried.Load())
+	}
+	fmt.Fprintf(w, "# HELP livenest_service_breaker_open Whether a service's circuit breaker is open.\n# TYPE livenest_service_breaker_open gauge\n")
+	for _, c := range clients {
+		open := 0
+		if c.Stats().Open {
+			open = 1
+		}
+		fmt.Fprintf(w, "livenest_service_breaker_open{service=%s} %d\n", quoteLabel(c.Name), open)
+	}
+	fmt.Fprintf(w, "# HELP livenest_service_duration_seconds Service client call time, including retries.\n# TYPE livenest_service_duration_seconds histogram\n")
+	for _, c := range clients {
+		c.mu.Lock()
+		if c.latency != nil {
+			writeHistogramSeries(w, "livenest_service_duration_seconds", "service="+quoteLabel(c.Name), c.latency)
+		}
+		c.mu.Unlock()
+	}
+}
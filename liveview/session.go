@@ -1,24 +1,77 @@
 package liveview
 
 import (
+	"context"
+	"net/http"
 	"sync"
+	"time"
 )
 
 // Session manages LiveView session state
 type Session struct {
-	mu     sync.RWMutex
-	Data   map[string]interface{}
-	Flashes map[string]string
+	mu      sync.RWMutex
+	Data    map[string]interface{}
+	Flashes []Flash
+
+	renew bool
+}
+
+// Flash levels, used as Flash.Type
+const (
+	FlashInfo    = "info"
+	FlashSuccess = "success"
+	FlashWarning = "warning"
+	FlashError   = "error"
+)
+
+// DefaultFlashTTL is how long a flash is shown when Flash.TTL is zero
+const DefaultFlashTTL = 5 * time.Second
+
+// Flash is a one-time message shown to the user
+type Flash struct {
+	Type    string        `json:"type"` // level: FlashInfo, FlashSuccess, FlashWarning or FlashError
+	Message string        `json:"message"`
+	Key     string        `json:"key,omitempty"` // flashes with the same key replace each other
+	TTL     time.Duration `json:"-"`             // how long the flash is shown; 0 uses DefaultFlashTTL, negative keeps it until dismissed
+
+	queuedAt time.Time
+}
+
+// ttl returns the effective display time, or 0 for a flash kept until dismissed
+func (f Flash) ttl() time.Duration {
+	switch {
+	case f.TTL < 0:
+		return 0
+	case f.TTL == 0:
+		return DefaultFlashTTL
+	}
+	return f.TTL
+}
+
+// expired reports whether a pending flash outlived its TTL before it could be shown
+func (f Flash) expired(now time.Time) bool {
+	ttl := f.ttl()
+	return ttl > 0 && !f.queuedAt.IsZero() && now.Sub(f.queuedAt) > ttl
 }
 
 // NewSession creates a new session
 func NewSession() *Session {
 	return &Session{
-		Data:    make(map[string]interface{}),
-		Flashes: make(map[string]string),
+		Data: make(map[string]interface{}),
 	}
 }
 
+// Values returns a copy of the session data
+func (s *Session) Values() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	values := make(map[string]interface{}, len(s.Data))
+	for key, value := range s.Data {
+		values[key] = value
+	}
+	return values
+}
+
 // Put stores a value in the session
 func (s *Session) Put(key string, value interface{}) {
 	s.mu.Lock()
@@ -41,22 +94,95 @@ func (s *Session) Delete(key string) {
 	delete(s.Data, key)
 }
 
-// PutFlash sets a flash message
-func (s *Session) PutFlash(key, message string) {
+// PutFlash queues a flash message
+// Messages are kept in the order they were put, so several can be shown at once
+func (s *Session) PutFlash(kind, message string) {
+	s.AddFlash(Flash{Type: kind, Message: message})
+}
+
+// AddFlash queues a flash message
+// A flash with a Key replaces the pending flash with the same key in place
+func (s *Session) AddFlash(flash Flash) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flash.queuedAt = time.Now()
+	if flash.Key != "" {
+		for i, existing := range s.Flashes {
+			if existing.Key == flash.Key {
+				s.Flashes[i] = flash
+				return
+			}
+		}
+	}
+	s.Flashes = append(s.Flashes, flash)
+}
+
+// GetFlash retrieves and clears the first pending flash message of a type
+func (s *Session) GetFlash(kind string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, flash := range s.Flashes {
+		if flash.Type == kind {
+			s.Flashes = append(s.Flashes[:i], s.Flashes[i+1:]...)
+			return flash.Message, true
+		}
+	}
+	return "", false
+}
+
+// PeekFlashes returns a copy of the pending flash messages without clearing them
+func (s *Session) PeekFlashes() []Flash {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Flash(nil), s.Flashes...)
+}
+
+// TakeFlashes returns the pending flash messages in order and clears them
+// Flashes that waited longer than their TTL are dropped
+func (s *Session) TakeFlashes() []Flash {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	var flashes []Flash
+	for _, flash := range s.Flashes {
+		if !flash.expired(now) {
+			flashes = append(flashes, flash)
+		}
+	}
+	s.Flashes = nil
+	return flashes
+}
+
+// DeleteFlashes removes pending flash messages of a type
+func (s *Session) DeleteFlashes(kind string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.Flashes[key] = message
+	kept := s.Flashes[:0]
+	for _, flash := range s.Flashes {
+		if flash.Type != kind {
+			kept = append(kept, flash)
+		}
+	}
+	s.Flashes = kept
 }
 
-// GetFlash retrieves and clears a flash message
-func (s *Session) GetFlash(key string) (string, bool) {
+// DeleteFlashKey removes the pending flash message with a key
+func (s *Session) DeleteFlashKey(key string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	msg, ok := s.Flashes[key]
-	if ok {
-		delete(s.Flashes, key)
+	for i, flash := range s.Flashes {
+		if flash.Key == key {
+			s.Flashes = append(s.Flashes[:i], s.Flashes[i+1:]...)
+			return
+		}
 	}
-	return msg, ok
+}
+
+// ClearFlashes removes all pending flash messages
+func (s *Session) ClearFlashes() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Flashes = nil
 }
 
 // Clear clears all session data
@@ -64,5 +190,61 @@ func (s *Session) Clear() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.Data = make(map[string]interface{})
-	s.Flashes = make(map[string]string)
+	s.Flashes = nil
+}
+
+// RenewID asks the session store to move the session to a new ID when it is saved, e.g.
+// after a login, so an ID planted before it can't be used to ride the signed-in session
+// The ID is saved with the HTTP response of the request, not over the WebSocket
+func (s *Session) RenewID() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.renew = true
+}
+
+// RenewRequested reports whether RenewID was called
+func (s *Session) RenewRequested() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.renew
+}
+
+// sessionContextKey carries the session of a request in its context
+type sessionContextKey struct{}
+
+// WithSession returns a copy of ctx carrying session, so the sockets rendered for a
+// request share the session of its HTTP middleware
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, session)
+}
+
+// SessionFrom returns the session carried by ctx
+func SessionFrom(ctx context.Context) (*Session, bool) {
+	session, ok := ctx.Value(sessionContextKey{}).(*Session)
+	return session, ok
+}
+
+// SetSessionLoader sets how sockets load the session of the request that opened them
+// when its context doesn't carry one, e.g. the WebSocket upgrade, which reads the session
+// cookie of the page. Without a loader such sockets start with an empty session
+func (h *Handler) SetSessionLoader(load func(r *http.Request) *Session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sessionLoader = load
+}
+
+// requestSession returns the session for a socket opened by r
+func (h *Handler) requestSession(r *http.Request) *Session {
+	if session, ok := SessionFrom(r.Context()); ok {
+		return session
+	}
+	h.mu.RLock()
+	load := h.sessionLoader
+	h.mu.RUnlock()
+	if load != nil {
+		if session := load(r); session != nil {
+			return session
+		}
+	}
+	return NewSession()
 }
@@ -1,28 +1,85 @@
 package liveview
 
 import (
-	"log"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
 	"math/rand"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/paulmanoni/livenest/i18n"
+	"github.com/paulmanoni/livenest/jobs"
+	"github.com/paulmanoni/livenest/protocol"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for development
-	},
 }
 
 // Handler manages LiveView WebSocket connections
 type Handler struct {
 	components map[string]Component
 	sockets    map[string]*Socket
-	mu         sync.RWMutex
+	conns      map[*liveConn]struct{}
+	policies   map[string][]Policy
+	layouts    map[string]Layout
+	hooks      map[string][]EventHook
+	behaviors  map[string][]Behavior
+	appAssigns map[string]interface{}
+	layout     Layout
+	strictCSP  bool
+	strict     bool
+	debug      bool
+	profiling  bool
+
+	tracerProvider trace.TracerProvider
+	logger         Logger
+	slowRender     time.Duration
+	largePayload   int
+	messageLimits  MessageLimits
+	connLimiter    *connLimiter
+	services       map[string]*ServiceClient
+	assets         map[string][]Asset
+	bundles        map[string]assetBundle
+	assetFiles     map[string][]byte
+	assetFS        fs.FS
+
+	flashPartial    *template.Template
+	printLayout     Layout
+	budgets         map[string]map[string]time.Duration
+	budgetReporters []func(BudgetReport)
+	recorders       map[string]func(*Recording)
+	routeAssigns    map[string][]AssignFunc
+	mountHooks      []MountHook
+	groups          map[string]string // route group prefix by component name
+	features        map[string]RenderFeatures
+
+	loadingTimeout time.Duration
+	eventTimeout   time.Duration
+	noJSAudit      bool
+	reconnect      ReconnectPolicy
+	sessionLoader  func(*http.Request) *Session
+	formKey        []byte // signs the CSRF tokens and timestamps of plain POST forms
+	allowedOrigins func(origin string) bool
+	translations   *i18n.Catalog
+	jobs           *jobs.Runner
+
+	counters  handlerCounters
+	metrics   *metrics
+	pending   *pendingSockets
+	throttles *topicThrottles
+	mu        sync.RWMutex
 }
 
 // NewHandler creates a new LiveView handler
@@ -30,22 +87,38 @@ func NewHandler() *Handler {
 	return &Handler{
 		components: make(map[string]Component),
 		sockets:    make(map[string]*Socket),
+		conns:      make(map[*liveConn]struct{}),
+		policies:   make(map[string][]Policy),
+		layouts:    make(map[string]Layout),
+		hooks:      make(map[string][]EventHook),
+		behaviors:  make(map[string][]Behavior),
+		appAssigns: make(map[string]interface{}),
+		layout:     DefaultLayout(),
+		metrics:    newMetrics(),
+		pending:    newPendingSockets(),
+		throttles:  newTopicThrottles(),
+		formKey:    randomKey(),
 	}
 }
 
 // Register registers a component with a route
 func (h *Handler) Register(name string, component Component) {
+	for event, methods := range EventCollisions(component) {
+		h.log().Warn("Component has ambiguous handlers for an event", "component", name, "event", event, "methods", methods)
+	}
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	h.components[name] = component
 }
 
-// HandleWebSocket handles WebSocket connections for LiveView
+// HandleWebSocket handles WebSocket connections for a single LiveView component
+// The component is named by the :component route param and uses the empty topic
 func (h *Handler) HandleWebSocket(c *gin.Context) {
 	componentName := c.Param("component")
 
 	h.mu.RLock()
-	component, exists := h.components[componentName]
+	_, exists := h.components[componentName]
 	h.mu.RUnlock()
 
 	if !exists {
@@ -53,150 +126,266 @@ func (h *Handler) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	release, ok := h.admitConnection(c)
+	if !ok {
+		return
+	}
+	defer release()
+
+	conn, err := h.upgrade(c)
 	if err != nil {
-		log.Printf("WebSocket upgrade error: %v", err)
+		h.log().Error("WebSocket upgrade error", "error", err)
 		return
 	}
 	defer conn.Close()
 
-	// Create socket
-	socket := NewSocket(c.Query("socket_id"))
+	lc := h.newLiveConn(c, conn)
+	defer lc.close()
 
-	// Mount component
-	if err := component.Mount(socket); err != nil {
-		log.Printf("Component mount error: %v", err)
+	if err := lc.join("", componentName, c.Query("socket_id"), c.Query("nonce"), nil); err != nil {
+		if errors.Is(err, ErrUnauthorized) {
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "unauthorized"))
+		}
 		return
 	}
 
-	// Store socket
-	h.mu.Lock()
-	h.sockets[socket.ID] = socket
-	h.mu.Unlock()
+	lc.run()
+}
 
-	// Send initial render
-	html, err := component.Render(socket)
-	if err != nil {
-		log.Printf("Render error: %v", err)
+// HandleMultiplexWebSocket handles a WebSocket shared by every LiveView container on a page
+// Containers join with an "lv:join" message and their messages carry the container's topic
+func (h *Handler) HandleMultiplexWebSocket(c *gin.Context) {
+	// Clients name the protocol version they speak; those that don't predate versions
+	if vsn := c.Query(protocol.VersionParam); vsn != "" && vsn != protocol.Version {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported protocol version", "version": protocol.Version})
 		return
 	}
 
-	htmlStr := string(html)
-	socket.previousHTML = htmlStr // Store for future diffs
-
-	renderData := map[string]interface{}{
-		"html": htmlStr,
+	release, ok := h.admitConnection(c)
+	if !ok {
+		return
 	}
-	h.addFlashToData(socket, renderData)
+	defer release()
 
-	if err := h.sendMessage(conn, "render", renderData); err != nil {
-		log.Printf("Send error: %v", err)
+	conn, err := h.upgrade(c)
+	if err != nil {
+		h.log().Error("WebSocket upgrade error", "error", err)
 		return
 	}
+	defer conn.Close()
 
-	// Listen for events
-	for {
-		var msg Message
-		if err := conn.ReadJSON(&msg); err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("WebSocket error: %v", err)
-			}
-			break
+	lc := h.newLiveConn(c, conn)
+	defer lc.close()
+
+	lc.run()
+}
+
+// Message represents a WebSocket message
+type Message struct {
+	Type    string                 `json:"type,omitempty"` // see protocol.MessageKind
+	Event   string                 `json:"event"`
+	Payload map[string]interface{} `json:"payload"`
+	Ref     string                 `json:"ref,omitempty"`   // echoed back in the reply
+	Topic   string                 `json:"topic,omitempty"` // container the message is for on a shared socket
+	Version string                 `json:"vsn,omitempty"`   // protocol version the message was written for
+
+	rejected error // set instead of Payload when the message is over the limits
+}
+
+// processEvent authorizes, handles and re-renders a single client event
+// It returns the render data to send, which is empty when nothing changed
+// Handler errors and panics are reported to the client with an error frame
+func (h *Handler) processEvent(conn *websocket.Conn, componentName string, component Component, socket *Socket, msg Message) (renderData map[string]interface{}) {
+	renderData = make(map[string]interface{})
+
+	// Handling and re-rendering are traced as one span
+	var spanErr error
+	end := h.startSpan("liveview.event", componentName, msg.Event, socket)
+	defer func() { end(spanErr) }()
+
+	// Handling and re-rendering count against the event's latency budget
+	defer h.checkBudget(componentName, component, socket, msg.Event, time.Now())
+
+	// A panicking handler or render fails the event instead of the whole server
+	defer func() {
+		if r := recover(); r != nil {
+			p := recoverPanic(r)
+			spanErr = p
+			socket.log().Error("Event handling panic", "component", componentName, "event", msg.Event, "panic", r, "stack", p.Stack)
+			h.sendMessage(conn, msg.Topic, "error", h.errorFrame(msg.Event, p))
+			renderData = make(map[string]interface{})
 		}
+	}()
 
-		// Handle event - try reflection-based routing first, then EventHandler interface
-		err := RouteEvent(component, msg.Event, msg.Payload, socket)
-		if err != nil {
-			// Fallback to EventHandler interface if routing fails
-			if handler, ok := component.(EventHandler); ok {
-				if err := handler.HandleEvent(msg.Event, msg.Payload, socket); err != nil {
-					log.Printf("Event handling error: %v", err)
-					continue
-				}
-			} else {
-				log.Printf("Event handling error: %v", err)
-				continue
+	// Check authorization before every event
+	if err := h.authorize(componentName, component, socket, msg.Event); err != nil {
+		spanErr = err
+		socket.log().Warn("Event rejected", "component", componentName, "event", msg.Event, "error", err)
+		return renderData
+	}
+
+	// Handle event - try reflection-based routing first, then EventHandler interface
+	if err := h.runEvent(componentName, component, msg.Event, msg.Payload, socket); err != nil {
+		spanErr = err
+		if errors.Is(err, ErrUnknownEvent) {
+			h.counters.unknownEvents.Add(1)
+			if h.isStrict(component) {
+				h.sendMessage(conn, msg.Topic, "error", map[string]interface{}{
+					"event":   msg.Event,
+					"reason":  "unknown_event",
+					"message": err.Error(),
+				})
 			}
+		} else if !errors.Is(err, ErrEventTimeout) {
+			// Timeouts are reported by the connection, which drops the socket
+			h.sendMessage(conn, msg.Topic, "error", h.errorFrame(msg.Event, err))
 		}
+		socket.log().Error("Event handling error", "component", componentName, "event", msg.Event, "error", err)
+		return renderData
+	}
 
-		// Re-render
-		html, err := component.Render(socket)
-		if err != nil {
-			log.Printf("Render error: %v", err)
-			continue
-		}
+	return h.renderUpdate(componentName, msg.Event, component, socket)
+}
+
+// renderComponent renders a component for an event, records the render time in the
+// handler stats and warns when it is slow
+func (h *Handler) renderComponent(componentName, event string, component Component, socket *Socket) (template.HTML, error) {
+	span := h.childSpan("liveview.render", socket)
+	start := time.Now()
+	syncPrintingAssign(socket)
+	html, err := component.Render(socket)
+	elapsed := time.Since(start)
+	span.End()
+	h.counters.recordRender(elapsed)
+	h.metrics.observeDuration(h.metrics.render, elapsed)
+	h.checkRender(componentName, event, socket, elapsed)
+	return html, err
+}
+
+// renderUpdate re-renders a component and returns the diff, flash and title changes
+// The returned map is empty when nothing changed
+func (h *Handler) renderUpdate(componentName, event string, component Component, socket *Socket) map[string]interface{} {
+	renderData := make(map[string]interface{})
+
+	// Re-render
+	html, err := h.renderComponent(componentName, event, component, socket)
+	if err != nil {
+		socket.log().Error("Render error", "socket", socket.ID, "error", err)
+		return renderData
+	}
 
-		htmlStr := string(html)
+	htmlStr := string(html)
+	features := h.featuresFor(componentName, component)
+	socket.pipeline = features.pipeline()
 
+	switch socket.pipeline {
+	case PipelineSplit:
+		addSplitToData(socket, htmlStr, renderData)
+	case PipelineDiff:
 		// Compute diff against previous render
+		span := h.childSpan("liveview.diff", socket)
+		start := time.Now()
 		diff, err := ComputeDiff(socket.previousHTML, htmlStr)
+		h.metrics.observeDuration(h.metrics.diff, time.Since(start))
+		span.End()
 		if err != nil {
-			log.Printf("Diff error: %v", err)
+			socket.log().Warn("Diff error, sending full HTML", "socket", socket.ID, "error", err)
 			// Fall back to full HTML
-			diff = nil
+			renderData["html"] = htmlStr
+		} else if len(diff) > 0 {
+			// Only include the diff when something changed
+			renderData["diff"] = diff
+		}
+	default:
+		if htmlStr != socket.previousHTML {
+			renderData["html"] = htmlStr
 		}
+	}
 
-		socket.previousHTML = htmlStr // Update for next diff
+	socket.previousHTML = htmlStr // Update for next diff
+	if !features.UseStreams {
+		h.dropStreams(componentName, socket)
+	}
 
-		renderData := make(map[string]interface{})
+	// Always check for flash messages, title changes, toasts and redirects
+	h.addFlashToData(socket, renderData)
+	h.addTitleToData(socket, renderData)
+	h.addToastsToData(socket, renderData)
+	h.addStreamsToData(socket, renderData)
+	h.addRedirectToData(socket, renderData)
+	h.addPrintToData(socket, renderData)
 
-		// If diff is nil or empty, no changes - skip sending
-		if diff == nil || len(diff) == 0 {
-			// Still check for flash messages
-			h.addFlashToData(socket, renderData)
-			if len(renderData) > 0 {
-				if err := h.sendMessage(conn, "render", renderData); err != nil {
-					log.Printf("Send error: %v", err)
-					break
-				}
+	return renderData
+}
+
+// readMessages reads client messages into incoming until the connection fails or closed is closed
+func (h *Handler) readMessages(conn *websocket.Conn, incoming chan<- Message, closed <-chan struct{}) {
+	defer close(incoming)
+	limits := h.limits()
+	conn.SetReadLimit(int64(limits.MaxBytes))
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if errors.Is(err, websocket.ErrReadLimit) {
+				h.log().Warn("WebSocket message too large", "limit", limits.MaxBytes)
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				h.log().Warn("WebSocket error", "error", err)
 			}
-			continue
+			return
 		}
+		h.metrics.observe(h.metrics.payloadIn, float64(len(data)))
 
-		// Send diff instead of full HTML
-		renderData["diff"] = diff
-
-		h.addFlashToData(socket, renderData)
+		var msg Message
+		if err := checkComplexity(data, limits); err != nil {
+			msg = rejectedMessage(data, err)
+		} else if err := json.Unmarshal(data, &msg); err != nil {
+			h.log().Warn("WebSocket error", "error", err)
+			return
+		}
 
-		if err := h.sendMessage(conn, "render", renderData); err != nil {
-			log.Printf("Send error: %v", err)
-			break
+		select {
+		case incoming <- msg:
+		case <-closed:
+			return
 		}
 	}
-
-	// Cleanup
-	h.mu.Lock()
-	delete(h.sockets, socket.ID)
-	h.mu.Unlock()
 }
 
-// Message represents a WebSocket message
-type Message struct {
-	Event   string                 `json:"event"`
-	Payload map[string]interface{} `json:"payload"`
+// sendMessage sends a message to the WebSocket client
+// The topic routes the message to a container on a shared socket and is omitted when empty
+func (h *Handler) sendMessage(conn *websocket.Conn, topic string, msgType string, data map[string]interface{}) error {
+	_, err := h.writeFrame(conn, topic, msgType, data)
+	return err
 }
 
-// sendMessage sends a message to the WebSocket client
-func (h *Handler) sendMessage(conn *websocket.Conn, msgType string, data map[string]interface{}) error {
+// writeFrame sends a message like sendMessage and returns its size in bytes
+func (h *Handler) writeFrame(conn *websocket.Conn, topic string, msgType string, data map[string]interface{}) (int, error) {
 	msg := map[string]interface{}{
 		"type": msgType,
 		"data": data,
 	}
-	return conn.WriteJSON(msg)
+	if topic != "" {
+		msg["topic"] = topic
+	}
+	frame, err := json.Marshal(msg)
+	if err != nil {
+		return 0, err
+	}
+	h.metrics.observe(h.metrics.payloadOut, float64(len(frame)))
+	return len(frame), conn.WriteMessage(websocket.TextMessage, frame)
 }
 
-// addFlashToData adds flash messages from socket to render data
+// addFlashToData adds pending flash messages from socket to render data in order
 func (h *Handler) addFlashToData(socket *Socket, data map[string]interface{}) {
-	// Check for all flash types
-	flashTypes := []string{"success", "error", "info", "warning"}
-	for _, flashType := range flashTypes {
-		if msg, ok := socket.GetFlash(flashType); ok {
-			data["flash"] = map[string]string{
-				"type":    flashType,
-				"message": msg,
-			}
-			break // Only send one flash message at a time
-		}
+	if flashes := socket.Session.TakeFlashes(); len(flashes) > 0 {
+		data["flashes"] = h.flashFrames(flashes)
+	}
+}
+
+// addTitleToData adds the document title to render data when it changed
+func (h *Handler) addTitleToData(socket *Socket, data map[string]interface{}) {
+	if title, ok := socket.takeTitleChange(); ok {
+		data["title"] = title
 	}
 }
 
@@ -208,27 +397,43 @@ func (h *Handler) HandleComponentTag(c *gin.Context) {
 	component, exists := h.components[componentName]
 	h.mu.RUnlock()
 
-	if !exists {
+	// Components of a route group are only served through the group's middleware
+	if !exists || h.checkGroup(componentName, requestGroup(c)) != nil {
 		c.JSON(404, gin.H{"error": "Component not found"})
 		return
 	}
 
 	// Create temporary socket for initial render
-	socket := NewSocket("")
+	socket := h.newSocket("")
+	socket.Request = c.Request
+	socket.RequestID = requestID(c.Request)
+	socket.Session = h.requestSession(c.Request)
+	socket.Nonce = c.Query("nonce")
+	socket.Params = pageParams(c.Query("params"))
+	socket.ctx = c.Request.Context()
+
+	if err := h.authorize(componentName, component, socket, ""); err != nil {
+		c.JSON(403, gin.H{"error": "Forbidden"})
+		return
+	}
 
-	if err := component.Mount(socket); err != nil {
+	if err := h.mount(componentName, component, socket, c); err != nil {
+		if errors.Is(err, ErrUnauthorized) {
+			c.JSON(403, gin.H{"error": "Forbidden"})
+			return
+		}
 		c.JSON(500, gin.H{"error": "Mount failed"})
 		return
 	}
 
-	html, err := component.Render(socket)
+	html, err := h.renderComponent(componentName, "mount", component, socket)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "Render failed"})
 		return
 	}
 
 	// Generate socket ID
-	socketID := generateSocketID()
+	socketID := h.issueSocketID(componentName, socket)
 
 	// Return JSON for component tag
 	c.JSON(200, gin.H{
@@ -251,26 +456,173 @@ func (h *Handler) HandleHTTP(componentName string) gin.HandlerFunc {
 		}
 
 		// Create temporary socket for initial render
-		socket := NewSocket("")
+		socket := h.newSocket("")
+		socket.Request = c.Request
+		socket.RequestID = requestID(c.Request)
+		socket.Session = h.requestSession(c.Request)
+		socket.Params = c.Request.URL.Query()
+		socket.Nonce = GenerateNonce()
+		socket.ctx = c.Request.Context()
+		if _, ok := component.(FormPoster); ok {
+			socket.csrfToken = h.csrfToken(c)
+		}
+		if printRequested(c) {
+			socket.RenderMode = RenderPrint
+		}
 
-		if err := component.Mount(socket); err != nil {
-			c.JSON(500, gin.H{"error": "Mount failed"})
+		if err := h.authorize(componentName, component, socket, ""); err != nil {
+			c.JSON(403, gin.H{"error": "Forbidden"})
 			return
 		}
 
-		html, err := component.Render(socket)
-		if err != nil {
-			c.JSON(500, gin.H{"error": "Render failed"})
+		if err := h.mount(componentName, component, socket, c); err != nil {
+			switch {
+			case IsUnavailable(err):
+				h.serveUnavailable(c, componentName, socket, err)
+			case errors.Is(err, ErrHalt) && socket.redirect != nil:
+				c.Redirect(http.StatusFound, socket.takeRedirect().to)
+			case errors.Is(err, ErrUnauthorized), errors.Is(err, ErrHalt):
+				c.JSON(403, gin.H{"error": "Forbidden"})
+			default:
+				c.JSON(500, gin.H{"error": "Mount failed"})
+			}
+			return
+		}
+
+		// A redirect during mount becomes a plain HTTP redirect
+		if r := socket.takeRedirect(); r != nil {
+			c.Redirect(http.StatusFound, r.to)
 			return
 		}
 
-		// Generate socket ID
-		socketID := generateSocketID()
+		if socket.Printing() {
+			h.servePrintPage(c, componentName, component, socket)
+			return
+		}
+		h.servePage(c, componentName, component, socket)
+	}
+}
+
+// servePage renders a mounted component into its layout as a full HTML page
+func (h *Handler) servePage(c *gin.Context, componentName string, component Component, socket *Socket) {
+	html, err := h.renderComponent(componentName, "mount", component, socket)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Render failed"})
+		return
+	}
+
+	// Generate socket ID
+	socketID := h.issueSocketID(componentName, socket)
+
+	h.mu.RLock()
+	strictCSP := h.strictCSP
+	h.mu.RUnlock()
+	if strictCSP {
+		c.Header("Content-Security-Policy", strictCSPHeader(socket.Nonce))
+	}
+
+	// Serve full HTML page with the component's layout
+	page := h.newPageData(componentName, html, socketID, socket, h.loadingTimeoutAttr()+h.reconnectAttrs()+h.socketPathAttr(componentName))
+	page.Flashes = h.flashesHTML(socket)
+	scripts, styles := h.assetTags(componentName, socket)
+	page.Assets = styles + page.Assets + scripts
+	if h.isNoJSAudit() {
+		_, postable := component.(FormPoster)
+		page.Assets = styles
+		page.LiveView += auditPage(h.log(), componentName, html, postable, socket.Nonce)
+	}
+	var buf bytes.Buffer
+	if err := h.layoutFor(componentName).RenderLayout(&buf, page); err != nil {
+		h.log().Error("Layout error", "component", componentName, "error", err)
+		c.JSON(500, gin.H{"error": "Render failed"})
+		return
+	}
+	h.checkPayload(componentName, "mount", socket, buf.Len())
+	c.Data(200, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// pageParams parses the page query string forwarded by the client
+func pageParams(query string) url.Values {
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return make(url.Values)
+	}
+	return params
+}
+
+// RenderLive renders a registered component inside its own LiveView container
+// Use it to place additional components on a page, e.g. a sidebar widget next to the main
+// #liveview container; every container on the page shares one WebSocket connection.
+// The component mounts with the page request's session and middleware values, like a
+// page of its own. Pass the page's nonce when strict CSP is enabled
+func (h *Handler) RenderLive(name string, c *gin.Context, nonce string) (template.HTML, error) {
+	h.mu.RLock()
+	component, exists := h.components[name]
+	h.mu.RUnlock()
 
-		// Serve full HTML page with LiveView wrapper
-		htmlWrapper := generateHTMLWrapper(componentName, string(html), socketID, socket.ComponentID)
-		c.Data(200, "text/html; charset=utf-8", []byte(htmlWrapper))
+	if !exists {
+		return "", fmt.Errorf("component %q not found", name)
 	}
+	// Components of a route group are only rendered on pages behind the group's middleware
+	if err := h.checkGroup(name, requestGroup(c)); err != nil {
+		return "", err
+	}
+
+	r := c.Request
+	socket := h.newSocket("")
+	socket.Request = r
+	socket.RequestID = requestID(r)
+	socket.Session = h.requestSession(r)
+	socket.Params = r.URL.Query()
+	socket.Nonce = nonce
+	socket.ctx = r.Context()
+
+	if err := h.authorize(name, component, socket, ""); err != nil {
+		return "", err
+	}
+
+	if err := h.mount(name, component, socket, c); err != nil {
+		return "", err
+	}
+
+	html, err := h.renderComponent(name, "mount", component, socket)
+	if err != nil {
+		return "", err
+	}
+
+	return containerHTML(socket.ComponentID, name, h.issueSocketID(name, socket), socket.ComponentID, "", html), nil
+}
+
+// SetAllowedOrigins sets which other sites may open LiveView WebSockets, e.g. sites that
+// embed component tags. The WebSocket carries the user's cookies, so other origins are
+// refused unless allowed; pages of the request's own host always are
+func (h *Handler) SetAllowedOrigins(allowed func(origin string) bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.allowedOrigins = allowed
+}
+
+// checkOrigin reports whether a WebSocket upgrade comes from an allowed page
+// Browsers always send Origin; clients without one, such as the Go client, pass
+func (h *Handler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	h.mu.RLock()
+	allowed := h.allowedOrigins
+	h.mu.RUnlock()
+	return allowed != nil && allowed(origin)
+}
+
+// upgrade upgrades a request to a WebSocket, refusing other sites' pages with a 403
+func (h *Handler) upgrade(c *gin.Context) (*websocket.Conn, error) {
+	u := upgrader
+	u.CheckOrigin = h.checkOrigin
+	return u.Upgrade(c.Writer, c.Request, nil)
 }
 
 // generateSocketID generates a unique socket ID
@@ -282,39 +634,3 @@ func generateSocketID() string {
 	}
 	return "socket_" + string(b)
 }
-
-// generateHTMLWrapper generates the full HTML page with LiveView JavaScript
-func generateHTMLWrapper(componentName, componentHTML, socketID, componentID string) string {
-	return `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>LiveNest - ` + componentName + `</title>
-    <style>
-        body {
-            margin: 0;
-            padding: 0;
-            font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, "Helvetica Neue", Arial, sans-serif;
-            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
-            min-height: 100vh;
-            display: flex;
-            justify-content: center;
-            align-items: center;
-        }
-        .liveview-container {
-            background: white;
-            border-radius: 15px;
-            padding: 40px;
-            box-shadow: 0 20px 60px rgba(0, 0, 0, 0.3);
-        }
-    </style>
-    <script src="/livenest/liveview.js"></script>
-</head>
-<body>
-    <div class="liveview-container">
-        <div id="liveview" data-component="` + componentName + `" data-socket-id="` + socketID + `" data-component-id="` + componentID + `">` + componentHTML + `</div>
-    </div>
-</body>
-</html>`
-}
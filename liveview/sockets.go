@@ -0,0 +1,101 @@
+package liveview
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// SocketInfo describes a connected socket
+type SocketInfo struct {
+	ID          string    `json:"id"`
+	Component   string    `json:"component"`
+	Topic       string    `json:"topic"` // container the component is mounted in
+	RemoteAddr  string    `json:"remote_addr"`
+	UserAgent   string    `json:"user_agent"`
+	RequestID   string    `json:"request_id,omitempty"` // of the page the socket was rendered for
+	ConnectedAt time.Time `json:"connected_at"`
+	LastEvent   string    `json:"last_event,omitempty"`
+	LastEventAt time.Time `json:"last_event_at,omitzero"`
+	Events      uint64    `json:"events"` // client events handled since mount
+}
+
+// socketMeta is the connection metadata of a mounted socket
+// It is updated on the connection goroutine and read by Sockets
+type socketMeta struct {
+	mu   sync.Mutex
+	info SocketInfo
+}
+
+// newSocketMeta records the mount of a socket
+func newSocketMeta(socket *Socket, componentName, topic string) *socketMeta {
+	info := SocketInfo{
+		ID:          socket.ID,
+		Component:   componentName,
+		Topic:       topic,
+		RequestID:   socket.RequestID,
+		ConnectedAt: time.Now(),
+	}
+	if socket.Request != nil {
+		info.RemoteAddr = socket.Request.RemoteAddr
+		info.UserAgent = socket.Request.UserAgent()
+	}
+	return &socketMeta{info: info}
+}
+
+// touch records a client event
+func (m *socketMeta) touch(event string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.info.LastEvent = event
+	m.info.LastEventAt = time.Now()
+	m.info.Events++
+}
+
+// snapshot returns a copy of the metadata
+func (m *socketMeta) snapshot() SocketInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.info
+}
+
+// Sockets lists the connected sockets, oldest first
+func (h *Handler) Sockets() []SocketInfo {
+	h.mu.RLock()
+	sockets := make([]SocketInfo, 0, len(h.sockets))
+	for _, socket := range h.sockets {
+		if socket.meta != nil {
+			sockets = append(sockets, socket.meta.snapshot())
+		}
+	}
+	h.mu.RUnlock()
+
+	sort.Slice(sockets, func(i, j int) bool {
+		return sockets[i].ConnectedAt.Before(sockets[j].ConnectedAt)
+	})
+	return sockets
+}
+
+// SocketInfo returns the metadata of a connected socket
+func (h *Handler) SocketInfo(id string) (SocketInfo, bool) {
+	h.mu.RLock()
+	socket, ok := h.sockets[id]
+	h.mu.RUnlock()
+	if !ok || socket.meta == nil {
+		return SocketInfo{}, false
+	}
+	return socket.meta.snapshot(), true
+}
+
+// Disconnect unmounts a connected socket and tells its client not to reconnect it
+// Other components sharing the connection stay mounted. It reports false when no
+// socket has the id
+func (h *Handler) Disconnect(id string) bool {
+	h.mu.RLock()
+	socket, ok := h.sockets[id]
+	h.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	return socket.enqueue(func() { socket.kicked = true })
+}
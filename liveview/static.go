@@ -1,13 +1,119 @@
 package liveview
 
 import (
+	"bytes"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
 //go:embed static/liveview.js
 var liveviewJS string
 
+// LiveViewJSPath is the URL path of the LiveView client JavaScript
+const LiveViewJSPath = "/livenest/liveview.js"
+
+// clientScript is a variant of the client JavaScript as it is served
+type clientScript struct {
+	body    []byte
+	version string // content hash, added to the URL so the file can be cached for good
+}
+
+var (
+	clientScriptsOnce sync.Once
+	devScript         clientScript // the readable source, served in debug mode
+	prodScript        clientScript // the minified source, pointing to its source map
+	sourceMap         clientScript
+	clientScriptsTime = time.Now()
+)
+
+// clientScripts builds the variants of the client JavaScript once
+func clientScripts() {
+	clientScriptsOnce.Do(func() {
+		source := GetLiveViewJS()
+		devScript = newClientScript([]byte(source))
+
+		minified, mappings := minifyJS(source)
+		sourceMap = newClientScript(mustJSON(map[string]interface{}{
+			"version":        3,
+			"file":           "liveview.js",
+			"sources":        []string{"liveview.src.js"},
+			"sourcesContent": []string{source},
+			"names":          []string{},
+			"mappings":       mappings,
+		}))
+		prodScript = newClientScript([]byte(minified + "\n//# sourceMappingURL=liveview.js.map?v=" + sourceMap.version + "\n"))
+	})
+}
+
+// newClientScript returns a script variant with its content hash
+func newClientScript(body []byte) clientScript {
+	sum := sha256.Sum256(body)
+	return clientScript{body: body, version: hex.EncodeToString(sum[:])[:12]}
+}
+
+// mustJSON encodes a value that can't fail to encode
+func mustJSON(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// GetLiveViewJSMin returns the minified LiveView client JavaScript served outside debug mode
+func GetLiveViewJSMin() string {
+	clientScripts()
+	return string(prodScript.body)
+}
+
+// liveViewScript returns the client JavaScript the handler serves: the readable source
+// in debug mode, the minified one otherwise
+func (h *Handler) liveViewScript() clientScript {
+	clientScripts()
+	if h.isDebug() {
+		return devScript
+	}
+	return prodScript
+}
+
+// liveViewJSURL returns the versioned URL of the client JavaScript for pages
+func (h *Handler) liveViewJSURL() string {
+	return LiveViewJSPath + "?v=" + h.liveViewScript().version
+}
+
+// HandleLiveViewJS serves the client JavaScript
+// The versioned URL pages link to is cached for a year, since a new version changes the
+// URL; the plain path, e.g. from hand-written pages, is revalidated by its ETag
+func (h *Handler) HandleLiveViewJS(c *gin.Context) {
+	serveClientScript(c, "liveview.js", h.liveViewScript())
+}
+
+// HandleLiveViewJSMap serves the source map of the minified client JavaScript
+func (h *Handler) HandleLiveViewJSMap(c *gin.Context) {
+	clientScripts()
+	c.Header("Content-Type", "application/json")
+	serveClientScript(c, "liveview.js.map", sourceMap)
+}
+
+// serveClientScript sends a script variant with caching headers
+func serveClientScript(c *gin.Context, name string, script clientScript) {
+	if c.Query("v") == script.version {
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		c.Header("Cache-Control", "no-cache")
+	}
+	c.Header("ETag", `"`+script.version+`"`)
+	http.ServeContent(c.Writer, c.Request, name, clientScriptsTime, bytes.NewReader(script.body))
+}
+
 // GetLiveViewJS returns the LiveView client JavaScript
 func GetLiveViewJS() string {
 	// Combine LiveView socket + Component tag
@@ -1,21 +1,259 @@
 // LiveNest LiveView Client
+
+// CSP nonce of the page, read while this script is executing
+const liveNestNonce = (document.currentScript && document.currentScript.nonce) || '';
+
+// LiveNestTime shows the RFC 3339 time of lv-time elements relative to now ("2m ago"),
+// refreshed in the browser so feeds don't need server events to stay current
+// The server-rendered text is the fallback without JavaScript; the full time is the title
+class LiveNestTime {
+    static format(date, now) {
+        const seconds = Math.round((now - date) / 1000);
+        const abs = Math.abs(seconds);
+        if (abs < 60) return 'just now';
+        const units = [['d', 86400], ['h', 3600], ['m', 60]];
+        if (abs >= 7 * 86400) return date.toLocaleDateString();
+        const [unit, size] = units.find(([, size]) => abs >= size);
+        const n = Math.floor(abs / size) + unit;
+        return seconds > 0 ? `${n} ago` : `in ${n}`;
+    }
+
+    static refresh(root) {
+        const now = Date.now();
+        (root || document).querySelectorAll('[lv-time]').forEach(el => {
+            const date = new Date(el.getAttribute('lv-time'));
+            if (isNaN(date)) return;
+            const text = LiveNestTime.format(date, now);
+            if (el.textContent !== text) el.textContent = text;
+            if (!el.title) el.title = date.toLocaleString();
+        });
+    }
+
+    static start() {
+        if (LiveNestTime.timer) return;
+        LiveNestTime.refresh();
+        // Minutes are the finest unit shown after the first one, so a coarse tick is enough
+        LiveNestTime.timer = setInterval(() => {
+            if (!document.hidden) LiveNestTime.refresh();
+        }, 15000);
+        document.addEventListener('visibilitychange', () => {
+            if (!document.hidden) LiveNestTime.refresh();
+        });
+    }
+}
+
+// LiveNestTransport is the WebSocket shared by every LiveView container on the page
+// Each container joins under its own topic; the transport reconnects and rejoins them
+class LiveNestTransport {
+    static shared(container) {
+        if (!LiveNestTransport.instance) {
+            LiveNestTransport.instance = new LiveNestTransport(container);
+        }
+        return LiveNestTransport.instance;
+    }
+
+    constructor(container) {
+        this.ws = null;
+        this.views = new Map(); // topic -> LiveViewSocket
+        this.connected = false;
+
+        // Reconnect backoff: the delay doubles from reconnectMin up to reconnectMax with jitter
+        const dataset = (container && container.dataset) || {};
+        this.reconnectMin = parseInt(dataset.reconnectMin || '500');
+        this.reconnectMax = parseInt(dataset.reconnectMax || '30000');
+        this.reconnectMaxAttempts = parseInt(dataset.reconnectAttempts || '0'); // 0 retries forever
+        // Pages of a route group connect through the group, so its middleware applies
+        this.wsPath = dataset.wsPath || '/live/ws';
+        this.reconnectAttempts = 0;
+        this.reconnectTimer = null;
+
+        // Heartbeats find connections that died without a close, e.g. behind a proxy
+        this.heartbeatTimer = null;
+        this.heartbeatRef = null;
+        this.refs = 0;
+
+        // Retry right away when the browser comes back online
+        window.addEventListener('online', () => {
+            if (!this.connected) this.reconnect();
+        });
+    }
+
+    join(view) {
+        this.views.set(view.topic, view);
+        if (this.isOpen()) {
+            this.sendJoin(view);
+        } else if (!this.ws) {
+            this.connect();
+        }
+    }
+
+    leave(view) {
+        if (this.views.get(view.topic) !== view) return;
+        this.views.delete(view.topic);
+        if (this.isOpen()) {
+            this.send(view, { type: 'leave', event: 'lv:leave', payload: {} });
+        }
+    }
+
+    isOpen() {
+        return !!this.ws && this.ws.readyState === WebSocket.OPEN;
+    }
+
+    send(view, msg) {
+        this.ws.send(JSON.stringify(Object.assign({ topic: view.topic }, msg)));
+    }
+
+    sendJoin(view) {
+        this.send(view, {
+            type: 'join',
+            event: 'lv:join',
+            payload: {
+                component: view.componentName,
+                socket_id: view.socketId,
+                nonce: liveNestNonce,
+                cursors: Object.assign({}, view.cursors, view.streamCursors())
+            }
+        });
+    }
+
+    connect() {
+        const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+        let wsUrl = `${protocol}//${window.location.host}${this.wsPath}?vsn=1&nonce=${encodeURIComponent(liveNestNonce)}`;
+        // Forward the page query so components mount with the same params
+        if (window.location.search.length > 1) {
+            wsUrl += `&params=${encodeURIComponent(window.location.search.slice(1))}`;
+        }
+
+        this.ws = new WebSocket(wsUrl);
+
+        this.ws.onmessage = (event) => {
+            const msg = JSON.parse(event.data);
+            if (msg.type === 'reply') {
+                if (msg.data.ref === this.heartbeatRef) this.heartbeatRef = null;
+                return;
+            }
+            const view = this.views.get(msg.topic);
+            if (view) {
+                view.handleMessage(msg);
+            }
+        };
+
+        this.ws.onopen = () => {
+            const reconnected = this.reconnectAttempts > 0;
+            this.reconnectAttempts = 0;
+            this.connected = true;
+            this.startHeartbeat();
+            this.views.forEach(view => {
+                this.sendJoin(view);
+                view.onTransportOpen(reconnected);
+            });
+        };
+
+        this.ws.onclose = (event) => {
+            this.connected = false;
+            this.stopHeartbeat();
+
+            // The server refused the connection (e.g. unauthorized); retrying won't help
+            const final = event.code === 1008;
+            this.views.forEach(view => view.onTransportClose({
+                code: event.code,
+                reason: event.reason,
+                attempt: this.reconnectAttempts,
+                final
+            }));
+
+            if (!final) {
+                this.scheduleReconnect(event);
+            }
+        };
+
+        this.ws.onerror = (error) => {
+            console.error('WebSocket error:', error);
+        };
+    }
+
+    startHeartbeat() {
+        this.stopHeartbeat();
+        this.heartbeatTimer = setInterval(() => {
+            // The previous heartbeat went unanswered: drop the connection and reconnect
+            if (this.heartbeatRef !== null) {
+                this.ws.close();
+                return;
+            }
+            this.heartbeatRef = 'hb' + (++this.refs);
+            this.ws.send(JSON.stringify({ type: 'heartbeat', event: 'lv:heartbeat', ref: this.heartbeatRef }));
+        }, 30000);
+    }
+
+    stopHeartbeat() {
+        clearInterval(this.heartbeatTimer);
+        this.heartbeatTimer = null;
+        this.heartbeatRef = null;
+    }
+
+    reconnect() {
+        // Reconnect now, resetting the backoff
+        clearTimeout(this.reconnectTimer);
+        this.stopHeartbeat();
+        this.reconnectTimer = null;
+        this.reconnectAttempts = 0;
+        if (this.ws && this.ws.readyState !== WebSocket.CLOSED) {
+            this.ws.onclose = null;
+            this.ws.close();
+        }
+        this.connect();
+    }
+
+    scheduleReconnect(event) {
+        if (this.reconnectMaxAttempts > 0 && this.reconnectAttempts >= this.reconnectMaxAttempts) {
+            this.views.forEach(view => view.onTransportClose({
+                code: event.code,
+                reason: event.reason,
+                attempt: this.reconnectAttempts,
+                final: true
+            }));
+            return;
+        }
+
+        // Equal jitter: wait between half and all of the exponential delay
+        const backoff = Math.min(this.reconnectMax, this.reconnectMin * Math.pow(2, this.reconnectAttempts));
+        const delay = Math.round(backoff / 2 + Math.random() * backoff / 2);
+        this.reconnectAttempts++;
+        this.reconnectTimer = setTimeout(() => this.connect(), delay);
+    }
+}
+
 class LiveViewSocket {
-    constructor(componentName, socketId) {
+    constructor(componentName, socketId, container) {
         this.componentName = componentName;
         this.socketId = socketId;
-        this.ws = null;
-        this.container = document.getElementById('liveview');
+        this.transport = null;
+        this.connected = false;
+        this.container = container || document.getElementById('liveview');
+        // Messages on the shared socket are routed by the container's topic
+        this.topic = (this.container && this.container.id) || socketId;
         this.debounceTimers = new Map(); // Store debounce timers per element
+        this.throttleStates = new Map(); // Store throttle state per element
         this.focusedInput = null; // Track currently focused input
         this.cursorPosition = null; // Track cursor position
         this.inputStates = new Map(); // Track input values and cursor positions
         this.pendingInputs = new Set(); // Track inputs with pending server updates
+        this.cursors = {}; // Last message of each subscription, replayed from after a reconnect
+        this.statics = null; // Markup of the last split render, for components using the static split
+        this.dynamics = null; // Text between the statics
+        this.refCounter = 0; // Ref sequence for events awaiting a reply
+        this.pendingRefs = new Map(); // ref -> { el, field } that triggered the event
+        this.loadingTimer = null; // Timer that shows the loading indicator
+        // Milliseconds an event may be in flight before the loading indicator shows
+        this.loadingTimeout = parseInt((this.container && this.container.dataset.loadingTimeout) || '1000');
 
         // Track focus/blur on inputs
         this.setupFocusTracking();
 
-        // Expose globally immediately for form handlers
-        window.liveSocket = this;
+        // Expose the first container globally for form handlers
+        if (!window.liveSocket) {
+            window.liveSocket = this;
+        }
         // Dispatch event so form scripts know liveSocket is ready
         window.dispatchEvent(new CustomEvent('liveSocketReady'));
     }
@@ -86,45 +324,552 @@ class LiveViewSocket {
 
     connect() {
         this.attachEventListeners();
-        this.connectWebSocket();
+        this.attachWindowListeners();
+        this.transport = LiveNestTransport.shared(this.container);
+        this.transport.join(this);
+
+        if (window.liveSocket === this) {
+            this.activatePageFlashes();
+            this.restoreFlashes();
+        }
     }
 
-    connectWebSocket() {
-        const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-        const wsUrl = `${protocol}//${window.location.host}/live/ws/${this.componentName}?socket_id=${this.socketId}`;
+    disconnect() {
+        // Unmount this container; the shared socket stays open for the others
+        if (this.transport) {
+            this.transport.leave(this);
+        }
+        this.clearAllPending();
+    }
 
-        this.ws = new WebSocket(wsUrl);
+    reconnect() {
+        if (this.transport) {
+            this.transport.reconnect();
+        }
+    }
 
-        this.ws.onmessage = (event) => {
-            const msg = JSON.parse(event.data);
+    onTransportOpen(reconnected) {
+        this.connected = true;
+        this.hideIndicator();
+        this.emitStatus('livenest:connected', { reconnected });
+    }
 
-            if (msg.type === 'render') {
-                // Handle diff-based updates (Phoenix LiveView style)
-                if (msg.data.diff) {
-                    this.applyDiff(msg.data.diff);
-                } else if (msg.data.html) {
-                    // Full HTML replacement (initial render)
-                    this.patch(msg.data.html);
-                }
+    onTransportClose(detail) {
+        // Events in flight will never be answered by the old connection
+        this.clearAllPending();
+
+        if (detail.final) {
+            this.connected = false;
+            this.showIndicator('Disconnected');
+            this.emitStatus('livenest:disconnected', detail);
+            return;
+        }
+
+        if (this.connected) {
+            this.connected = false;
+            this.emitStatus('livenest:disconnected', detail);
+        }
+        this.showIndicator('Reconnecting...');
+    }
+
+    emitStatus(name, detail) {
+        this.container.dispatchEvent(new CustomEvent(name, { bubbles: true, detail }));
+    }
+
+    attachWindowListeners() {
+        // Window and document bindings are looked up when the event fires,
+        // so elements added by later renders work without re-attaching
+        ['keydown', 'keyup'].forEach(type => {
+            window.addEventListener(type, (e) => {
+                this.container.querySelectorAll(`[lv-window-${type}]`).forEach(el => {
+                    const spec = el.getAttribute('lv-key');
+                    if (!this.matchesKey(e, spec)) return;
+                    // Plain keys are left alone while the user types in a field
+                    if (this.isEditable(e.target) && !this.hasModifier(spec)) return;
+
+                    this.dispatchBinding(el, `lv-window-${type}`, {
+                        key: e.key,
+                        ctrlKey: e.ctrlKey,
+                        metaKey: e.metaKey,
+                        shiftKey: e.shiftKey,
+                        altKey: e.altKey
+                    });
+                });
+            });
+        });
+
+        ['focus', 'blur'].forEach(type => {
+            window.addEventListener(type, () => {
+                this.container.querySelectorAll(`[lv-window-${type}]`).forEach(el => {
+                    this.dispatchBinding(el, `lv-window-${type}`, {});
+                });
+            });
+        });
+
+        document.addEventListener('visibilitychange', () => {
+            this.container.querySelectorAll('[lv-document-visibilitychange]').forEach(el => {
+                this.dispatchBinding(el, 'lv-document-visibilitychange', {
+                    visible: document.visibilityState === 'visible',
+                    state: document.visibilityState
+                });
+            });
+        });
+    }
+
+    observeViewport() {
+        // lv-viewport-top fires when the first child of the element scrolls into view,
+        // lv-viewport-bottom when the last child does. Sentinels are only observed
+        // once, so a binding fires again only after rows were added or removed
+        if (!('IntersectionObserver' in window)) return;
+
+        if (!this.viewportObserver) {
+            this.viewportSentinels = new Map(); // sentinel -> { el, attr }
+            this.viewportObserver = new IntersectionObserver(entries => {
+                entries.forEach(entry => {
+                    const binding = this.viewportSentinels.get(entry.target);
+                    if (entry.isIntersecting && binding) {
+                        this.dispatchBinding(binding.el, binding.attr, {});
+                    }
+                });
+            });
+        }
 
-                // Handle flash messages if present
-                if (msg.data.flash) {
-                    this.showFlash(msg.data.flash);
+        const current = new Map();
+        [['top', 'firstElementChild'], ['bottom', 'lastElementChild']].forEach(([edge, child]) => {
+            this.container.querySelectorAll(`[lv-viewport-${edge}]`).forEach(el => {
+                const sentinel = el[child];
+                if (sentinel) {
+                    current.set(sentinel, { el, attr: `lv-viewport-${edge}` });
                 }
+            });
+        });
+
+        this.viewportSentinels.forEach((binding, sentinel) => {
+            if (!current.has(sentinel)) {
+                this.viewportObserver.unobserve(sentinel);
             }
-        };
+        });
+        current.forEach((binding, sentinel) => {
+            if (!this.viewportSentinels.has(sentinel)) {
+                this.viewportObserver.observe(sentinel);
+            }
+        });
+        this.viewportSentinels = current;
+    }
 
-        this.ws.onopen = () => {
-            // WebSocket connected
-        };
+    dispatchBinding(el, attr, extra) {
+        const event = el.getAttribute(attr);
+        this.schedule(el, () => {
+            const payload = Object.assign(this.getPayloadFromElement(el), extra);
+            this.run(el, event, payload);
+        });
+    }
 
-        this.ws.onclose = (event) => {
-            setTimeout(() => this.connectWebSocket(), 1000);
+    run(el, binding, payload) {
+        // A binding is either an event name or a JSON list of commands built with the js package
+        const commands = this.parseCommands(binding);
+        if (commands) {
+            this.execCommands(el, commands, payload);
+        } else {
+            this.pushEvent(binding, payload, el);
+        }
+    }
+
+    parseCommands(binding) {
+        if (!binding || binding.trim().charAt(0) !== '[') return null;
+        try {
+            return JSON.parse(binding);
+        } catch (e) {
+            console.error('LiveView: invalid command list', binding, e);
+            return [];
+        }
+    }
+
+    execCommands(el, commands, payload) {
+        // Commands run on the client immediately; only "push" talks to the server
+        commands.forEach(cmd => {
+            const args = cmd.args || {};
+            const targets = cmd.to ? Array.from(document.querySelectorAll(cmd.to)) : [el];
+            const classes = (args.names || '').split(/\s+/).filter(Boolean);
+
+            switch (cmd.op) {
+                case 'show':
+                    targets.forEach(target => this.showElement(target, args.display));
+                    break;
+                case 'hide':
+                    targets.forEach(target => { target.style.display = 'none'; });
+                    break;
+                case 'toggle':
+                    targets.forEach(target => {
+                        if (this.isHidden(target)) {
+                            this.showElement(target, args.display);
+                        } else {
+                            target.style.display = 'none';
+                        }
+                    });
+                    break;
+                case 'add_class':
+                    targets.forEach(target => target.classList.add(...classes));
+                    break;
+                case 'remove_class':
+                    targets.forEach(target => target.classList.remove(...classes));
+                    break;
+                case 'toggle_class':
+                    targets.forEach(target => classes.forEach(name => target.classList.toggle(name)));
+                    break;
+                case 'set_attr':
+                    targets.forEach(target => target.setAttribute(args.name, args.value));
+                    break;
+                case 'remove_attr':
+                    targets.forEach(target => target.removeAttribute(args.name));
+                    break;
+                case 'focus':
+                    if (targets[0]) targets[0].focus();
+                    break;
+                case 'push':
+                    this.pushEvent(args.event, Object.assign({}, payload, args.payload), el);
+                    break;
+                case 'dispatch':
+                    targets.forEach(target => target.dispatchEvent(new CustomEvent(args.event, {
+                        bubbles: true,
+                        detail: args.detail || {}
+                    })));
+                    break;
+                default:
+                    console.error(`LiveView: unknown command "${cmd.op}"`);
+            }
+        });
+    }
+
+    showElement(el, display) {
+        el.style.display = display || '';
+        // Elements hidden by a stylesheet need an explicit display value
+        if (this.isHidden(el)) {
+            el.style.display = 'block';
+        }
+    }
+
+    isHidden(el) {
+        return window.getComputedStyle(el).display === 'none';
+    }
+
+    matchesKey(e, spec) {
+        // spec is a key name optionally prefixed with modifiers, e.g. "Enter", "ctrl+k" or "mod+s"
+        // "mod" matches Ctrl or Cmd so shortcuts work across platforms
+        if (!spec) return true;
+
+        const parts = spec.split('+').map(part => part.trim().toLowerCase());
+        const key = parts.pop();
+        if (e.key.toLowerCase() !== key) return false;
+
+        const wanted = new Set(parts);
+        const mod = wanted.has('mod');
+        if (mod && !(e.ctrlKey || e.metaKey)) return false;
+        if (!mod && wanted.has('ctrl') !== e.ctrlKey) return false;
+        if (!mod && wanted.has('meta') !== e.metaKey) return false;
+        if (wanted.has('alt') !== e.altKey) return false;
+        // Shift is only checked when asked for, since it changes e.key for symbols
+        if (wanted.has('shift') && !e.shiftKey) return false;
+        return true;
+    }
+
+    hasModifier(spec) {
+        return !!spec && spec.includes('+');
+    }
+
+    isEditable(el) {
+        return !!el && (el.tagName === 'INPUT' || el.tagName === 'TEXTAREA' || el.tagName === 'SELECT' || el.isContentEditable);
+    }
+
+    handleMessage(msg) {
+        if (msg.type === 'render' && msg.data.redirect) {
+            this.followRedirect(msg.data);
+        } else if (msg.type === 'render') {
+            // Clear the loading state of the event this render replies to
+            const replied = msg.data.ref ? this.clearPending(msg.data.ref) : null;
+
+            // Handle diff-based updates (Phoenix LiveView style)
+            // Focus, selection and scroll positions survive the patch
+            if (msg.data.diff) {
+                this.preserveState(() => this.applyDiff(msg.data.diff));
+            } else if (msg.data.html) {
+                // Full HTML replacement (initial render, or components without diffs)
+                this.statics = null;
+                this.preserveState(() => this.patch(msg.data.html));
+                this.clearUnavailable();
+            } else if (msg.data.split) {
+                this.preserveState(() => this.patch(this.applySplit(msg.data.split)));
+            }
+
+            // Append streamed rows once their containers are in place
+            if (msg.data.streams) {
+                this.applyStreams(msg.data.streams);
+            }
+
+            // Remember how far subscriptions got, for the next join
+            if (msg.data.cursors) {
+                Object.assign(this.cursors, msg.data.cursors);
+            }
+
+            // Mark the field that triggered the event as checked, after patching
+            if (replied && replied.field) {
+                this.resolveField(replied.field);
+            }
+
+            // Handle flash messages if present
+            if (msg.data.flashes) {
+                this.showFlashes(msg.data.flashes);
+            }
+
+            // Show queued toasts
+            if (msg.data.toasts) {
+                msg.data.toasts.forEach(toast => this.showToast(toast));
+            }
+
+            // Update the document title if the server changed it
+            if (msg.data.title !== undefined) {
+                document.title = msg.data.title;
+            }
+
+            // Debug mode reports the event's timing and database queries
+            if (msg.data.debug) {
+                this.showDebug(msg.data.debug);
+            }
+
+            if (msg.data.print) {
+                this.printPage();
+            }
+        } else if (msg.type === 'error') {
+            this.handleError(msg.data);
+        }
+    }
+
+    streamCursors() {
+        // Cursors of the rows stream containers already hold, reported when joining again
+        const cursors = {};
+        this.container.querySelectorAll('[lv-update="stream"][id][data-lv-cursor]').forEach(el => {
+            cursors[el.id] = el.getAttribute('data-lv-cursor');
+        });
+        return cursors;
+    }
+
+    applyStreams(ops) {
+        // Each op empties and/or appends to an lv-update="stream" container by id
+        ops.forEach(op => {
+            const el = document.getElementById(op.target);
+            if (!el || !this.container.contains(el)) {
+                console.warn(`LiveView stream container #${op.target} not found`);
+                return;
+            }
+            if (op.reset) {
+                el.innerHTML = '';
+                el.removeAttribute('data-lv-cursor');
+            }
+            if (op.html) {
+                el.insertAdjacentHTML('beforeend', op.html);
+            }
+            // The cursor survives reconnects with the rows, so the server can resume after it
+            if (op.cursor) {
+                el.setAttribute('data-lv-cursor', op.cursor);
+            }
+        });
+        this.attachEventListeners();
+    }
+
+    printPage() {
+        // The printable variant is rendered by the server into a hidden frame, so the
+        // live page keeps its state and no popup is opened
+        const url = new URL(window.location.href);
+        url.searchParams.set('print', '1');
+        const frame = document.createElement('iframe');
+        frame.setAttribute('aria-hidden', 'true');
+        frame.style.cssText = 'position:fixed;width:0;height:0;border:0;visibility:hidden';
+        frame.onload = () => {
+            const win = frame.contentWindow;
+            win.addEventListener('afterprint', () => frame.remove());
+            win.focus();
+            win.print();
         };
+        frame.src = url.toString();
+        document.body.appendChild(frame);
+    }
 
-        this.ws.onerror = (error) => {
-            console.error('WebSocket error:', error);
+    followRedirect(data) {
+        // Flashes of the redirecting event are shown on the page redirected to
+        if (!data.redirect.external && data.flashes) {
+            try {
+                sessionStorage.setItem('lv-flashes', JSON.stringify(data.flashes));
+            } catch (e) {
+                // Storage may be unavailable (private mode); the flashes are dropped
+            }
+        }
+        this.clearAllPending();
+        window.location.assign(data.redirect.to);
+    }
+
+    restoreFlashes() {
+        let flashes = null;
+        try {
+            flashes = JSON.parse(sessionStorage.getItem('lv-flashes'));
+            sessionStorage.removeItem('lv-flashes');
+        } catch (e) {
+            return;
+        }
+        if (flashes && flashes.length) {
+            this.showFlashes(flashes);
+        }
+    }
+
+    handleError(error) {
+        // Errors are reported by the server for failed handlers, in strict mode
+        // (e.g. unknown events) and when a container can't be mounted
+        console.error(`LiveView error (${error.reason}): ${error.message}`);
+        if (error.reason === 'unavailable' && error.event === 'lv:join') {
+            // A dependency such as the database is down; the server probes it, so join again later
+            this.showUnavailable(error);
+        } else if (error.event === 'lv:join' || error.reason === 'disconnected') {
+            // Don't rejoin a container the server refused or disconnected
+            this.transport.leave(this);
+            this.onTransportClose({ code: 0, reason: error.reason, attempt: 0, final: true });
+        } else if (error.reason === 'timeout' && this.transport.isOpen()) {
+            // The server dropped the component after a hung handler; mount it again
+            this.transport.sendJoin(this);
+        }
+
+        if (error.reason === 'unavailable') {
+            // Outages are expected to pass, so they get a notice rather than the overlay
+            if (error.event !== 'lv:join') {
+                this.showFlash({ type: 'warning', message: error.message });
+            }
+        } else if (error.debug) {
+            // Debug mode sends the real message and stack trace
+            this.showErrorOverlay(error);
+        } else if (['handler_error', 'panic', 'timeout', 'protocol_error'].includes(error.reason)) {
+            this.showFlash({ type: 'error', message: error.message });
+        }
+
+        this.container.dispatchEvent(new CustomEvent('livenest:error', {
+            bubbles: true,
+            detail: error
+        }));
+    }
+
+    showUnavailable(error) {
+        this.container.classList.add('lv-unavailable');
+        this.showIndicator('Temporarily unavailable, retrying...');
+        clearTimeout(this.rejoinTimer);
+        this.rejoinTimer = setTimeout(() => {
+            if (this.transport.isOpen()) {
+                this.transport.sendJoin(this);
+            }
+        }, Math.max(error.retry_after_ms || 0, 1000));
+    }
+
+    clearUnavailable() {
+        if (!this.container.classList.contains('lv-unavailable')) return;
+        clearTimeout(this.rejoinTimer);
+        this.container.classList.remove('lv-unavailable');
+        this.hideIndicator();
+    }
+
+    showErrorOverlay(error) {
+        this.ensureErrorOverlayStyles();
+        document.getElementById('lv-error-overlay')?.remove();
+
+        const overlay = document.createElement('div');
+        overlay.id = 'lv-error-overlay';
+        overlay.className = 'lv-error-overlay';
+
+        // Built with textContent so error text is never parsed as HTML
+        const panel = document.createElement('div');
+        panel.className = 'lv-error-panel';
+
+        const title = document.createElement('h2');
+        title.textContent = `${error.reason === 'panic' ? 'Panic' : 'Error'} in ${this.componentName}` +
+            (error.event ? ` while handling "${error.event}"` : '');
+        panel.appendChild(title);
+
+        const message = document.createElement('p');
+        message.className = 'lv-error-message';
+        message.textContent = error.message;
+        panel.appendChild(message);
+
+        (error.causes || []).forEach(cause => {
+            const causeLine = document.createElement('p');
+            causeLine.className = 'lv-error-cause';
+            causeLine.textContent = `caused by: ${cause}`;
+            panel.appendChild(causeLine);
+        });
+
+        if (error.stack) {
+            const stack = document.createElement('pre');
+            stack.className = 'lv-error-stack';
+            stack.textContent = error.stack;
+            panel.appendChild(stack);
+        }
+
+        const hint = document.createElement('p');
+        hint.className = 'lv-error-hint';
+        hint.textContent = 'Click outside or press Escape to dismiss. This overlay is only shown in debug mode.';
+        panel.appendChild(hint);
+
+        overlay.appendChild(panel);
+        document.body.appendChild(overlay);
+
+        const close = () => {
+            overlay.remove();
+            document.removeEventListener('keydown', onKey);
         };
+        const onKey = (e) => {
+            if (e.key === 'Escape') close();
+        };
+        overlay.addEventListener('click', (e) => {
+            if (e.target === overlay) close();
+        });
+        document.addEventListener('keydown', onKey);
+    }
+
+    ensureErrorOverlayStyles() {
+        if (document.getElementById('lv-error-styles')) return;
+        const style = document.createElement('style');
+        style.id = 'lv-error-styles';
+        if (liveNestNonce) {
+            style.setAttribute('nonce', liveNestNonce);
+        }
+        style.textContent = `
+            .lv-error-overlay {
+                position: fixed;
+                inset: 0;
+                background: rgba(0,0,0,0.75);
+                display: flex;
+                align-items: center;
+                justify-content: center;
+                z-index: 10000;
+            }
+            .lv-error-panel {
+                background: #1e1e1e;
+                color: #eee;
+                width: min(960px, 92vw);
+                max-height: 88vh;
+                overflow: auto;
+                padding: 24px 28px;
+                border-top: 6px solid #e74c3c;
+                border-radius: 6px;
+                font-family: monospace;
+            }
+            .lv-error-panel h2 { margin: 0 0 12px; color: #e74c3c; }
+            .lv-error-message { font-size: 16px; white-space: pre-wrap; }
+            .lv-error-cause { color: #f39c12; margin: 4px 0; }
+            .lv-error-stack {
+                background: #111;
+                padding: 12px;
+                font-size: 12px;
+                line-height: 1.4;
+                overflow-x: auto;
+            }
+            .lv-error-hint { color: #888; font-size: 12px; }
+        `;
+        document.head.appendChild(style);
     }
 
     attachEventListeners() {
@@ -138,51 +883,53 @@ class LiveViewSocket {
             const event = el.getAttribute('lv-click');
             el.addEventListener('click', (e) => {
                 e.preventDefault();
-                const payload = this.getPayloadFromElement(el);
-                this.pushEvent(event, payload);
+                this.schedule(el, () => {
+                    const payload = this.getPayloadFromElement(el);
+                    this.run(el, event, payload);
+                });
             });
         });
 
-        // Handle lv-change events with debouncing
+        // Handle lv-change on inputs and forms
+        // Typing is debounced by 300ms unless lv-debounce or lv-throttle say otherwise
         const changeElements = this.container.querySelectorAll('[lv-change]');
         changeElements.forEach(el => {
             if (el.__lv_change_attached) return;
             el.__lv_change_attached = true;
 
             const event = el.getAttribute('lv-change');
-            // Get debounce time from lv-debounce attribute (default: 300ms)
-            const debounceMs = parseInt(el.getAttribute('lv-debounce') || '300');
+            const isForm = el.tagName === 'FORM';
 
             el.addEventListener('input', (e) => {
-                // Clear existing timer for this element
-                const timerId = this.debounceTimers.get(el);
-                if (timerId) {
-                    clearTimeout(timerId);
-                }
+                const target = e.target;
+                // Forms rate limit per control when the control asks for it
+                const limiter = isForm && !this.hasRateLimit(target) ? el : target;
+                const defaultDebounce = this.isTextInput(target) ? 300 : 0;
 
-                // Set new timer
-                const newTimerId = setTimeout(() => {
+                this.schedule(limiter, () => {
                     const payload = this.getPayloadFromElement(el);
-                    payload.value = el.value;
-                    this.pushEvent(event, payload);
-                    this.debounceTimers.delete(el);
+                    if (isForm) {
+                        Object.assign(payload, this.serializeForm(el));
+                        payload._target = target.name || '';
+                    } else {
+                        payload.value = this.inputValue(el);
+                    }
+                    this.pushEvent(event, payload, el);
 
                     // Clear pending after a short delay to allow server to catch up
                     // This gives the server time to process and respond
                     // If user keeps typing, it will be marked pending again
                     setTimeout(() => {
                         // Only clear if input is still focused but user hasn't typed more
-                        if (this.focusedInput !== el) {
-                            this.pendingInputs.delete(el);
+                        if (this.focusedInput !== target) {
+                            this.pendingInputs.delete(target);
                         }
                     }, 100);
-                }, debounceMs);
-
-                this.debounceTimers.set(el, newTimerId);
+                }, defaultDebounce);
             });
         });
 
-        // Handle lv-submit events
+        // Handle lv-submit events, sending every named control of the form
         const formElements = this.container.querySelectorAll('[lv-submit]');
         formElements.forEach(el => {
             if (el.__lv_submit_attached) return;
@@ -192,14 +939,168 @@ class LiveViewSocket {
             el.addEventListener('submit', (e) => {
                 e.preventDefault();
                 const payload = this.getPayloadFromElement(el);
-                this.pushEvent(event, payload);
+                if (el.tagName === 'FORM') {
+                    Object.assign(payload, this.serializeForm(el));
+                }
+                this.run(el, event, payload);
+
+                // lv-reset clears the form once it has been sent
+                if (el.tagName === 'FORM' && el.hasAttribute('lv-reset')) {
+                    el.reset();
+                    Array.from(el.elements).forEach(input => this.pendingInputs.delete(input));
+                }
             });
         });
+
+        // Watch lv-viewport-top / lv-viewport-bottom sentinels
+        this.observeViewport();
+
+        // Patches restore the server's text of lv-time elements
+        LiveNestTime.refresh(this.container);
+
+        // Handle lv-keydown and lv-keyup, optionally filtered with lv-key="Enter"
+        ['keydown', 'keyup'].forEach(type => {
+            const keyElements = this.container.querySelectorAll(`[lv-${type}]`);
+            keyElements.forEach(el => {
+                const flag = `__lv_${type}_attached`;
+                if (el[flag]) return;
+                el[flag] = true;
+
+                const event = el.getAttribute(`lv-${type}`);
+                el.addEventListener(type, (e) => {
+                    if (!this.matchesKey(e, el.getAttribute('lv-key'))) return;
+
+                    this.schedule(el, () => {
+                        const payload = this.getPayloadFromElement(el);
+                        payload.key = e.key;
+                        if ('value' in el) {
+                            payload.value = this.inputValue(el);
+                        }
+                        this.run(el, event, payload);
+                    });
+                });
+            });
+        });
+    }
+
+    serializeForm(form) {
+        // Collect named controls into a payload; repeated names become arrays
+        const values = {};
+        Array.from(form.elements).forEach(input => {
+            if (!input.name || input.disabled) return;
+            if (['submit', 'button', 'reset', 'file'].includes(input.type)) return;
+
+            let value;
+            if (input.type === 'checkbox' && !input.hasAttribute('value')) {
+                // Bare checkboxes report their state
+                value = input.checked.toString();
+            } else if (input.type === 'checkbox' || input.type === 'radio') {
+                if (!input.checked) return;
+                value = input.value;
+            } else if (input.tagName === 'SELECT' && input.multiple) {
+                value = Array.from(input.selectedOptions).map(option => option.value);
+            } else {
+                value = input.value;
+            }
+
+            if (Object.prototype.hasOwnProperty.call(values, input.name)) {
+                values[input.name] = [].concat(values[input.name], value);
+            } else {
+                values[input.name] = value;
+            }
+        });
+        return values;
+    }
+
+    inputValue(el) {
+        // Checkboxes report their checked state as "true" or "false"
+        if (el.type === 'checkbox') {
+            return el.checked.toString();
+        }
+        return el.value;
+    }
+
+    isTextInput(el) {
+        if (el.tagName === 'TEXTAREA') return true;
+        if (el.tagName !== 'INPUT') return false;
+        return !['checkbox', 'radio', 'range', 'color', 'date', 'datetime-local', 'month', 'time', 'week', 'file'].includes(el.type);
+    }
+
+    hasRateLimit(el) {
+        return el.hasAttribute && (el.hasAttribute('lv-debounce') || el.hasAttribute('lv-throttle'));
+    }
+
+    schedule(el, callback, defaultDebounce = 0) {
+        // Rate limit callbacks per element with lv-throttle or lv-debounce (milliseconds)
+        const throttle = el.getAttribute('lv-throttle');
+        if (throttle !== null) {
+            this.throttle(el, parseInt(throttle) || 0, callback);
+            return;
+        }
+
+        const debounce = el.getAttribute('lv-debounce');
+        const debounceMs = debounce !== null ? (parseInt(debounce) || 0) : defaultDebounce;
+        if (debounceMs > 0) {
+            this.debounce(el, debounceMs, callback);
+            return;
+        }
+
+        callback();
+    }
+
+    debounce(el, ms, callback) {
+        // Run callback once input has been quiet for ms
+        const timerId = this.debounceTimers.get(el);
+        if (timerId) {
+            clearTimeout(timerId);
+        }
+
+        this.debounceTimers.set(el, setTimeout(() => {
+            this.debounceTimers.delete(el);
+            callback();
+        }, ms));
+    }
+
+    throttle(el, ms, callback) {
+        // Run callback at most once every ms, keeping the latest call for the end of the window
+        const state = this.throttleStates.get(el) || { last: 0, timer: null, callback: null };
+        this.throttleStates.set(el, state);
+
+        const wait = state.last + ms - Date.now();
+        if (wait <= 0 && !state.timer) {
+            state.last = Date.now();
+            callback();
+            return;
+        }
+
+        state.callback = callback;
+        if (!state.timer) {
+            state.timer = setTimeout(() => {
+                state.timer = null;
+                state.last = Date.now();
+                const pending = state.callback;
+                state.callback = null;
+                if (pending) {
+                    pending();
+                }
+            }, Math.max(wait, 0));
+        }
     }
 
     getPayloadFromElement(el) {
         const payload = {};
-        // Collect all lv-value-* attributes
+
+        // lv-values holds a JSON object, so numbers and booleans keep their type
+        const values = el.getAttribute('lv-values');
+        if (values) {
+            try {
+                Object.assign(payload, JSON.parse(values));
+            } catch (e) {
+                console.error('LiveView: invalid lv-values JSON', values);
+            }
+        }
+
+        // Collect all lv-value-* attributes as strings
         Array.from(el.attributes).forEach(attr => {
             if (attr.name.startsWith('lv-value-')) {
                 const key = attr.name.replace('lv-value-', '');
@@ -209,13 +1110,265 @@ class LiveViewSocket {
         return payload;
     }
 
-    pushEvent(event, payload) {
-        if (this.ws && this.ws.readyState === WebSocket.OPEN) {
-            this.ws.send(JSON.stringify({
+    pushEvent(event, payload, el) {
+        if (this.connected && this.transport.isOpen()) {
+            const ref = String(++this.refCounter);
+            // Form changes carry the changed field in _target
+            this.markPending(ref, el, payload && payload._target);
+            this.transport.send(this, {
                 event: event,
-                payload: payload
-            }));
+                payload: payload,
+                ref: ref
+            });
+        }
+    }
+
+    markPending(ref, el, field) {
+        // Loading state lasts until the server replies with the same ref
+        this.pendingRefs.set(ref, { el: el || null, field: field || null });
+        this.container.classList.add('lv-loading');
+
+        if (field) {
+            this.fieldElements(field).forEach(node => {
+                node.classList.add('lv-pending');
+                node.classList.remove('lv-resolved');
+            });
+        }
+
+        if (el) {
+            el.classList.add('lv-loading');
+
+            // Forms disable their submit buttons, other elements disable themselves
+            const targets = el.tagName === 'FORM'
+                ? Array.from(el.querySelectorAll('[lv-disable-with]'))
+                : (el.hasAttribute('lv-disable-with') ? [el] : []);
+            targets.forEach(target => {
+                if (target.__lv_original_text === undefined) {
+                    target.__lv_original_text = target.textContent;
+                    target.__lv_was_disabled = target.disabled;
+                }
+                target.textContent = target.getAttribute('lv-disable-with');
+                target.disabled = true;
+            });
+            el.__lv_disabled_targets = targets;
+        }
+
+        if (!this.loadingTimer) {
+            this.loadingTimer = setTimeout(() => {
+                this.loadingTimer = null;
+                if (this.pendingRefs.size > 0) {
+                    this.showIndicator('Loading...');
+                }
+            }, this.loadingTimeout);
+        }
+    }
+
+    clearPending(ref) {
+        if (!this.pendingRefs.has(ref)) return null;
+        const entry = this.pendingRefs.get(ref);
+        const el = entry.el;
+        this.pendingRefs.delete(ref);
+
+        // Keep the element loading while another event from it is still in flight
+        const stillPending = el && Array.from(this.pendingRefs.values()).some(other => other.el === el);
+        if (el && !stillPending) {
+            el.classList.remove('lv-loading');
+            (el.__lv_disabled_targets || []).forEach(target => {
+                if (target.__lv_original_text !== undefined) {
+                    target.textContent = target.__lv_original_text;
+                    target.disabled = target.__lv_was_disabled;
+                    delete target.__lv_original_text;
+                    delete target.__lv_was_disabled;
+                }
+            });
+            el.__lv_disabled_targets = null;
+        }
+
+        if (this.pendingRefs.size === 0) {
+            this.container.classList.remove('lv-loading');
+            clearTimeout(this.loadingTimer);
+            this.loadingTimer = null;
+            this.hideIndicator();
+        }
+
+        return entry;
+    }
+
+    fieldElements(field) {
+        // The named controls of a field plus anything marked lv-feedback-for="field"
+        const name = CSS.escape(field);
+        return Array.from(this.container.querySelectorAll(`[name="${name}"], [lv-feedback-for="${name}"]`));
+    }
+
+    resolveField(field) {
+        // A newer change to the same field is still waiting for the server
+        const stillPending = Array.from(this.pendingRefs.values()).some(other => other.field === field);
+        if (stillPending) return;
+
+        this.fieldElements(field).forEach(node => {
+            node.classList.remove('lv-pending');
+            node.classList.add('lv-resolved');
+        });
+    }
+
+    clearAllPending() {
+        Array.from(this.pendingRefs.entries()).forEach(([ref, entry]) => {
+            this.clearPending(ref);
+            if (entry.field) {
+                this.fieldElements(entry.field).forEach(node => node.classList.remove('lv-pending'));
+            }
+        });
+    }
+
+    showIndicator(text) {
+        let indicator = document.getElementById('lv-indicator');
+        if (!indicator) {
+            this.ensureIndicatorStyles();
+            indicator = document.createElement('div');
+            indicator.id = 'lv-indicator';
+            indicator.className = 'lv-indicator';
+            document.body.appendChild(indicator);
+        }
+        indicator.textContent = text;
+        indicator.hidden = false;
+    }
+
+    hideIndicator() {
+        const indicator = document.getElementById('lv-indicator');
+        if (indicator) {
+            indicator.hidden = true;
+        }
+    }
+
+    ensureIndicatorStyles() {
+        if (document.getElementById('lv-indicator-styles')) return;
+        const style = document.createElement('style');
+        style.id = 'lv-indicator-styles';
+        if (liveNestNonce) {
+            style.setAttribute('nonce', liveNestNonce);
+        }
+        style.textContent = `
+            .lv-indicator {
+                position: fixed;
+                top: 0;
+                left: 50%;
+                transform: translateX(-50%);
+                padding: 6px 16px;
+                border-radius: 0 0 5px 5px;
+                background: #34495e;
+                color: white;
+                font-size: 13px;
+                z-index: 10000;
+            }
+            .lv-indicator[hidden] {
+                display: none;
+            }
+        `;
+        document.head.appendChild(style);
+    }
+
+    preserveState(patch) {
+        // Record focus, selection and scroll positions, run the patch, then restore them
+        // Elements are found again by id, by name or by their position in the container
+        const active = document.activeElement;
+        let focus = null;
+        if (active && active !== document.body && this.container.contains(active)) {
+            focus = { el: active, key: this.elementKey(active) };
+            if (typeof active.selectionStart === 'number') {
+                try {
+                    focus.selection = [active.selectionStart, active.selectionEnd, active.selectionDirection];
+                } catch (e) {
+                    // Some input types don't expose a selection
+                }
+            }
+        }
+
+        const scrolls = [];
+        this.container.querySelectorAll('*').forEach(el => {
+            if (el.scrollTop || el.scrollLeft) {
+                scrolls.push({ el, key: this.elementKey(el), top: el.scrollTop, left: el.scrollLeft });
+            }
+        });
+        const windowScroll = [window.scrollX, window.scrollY];
+
+        patch();
+
+        if (focus) {
+            const el = this.container.contains(focus.el) ? focus.el : this.findByKey(focus.key);
+            if (el && document.activeElement !== el) {
+                el.focus({ preventScroll: true });
+            }
+            if (el && focus.selection && el === document.activeElement && !this.pendingInputs.has(el)) {
+                try {
+                    el.setSelectionRange(...focus.selection);
+                } catch (e) {
+                    // Ignore errors for input types that don't support selection
+                }
+            }
+        }
+
+        scrolls.forEach(({ el, key, top, left }) => {
+            const target = this.container.contains(el) ? el : this.findByKey(key);
+            if (!target) return;
+            if (target.scrollTop !== top) target.scrollTop = top;
+            if (target.scrollLeft !== left) target.scrollLeft = left;
+        });
+
+        if (window.scrollX !== windowScroll[0] || window.scrollY !== windowScroll[1]) {
+            window.scrollTo(windowScroll[0], windowScroll[1]);
+        }
+    }
+
+    elementKey(el) {
+        if (el.id) return { id: el.id };
+        if (el.name && el.form) return { name: el.name, form: this.elementKey(el.form) };
+
+        // Child indices from the container
+        const path = [];
+        for (let node = el; node && node !== this.container; node = node.parentElement) {
+            path.unshift(Array.prototype.indexOf.call(node.parentElement.children, node));
+        }
+        return { path };
+    }
+
+    findByKey(key) {
+        if (key.id) {
+            const el = document.getElementById(key.id);
+            return el && this.container.contains(el) ? el : null;
+        }
+        if (key.name) {
+            const form = this.findByKey(key.form);
+            return form && form.elements ? form.elements.namedItem(key.name) : null;
+        }
+
+        let node = this.container;
+        for (const index of key.path) {
+            node = node && node.children[index];
+        }
+        return node && node !== this.container ? node : null;
+    }
+
+    isIgnored(node) {
+        // lv-update="ignore" marks client-owned regions the server never patches;
+        // lv-update="stream" containers hold rows only the client keeps
+        const el = node.nodeType === Node.ELEMENT_NODE ? node : node.parentElement;
+        const ignored = el && el.closest('[lv-update="ignore"], [lv-update="stream"]');
+        return !!ignored && this.container.contains(ignored);
+    }
+
+    applySplit(split) {
+        // New statics come with every dynamic; otherwise only changed dynamics, by index
+        if (split.s) {
+            this.statics = split.s;
+            this.dynamics = new Array(split.s.length - 1).fill('');
+        }
+        if (!this.statics) {
+            return '';
         }
+        for (const [i, text] of Object.entries(split.d || {})) {
+            this.dynamics[i] = text;
+        }
+        return this.statics.map((s, i) => s + (i < this.dynamics.length ? this.dynamics[i] : '')).join('');
     }
 
     applyDiff(diff) {
@@ -271,7 +1424,7 @@ class LiveViewSocket {
     }
 
     applyNodeChanges(parent, node, index, changes) {
-        if (!node) {
+        if (!node || this.isIgnored(node)) {
             return;
         }
 
@@ -303,9 +1456,11 @@ class LiveViewSocket {
                 }
             }
 
-            // Check if this node contains a focused input
-            // If so, use morphdom instead of replacement to preserve input state
-            if (this.focusedInput && node.contains && node.contains(this.focusedInput)) {
+            // Check if this node contains a focused input or an ignored region
+            // If so, use morphdom instead of replacement to preserve their state
+            const keepsState = (this.focusedInput && node.contains && node.contains(this.focusedInput)) ||
+                (node.querySelector && node.querySelector('[lv-update="ignore"], [lv-update="stream"]'));
+            if (keepsState) {
                 const temp = document.createElement('div');
                 temp.innerHTML = content;
                 const newNode = temp.firstElementChild;
@@ -398,6 +1553,12 @@ class LiveViewSocket {
             return;
         }
 
+        // Client-owned regions keep whatever the client put there
+        if (fromNode.nodeType === Node.ELEMENT_NODE &&
+            (fromNode.getAttribute('lv-update') === 'ignore' || fromNode.getAttribute('lv-update') === 'stream')) {
+            return;
+        }
+
         // If nodes are different types, replace entirely
         if (fromNode.nodeName !== toNode.nodeName) {
             fromNode.parentNode.replaceChild(toNode.cloneNode(true), fromNode);
@@ -525,83 +1686,363 @@ class LiveViewSocket {
         });
     }
 
+    showFlashes(flashes) {
+        // A new batch of flashes replaces the ones on screen
+        document.querySelectorAll('.lv-flash').forEach(el => el.remove());
+        flashes.forEach(flash => this.showFlash(flash));
+    }
+
     showFlash(flash) {
-        // Remove existing flash messages
-        const existing = document.querySelectorAll('.lv-flash');
-        existing.forEach(el => el.remove());
-
-        // Create flash container
-        const flashDiv = document.createElement('div');
-        flashDiv.className = `lv-flash lv-flash-${flash.type || 'info'}`;
-        flashDiv.innerHTML = `
-            <span class="lv-flash-message">${flash.message}</span>
-            <button class="lv-flash-close">&times;</button>
-        `;
+        this.ensureFlashStyles();
+
+        let stack = document.getElementById('lv-flashes');
+        if (!stack) {
+            stack = document.createElement('div');
+            stack.id = 'lv-flashes';
+            stack.className = 'lv-flashes';
+            document.body.appendChild(stack);
+        }
 
-        // Add styles if not already present
-        if (!document.getElementById('lv-flash-styles')) {
-            const style = document.createElement('style');
-            style.id = 'lv-flash-styles';
-            style.textContent = `
-                .lv-flash {
-                    position: fixed;
-                    top: 20px;
-                    right: 20px;
-                    padding: 15px 20px;
-                    border-radius: 5px;
-                    box-shadow: 0 4px 6px rgba(0,0,0,0.1);
-                    display: flex;
-                    align-items: center;
-                    gap: 15px;
-                    z-index: 9999;
-                    animation: slideIn 0.3s ease-out;
-                }
-                @keyframes slideIn {
-                    from { transform: translateX(100%); opacity: 0; }
-                    to { transform: translateX(0); opacity: 1; }
-                }
-                .lv-flash-success {
-                    background: #27ae60;
-                    color: white;
-                }
-                .lv-flash-error {
-                    background: #e74c3c;
-                    color: white;
-                }
-                .lv-flash-info {
-                    background: #3498db;
-                    color: white;
-                }
-                .lv-flash-warning {
-                    background: #f39c12;
-                    color: white;
-                }
-                .lv-flash-close {
-                    background: none;
-                    border: none;
-                    color: white;
-                    font-size: 24px;
-                    cursor: pointer;
-                    padding: 0;
-                    line-height: 1;
-                }
-            `;
-            document.head.appendChild(style);
+        // A flash with the same key replaces the one on screen
+        if (flash.key) {
+            const existing = stack.querySelector(`[data-flash-key="${CSS.escape(flash.key)}"]`);
+            if (existing) existing.remove();
         }
 
-        // Add to page
-        document.body.appendChild(flashDiv);
+        let flashDiv;
+        if (flash.html) {
+            // Rendered by the server with the app's flash partial (already escaped)
+            const template = document.createElement('template');
+            template.innerHTML = flash.html.trim();
+            flashDiv = template.content.firstElementChild;
+        }
+        if (!flashDiv) {
+            flashDiv = document.createElement('div');
+            flashDiv.className = `lv-flash lv-flash-${flash.type || 'info'}`;
+            if (flash.key) {
+                flashDiv.dataset.flashKey = flash.key;
+            }
+            flashDiv.dataset.ttl = flash.ttl !== undefined ? flash.ttl : 5000;
+
+            // Build with textContent so server-provided text is never parsed as HTML
+            const messageSpan = document.createElement('span');
+            messageSpan.className = 'lv-flash-message';
+            messageSpan.textContent = flash.message;
+            const closeButton = document.createElement('button');
+            closeButton.className = 'lv-flash-close';
+            closeButton.innerHTML = '&times;';
+            flashDiv.appendChild(messageSpan);
+            flashDiv.appendChild(closeButton);
+        }
 
-        // Auto-remove after 5 seconds
-        setTimeout(() => {
-            flashDiv.style.animation = 'slideIn 0.3s ease-out reverse';
-            setTimeout(() => flashDiv.remove(), 300);
-        }, 5000);
+        // Add to the flash stack
+        stack.appendChild(flashDiv);
+        this.activateFlash(flashDiv);
+    }
 
-        // Close button
-        flashDiv.querySelector('.lv-flash-close').addEventListener('click', () => {
+    activateFlash(flashDiv) {
+        // Flashes leave after their TTL; a TTL of 0 keeps them until dismissed
+        const ttl = parseInt(flashDiv.dataset.ttl || '5000');
+        const remove = () => {
+            clearTimeout(flashDiv.__lv_timer);
             flashDiv.remove();
+        };
+        if (ttl > 0) {
+            flashDiv.__lv_timer = setTimeout(() => {
+                flashDiv.style.animation = 'slideIn 0.3s ease-out reverse';
+                setTimeout(remove, 300);
+            }, ttl);
+        }
+
+        const closeButton = flashDiv.querySelector('.lv-flash-close');
+        if (closeButton) {
+            closeButton.addEventListener('click', remove);
+        }
+    }
+
+    activatePageFlashes() {
+        // Flashes rendered into the page by the server on the first load
+        const flashes = document.querySelectorAll('#lv-flashes .lv-flash');
+        if (flashes.length === 0) return;
+        this.ensureFlashStyles();
+        flashes.forEach(flashDiv => this.activateFlash(flashDiv));
+    }
+
+    ensureFlashStyles() {
+        if (document.getElementById('lv-flash-styles')) return;
+        const style = document.createElement('style');
+        style.id = 'lv-flash-styles';
+        if (liveNestNonce) {
+            style.setAttribute('nonce', liveNestNonce);
+        }
+        style.textContent = `
+            .lv-flashes {
+                position: fixed;
+                top: 20px;
+                right: 20px;
+                display: flex;
+                flex-direction: column;
+                gap: 10px;
+                z-index: 9999;
+            }
+            .lv-flash {
+                padding: 15px 20px;
+                border-radius: 5px;
+                box-shadow: 0 4px 6px rgba(0,0,0,0.1);
+                display: flex;
+                align-items: center;
+                gap: 15px;
+                z-index: 9999;
+                animation: slideIn 0.3s ease-out;
+            }
+            @keyframes slideIn {
+                from { transform: translateX(100%); opacity: 0; }
+                to { transform: translateX(0); opacity: 1; }
+            }
+            .lv-flash-success {
+                background: #27ae60;
+                color: white;
+            }
+            .lv-flash-error {
+                background: #e74c3c;
+                color: white;
+            }
+            .lv-flash-info {
+                background: #3498db;
+                color: white;
+            }
+            .lv-flash-warning {
+                background: #f39c12;
+                color: white;
+            }
+            .lv-flash-close {
+                background: none;
+                border: none;
+                color: white;
+                font-size: 24px;
+                cursor: pointer;
+                padding: 0;
+                line-height: 1;
+            }
+        `;
+        document.head.appendChild(style);
+    }
+
+    showToast(toast) {
+        this.ensureToastStyles();
+
+        let stack = document.getElementById('lv-toasts');
+        if (!stack) {
+            stack = document.createElement('div');
+            stack.id = 'lv-toasts';
+            stack.className = 'lv-toasts';
+            document.body.appendChild(stack);
+        }
+
+        // A toast with the same ID replaces the one on screen
+        const existing = stack.querySelector(`[data-toast-id="${CSS.escape(toast.id)}"]`);
+        if (existing) {
+            clearTimeout(existing.__lv_timer);
+            existing.remove();
+        }
+
+        const toastDiv = document.createElement('div');
+        toastDiv.className = `lv-toast lv-toast-${toast.level || 'info'}`;
+        toastDiv.dataset.toastId = toast.id;
+
+        // Build with textContent so server-provided text is never parsed as HTML
+        const messageSpan = document.createElement('span');
+        messageSpan.className = 'lv-toast-message';
+        messageSpan.textContent = toast.message;
+        toastDiv.appendChild(messageSpan);
+
+        const dismiss = () => {
+            clearTimeout(toastDiv.__lv_timer);
+            toastDiv.remove();
+        };
+
+        (toast.actions || []).forEach(action => {
+            const button = document.createElement('button');
+            button.className = 'lv-toast-action';
+            button.textContent = action.label;
+            button.addEventListener('click', () => {
+                this.pushEvent(action.event, action.payload || {});
+                dismiss();
+            });
+            toastDiv.appendChild(button);
+        });
+
+        const closeButton = document.createElement('button');
+        closeButton.className = 'lv-toast-close';
+        closeButton.innerHTML = '&times;';
+        closeButton.addEventListener('click', dismiss);
+        toastDiv.appendChild(closeButton);
+
+        stack.appendChild(toastDiv);
+
+        // A duration of 0 keeps the toast until dismissed
+        if (toast.duration > 0) {
+            toastDiv.__lv_timer = setTimeout(dismiss, toast.duration);
+        }
+    }
+
+    showDebug(frame) {
+        this.ensureDebugStyles();
+
+        let panel = document.getElementById('lv-debug');
+        if (!panel) {
+            panel = document.createElement('div');
+            panel.id = 'lv-debug';
+            panel.className = 'lv-debug lv-debug-collapsed';
+
+            const header = document.createElement('div');
+            header.className = 'lv-debug-header';
+            header.addEventListener('click', () => panel.classList.toggle('lv-debug-collapsed'));
+            panel.appendChild(header);
+
+            const list = document.createElement('div');
+            list.className = 'lv-debug-list';
+            panel.appendChild(list);
+
+            document.body.appendChild(panel);
+        }
+
+        const queries = frame.queries || [];
+        const queryMs = queries.reduce((sum, q) => sum + q.ms, 0);
+
+        // Built with textContent so SQL is never parsed as HTML
+        const entry = document.createElement('div');
+        entry.className = 'lv-debug-entry';
+        const title = document.createElement('div');
+        title.className = 'lv-debug-title';
+        title.textContent = `${this.componentName} · ${frame.event || 'server update'} · ${frame.ms.toFixed(1)}ms · ` +
+            `${queries.length} ${queries.length === 1 ? 'query' : 'queries'} (${queryMs.toFixed(1)}ms)`;
+        entry.appendChild(title);
+
+        queries.forEach(q => {
+            const row = document.createElement('div');
+            row.className = 'lv-debug-query';
+            if (q.ms >= 100) row.classList.add('lv-debug-slow');
+            if (q.error) row.classList.add('lv-debug-failed');
+            const rows = q.rows >= 0 ? `${q.rows} rows` : '';
+            row.textContent = `${q.ms.toFixed(1)}ms ${rows} ${q.sql}${q.error ? ' — ' + q.error : ''}`;
+            entry.appendChild(row);
         });
+
+        // Newest first, keeping the last 20 events
+        const list = panel.querySelector('.lv-debug-list');
+        list.insertBefore(entry, list.firstChild);
+        while (list.children.length > 20) {
+            list.lastChild.remove();
+        }
+
+        panel.querySelector('.lv-debug-header').textContent = `LiveNest debug — last: ${title.textContent}`;
+    }
+
+    ensureDebugStyles() {
+        if (document.getElementById('lv-debug-styles')) return;
+        const style = document.createElement('style');
+        style.id = 'lv-debug-styles';
+        if (liveNestNonce) {
+            style.setAttribute('nonce', liveNestNonce);
+        }
+        style.textContent = `
+            .lv-debug {
+                position: fixed;
+                bottom: 0;
+                left: 0;
+                right: 0;
+                max-height: 40vh;
+                display: flex;
+                flex-direction: column;
+                background: #1e1e1e;
+                color: #ddd;
+                font: 12px/1.4 monospace;
+                z-index: 9998;
+            }
+            .lv-debug-header {
+                padding: 4px 10px;
+                background: #333;
+                cursor: pointer;
+                white-space: nowrap;
+                overflow: hidden;
+                text-overflow: ellipsis;
+            }
+            .lv-debug-list { overflow-y: auto; }
+            .lv-debug-collapsed .lv-debug-list { display: none; }
+            .lv-debug-entry { padding: 4px 10px; border-bottom: 1px solid #333; }
+            .lv-debug-title { color: #fff; }
+            .lv-debug-query { padding-left: 16px; white-space: pre-wrap; word-break: break-all; }
+            .lv-debug-slow { color: #f39c12; }
+            .lv-debug-failed { color: #e74c3c; }
+        `;
+        document.head.appendChild(style);
+    }
+
+    static ensurePrintStyles() {
+        // lv-print-only content shows in printouts only, lv-print-hide content on screen only
+        if (document.getElementById('lv-print-styles')) return;
+        const style = document.createElement('style');
+        style.id = 'lv-print-styles';
+        if (liveNestNonce) {
+            style.setAttribute('nonce', liveNestNonce);
+        }
+        style.textContent = `
+            @media screen { .lv-print-only { display: none !important; } }
+            @media print { .lv-print-hide { display: none !important; } }
+        `;
+        document.head.appendChild(style);
+    }
+
+    ensureToastStyles() {
+        if (document.getElementById('lv-toast-styles')) return;
+        const style = document.createElement('style');
+        style.id = 'lv-toast-styles';
+        if (liveNestNonce) {
+            style.setAttribute('nonce', liveNestNonce);
+        }
+        style.textContent = `
+            .lv-toasts {
+                position: fixed;
+                bottom: 20px;
+                right: 20px;
+                display: flex;
+                flex-direction: column;
+                gap: 10px;
+                z-index: 9999;
+            }
+            .lv-toast {
+                padding: 12px 16px;
+                border-radius: 5px;
+                box-shadow: 0 4px 6px rgba(0,0,0,0.1);
+                display: flex;
+                align-items: center;
+                gap: 12px;
+                color: white;
+                background: #3498db;
+            }
+            .lv-toast-success { background: #27ae60; }
+            .lv-toast-error { background: #e74c3c; }
+            .lv-toast-warning { background: #f39c12; }
+            .lv-toast-action {
+                background: rgba(255,255,255,0.2);
+                border: none;
+                color: white;
+                padding: 4px 10px;
+                border-radius: 3px;
+                cursor: pointer;
+            }
+            .lv-toast-close {
+                background: none;
+                border: none;
+                color: white;
+                font-size: 20px;
+                cursor: pointer;
+                padding: 0;
+                line-height: 1;
+            }
+        `;
+        document.head.appendChild(style);
     }
 
     // Expose pushEvent globally for custom usage
@@ -610,16 +2051,17 @@ class LiveViewSocket {
     }
 }
 
-// Auto-initialize if liveview container exists
+// Auto-initialize every LiveView container on the page
+// Containers mount independently but share one WebSocket
 window.addEventListener('DOMContentLoaded', () => {
-    const container = document.getElementById('liveview');
-    if (container && container.dataset.component && container.dataset.socketId) {
+    LiveNestTime.start();
+    LiveViewSocket.ensurePrintStyles();
+    document.querySelectorAll('[data-component][data-socket-id]').forEach(container => {
         const liveview = new LiveViewSocket(
             container.dataset.component,
-            container.dataset.socketId
+            container.dataset.socketId,
+            container
         );
         liveview.connect();
-        // Expose globally for custom form handlers
-        window.liveSocket = liveview;
-    }
-});
+    });
+});
\ No newline at end of file
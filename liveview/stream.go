@@ -0,0 +1,79 @@
+package liveview
+
+import "html/template"
+
+// streamOp changes a stream container on the client
+type streamOp struct {
+	Target string        `json:"target"`
+	Reset  bool          `json:"reset,omitempty"`
+	HTML   template.HTML `json:"html,omitempty"`
+	Cursor string        `json:"cursor,omitempty"`
+}
+
+// StreamAppend appends HTML to the stream container with an id, an element marked
+// lv-update="stream"
+// Streamed rows are kept only by the browser: the server neither stores nor diffs them,
+// so long lists can be sent batch by batch, e.g. from StartStream
+func (s *Socket) StreamAppend(id string, html template.HTML) {
+	s.streams = append(s.streams, streamOp{Target: id, HTML: html})
+}
+
+// StreamAppendAt appends HTML to a stream container like StreamAppend and records cursor,
+// e.g. the key of its last row, as the position the client has reached
+// The client keeps the cursor with the container and reports it when it joins again
+// after a reconnect, so the stream can resume from StreamCursor instead of starting over
+func (s *Socket) StreamAppendAt(id string, html template.HTML, cursor string) {
+	s.streams = append(s.streams, streamOp{Target: id, HTML: html, Cursor: cursor})
+	if s.cursors == nil {
+		s.cursors = make(map[string]string)
+	}
+	s.cursors[id] = cursor
+}
+
+// StreamReset empties the stream container with an id, e.g. before streaming it again,
+// and forgets its cursor
+func (s *Socket) StreamReset(id string) {
+	s.streams = append(s.streams, streamOp{Target: id, Reset: true})
+	delete(s.cursors, id)
+}
+
+// StreamCursor returns the last cursor delivered to the stream container with an id,
+// or "" when it is empty
+// After a reconnect it is the cursor the client still shows, so a component can append
+// what it missed rather than send the whole list again
+func (s *Socket) StreamCursor(id string) string {
+	return s.cursors[id]
+}
+
+// takeStreams returns and clears the pending stream operations
+func (s *Socket) takeStreams() []streamOp {
+	ops := s.streams
+	s.streams = nil
+	return ops
+}
+
+// streamCursors reads the cursors a client reports for its stream containers and subscriptions on join
+func streamCursors(value interface{}) map[string]string {
+	reported, ok := value.(map[string]interface{})
+	if !ok || len(reported) == 0 {
+		return nil
+	}
+	cursors := make(map[string]string, len(reported))
+	for id, cursor := range reported {
+		if cursor, ok := cursor.(string); ok && cursor != "" {
+			cursors[id] = cursor
+		}
+	}
+	return cursors
+}
+
+// addStreamsToData adds pending stream operations and subscription cursors to render data
+func (h *Handler) addStreamsToData(socket *Socket, data map[string]interface{}) {
+	if ops := socket.takeStreams(); len(ops) > 0 {
+		data["streams"] = ops
+	}
+	if len(socket.sentCursors) > 0 {
+		data["cursors"] = socket.sentCursors
+		socket.sentCursors = nil
+	}
+}
@@ -0,0 +1,101 @@
+package liveview
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StrictComponent is an optional interface for components that want unknown
+// events reported back to the client instead of silently ignored
+type StrictComponent interface {
+	StrictEvents() bool
+}
+
+// SetStrictEvents enables strict mode for all components
+// In strict mode unknown events get an explicit error reply
+func (h *Handler) SetStrictEvents(enabled bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.strict = enabled
+}
+
+// isStrict reports whether unknown events should be reported for a component
+func (h *Handler) isStrict(component Component) bool {
+	if sc, ok := component.(StrictComponent); ok {
+		return sc.StrictEvents()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.strict
+}
+
+// HandlerStats is a snapshot of handler counters
+type HandlerStats struct {
+	UnknownEvents  uint64            `json:"unknown_events"`
+	BudgetExceeded uint64            `json:"budget_exceeded"`
+	TimedOutEvents uint64            `json:"timed_out_events"`
+	RejectedConns  uint64            `json:"rejected_connections"`  // WebSocket connections over the connection limits
+	PendingSockets int               `json:"pending_sockets"`       // server renders waiting for their WebSocket join
+	ExpiredSockets uint64            `json:"expired_sockets"`       // server renders never joined before their TTL
+	Events         uint64            `json:"events"`                // events handled, from clients and timers
+	Renders        uint64            `json:"renders"`               // component renders
+	RenderTime     time.Duration     `json:"render_time_ns"`        // total time spent rendering
+	SlowEvents     map[string]uint64 `json:"slow_events,omitempty"` // budget misses by "component/event"
+}
+
+// handlerCounters holds the live counters behind HandlerStats
+type handlerCounters struct {
+	unknownEvents  atomic.Uint64
+	budgetExceeded atomic.Uint64
+	timedOutEvents atomic.Uint64
+	rejectedConns  atomic.Uint64
+	events         atomic.Uint64
+	renders        atomic.Uint64
+	renderNanos    atomic.Int64
+
+	mu         sync.Mutex
+	slowEvents map[string]uint64
+}
+
+// recordBudgetMiss counts an event that took longer than its budget
+func (c *handlerCounters) recordBudgetMiss(key string) {
+	c.budgetExceeded.Add(1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.slowEvents == nil {
+		c.slowEvents = make(map[string]uint64)
+	}
+	c.slowEvents[key]++
+}
+
+// recordRender counts a component render and its duration
+func (c *handlerCounters) recordRender(elapsed time.Duration) {
+	c.renders.Add(1)
+	c.renderNanos.Add(int64(elapsed))
+}
+
+// Stats returns a snapshot of handler counters
+func (h *Handler) Stats() HandlerStats {
+	stats := HandlerStats{
+		UnknownEvents:  h.counters.unknownEvents.Load(),
+		BudgetExceeded: h.counters.budgetExceeded.Load(),
+		TimedOutEvents: h.counters.timedOutEvents.Load(),
+		RejectedConns:  h.counters.rejectedConns.Load(),
+		Events:         h.counters.events.Load(),
+		Renders:        h.counters.renders.Load(),
+		RenderTime:     time.Duration(h.counters.renderNanos.Load()),
+	}
+	stats.PendingSockets, stats.ExpiredSockets = h.pending.stats()
+
+	h.counters.mu.Lock()
+	defer h.counters.mu.Unlock()
+	if len(h.counters.slowEvents) > 0 {
+		stats.SlowEvents = make(map[string]uint64, len(h.counters.slowEvents))
+		for key, n := range h.counters.slowEvents {
+			stats.SlowEvents[key] = n
+		}
+	}
+	return stats
+}
@@ -2,15 +2,21 @@ package liveview
 
 import (
 	"html/template"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+
+	"github.com/paulmanoni/livenest/i18n"
 )
 
 // TemplateComponent is a base component that loads templates from files
 type TemplateComponent struct {
 	TemplateDir  string
 	TemplateName string
+	Funcs        template.FuncMap // helpers available to the template, e.g. Scope.Funcs()
+	FS           fs.FS            // reads templates from here instead of the OS, e.g. an embed.FS; TemplateDir is relative to it
 	templateContent string
 }
 
@@ -20,14 +26,24 @@ func (t *TemplateComponent) LoadTemplate() error {
 		return nil // Already loaded
 	}
 
-	templatePath := filepath.Join(t.TemplateDir, t.TemplateName)
+	var content []byte
+	var err error
+	if t.FS != nil {
+		// fs.FS paths are slash-separated and relative to its root
+		templatePath := path.Join(t.TemplateDir, t.TemplateName)
+		if !strings.HasSuffix(templatePath, ".html") {
+			templatePath += ".html"
+		}
+		content, err = fs.ReadFile(t.FS, templatePath)
+	} else {
+		templatePath := filepath.Join(t.TemplateDir, t.TemplateName)
 
-	// Try with .html extension if not present
-	if !strings.HasSuffix(templatePath, ".html") {
-		templatePath += ".html"
+		// Try with .html extension if not present
+		if !strings.HasSuffix(templatePath, ".html") {
+			templatePath += ".html"
+		}
+		content, err = os.ReadFile(templatePath)
 	}
-
-	content, err := os.ReadFile(templatePath)
 	if err != nil {
 		return err
 	}
@@ -42,8 +58,8 @@ func (t *TemplateComponent) RenderTemplate(data interface{}) (template.HTML, err
 		return "", err
 	}
 
-	// Parse and execute template
-	tmpl, err := template.New(t.TemplateName).Parse(t.templateContent)
+	// Parse and execute template; t translates into the locale of the data's localizer
+	tmpl, err := template.New(t.TemplateName).Funcs(i18n.FromData(data).Funcs()).Funcs(t.Funcs).Parse(t.templateContent)
 	if err != nil {
 		return "", err
 	}
@@ -67,7 +83,7 @@ func (t *TemplateComponent) SetTemplateContent(content string) {
 func (t *TemplateComponent) Render(templatePath string, data interface{}) (template.HTML, error) {
 	// Set template path
 	t.TemplateName = templatePath
-	if t.TemplateDir == "" {
+	if t.TemplateDir == "" && t.FS == nil {
 		t.TemplateDir = "templates" // default directory
 	}
 
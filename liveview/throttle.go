@@ -0,0 +1,189 @@
+package liveview
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/paulmanoni/livenest/pubsub"
+)
+
+// Throttle limits how many messages of a topic each subscribed socket receives, to
+// protect clients from firehose topics such as market data
+// Messages over the rate are dropped, so the socket samples the topic, or with Coalesce
+// the latest of them is held back and delivered once the rate allows, so it always ends
+// up with the newest value
+type Throttle struct {
+	Rate     float64 // messages per second per socket
+	Coalesce bool    // deliver the latest held-back message instead of dropping it
+}
+
+// ThrottleInfo describes a throttle rule and what it held back, for the LiveDashboard
+type ThrottleInfo struct {
+	Topic     string // topic, or a prefix ending in "*"
+	Throttle  Throttle
+	Delivered uint64
+	Dropped   uint64
+	Coalesced uint64 // held back, then replaced by a newer message
+}
+
+// throttleRule is a throttle and its counters
+type throttleRule struct {
+	throttle  Throttle
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+	coalesced atomic.Uint64
+}
+
+// topicThrottles holds the throttle rules of a handler; it is shared by its sockets
+type topicThrottles struct {
+	mu    sync.RWMutex
+	rules map[string]*throttleRule
+}
+
+// ThrottleTopic limits the rate at which each socket receives the messages of a topic
+// subscribed to with Socket.Subscribe. A topic ending in "*" matches every topic with
+// that prefix, e.g. "prices.*"; an exact topic takes precedence, then the longest prefix
+// Rules can change at any time, e.g. from the LiveDashboard, and apply to the next message
+func (h *Handler) ThrottleTopic(topic string, throttle Throttle) {
+	t := h.throttles
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if rule, ok := t.rules[topic]; ok {
+		rule.throttle = throttle
+		return
+	}
+	t.rules[topic] = &throttleRule{throttle: throttle}
+}
+
+// UnthrottleTopic removes the throttle rule of a topic
+func (h *Handler) UnthrottleTopic(topic string) {
+	t := h.throttles
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.rules, topic)
+}
+
+// Throttles lists the throttle rules by topic
+func (h *Handler) Throttles() []ThrottleInfo {
+	t := h.throttles
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	infos := make([]ThrottleInfo, 0, len(t.rules))
+	for topic, rule := range t.rules {
+		infos = append(infos, ThrottleInfo{
+			Topic:     topic,
+			Throttle:  rule.throttle,
+			Delivered: rule.delivered.Load(),
+			Dropped:   rule.dropped.Load(),
+			Coalesced: rule.coalesced.Load(),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Topic < infos[j].Topic })
+	return infos
+}
+
+// newTopicThrottles creates an empty rule set
+func newTopicThrottles() *topicThrottles {
+	return &topicThrottles{rules: make(map[string]*throttleRule)}
+}
+
+// ruleFor returns the rule applying to a topic and its throttle, or nil
+func (t *topicThrottles) ruleFor(topic string) (*throttleRule, Throttle) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	rule := t.match(topic)
+	if rule == nil {
+		return nil, Throttle{}
+	}
+	return rule, rule.throttle
+}
+
+// match finds the rule of a topic; the caller holds t.mu
+func (t *topicThrottles) match(topic string) *throttleRule {
+	if rule, ok := t.rules[topic]; ok {
+		return rule
+	}
+	var match *throttleRule
+	longest := -1
+	for pattern, rule := range t.rules {
+		prefix, ok := strings.CutSuffix(pattern, "*")
+		if ok && len(prefix) > longest && strings.HasPrefix(topic, prefix) {
+			match, longest = rule, len(prefix)
+		}
+	}
+	return match
+}
+
+// subscriptionThrottle throttles the messages of one subscription of a socket
+type subscriptionThrottle struct {
+	rules   *topicThrottles
+	topic   string
+	deliver func(pubsub.Message)
+
+	mu      sync.Mutex
+	last    time.Time       // when the last message was delivered
+	pending *pubsub.Message // latest message held back to coalesce
+	timer   *time.Timer     // delivers pending
+}
+
+// receive delivers a message now, holds it back or drops it, as the topic's rule says
+func (s *subscriptionThrottle) receive(msg pubsub.Message) {
+	rule, throttle := s.rules.ruleFor(s.topic)
+	if rule == nil || throttle.Rate <= 0 {
+		s.deliver(msg)
+		return
+	}
+
+	interval := time.Duration(float64(time.Second) / throttle.Rate)
+	s.mu.Lock()
+	now := time.Now()
+	wait := s.last.Add(interval).Sub(now)
+	switch {
+	case wait <= 0 && s.pending == nil:
+		s.last = now
+		s.mu.Unlock()
+		rule.delivered.Add(1)
+		s.deliver(msg)
+		return
+	case !throttle.Coalesce:
+		s.mu.Unlock()
+		rule.dropped.Add(1)
+		return
+	}
+
+	replaced := s.pending != nil
+	s.pending = &msg
+	if s.timer == nil {
+		s.timer = time.AfterFunc(max(wait, 0), func() { s.flush(rule) })
+	}
+	s.mu.Unlock()
+	if replaced {
+		rule.coalesced.Add(1)
+	}
+}
+
+// flush delivers the message held back
+func (s *subscriptionThrottle) flush(rule *throttleRule) {
+	s.mu.Lock()
+	msg := s.pending
+	s.pending, s.timer = nil, nil
+	s.last = time.Now()
+	s.mu.Unlock()
+	if msg != nil {
+		rule.delivered.Add(1)
+		s.deliver(*msg)
+	}
+}
+
+// stop cancels the delivery of a held-back message
+func (s *subscriptionThrottle) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.pending, s.timer = nil, nil
+	}
+}
@@ -0,0 +1,94 @@
+package liveview
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// SendAfter sends event with payload to the component after delay, as if the client had sent it
+// The timer is cancelled when the connection closes. SendAfter reports false when the
+// socket has no live connection
+func (s *Socket) SendAfter(delay time.Duration, event string, payload map[string]interface{}) bool {
+	if s.updates == nil || s.dispatch == nil {
+		return false
+	}
+	if payload == nil {
+		payload = make(map[string]interface{})
+	}
+
+	ctx := s.Context()
+	go func() {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			s.enqueue(func() { s.sendEvent(event, payload) })
+		case <-ctx.Done():
+		}
+	}()
+	return true
+}
+
+// EveryTick sends event to the component every interval until CancelTick or disconnect
+// Starting a tick for an event that already ticks replaces it. Ticks are dropped
+// rather than queued up when the component can't keep up: while one waits to run or
+// the connection's queue is full, the next is skipped
+func (s *Socket) EveryTick(interval time.Duration, event string) bool {
+	if s.updates == nil || s.dispatch == nil || interval <= 0 {
+		return false
+	}
+
+	s.CancelTick(event)
+
+	ctx, cancel := context.WithCancel(s.Context())
+	if s.ticks == nil {
+		s.ticks = make(map[string]context.CancelFunc)
+	}
+	s.ticks[event] = cancel
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		// At most one tick waits for the connection goroutine; later ones are dropped
+		var pending atomic.Bool
+		tick := func() {
+			pending.Store(false)
+			if ctx.Err() == nil {
+				s.sendEvent(event, map[string]interface{}{})
+			}
+		}
+		for {
+			select {
+			case <-ticker.C:
+				if pending.CompareAndSwap(false, true) && !s.tryEnqueue(tick) {
+					pending.Store(false)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return true
+}
+
+// CancelTick stops the periodic tick of an event
+func (s *Socket) CancelTick(event string) {
+	if cancel, ok := s.ticks[event]; ok {
+		cancel()
+		delete(s.ticks, event)
+	}
+}
+
+// Ticking reports whether an event has a periodic tick
+func (s *Socket) Ticking(event string) bool {
+	_, ok := s.ticks[event]
+	return ok
+}
+
+// sendEvent runs a server-sent event through the component's handlers
+func (s *Socket) sendEvent(event string, payload map[string]interface{}) {
+	if err := s.dispatch(event, payload); err != nil {
+		s.log().Error("Timer event error", "event", event, "error", err)
+	}
+}
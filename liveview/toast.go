@@ -0,0 +1,81 @@
+package liveview
+
+import (
+	"strconv"
+	"time"
+)
+
+// DefaultToastDuration is how long a toast stays on screen unless told otherwise
+const DefaultToastDuration = 5 * time.Second
+
+// Toast is a notification queued for the client
+// Unlike flash messages, any number of toasts can be pushed per event
+type Toast struct {
+	ID       string        `json:"id"`
+	Level    string        `json:"level"`
+	Message  string        `json:"message"`
+	Duration int64         `json:"duration"` // milliseconds, 0 keeps the toast until dismissed
+	Actions  []ToastAction `json:"actions,omitempty"`
+}
+
+// ToastAction is a button on a toast that sends an event when clicked
+type ToastAction struct {
+	Label   string                 `json:"label"`
+	Event   string                 `json:"event"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+}
+
+// ToastOptions configures a toast
+type ToastOptions struct {
+	// ID replaces an on-screen toast with the same ID instead of stacking a new one
+	ID string
+
+	// Duration defaults to DefaultToastDuration
+	Duration time.Duration
+
+	// Sticky keeps the toast until the user dismisses it
+	Sticky bool
+
+	Actions []ToastAction
+}
+
+// PushToast queues a toast for the client
+// Level is typically "success", "error", "info" or "warning"
+func (s *Socket) PushToast(level, message string, opts ToastOptions) {
+	s.toastSeq++
+
+	id := opts.ID
+	if id == "" {
+		id = s.ComponentID + "-toast-" + strconv.Itoa(s.toastSeq)
+	}
+
+	duration := opts.Duration
+	if duration == 0 {
+		duration = DefaultToastDuration
+	}
+	if opts.Sticky {
+		duration = 0
+	}
+
+	s.toasts = append(s.toasts, Toast{
+		ID:       id,
+		Level:    level,
+		Message:  message,
+		Duration: duration.Milliseconds(),
+		Actions:  opts.Actions,
+	})
+}
+
+// takeToasts returns the queued toasts and clears the queue
+func (s *Socket) takeToasts() []Toast {
+	toasts := s.toasts
+	s.toasts = nil
+	return toasts
+}
+
+// addToastsToData adds queued toasts to render data
+func (h *Handler) addToastsToData(socket *Socket, data map[string]interface{}) {
+	if toasts := socket.takeToasts(); len(toasts) > 0 {
+		data["toasts"] = toasts
+	}
+}
@@ -0,0 +1,73 @@
+package liveview
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of LiveView spans
+const tracerName = "github.com/paulmanoni/livenest/liveview"
+
+// SetTracerProvider sets the OpenTelemetry provider of LiveView spans
+// Without one the global provider is used, so spans are recorded once the app
+// calls otel.SetTracerProvider
+func (h *Handler) SetTracerProvider(tp trace.TracerProvider) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tracerProvider = tp
+}
+
+// tracer returns the tracer of LiveView spans
+func (h *Handler) tracer() trace.Tracer {
+	h.mu.RLock()
+	tp := h.tracerProvider
+	h.mu.RUnlock()
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(tracerName)
+}
+
+// startSpan starts a span for work on a socket, as a child of the socket's current span
+// The span becomes the socket's current span, so renders and the handler's own
+// spans (through EventContext) nest under it; end restores the previous one
+func (h *Handler) startSpan(name, componentName, event string, socket *Socket) (end func(error)) {
+	attrs := []attribute.KeyValue{
+		attribute.String("livenest.component", componentName),
+		attribute.String("livenest.socket_id", socket.ID),
+	}
+	if event != "" {
+		attrs = append(attrs, attribute.String("livenest.event", event))
+	}
+
+	parent := socket.traceCtx
+	ctx, span := h.tracer().Start(socket.traceContext(), name, trace.WithAttributes(attrs...))
+	socket.traceCtx = ctx
+
+	return func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		socket.traceCtx = parent
+	}
+}
+
+// childSpan starts a span nested under the socket's current span without replacing it
+func (h *Handler) childSpan(name string, socket *Socket) trace.Span {
+	_, span := h.tracer().Start(socket.traceContext(), name)
+	return span
+}
+
+// traceContext returns the context of the socket's current span
+func (s *Socket) traceContext() context.Context {
+	if s.traceCtx != nil {
+		return s.traceCtx
+	}
+	return s.Context()
+}
@@ -0,0 +1,279 @@
+package liveview
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Transcripts
+//
+// A transcript mounts a component in-process, sends it a scripted sequence of events and
+// records what each step changed: the assigns, the diff operations and their size. Tests
+// compare it with a golden file, so a change that makes a small update resend large
+// parts of the page fails like a wrong result would.
+
+// UpdateTranscriptsEnv is the environment variable that makes MatchFile rewrite golden
+// files instead of comparing, e.g. LIVENEST_UPDATE_TRANSCRIPTS=1 go test ./...
+const UpdateTranscriptsEnv = "LIVENEST_UPDATE_TRANSCRIPTS"
+
+// ScriptedEvent is an event sent to a component by Transcript
+type ScriptedEvent struct {
+	Event   string
+	Payload map[string]interface{}
+}
+
+// On returns a scripted event
+func On(event string, payload map[string]interface{}) ScriptedEvent {
+	return ScriptedEvent{Event: event, Payload: payload}
+}
+
+// DiffOp is one change of a render diff
+type DiffOp struct {
+	Path  string `json:"path"`  // child indexes from the component root, e.g. "0.2.1"
+	Op    string `json:"op"`    // "text" replaces a text node, "replace" an element
+	Bytes int    `json:"bytes"` // size of the new content
+}
+
+// TranscriptStep is the mount or one event of a transcript
+type TranscriptStep struct {
+	Event     string                     `json:"event"` // "mount" for the first step
+	Payload   map[string]interface{}     `json:"payload,omitempty"`
+	Error     string                     `json:"error,omitempty"`
+	Assigns   map[string]json.RawMessage `json:"assigns,omitempty"` // assigns set or changed by the step, with their new values
+	Removed   []string                   `json:"removed,omitempty"` // assigns the step deleted
+	Ops       []DiffOp                   `json:"ops,omitempty"`
+	DiffBytes int                        `json:"diff_bytes"`        // size of the diff sent to the client
+	HTMLBytes int                        `json:"html_bytes"`        // size of the whole render, what the diff saves against
+	Effects   []string                   `json:"effects,omitempty"` // other parts of the reply, e.g. "flashes", "redirect" or "title"
+}
+
+// Transcript is the record of a scripted session of one component
+type Transcript struct {
+	Component string           `json:"component"`
+	Params    url.Values       `json:"params,omitempty"`
+	Steps     []TranscriptStep `json:"steps"`
+}
+
+// Transcript mounts a registered component with params and sends it events in order,
+// re-rendering after each one like a connected browser would. Authorization policies and
+// event hooks run as usual. A failed event is recorded in its step and the script goes on;
+// a failed mount returns an error
+func (h *Handler) Transcript(ctx context.Context, componentName string, params url.Values, events ...ScriptedEvent) (*Transcript, error) {
+	h.mu.RLock()
+	component, exists := h.components[componentName]
+	h.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("component %q not found", componentName)
+	}
+	if params == nil {
+		params = make(url.Values)
+	}
+
+	socket := h.newSocket("transcript")
+	socket.Params = params
+	socket.Request = &http.Request{
+		Method:     http.MethodGet,
+		URL:        &url.URL{Path: "/", RawQuery: params.Encode()},
+		Header:     make(http.Header),
+		RemoteAddr: "127.0.0.1:0",
+	}
+	socketCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	socket.ctx = socketCtx
+
+	if err := h.authorize(componentName, component, socket, ""); err != nil {
+		return nil, fmt.Errorf("mount %s: %w", componentName, err)
+	}
+	if err := h.mount(componentName, component, socket, nil); err != nil {
+		return nil, fmt.Errorf("mount %s: %w", componentName, err)
+	}
+	html, err := h.renderComponent(componentName, "mount", component, socket)
+	if err != nil {
+		return nil, fmt.Errorf("render %s: %w", componentName, err)
+	}
+	socket.previousHTML = string(html)
+
+	t := &Transcript{Component: componentName, Params: params}
+	assigns := snapshotAssigns(socket.Assigns)
+	t.Steps = append(t.Steps, TranscriptStep{Event: "mount", Assigns: assigns, HTMLBytes: len(html)})
+
+	for _, event := range events {
+		if ctx.Err() != nil {
+			return t, ctx.Err()
+		}
+		step := TranscriptStep{Event: event.Event, Payload: event.Payload}
+
+		// Handlers may modify the payload, which the step keeps as scripted
+		payload := make(map[string]interface{}, len(event.Payload))
+		for k, v := range event.Payload {
+			payload[k] = v
+		}
+		err := h.authorize(componentName, component, socket, event.Event)
+		if err == nil {
+			err = h.runEvent(componentName, component, event.Event, payload, socket)
+		}
+		if err != nil {
+			step.Error = err.Error()
+		} else {
+			data := h.renderUpdate(componentName, event.Event, component, socket)
+			if diff, ok := data["diff"].(Diff); ok {
+				step.Ops = diffOps(diff, "")
+				encoded, _ := json.Marshal(diff)
+				step.DiffBytes = len(encoded)
+			}
+			for key := range data {
+				if key != "diff" && key != "ref" && key != "debug" {
+					step.Effects = append(step.Effects, key)
+				}
+			}
+			sort.Strings(step.Effects)
+		}
+		step.HTMLBytes = len(socket.previousHTML)
+
+		current := snapshotAssigns(socket.Assigns)
+		step.Assigns, step.Removed = assignDelta(assigns, current)
+		assigns = current
+		t.Steps = append(t.Steps, step)
+	}
+	return t, nil
+}
+
+// DiffBytes returns the bytes of all diffs of the transcript
+func (t *Transcript) DiffBytes() int {
+	total := 0
+	for _, step := range t.Steps {
+		total += step.DiffBytes
+	}
+	return total
+}
+
+// JSON returns the transcript as indented JSON; map keys are sorted, so equal sessions
+// give equal output
+func (t *Transcript) JSON() []byte {
+	data, _ := json.MarshalIndent(t, "", "  ")
+	return append(data, '\n')
+}
+
+// MatchFile compares the transcript with the golden file at path and describes the first
+// difference; with UpdateTranscriptsEnv set, it writes the file instead
+//
+//	if err := transcript.MatchFile("testdata/cart.json"); err != nil {
+//		t.Fatal(err)
+//	}
+func (t *Transcript) MatchFile(path string) error {
+	got := t.JSON()
+	if os.Getenv(UpdateTranscriptsEnv) != "" {
+		return os.WriteFile(path, got, 0o644)
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("transcript of %s: %w (set %s=1 to create it)", t.Component, err, UpdateTranscriptsEnv)
+	}
+	if bytes.Equal(got, want) {
+		return nil
+	}
+
+	gotLines := strings.Split(string(got), "\n")
+	wantLines := strings.Split(string(want), "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			return fmt.Errorf("transcript of %s differs from %s at line %d:\n  want: %s\n  got:  %s\n(set %s=1 to update it)",
+				t.Component, path, i+1, strings.TrimSpace(w), strings.TrimSpace(g), UpdateTranscriptsEnv)
+		}
+	}
+	return nil
+}
+
+// snapshotAssigns encodes each assign as JSON, so later changes can be detected
+// Values JSON can't encode are recorded by their type
+func snapshotAssigns(assigns map[string]interface{}) map[string]json.RawMessage {
+	snapshot := make(map[string]json.RawMessage, len(assigns))
+	for key, value := range assigns {
+		data, err := json.Marshal(value)
+		if err != nil {
+			data, _ = json.Marshal(fmt.Sprintf("<%s>", reflect.TypeOf(value)))
+		}
+		snapshot[key] = data
+	}
+	return snapshot
+}
+
+// assignDelta returns the assigns that were added or changed and the keys removed
+func assignDelta(before, after map[string]json.RawMessage) (map[string]json.RawMessage, []string) {
+	var changed map[string]json.RawMessage
+	for key, value := range after {
+		if old, ok := before[key]; !ok || !bytes.Equal(old, value) {
+			if changed == nil {
+				changed = make(map[string]json.RawMessage)
+			}
+			changed[key] = value
+		}
+	}
+	var removed []string
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+	return changed, removed
+}
+
+// diffOps flattens a diff into its operations, ordered by path
+func diffOps(diff Diff, prefix string) []DiffOp {
+	var ops []DiffOp
+	for key, value := range diff {
+		node, ok := value.(Diff)
+		if !ok {
+			continue
+		}
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if statics, ok := node["s"].([]string); ok {
+			op := DiffOp{Path: path, Op: "text"}
+			for _, s := range statics {
+				op.Bytes += len(s)
+				if strings.HasPrefix(s, "<") {
+					op.Op = "replace"
+				}
+			}
+			ops = append(ops, op)
+		}
+		if children, ok := node["children"].(Diff); ok {
+			ops = append(ops, diffOps(children, path)...)
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool { return pathLess(ops[i].Path, ops[j].Path) })
+	return ops
+}
+
+// pathLess orders diff paths by their numeric indexes
+func pathLess(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			if len(as[i]) != len(bs[i]) {
+				return len(as[i]) < len(bs[i])
+			}
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
@@ -0,0 +1,67 @@
+package liveview
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unavailableMessage is shown while a dependency such as the database is down
+const unavailableMessage = "This is temporarily unavailable. We'll keep trying."
+
+// defaultRetryAfter is how long clients wait before retrying an outage without a hint
+const defaultRetryAfter = 5 * time.Second
+
+// unavailableContent stands in for a component that couldn't mount during an outage
+const unavailableContent template.HTML = `<div class="lv-unavailable-state" role="status">` + unavailableMessage + `</div>`
+
+// IsUnavailable reports whether an error is a temporary outage, e.g. an open database
+// circuit breaker (orm.CircuitOpenError)
+// Errors mark themselves with an Unavailable() bool method and may suggest when to retry
+// with RetryAfter() time.Duration. Components failing with one show a friendly
+// unavailable state instead of an error, and the client mounts them again later
+func IsUnavailable(err error) bool {
+	var u interface{ Unavailable() bool }
+	return errors.As(err, &u) && u.Unavailable()
+}
+
+// retryAfter returns when to retry after an outage
+func retryAfter(err error) time.Duration {
+	var r interface{ RetryAfter() time.Duration }
+	if errors.As(err, &r) && r.RetryAfter() > 0 {
+		return r.RetryAfter()
+	}
+	return defaultRetryAfter
+}
+
+// serveUnavailable serves the page of a component that couldn't mount during an outage
+// The live client joins the container and keeps retrying until the component mounts
+func (h *Handler) serveUnavailable(c *gin.Context, componentName string, socket *Socket, err error) {
+	h.log().Warn("Component unavailable", "component", componentName, "error", err)
+	wait := retryAfter(err)
+	c.Header("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+
+	h.mu.RLock()
+	strictCSP := h.strictCSP
+	h.mu.RUnlock()
+	if strictCSP {
+		c.Header("Content-Security-Policy", strictCSPHeader(socket.Nonce))
+	}
+
+	page := h.newPageData(componentName, unavailableContent, generateSocketID(), socket, h.loadingTimeoutAttr()+h.reconnectAttrs()+h.socketPathAttr(componentName))
+	page.Flashes = h.flashesHTML(socket)
+	scripts, styles := h.assetTags(componentName, socket)
+	page.Assets = styles + page.Assets + scripts
+	var buf bytes.Buffer
+	if err := h.layoutFor(componentName).RenderLayout(&buf, page); err != nil {
+		h.log().Error("Layout error", "component", componentName, "error", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Service unavailable"})
+		return
+	}
+	c.Data(http.StatusServiceUnavailable, "text/html; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,43 @@
+package liveview
+
+import "time"
+
+// SetSlowRenderThreshold logs a warning with the component and event for every render
+// slower than threshold (0 disables)
+func (h *Handler) SetSlowRenderThreshold(threshold time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.slowRender = threshold
+}
+
+// SetLargePayloadThreshold logs a warning with the component and event for every page or
+// render message larger than threshold bytes (0 disables)
+func (h *Handler) SetLargePayloadThreshold(threshold int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.largePayload = threshold
+}
+
+// checkRender warns about a render slower than the threshold
+func (h *Handler) checkRender(componentName, event string, socket *Socket, elapsed time.Duration) {
+	h.mu.RLock()
+	threshold := h.slowRender
+	h.mu.RUnlock()
+	if threshold <= 0 || elapsed <= threshold {
+		return
+	}
+	h.log().Warn("Slow render", "component", componentName, "event", event, "socket", socket.ID,
+		"elapsed", elapsed.Round(time.Microsecond), "threshold", threshold)
+}
+
+// checkPayload warns about a page or message larger than the threshold
+func (h *Handler) checkPayload(componentName, event string, socket *Socket, size int) {
+	h.mu.RLock()
+	threshold := h.largePayload
+	h.mu.RUnlock()
+	if threshold <= 0 || size <= threshold {
+		return
+	}
+	h.log().Warn("Large payload", "component", componentName, "event", event, "socket", socket.ID,
+		"bytes", size, "threshold", threshold)
+}
@@ -0,0 +1,166 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html"
+	"io"
+	"path"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// Renderer renders named templates, e.g. a template.Engine
+type Renderer interface {
+	RenderTo(w io.Writer, name string, data interface{}) error
+	Exists(name string) bool
+}
+
+// Mailer sends messages through a transport, rendering their bodies from templates
+type Mailer struct {
+	Transport Transport
+	From      string   // sender of messages without one, e.g. "Shop <no-reply@example.com>"
+	Templates Renderer // templates of Render and SendTemplate
+}
+
+// NewMailer creates a mailer sending through transport from a default sender
+func NewMailer(transport Transport, from string) *Mailer {
+	return &Mailer{Transport: transport, From: from}
+}
+
+// Send sends msg, from the mailer's sender when it has none and with a text body derived
+// from its HTML when it has only that
+func (m *Mailer) Send(ctx context.Context, msg *Message) error {
+	if m.Transport == nil {
+		return errors.New("mail: no transport")
+	}
+	out := *msg
+	if out.From == "" {
+		out.From = m.From
+	}
+	if out.Text == "" && out.HTML != "" {
+		out.Text = TextFromHTML(out.HTML)
+	}
+	return m.Transport.Send(ctx, &out)
+}
+
+// Render fills msg's HTML body by rendering template name with data, and its text body
+// with the template's text sibling when there is one: "emails/welcome.txt.tmpl" for
+// "emails/welcome.html". Data holding an i18n.Localizer, e.g. under "i18n", renders t
+// in its locale
+func (m *Mailer) Render(msg *Message, name string, data interface{}) error {
+	if m.Templates == nil {
+		return errors.New("mail: no templates")
+	}
+	var buf bytes.Buffer
+	if err := m.Templates.RenderTo(&buf, name, data); err != nil {
+		return err
+	}
+	msg.HTML = buf.String()
+
+	text := strings.TrimSuffix(name, path.Ext(name)) + ".txt.tmpl"
+	if !m.Templates.Exists(text) {
+		return nil
+	}
+	buf.Reset()
+	if err := m.Templates.RenderTo(&buf, text, data); err != nil {
+		return err
+	}
+	// Templates escape their output for HTML, which a text body must not be
+	msg.Text = html.UnescapeString(buf.String())
+	return nil
+}
+
+// SendTemplate sends a message to an address with a body rendered from template name
+func (m *Mailer) SendTemplate(ctx context.Context, to, subject, name string, data interface{}) error {
+	msg := &Message{To: []string{to}, Subject: subject}
+	if err := m.Render(msg, name, data); err != nil {
+		return err
+	}
+	return m.Send(ctx, msg)
+}
+
+// TextFromHTML returns the text of an HTML body, with a line per block and the targets of
+// links after them, for mail clients that don't show HTML
+func TextFromHTML(body string) string {
+	var out strings.Builder
+	var href []string // targets of the open links
+	skip := 0         // depth inside elements whose text isn't shown
+	z := nethtml.NewTokenizer(strings.NewReader(body))
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			break
+		}
+		token := z.Token()
+		switch tt {
+		case nethtml.TextToken:
+			words := strings.Fields(token.Data)
+			if skip > 0 || len(words) == 0 {
+				if skip == 0 && token.Data != "" {
+					out.WriteString(" ")
+				}
+				continue
+			}
+			if strings.TrimLeft(token.Data, " \t\r\n") != token.Data {
+				out.WriteString(" ")
+			}
+			out.WriteString(strings.Join(words, " "))
+			if strings.TrimRight(token.Data, " \t\r\n") != token.Data {
+				out.WriteString(" ")
+			}
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			switch token.Data {
+			case "head", "style", "script", "title":
+				if tt == nethtml.StartTagToken {
+					skip++
+				}
+			case "br", "p", "div", "tr", "table", "h1", "h2", "h3", "h4", "h5", "h6":
+				out.WriteString("\n")
+			case "li":
+				out.WriteString("\n- ")
+			case "a":
+				target := ""
+				for _, attr := range token.Attr {
+					if attr.Key == "href" && !strings.HasPrefix(attr.Val, "#") {
+						target = attr.Val
+					}
+				}
+				href = append(href, target)
+			}
+		case nethtml.EndTagToken:
+			switch token.Data {
+			case "head", "style", "script", "title":
+				skip = max(skip-1, 0)
+			case "p", "div", "tr", "table", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol":
+				out.WriteString("\n")
+			case "a":
+				if len(href) > 0 {
+					if target := href[len(href)-1]; target != "" {
+						out.WriteString(" (" + target + ")")
+					}
+					href = href[:len(href)-1]
+				}
+			}
+		}
+	}
+
+	// One blank line at most between blocks, and no indentation left by the markup
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(out.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
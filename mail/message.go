@@ -0,0 +1,252 @@
+// Package mail sends email: messages with text and HTML bodies and attachments,
+// delivered by a Transport such as an SMTP server or a provider's API, and a Mailer
+// that renders them from templates.
+//
+//	mailer := mail.NewMailer(&mail.SMTPTransport{Host: "smtp.example.com", Port: 587,
+//		Username: "app", Password: secret}, "Shop <no-reply@example.com>")
+//	mailer.Templates = engine // e.g. a template.Engine on templates/emails
+//	err := mailer.SendTemplate(ctx, "ana@example.com", "Welcome", "welcome.html", data)
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message is an email
+type Message struct {
+	From        string // e.g. "Shop <no-reply@example.com>"; the mailer's From when empty
+	To          []string
+	Cc          []string
+	Bcc         []string // receive the message without being listed in it
+	ReplyTo     string
+	Subject     string
+	Text        string // plain text body
+	HTML        string // HTML body; mailers derive Text from it when Text is empty
+	Headers     map[string]string
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to a message
+type Attachment struct {
+	Filename    string
+	ContentType string // detected from the file name when empty
+	Data        []byte
+}
+
+// Recipients returns the addresses the message is delivered to: To, Cc and Bcc
+func (m *Message) Recipients() ([]string, error) {
+	var recipients []string
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, addr := range list {
+			parsed, err := netmail.ParseAddress(addr)
+			if err != nil {
+				return nil, fmt.Errorf("mail: recipient %q: %w", addr, err)
+			}
+			recipients = append(recipients, parsed.Address)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("mail: message has no recipients")
+	}
+	return recipients, nil
+}
+
+// Sender returns the address of From
+func (m *Message) Sender() (string, error) {
+	from, err := netmail.ParseAddress(m.From)
+	if err != nil {
+		return "", fmt.Errorf("mail: sender %q: %w", m.From, err)
+	}
+	return from.Address, nil
+}
+
+// Bytes encodes the message in the Internet Message Format, ready to be sent over SMTP
+func (m *Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	header := make(textproto.MIMEHeader)
+
+	from, err := netmail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("mail: sender %q: %w", m.From, err)
+	}
+	header.Set("From", from.String())
+	for name, list := range map[string][]string{"To": m.To, "Cc": m.Cc} {
+		if len(list) == 0 {
+			continue
+		}
+		formatted, err := formatAddresses(list)
+		if err != nil {
+			return nil, err
+		}
+		header.Set(name, formatted)
+	}
+	if m.ReplyTo != "" {
+		formatted, err := formatAddresses([]string{m.ReplyTo})
+		if err != nil {
+			return nil, err
+		}
+		header.Set("Reply-To", formatted)
+	}
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("Message-ID", messageID(from.Address))
+	header.Set("MIME-Version", "1.0")
+	for name, value := range m.Headers {
+		header.Set(name, mime.QEncoding.Encode("utf-8", value))
+	}
+
+	contentHeader, body, err := encodeBody(m.Text, m.HTML)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Attachments) == 0 {
+		for name, values := range contentHeader {
+			header[name] = values
+		}
+		writeHeader(&buf, header)
+		buf.Write(body)
+		return buf.Bytes(), nil
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	header.Set("Content-Type", "multipart/mixed; boundary="+mixed.Boundary())
+	writeHeader(&buf, header)
+	w, err := mixed.CreatePart(contentHeader)
+	if err != nil {
+		return nil, err
+	}
+	w.Write(body)
+
+	for _, a := range m.Attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = mime.TypeByExtension(extension(a.Filename))
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Type", contentType)
+		h.Set("Content-Transfer-Encoding", "base64")
+		h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}))
+		w, err := mixed.CreatePart(h)
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(w, a.Data)
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeBody encodes the text and HTML bodies, as multipart/alternative when there are
+// both, returning the content headers and the encoded body
+func encodeBody(text, html string) (textproto.MIMEHeader, []byte, error) {
+	var buf bytes.Buffer
+	header := make(textproto.MIMEHeader)
+	if text == "" || html == "" {
+		contentType, body := "text/plain; charset=utf-8", text
+		if html != "" {
+			contentType, body = "text/html; charset=utf-8", html
+		}
+		header.Set("Content-Type", contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		err := writeQuotedPrintable(&buf, body)
+		return header, buf.Bytes(), err
+	}
+
+	alternative := multipart.NewWriter(&buf)
+	header.Set("Content-Type", "multipart/alternative; boundary="+alternative.Boundary())
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Type", part.contentType)
+		h.Set("Content-Transfer-Encoding", "quoted-printable")
+		w, err := alternative.CreatePart(h)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, nil, err
+		}
+	}
+	err := alternative.Close()
+	return header, buf.Bytes(), err
+}
+
+// writeHeader writes a header block and the blank line ending it
+func writeHeader(w io.Writer, header textproto.MIMEHeader) {
+	for name, values := range header {
+		for _, value := range values {
+			fmt.Fprintf(w, "%s: %s\r\n", name, value)
+		}
+	}
+	io.WriteString(w, "\r\n")
+}
+
+// writeQuotedPrintable writes a body with quoted-printable encoding
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := io.WriteString(qp, body); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeBase64 writes data in base64 with lines of 76 characters
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		io.WriteString(w, encoded[:76]+"\r\n")
+		encoded = encoded[76:]
+	}
+	io.WriteString(w, encoded+"\r\n")
+}
+
+// formatAddresses formats a list of addresses for a header, encoding display names
+func formatAddresses(list []string) (string, error) {
+	formatted := make([]string, len(list))
+	for i, addr := range list {
+		parsed, err := netmail.ParseAddress(addr)
+		if err != nil {
+			return "", fmt.Errorf("mail: address %q: %w", addr, err)
+		}
+		formatted[i] = parsed.String()
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
+// messageID returns a unique Message-ID in the sender's domain
+func messageID(sender string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	_, domain, ok := strings.Cut(sender, "@")
+	if !ok {
+		domain = "localhost"
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
+
+// extension returns the extension of a file name, e.g. ".pdf"
+func extension(filename string) string {
+	if i := strings.LastIndex(filename, "."); i >= 0 {
+		return filename[i:]
+	}
+	return ""
+}
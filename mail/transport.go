@@ -0,0 +1,177 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Transport delivers messages, e.g. to an SMTP server or a provider's HTTP API
+// Implementations must be safe for concurrent use
+type Transport interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// TransportFunc adapts a function to a Transport
+type TransportFunc func(ctx context.Context, msg *Message) error
+
+// Send calls f
+func (f TransportFunc) Send(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// SMTPTransport delivers messages to an SMTP server, a connection per message
+// The connection is upgraded with STARTTLS when the server offers it
+type SMTPTransport struct {
+	Host        string
+	Port        int    // default 587
+	Username    string // authenticates with PLAIN when set, which needs TLS unless Host is local
+	Password    string
+	ImplicitTLS bool          // speak TLS from the start instead of STARTTLS, as on port 465 (default when Port is 465)
+	TLSConfig   *tls.Config   // default verifies the certificate of Host
+	Timeout     time.Duration // bound for delivering a message when ctx has no deadline (default 30s)
+}
+
+// Send delivers msg
+func (t *SMTPTransport) Send(ctx context.Context, msg *Message) error {
+	from, err := msg.Sender()
+	if err != nil {
+		return err
+	}
+	recipients, err := msg.Recipients()
+	if err != nil {
+		return err
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	port := t.Port
+	if port == 0 {
+		port = 587
+	}
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(timeout)
+	}
+	tlsConfig := t.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: t.Host}
+	}
+
+	dialer := &net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(t.Host, strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("mail: connect to %s: %w", t.Host, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+	if t.ImplicitTLS || port == 465 {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	c, err := smtp.NewClient(conn, t.Host)
+	if err != nil {
+		return fmt.Errorf("mail: %s: %w", t.Host, err)
+	}
+	defer c.Close()
+	if _, isTLS := conn.(*tls.Conn); !isTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("mail: starttls: %w", err)
+			}
+		}
+	}
+	if t.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", t.Username, t.Password, t.Host)); err != nil {
+			return fmt.Errorf("mail: auth: %w", err)
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return fmt.Errorf("mail: sender %s: %w", from, err)
+	}
+	for _, rcpt := range recipients {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("mail: recipient %s: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("mail: data: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("mail: data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mail: data: %w", err)
+	}
+	return c.Quit()
+}
+
+// Logger is the logger of LogTransport, e.g. a *slog.Logger
+type Logger interface {
+	Info(msg string, args ...any)
+}
+
+// LogTransport logs messages instead of delivering them, for development
+type LogTransport struct {
+	Logger Logger // default slog.Default()
+}
+
+// Send logs msg with its text body
+func (t *LogTransport) Send(ctx context.Context, msg *Message) error {
+	logger := t.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	recipients, err := msg.Recipients()
+	if err != nil {
+		return err
+	}
+	logger.Info("Mail not delivered (log transport)", "from", msg.From, "to", recipients, "subject", msg.Subject,
+		"attachments", len(msg.Attachments), "text", msg.Text)
+	return nil
+}
+
+// MemoryTransport keeps messages instead of delivering them, for tests
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// Send keeps a copy of msg
+func (t *MemoryTransport) Send(ctx context.Context, msg *Message) error {
+	if _, err := msg.Recipients(); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = append(t.messages, *msg)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first
+func (t *MemoryTransport) Messages() []Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Message(nil), t.messages...)
+}
+
+// Reset forgets the messages sent so far
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = nil
+}
@@ -0,0 +1,108 @@
+package orm
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Driver tells a Manager how to open a database named in DatabaseConfig.Driver
+// Register one with RegisterDriver to use a database LiveNest doesn't ship, e.g.
+//
+//	orm.RegisterDriver("clickhouse", orm.Driver{Open: clickhouse.Open, SQLDriver: "clickhouse"})
+type Driver struct {
+	// Open returns the GORM dialector for a connection string
+	Open func(dsn string) gorm.Dialector
+
+	// DSN builds the connection string from the config when DatabaseConfig.DSN is empty
+	// Without it the connection string is DatabaseConfig.Database
+	DSN func(config *DatabaseConfig) string
+
+	// SQLDriver is the database/sql driver used in ModeSQL; empty when the database is GORM-only
+	// The driver must be registered with database/sql, usually by importing its package
+	SQLDriver string
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Driver{
+		"sqlite":     {Open: sqlite.Open, SQLDriver: "sqlite3"},
+		"postgres":   {Open: postgres.Open, DSN: postgresDSN, SQLDriver: "pgx"},
+		"postgresql": {Open: postgres.Open, DSN: postgresDSN, SQLDriver: "pgx"},
+		"mysql":      {Open: mysql.Open, DSN: mysqlDSN, SQLDriver: "mysql"},
+	}
+)
+
+// RegisterDriver makes a database available by name in DatabaseConfig.Driver
+// Registering a name again replaces the driver, so built-in ones can be overridden
+func RegisterDriver(name string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	drivers[name] = driver
+}
+
+// Drivers returns the names of the registered drivers, sorted
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	names := make([]string, 0, len(drivers))
+	for name, driver := range drivers {
+		if driver.Open != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupDriver returns the driver registered under name
+func lookupDriver(name string) (Driver, error) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	driver, ok := drivers[name]
+	if !ok || driver.Open == nil {
+		return Driver{}, fmt.Errorf("unsupported database driver: %s", name)
+	}
+	return driver, nil
+}
+
+// dataSourceName returns the connection string for config
+func (d Driver) dataSourceName(config *DatabaseConfig) string {
+	if config.DSN != "" {
+		return config.DSN
+	}
+	if d.DSN != nil {
+		return d.DSN(config)
+	}
+	return config.Database
+}
+
+// postgresDSN builds a PostgreSQL connection string
+func postgresDSN(config *DatabaseConfig) string {
+	return fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+		config.Host,
+		config.Username,
+		config.Password,
+		config.Database,
+		config.Port,
+		config.SSLMode,
+	)
+}
+
+// mysqlDSN builds a MySQL connection string
+func mysqlDSN(config *DatabaseConfig) string {
+	return fmt.Sprintf(
+		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		config.Username,
+		config.Password,
+		config.Host,
+		config.Port,
+		config.Database,
+	)
+}
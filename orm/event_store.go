@@ -0,0 +1,222 @@
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AnyVersion appends to a stream whatever its current version
+const AnyVersion = -1
+
+// ErrVersionConflict is returned by Append when the stream moved past the expected version,
+// e.g. because another node appended to it first
+var ErrVersionConflict = errors.New("event stream version conflict")
+
+// StoredEvent is an event recorded in a stream of the event store
+// Versions count up from 1 within a stream and are unique, so appends from
+// several nodes can't interleave
+type StoredEvent struct {
+	ID        uint64          `gorm:"primaryKey" json:"id"`
+	Stream    string          `gorm:"size:255;uniqueIndex:idx_stored_events_stream_version" json:"stream"`
+	Version   int             `gorm:"uniqueIndex:idx_stored_events_stream_version" json:"version"`
+	Type      string          `gorm:"size:255;index" json:"type"`
+	Data      json.RawMessage `gorm:"type:text" json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// TableName keeps events in stored_events
+func (StoredEvent) TableName() string {
+	return "stored_events"
+}
+
+// Decode unmarshals the event data into v
+func (e StoredEvent) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// Event is an event to append: its type and a JSON-encodable payload
+type Event struct {
+	Type string
+	Data interface{}
+}
+
+// EventStore is an append-only log of events grouped in streams
+// State is rebuilt by folding a stream's events, and subscribers are told about
+// new events whichever node appended them
+type EventStore struct {
+	Interval time.Duration // how often appends of other nodes are polled for while there are subscribers (default 500ms)
+	OnError  func(error)   // called when polling or a subscriber's read fails; both are retried
+
+	db      *gorm.DB
+	watches *watchHub
+}
+
+// NewEventStore creates an event store on db
+// The stored_events table must exist, e.g. via AutoMigrate(&StoredEvent{})
+func NewEventStore(db *gorm.DB) *EventStore {
+	s := &EventStore{Interval: 500 * time.Millisecond, db: db}
+	s.watches = newWatchHub(&s.Interval, s.heads, func(err error) {
+		s.reportError(fmt.Errorf("event store poll: %w", err))
+	})
+	return s
+}
+
+// Append adds events to a stream and returns its new version
+// expected is the version the caller last saw, 0 for a new stream or AnyVersion
+// to skip the check; a mismatch fails with ErrVersionConflict
+func (s *EventStore) Append(ctx context.Context, stream string, expected int, events ...Event) (int, error) {
+	var version int
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		version, err = s.AppendTx(tx, stream, expected, events...)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.watches.notify(stream)
+	return version, nil
+}
+
+// AppendTx adds events to a stream within tx, e.g. next to an orm.Enqueue
+// Subscribers on other nodes see them once tx commits; on this node too, at the next poll
+func (s *EventStore) AppendTx(tx *gorm.DB, stream string, expected int, events ...Event) (int, error) {
+	current, err := s.version(tx, stream)
+	if err != nil {
+		return 0, err
+	}
+	if expected != AnyVersion && expected != current {
+		return 0, fmt.Errorf("%w: %s is at version %d, expected %d", ErrVersionConflict, stream, current, expected)
+	}
+	if len(events) == 0 {
+		return current, nil
+	}
+
+	now := time.Now()
+	rows := make([]StoredEvent, len(events))
+	for i, event := range events {
+		data, err := json.Marshal(event.Data)
+		if err != nil {
+			return 0, fmt.Errorf("event %s data: %w", event.Type, err)
+		}
+		rows[i] = StoredEvent{Stream: stream, Version: current + i + 1, Type: event.Type, Data: data, CreatedAt: now}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		if isDuplicateKey(err) {
+			return 0, fmt.Errorf("%w: %s was appended to concurrently", ErrVersionConflict, stream)
+		}
+		return 0, err
+	}
+	return current + len(events), nil
+}
+
+// Version returns the current version of a stream, 0 if it has no events
+func (s *EventStore) Version(ctx context.Context, stream string) (int, error) {
+	return s.version(s.db.WithContext(ctx), stream)
+}
+
+// version reads the latest version of a stream on db
+func (s *EventStore) version(db *gorm.DB, stream string) (int, error) {
+	var version int
+	err := db.Model(&StoredEvent{}).Where("stream = ?", stream).
+		Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// Load returns the events of a stream after a version, oldest first
+func (s *EventStore) Load(ctx context.Context, stream string, after int) ([]StoredEvent, error) {
+	var events []StoredEvent
+	err := s.db.WithContext(ctx).Where("stream = ? AND version > ?", stream, after).
+		Order("version").Find(&events).Error
+	return events, err
+}
+
+// Fold rebuilds state from the history of a stream by applying its events in order
+// to initial; it also returns the version the state reflects, to Subscribe from
+//
+//	totals, version, err := orm.Fold(ctx, store, "orders", Totals{}, func(t Totals, e orm.StoredEvent) Totals {
+//		var order Order
+//		e.Decode(&order)
+//		t.Count++
+//		t.Revenue += order.Amount
+//		return t
+//	})
+func Fold[S any](ctx context.Context, store *EventStore, stream string, initial S, apply func(S, StoredEvent) S) (S, int, error) {
+	events, err := store.Load(ctx, stream, 0)
+	if err != nil {
+		return initial, 0, err
+	}
+	state, version := initial, 0
+	for _, event := range events {
+		state = apply(state, event)
+		version = event.Version
+	}
+	return state, version, nil
+}
+
+// Subscribe calls fn with every event of a stream after a version, in order, until ctx
+// is cancelled; it returns ctx's error
+// Events appended on this node are delivered right away, those of other nodes within Interval
+func (s *EventStore) Subscribe(ctx context.Context, stream string, after int, fn func(StoredEvent)) error {
+	sub := s.watches.subscribe(stream)
+	defer s.watches.unsubscribe(stream, sub)
+
+	for {
+		events, err := s.Load(ctx, stream, after)
+		if err != nil && ctx.Err() == nil {
+			s.reportError(fmt.Errorf("event subscription to %s: %w", stream, err))
+		}
+		for _, event := range events {
+			fn(event)
+			after = event.Version
+		}
+
+		var retry <-chan time.Time
+		if err != nil {
+			retry = time.After(s.Interval)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-sub.wake:
+		case <-retry:
+		}
+	}
+}
+
+// heads returns the current version of each stream
+func (s *EventStore) heads(streams []string) (map[string]int, error) {
+	var rows []struct {
+		Stream  string
+		Version int
+	}
+	err := s.db.Model(&StoredEvent{}).Select("stream, MAX(version) AS version").
+		Where("stream IN ?", streams).Group("stream").Scan(&rows).Error
+	heads := make(map[string]int, len(rows))
+	for _, row := range rows {
+		heads[row.Stream] = row.Version
+	}
+	return heads, err
+}
+
+// reportError hands an error to OnError
+func (s *EventStore) reportError(err error) {
+	if s.OnError != nil {
+		s.OnError(err)
+	}
+}
+
+// isDuplicateKey reports whether err is a unique constraint violation
+func isDuplicateKey(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "unique") || strings.Contains(message, "duplicate")
+}
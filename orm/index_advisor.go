@@ -0,0 +1,320 @@
+package orm
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// IndexSuggestion is an index that would let a recorded query avoid a full table scan
+type IndexSuggestion struct {
+	Table       string        `json:"table"`
+	Columns     []string      `json:"columns"`
+	Query       string        `json:"query"` // normalized query pattern
+	Calls       int           `json:"calls"`
+	AvgDuration time.Duration `json:"avg_duration_ns"`
+	Reason      string        `json:"reason"` // the scan reported by EXPLAIN
+	SQL         string        `json:"sql"`    // CREATE INDEX statement
+}
+
+// IndexAdvisor collects query patterns and suggests indexes for the slow or frequent
+// ones that EXPLAIN shows scanning a whole table
+// Feed it with Observe, e.g. from a GORM logger; core does that in debug mode
+type IndexAdvisor struct {
+	SlowThreshold time.Duration // a pattern with a query this slow is explained (default 50ms)
+	FrequentCalls int           // a pattern run this often is explained (default 100)
+	MaxPatterns   int           // patterns kept; later ones are ignored (default 500)
+
+	db       *gorm.DB
+	mu       sync.Mutex
+	patterns map[string]*queryPattern
+}
+
+// queryPattern aggregates the executions of a normalized query
+type queryPattern struct {
+	sample    string // one executed query, with values, for EXPLAIN
+	calls     int
+	total     time.Duration
+	slowest   time.Duration
+	explained bool
+	suggested []IndexSuggestion
+}
+
+// NewIndexAdvisor creates an advisor that explains queries on db
+func NewIndexAdvisor(db *gorm.DB) *IndexAdvisor {
+	return &IndexAdvisor{
+		SlowThreshold: 50 * time.Millisecond,
+		FrequentCalls: 100,
+		MaxPatterns:   500,
+		db:            db,
+		patterns:      make(map[string]*queryPattern),
+	}
+}
+
+// Observe records an executed query; only SELECTs are considered
+func (a *IndexAdvisor) Observe(sql string, elapsed time.Duration) {
+	trimmed := strings.TrimSpace(sql)
+	if len(trimmed) < 6 || !strings.EqualFold(trimmed[:6], "select") {
+		return
+	}
+	pattern := trimmed
+	if a.db.Dialector.Name() != "postgres" {
+		// GORM's SQLite and MySQL dialects quote logged strings with double quotes
+		pattern = doubleQuoted.ReplaceAllString(pattern, "?")
+	}
+	pattern = NormalizeQuery(pattern)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p, ok := a.patterns[pattern]
+	if !ok {
+		if len(a.patterns) >= a.MaxPatterns {
+			return
+		}
+		p = &queryPattern{sample: trimmed}
+		a.patterns[pattern] = p
+	}
+	p.calls++
+	p.total += elapsed
+	if elapsed > p.slowest {
+		p.slowest = elapsed
+		p.sample = trimmed
+	}
+}
+
+// Suggestions explains the slow and frequent patterns not explained yet and returns
+// every suggestion so far, most expensive first
+func (a *IndexAdvisor) Suggestions() []IndexSuggestion {
+	a.mu.Lock()
+	var pending []string
+	for pattern, p := range a.patterns {
+		if !p.explained && (p.slowest >= a.SlowThreshold || p.calls >= a.FrequentCalls) {
+			pending = append(pending, pattern)
+		}
+	}
+	a.mu.Unlock()
+
+	// EXPLAIN runs without the lock, so queries keep being observed meanwhile
+	for _, pattern := range pending {
+		a.mu.Lock()
+		sample := a.patterns[pattern].sample
+		a.mu.Unlock()
+
+		scans, err := a.explain(sample)
+		if err != nil {
+			scans = nil
+		}
+
+		a.mu.Lock()
+		p := a.patterns[pattern]
+		p.explained = true
+		p.suggested = suggestIndexes(pattern, scans)
+		a.mu.Unlock()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var suggestions []IndexSuggestion
+	for _, p := range a.patterns {
+		for _, s := range p.suggested {
+			s.Calls = p.calls
+			s.AvgDuration = p.total / time.Duration(p.calls)
+			suggestions = append(suggestions, s)
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		ci := suggestions[i].AvgDuration * time.Duration(suggestions[i].Calls)
+		cj := suggestions[j].AvgDuration * time.Duration(suggestions[j].Calls)
+		return ci > cj
+	})
+	return suggestions
+}
+
+// Reset forgets every recorded pattern, e.g. after adding the suggested indexes
+func (a *IndexAdvisor) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.patterns = make(map[string]*queryPattern)
+}
+
+// tableScan is a full scan of a table reported by EXPLAIN
+type tableScan struct {
+	table  string
+	detail string
+}
+
+// explain runs the dialect's EXPLAIN on a query and returns its full table scans
+func (a *IndexAdvisor) explain(query string) ([]tableScan, error) {
+	db := a.db.Session(&gorm.Session{Logger: logger.Discard})
+	switch a.db.Dialector.Name() {
+	case "sqlite":
+		return explainSQLite(db, query)
+	case "postgres":
+		return explainPostgres(db, query)
+	case "mysql":
+		return explainMySQL(db, query)
+	default:
+		return nil, fmt.Errorf("orm: EXPLAIN is not supported for %s", a.db.Dialector.Name())
+	}
+}
+
+// explainSQLite finds "SCAN table" steps without an index in EXPLAIN QUERY PLAN
+func explainSQLite(db *gorm.DB, query string) ([]tableScan, error) {
+	rows, err := db.Raw("EXPLAIN QUERY PLAN " + query).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scans []tableScan
+	for rows.Next() {
+		var id, parent, unused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &unused, &detail); err != nil {
+			return nil, err
+		}
+		fields := strings.Fields(detail)
+		if len(fields) < 2 || fields[0] != "SCAN" || strings.Contains(detail, "INDEX") {
+			continue
+		}
+		table := fields[1]
+		if table == "TABLE" && len(fields) > 2 {
+			table = fields[2]
+		}
+		scans = append(scans, tableScan{table: table, detail: detail})
+	}
+	return scans, rows.Err()
+}
+
+// explainPostgres finds "Seq Scan on table" nodes in EXPLAIN
+func explainPostgres(db *gorm.DB, query string) ([]tableScan, error) {
+	rows, err := db.Raw("EXPLAIN " + query).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scans []tableScan
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		_, after, ok := strings.Cut(line, "Seq Scan on ")
+		if !ok {
+			continue
+		}
+		if fields := strings.Fields(after); len(fields) > 0 {
+			scans = append(scans, tableScan{table: fields[0], detail: strings.TrimSpace(line)})
+		}
+	}
+	return scans, rows.Err()
+}
+
+// explainMySQL finds rows with access type ALL in EXPLAIN
+func explainMySQL(db *gorm.DB, query string) ([]tableScan, error) {
+	var plan []map[string]interface{}
+	if err := db.Raw("EXPLAIN " + query).Scan(&plan).Error; err != nil {
+		return nil, err
+	}
+
+	var scans []tableScan
+	for _, row := range plan {
+		if fmt.Sprint(row["type"]) != "ALL" {
+			continue
+		}
+		table := fmt.Sprint(row["table"])
+		scans = append(scans, tableScan{table: table, detail: "full scan of " + table})
+	}
+	return scans, nil
+}
+
+// Patterns for NormalizeQuery and the column heuristics
+var (
+	stringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	doubleQuoted   = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+	numberLiteral  = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	dollarParam    = regexp.MustCompile(`\$\d+`)
+	placeholderSet = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	whereClause    = regexp.MustCompile(`(?is)\bWHERE\b(.*?)(?:\bGROUP BY\b|\bORDER BY\b|\bLIMIT\b|\bOFFSET\b|$)`)
+	orderClause    = regexp.MustCompile(`(?is)\bORDER BY\b(.*?)(?:\bLIMIT\b|\bOFFSET\b|$)`)
+	predicate      = regexp.MustCompile("(?i)(?:[\"`]?(\\w+)[\"`]?\\.)?[\"`]?(\\w+)[\"`]?\\s*(=|<>|!=|<=|>=|<|>|\\bIN\\b|\\bLIKE\\b|\\bIS\\b|\\bBETWEEN\\b)")
+	orderColumn    = regexp.MustCompile("(?i)(?:[\"`]?(\\w+)[\"`]?\\.)?[\"`]?(\\w+)[\"`]?")
+)
+
+// NormalizeQuery replaces the values in a query with placeholders, so executions of
+// the same statement share a pattern
+func NormalizeQuery(query string) string {
+	query = stringLiteral.ReplaceAllString(query, "?")
+	query = dollarParam.ReplaceAllString(query, "?")
+	query = numberLiteral.ReplaceAllString(query, "?")
+	query = placeholderSet.ReplaceAllString(query, "(?)")
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// suggestIndexes proposes an index for each scanned table, on the columns the query
+// filters it by: equality filters first, then ranges, then the sort order
+func suggestIndexes(pattern string, scans []tableScan) []IndexSuggestion {
+	var suggestions []IndexSuggestion
+	for _, scan := range scans {
+		columns := filterColumns(pattern, scan.table)
+		if len(columns) == 0 {
+			continue // a scan without filters needs every row anyway
+		}
+		name := "idx_" + scan.table + "_" + strings.Join(columns, "_")
+		suggestions = append(suggestions, IndexSuggestion{
+			Table:   scan.table,
+			Columns: columns,
+			Query:   pattern,
+			Reason:  scan.detail,
+			SQL:     fmt.Sprintf("CREATE INDEX %s ON %s (%s);", name, scan.table, strings.Join(columns, ", ")),
+		})
+	}
+	return suggestions
+}
+
+// filterColumns returns the columns of table used in the WHERE and ORDER BY clauses
+func filterColumns(pattern, table string) []string {
+	var equality, ranges, order []string
+	seen := make(map[string]bool)
+	add := func(list *[]string, qualifier, column string) {
+		column = strings.ToLower(column)
+		if (qualifier != "" && !strings.EqualFold(qualifier, table)) || seen[column] || sqlKeywords[column] {
+			return
+		}
+		seen[column] = true
+		*list = append(*list, column)
+	}
+
+	if m := whereClause.FindStringSubmatch(pattern); m != nil {
+		for _, p := range predicate.FindAllStringSubmatch(m[1], -1) {
+			switch strings.ToUpper(p[3]) {
+			case "=", "IN", "IS":
+				add(&equality, p[1], p[2])
+			default:
+				add(&ranges, p[1], p[2])
+			}
+		}
+	}
+	if m := orderClause.FindStringSubmatch(pattern); m != nil {
+		for _, part := range strings.Split(m[1], ",") {
+			// GORM's First and Last add the primary key as a tie breaker
+			if c := orderColumn.FindStringSubmatch(strings.TrimSpace(part)); c != nil && !strings.EqualFold(c[2], "id") {
+				add(&order, c[1], c[2])
+			}
+		}
+	}
+	return append(append(equality, ranges...), order...)
+}
+
+// sqlKeywords are words the column patterns may pick up that aren't columns
+var sqlKeywords = map[string]bool{
+	"and": true, "or": true, "not": true, "null": true, "asc": true, "desc": true,
+	"lower": true, "upper": true, "select": true, "where": true,
+}
@@ -0,0 +1,138 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/paulmanoni/livenest/jobs"
+	"gorm.io/gorm"
+)
+
+// JobQueue keeps background jobs in the jobs table, so they survive restarts and every
+// node runs its share; claims are leases taken with a conditional update, so a job is
+// run by one node at a time, and saves are conditional on the revision, so a node that
+// lost its lease can't overwrite the new owner
+type JobQueue struct {
+	db *gorm.DB
+}
+
+// NewJobQueue creates a queue storing jobs on db
+// The jobs table must exist, e.g. via AutoMigrate(&jobs.Job{})
+func NewJobQueue(db *gorm.DB) *JobQueue {
+	return &JobQueue{db: db}
+}
+
+// Push adds a new job
+func (q *JobQueue) Push(ctx context.Context, job *jobs.Job) error {
+	return q.db.WithContext(ctx).Create(job).Error
+}
+
+// Claim leases the due job that has waited longest
+// Another node may claim a candidate first, so a few are tried in turn
+func (q *JobQueue) Claim(ctx context.Context, lease time.Duration) (*jobs.Job, error) {
+	now := time.Now()
+	due := q.db.WithContext(ctx).Model(&jobs.Job{}).
+		Where("((status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?))",
+			jobs.StatusQueued, now, jobs.StatusRunning, now).
+		Session(&gorm.Session{})
+
+	var ids []string
+	if err := due.Order("run_at").Limit(10).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		result := due.Where("id = ?", id).Updates(map[string]interface{}{
+			"status":       jobs.StatusRunning,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"locked_until": now.Add(lease),
+			"revision":     gorm.Expr("revision + 1"),
+			"updated_at":   now,
+		})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return q.Get(ctx, id)
+		}
+	}
+	return nil, nil
+}
+
+// Save stores the job's state and progress unless it changed meanwhile
+func (q *JobQueue) Save(ctx context.Context, job *jobs.Job) error {
+	now := time.Now()
+	result := q.db.WithContext(ctx).Model(&jobs.Job{}).Where("id = ? AND revision = ?", job.ID, job.Revision).Updates(map[string]interface{}{
+		"status":       job.Status,
+		"result":       job.Result,
+		"progress":     job.Progress,
+		"message":      job.Message,
+		"error":        job.Error,
+		"attempts":     job.Attempts,
+		"run_at":       job.RunAt,
+		"locked_until": job.LockedUntil,
+		"revision":     gorm.Expr("revision + 1"),
+		"updated_at":   now,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return q.notOwned(ctx, job.ID)
+	}
+	job.Revision++
+	job.UpdatedAt = now
+	return nil
+}
+
+// Renew extends the lease of a running job unless it changed meanwhile
+func (q *JobQueue) Renew(ctx context.Context, job *jobs.Job, lease time.Duration) error {
+	now := time.Now()
+	until := now.Add(lease)
+	result := q.db.WithContext(ctx).Model(&jobs.Job{}).
+		Where("id = ? AND revision = ? AND status = ?", job.ID, job.Revision, jobs.StatusRunning).
+		Updates(map[string]interface{}{
+			"locked_until": until,
+			"revision":     gorm.Expr("revision + 1"),
+			"updated_at":   now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return q.notOwned(ctx, job.ID)
+	}
+	job.LockedUntil = until
+	job.Revision++
+	job.UpdatedAt = now
+	return nil
+}
+
+// notOwned explains a conditional update that matched nothing: the job is gone, or it
+// changed since the caller read it
+func (q *JobQueue) notOwned(ctx context.Context, id string) error {
+	if _, err := q.Get(ctx, id); err != nil {
+		return err
+	}
+	return jobs.ErrLeaseLost
+}
+
+// Get returns a job
+func (q *JobQueue) Get(ctx context.Context, id string) (*jobs.Job, error) {
+	var job jobs.Job
+	err := q.db.WithContext(ctx).First(&job, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, jobs.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Purge deletes the jobs that finished before cutoff
+func (q *JobQueue) Purge(cutoff time.Time) (int64, error) {
+	result := q.db.Where("status IN ? AND updated_at < ?", []jobs.Status{jobs.StatusSucceeded, jobs.StatusFailed}, cutoff).
+		Delete(&jobs.Job{})
+	return result.RowsAffected, result.Error
+}
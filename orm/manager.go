@@ -1,11 +1,11 @@
 package orm
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
-	"gorm.io/driver/mysql"
-	"gorm.io/driver/postgres"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -18,22 +18,36 @@ type DatabaseConfig struct {
 	Username string
 	Password string
 	SSLMode  string
+	DSN      string // full connection string; overrides the fields above
+	Mode     string // ModeGORM (the default) or ModeSQL
 }
 
+// Database access modes of a Manager
+const (
+	ModeGORM = "gorm" // queries go through GORM
+	ModeSQL  = "sql"  // queries use database/sql directly, for apps that can't adopt GORM
+)
+
 // Manager wraps GORM with additional functionality
 type Manager struct {
-	DB     *gorm.DB
-	Config *DatabaseConfig
+	DB         *gorm.DB    // set in ModeGORM
+	SQL        *sql.DB     // set in ModeSQL
+	Resilience *Resilience // retry and circuit breaker policy of Query; see EnableResilience
+	Config     *DatabaseConfig
 }
 
 // NewManager creates a new ORM manager
 func NewManager(config *DatabaseConfig) (*Manager, error) {
-	dialector, err := getDialector(config)
+	if config.Mode == ModeSQL {
+		return newSQLManager(config)
+	}
+
+	driver, err := lookupDriver(config.Driver)
 	if err != nil {
 		return nil, err
 	}
 
-	db, err := gorm.Open(dialector, &gorm.Config{})
+	db, err := gorm.Open(driver.Open(driver.dataSourceName(config)), &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
@@ -44,47 +58,81 @@ func NewManager(config *DatabaseConfig) (*Manager, error) {
 	}, nil
 }
 
-// getDialector returns the appropriate GORM dialector based on config
-func getDialector(config *DatabaseConfig) (gorm.Dialector, error) {
-	switch config.Driver {
-	case "sqlite":
-		return sqlite.Open(config.Database), nil
-
-	case "postgres", "postgresql":
-		dsn := fmt.Sprintf(
-			"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
-			config.Host,
-			config.Username,
-			config.Password,
-			config.Database,
-			config.Port,
-			config.SSLMode,
-		)
-		return postgres.Open(dsn), nil
-
-	case "mysql":
-		dsn := fmt.Sprintf(
-			"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-			config.Username,
-			config.Password,
-			config.Host,
-			config.Port,
-			config.Database,
-		)
-		return mysql.Open(dsn), nil
-
-	default:
-		return nil, fmt.Errorf("unsupported database driver: %s", config.Driver)
+// newSQLManager opens a database/sql connection for ModeSQL
+func newSQLManager(config *DatabaseConfig) (*Manager, error) {
+	driver, err := lookupDriver(config.Driver)
+	if err != nil {
+		return nil, err
+	}
+	if driver.SQLDriver == "" {
+		return nil, fmt.Errorf("database driver %s has no database/sql driver for sql mode", config.Driver)
 	}
+
+	db, err := sql.Open(driver.SQLDriver, driver.dataSourceName(config))
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Manager{
+		SQL:    db,
+		Config: config,
+	}, nil
+}
+
+// Query returns a QuerySet for the manager's connection, backed by GORM or database/sql
+func (m *Manager) Query() QuerySet {
+	var qs QuerySet
+	if m.SQL != nil {
+		qs = NewSQLQuerySet(m.SQL, m.Config.Driver)
+	} else {
+		qs = NewQuerySet(m.DB)
+	}
+	if m.Resilience != nil {
+		qs = m.Resilience.Wrap(qs)
+	}
+	return qs
+}
+
+// EnableResilience retries transient errors of Query operations and opens a circuit
+// breaker when the database is down; tune the returned policy before use
+func (m *Manager) EnableResilience() *Resilience {
+	m.Resilience = NewResilience(m.Ping)
+	return m.Resilience
+}
+
+// Ping checks that the database answers
+func (m *Manager) Ping(ctx context.Context) error {
+	if m.SQL != nil {
+		return m.SQL.PingContext(ctx)
+	}
+	sqlDB, err := m.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
 }
 
 // AutoMigrate runs auto migration for given models
+// AutoMigrate needs GORM; in ModeSQL run migrations with your own tooling
 func (m *Manager) AutoMigrate(models ...interface{}) error {
+	if m.DB == nil {
+		return errors.New("orm: AutoMigrate is not available in sql mode")
+	}
 	return m.DB.AutoMigrate(models...)
 }
 
 // Close closes the database connection
 func (m *Manager) Close() error {
+	if m.Resilience != nil {
+		m.Resilience.Stop()
+	}
+	if m.SQL != nil {
+		return m.SQL.Close()
+	}
 	sqlDB, err := m.DB.DB()
 	if err != nil {
 		return err
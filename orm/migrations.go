@@ -0,0 +1,103 @@
+package orm
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is a versioned schema change
+// IDs sort in the order migrations apply, e.g. "20250101_create_users"
+type Migration struct {
+	ID string
+	Up func(tx *gorm.DB) error
+}
+
+// MigrationStatus lists applied and pending migrations by ID
+type MigrationStatus struct {
+	Applied []string `json:"applied"`
+	Pending []string `json:"pending"`
+}
+
+// schemaMigration records an applied migration
+type schemaMigration struct {
+	ID        string `gorm:"primaryKey"`
+	AppliedAt time.Time
+}
+
+// TableName keeps applied migrations in schema_migrations
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator applies migrations and records them in the schema_migrations table
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the given migrations
+func NewMigrator(db *gorm.DB, migrations ...Migration) *Migrator {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+	return &Migrator{db: db, migrations: sorted}
+}
+
+// Status compares the applied migrations with the available ones
+func (m *Migrator) Status() (MigrationStatus, error) {
+	status := MigrationStatus{Applied: []string{}, Pending: []string{}}
+
+	applied := make(map[string]bool)
+	if m.db.Migrator().HasTable(&schemaMigration{}) {
+		var records []schemaMigration
+		if err := m.db.Order("id").Find(&records).Error; err != nil {
+			return status, err
+		}
+		for _, record := range records {
+			applied[record.ID] = true
+			status.Applied = append(status.Applied, record.ID)
+		}
+	}
+
+	for _, migration := range m.migrations {
+		if !applied[migration.ID] {
+			status.Pending = append(status.Pending, migration.ID)
+		}
+	}
+	return status, nil
+}
+
+// Migrate applies the pending migrations in ID order, each in its own transaction
+func (m *Migrator) Migrate() error {
+	if err := m.db.AutoMigrate(&schemaMigration{}); err != nil {
+		return err
+	}
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	pending := make(map[string]bool, len(status.Pending))
+	for _, id := range status.Pending {
+		pending[id] = true
+	}
+	for _, migration := range m.migrations {
+		if !pending[migration.ID] {
+			continue
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{ID: migration.ID, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", migration.ID, err)
+		}
+	}
+	return nil
+}
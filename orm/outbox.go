@@ -0,0 +1,177 @@
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OutboxMessage is a notification stored in the transaction of the change it describes,
+// so it is published if and only if the change is committed
+type OutboxMessage struct {
+	ID            uint64          `gorm:"primaryKey" json:"id"`
+	Topic         string          `gorm:"size:255;index" json:"topic"`
+	Payload       json.RawMessage `gorm:"type:text" json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttemptAt time.Time       `gorm:"index" json:"next_attempt_at"`
+	PublishedAt   *time.Time      `gorm:"index" json:"published_at,omitempty"`
+}
+
+// TableName keeps outbox messages in outbox_messages
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
+
+// Enqueue stores a message in the outbox within tx, next to the change it announces
+//
+//	db.Transaction(func(tx *gorm.DB) error {
+//		if err := tx.Create(&order).Error; err != nil {
+//			return err
+//		}
+//		return orm.Enqueue(tx, "order.created", order)
+//	})
+func Enqueue(tx *gorm.DB, topic string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("outbox payload for %s: %w", topic, err)
+	}
+	now := time.Now()
+	return tx.Create(&OutboxMessage{Topic: topic, Payload: data, CreatedAt: now, NextAttemptAt: now}).Error
+}
+
+// Publisher delivers outbox messages, e.g. to a message broker or a webhook
+// Delivery is at least once: a message may be published again if the relay stops
+// between publishing and recording it, so consumers should deduplicate by ID
+type Publisher interface {
+	Publish(ctx context.Context, msg OutboxMessage) error
+}
+
+// PublisherFunc adapts a function to a Publisher
+type PublisherFunc func(ctx context.Context, msg OutboxMessage) error
+
+// Publish calls f
+func (f PublisherFunc) Publish(ctx context.Context, msg OutboxMessage) error {
+	return f(ctx, msg)
+}
+
+// OutboxRelay publishes committed outbox messages in the order they were enqueued
+// A failed message is retried with exponential backoff without holding up the others;
+// after MaxAttempts it stays in the table with its last error for inspection
+type OutboxRelay struct {
+	Interval    time.Duration              // pause between polls when the outbox is empty (default 1s)
+	BatchSize   int                        // messages claimed per poll (default 100)
+	MaxAttempts int                        // attempts before a message is given up (default 10)
+	MinBackoff  time.Duration              // delay after the first failure, doubled for each one (default 1s)
+	MaxBackoff  time.Duration              // upper bound for the retry delay (default 1h)
+	Timeout     time.Duration              // bound for a single Publish call (default 30s)
+	OnError     func(OutboxMessage, error) // called for every failed attempt, with a zero message when the outbox can't be read
+
+	db        *gorm.DB
+	publisher Publisher
+}
+
+// NewOutboxRelay creates a relay publishing the outbox on db
+func NewOutboxRelay(db *gorm.DB, publisher Publisher) *OutboxRelay {
+	return &OutboxRelay{
+		Interval:    time.Second,
+		BatchSize:   100,
+		MaxAttempts: 10,
+		MinBackoff:  time.Second,
+		MaxBackoff:  time.Hour,
+		Timeout:     30 * time.Second,
+		db:          db,
+		publisher:   publisher,
+	}
+}
+
+// Run relays messages until ctx is cancelled
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	for {
+		n, err := r.RelayOnce(ctx)
+		if err != nil && r.OnError != nil {
+			r.OnError(OutboxMessage{}, err)
+		}
+		if n == r.BatchSize {
+			continue // there may be more
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.Interval):
+		}
+	}
+}
+
+// RelayOnce claims a batch of due messages, publishes them and records the outcome
+// It returns the number of messages attempted
+// Rows are locked with SKIP LOCKED where the database supports it, so several
+// relays can share an outbox
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	attempted := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("published_at IS NULL AND attempts < ? AND next_attempt_at <= ?", r.MaxAttempts, time.Now()).
+			Order("id").Limit(r.BatchSize)
+		if tx.Dialector.Name() != "sqlite" {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+
+		var batch []OutboxMessage
+		if err := query.Find(&batch).Error; err != nil {
+			return err
+		}
+
+		for _, msg := range batch {
+			attempted++
+			if publishErr := r.publish(ctx, msg); publishErr != nil {
+				msg.Attempts++
+				if r.OnError != nil {
+					r.OnError(msg, publishErr)
+				}
+				if err := tx.Model(&msg).Updates(map[string]interface{}{
+					"attempts":        msg.Attempts,
+					"last_error":      publishErr.Error(),
+					"next_attempt_at": time.Now().Add(r.backoff(msg.Attempts)),
+				}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Model(&msg).Updates(map[string]interface{}{
+				"attempts":     msg.Attempts + 1,
+				"published_at": time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return attempted, err
+}
+
+// publish hands a message to the publisher within the relay's timeout
+func (r *OutboxRelay) publish(ctx context.Context, msg OutboxMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+	return r.publisher.Publish(ctx, msg)
+}
+
+// backoff returns the delay before the next attempt of a message that failed attempts times
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	delay := r.MinBackoff << (attempts - 1)
+	if delay <= 0 || delay > r.MaxBackoff {
+		delay = r.MaxBackoff
+	}
+	return delay
+}
+
+// Purge deletes messages published before cutoff and returns how many were removed
+func (r *OutboxRelay) Purge(cutoff time.Time) (int64, error) {
+	result := r.db.Where("published_at IS NOT NULL AND published_at < ?", cutoff).Delete(&OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
@@ -1,111 +1,237 @@
 package orm
 
 import (
+	"fmt"
+	"reflect"
+	"strconv"
+
 	"gorm.io/gorm"
 )
 
-// QuerySet provides Django-like queryset API on top of GORM
-type QuerySet struct {
+// QuerySet provides a Django-like queryset API
+// NewQuerySet implements it on top of GORM and NewSQLQuerySet on database/sql alone
+type QuerySet interface {
+	All(dest interface{}) error
+	Filter(query interface{}, args ...interface{}) QuerySet
+	Exclude(query interface{}, args ...interface{}) QuerySet
+	Get(dest interface{}) error
+	Count() (int64, error)
+	Exists() (bool, error)
+	Model(value interface{}) QuerySet
+	Table(name string) QuerySet
+	OrderBy(fields ...string) QuerySet
+	Limit(limit int) QuerySet
+	Offset(offset int) QuerySet
+	Select(fields ...string) QuerySet
+	Preload(associations ...string) QuerySet
+	Create(value interface{}) error
+	Update(column string, value interface{}) error
+	Updates(values interface{}) error
+	UpdateFields(value interface{}, fields ...string) error
+	Delete(value interface{}) error
+	First(dest interface{}) error
+	Last(dest interface{}) error
+	Stream(dest interface{}, batchSize int, fn func(batch int) error) error
+}
+
+// Errors shared by both QuerySet implementations
+var (
+	ErrRecordNotFound     = gorm.ErrRecordNotFound
+	ErrMissingWhereClause = gorm.ErrMissingWhereClause
+)
+
+// gormQuerySet implements QuerySet with GORM
+type gormQuerySet struct {
 	db *gorm.DB
 }
 
-// NewQuerySet creates a new QuerySet
-func NewQuerySet(db *gorm.DB) *QuerySet {
-	return &QuerySet{db: db}
+// NewQuerySet creates a new QuerySet on a GORM connection
+func NewQuerySet(db *gorm.DB) QuerySet {
+	return &gormQuerySet{db: db}
 }
 
 // All returns all records
-func (q *QuerySet) All(dest interface{}) error {
+func (q *gormQuerySet) All(dest interface{}) error {
 	return q.db.Find(dest).Error
 }
 
 // Filter filters records by conditions
-func (q *QuerySet) Filter(query interface{}, args ...interface{}) *QuerySet {
-	return &QuerySet{db: q.db.Where(query, args...)}
+func (q *gormQuerySet) Filter(query interface{}, args ...interface{}) QuerySet {
+	return &gormQuerySet{db: q.db.Where(query, args...)}
 }
 
 // Exclude excludes records by conditions
-func (q *QuerySet) Exclude(query interface{}, args ...interface{}) *QuerySet {
-	return &QuerySet{db: q.db.Not(query, args...)}
+func (q *gormQuerySet) Exclude(query interface{}, args ...interface{}) QuerySet {
+	return &gormQuerySet{db: q.db.Not(query, args...)}
 }
 
 // Get retrieves a single record
-func (q *QuerySet) Get(dest interface{}) error {
+func (q *gormQuerySet) Get(dest interface{}) error {
 	return q.db.First(dest).Error
 }
 
+// Model sets the model whose table is queried, e.g. for Count or Update
+func (q *gormQuerySet) Model(value interface{}) QuerySet {
+	return &gormQuerySet{db: q.db.Model(value)}
+}
+
+// Table sets the table to query by name
+func (q *gormQuerySet) Table(name string) QuerySet {
+	return &gormQuerySet{db: q.db.Table(name)}
+}
+
 // Count returns the count of records
-func (q *QuerySet) Count() (int64, error) {
+func (q *gormQuerySet) Count() (int64, error) {
 	var count int64
 	err := q.db.Count(&count).Error
 	return count, err
 }
 
 // Exists checks if records exist
-func (q *QuerySet) Exists() (bool, error) {
+func (q *gormQuerySet) Exists() (bool, error) {
 	count, err := q.Count()
 	return count > 0, err
 }
 
 // OrderBy orders the results
-func (q *QuerySet) OrderBy(fields ...string) *QuerySet {
+func (q *gormQuerySet) OrderBy(fields ...string) QuerySet {
 	db := q.db
 	for _, field := range fields {
 		db = db.Order(field)
 	}
-	return &QuerySet{db: db}
+	return &gormQuerySet{db: db}
 }
 
 // Limit limits the number of results
-func (q *QuerySet) Limit(limit int) *QuerySet {
-	return &QuerySet{db: q.db.Limit(limit)}
+func (q *gormQuerySet) Limit(limit int) QuerySet {
+	return &gormQuerySet{db: q.db.Limit(limit)}
 }
 
 // Offset sets the offset for results
-func (q *QuerySet) Offset(offset int) *QuerySet {
-	return &QuerySet{db: q.db.Offset(offset)}
+func (q *gormQuerySet) Offset(offset int) QuerySet {
+	return &gormQuerySet{db: q.db.Offset(offset)}
 }
 
 // Select specifies fields to retrieve
-func (q *QuerySet) Select(fields ...string) *QuerySet {
-	return &QuerySet{db: q.db.Select(fields)}
+func (q *gormQuerySet) Select(fields ...string) QuerySet {
+	return &gormQuerySet{db: q.db.Select(fields)}
 }
 
 // Preload preloads associations
-func (q *QuerySet) Preload(associations ...string) *QuerySet {
+func (q *gormQuerySet) Preload(associations ...string) QuerySet {
 	db := q.db
 	for _, assoc := range associations {
 		db = db.Preload(assoc)
 	}
-	return &QuerySet{db: db}
+	return &gormQuerySet{db: db}
 }
 
 // Create creates a new record
-func (q *QuerySet) Create(value interface{}) error {
+func (q *gormQuerySet) Create(value interface{}) error {
 	return q.db.Create(value).Error
 }
 
 // Update updates records
-func (q *QuerySet) Update(column string, value interface{}) error {
+func (q *gormQuerySet) Update(column string, value interface{}) error {
 	return q.db.Update(column, value).Error
 }
 
 // Updates updates multiple columns
-func (q *QuerySet) Updates(values interface{}) error {
+func (q *gormQuerySet) Updates(values interface{}) error {
 	return q.db.Updates(values).Error
 }
 
+// UpdateFields saves only the named fields of a record, e.g. the changed fields of an edit form
+// Zero values are written too, so a field cleared by the user is saved
+func (q *gormQuerySet) UpdateFields(value interface{}, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return q.db.Model(value).Select(fields).Updates(value).Error
+}
+
 // Delete deletes records
-func (q *QuerySet) Delete(value interface{}) error {
+func (q *gormQuerySet) Delete(value interface{}) error {
 	return q.db.Delete(value).Error
 }
 
 // First gets the first record
-func (q *QuerySet) First(dest interface{}) error {
+func (q *gormQuerySet) First(dest interface{}) error {
 	return q.db.First(dest).Error
 }
 
 // Last gets the last record
-func (q *QuerySet) Last(dest interface{}) error {
+func (q *gormQuerySet) Last(dest interface{}) error {
 	return q.db.Last(dest).Error
+}
+
+// Stream loads the matching records batch by batch into dest, a pointer to a slice,
+// and calls fn with the batch number after each one; an error from fn stops the stream
+// Batches are paged by primary key rather than offset, so the last batch is as quick
+// as the first, and Limit caps the total
+func (q *gormQuerySet) Stream(dest interface{}, batchSize int, fn func(batch int) error) error {
+	return q.db.FindInBatches(dest, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(batch)
+	}).Error
+}
+
+// StreamAs streams the records of a QuerySet as batches of T
+//
+//	err := orm.StreamAs(qs.Filter("status = ?", "paid"), 500, func(orders []Order) error {
+//		return export(orders)
+//	})
+func StreamAs[T any](qs QuerySet, batchSize int, fn func(batch []T) error) error {
+	var rows []T
+	return qs.Stream(&rows, batchSize, func(int) error {
+		return fn(rows)
+	})
+}
+
+// KeyCursor returns the primary key of a record as a cursor, e.g. the last row a stream
+// delivered, to continue after it with AfterKey
+func KeyCursor(record interface{}) (string, error) {
+	schema, err := schemaOf(record)
+	if err != nil {
+		return "", err
+	}
+	if schema.primary == nil {
+		return "", fmt.Errorf("orm: %s has no primary key", schema.table)
+	}
+	key := schema.primary.value(reflect.Indirect(reflect.ValueOf(record)))
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	case reflect.String:
+		return key.String(), nil
+	}
+	return "", fmt.Errorf("orm: %s has a %s primary key, which can't be a cursor", schema.table, key.Type())
+}
+
+// AfterKey narrows qs to the records of model whose primary key comes after a KeyCursor
+func AfterKey(qs QuerySet, model interface{}, cursor string) (QuerySet, error) {
+	schema, err := schemaOf(model)
+	if err != nil {
+		return nil, err
+	}
+	if schema.primary == nil {
+		return nil, fmt.Errorf("orm: %s has no primary key", schema.table)
+	}
+
+	var key interface{}
+	switch schema.primary.typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		key, err = strconv.ParseInt(cursor, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		key, err = strconv.ParseUint(cursor, 10, 64)
+	case reflect.String:
+		key = cursor
+	default:
+		err = fmt.Errorf("a %s primary key can't be a cursor", schema.primary.typ)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("orm: cursor %q for %s: %w", cursor, schema.table, err)
+	}
+	return qs.Filter(schema.primary.column+" > ?", key), nil
 }
\ No newline at end of file
@@ -0,0 +1,444 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrCircuitOpen matches the error returned while the database is considered down
+var ErrCircuitOpen = errors.New("orm: database unavailable")
+
+// CircuitOpenError is returned without touching the database while the circuit is open
+// LiveView shows components failing with it as temporarily unavailable
+type CircuitOpenError struct {
+	Cause error         // the failure that opened the circuit
+	Retry time.Duration // time until the next recovery probe
+}
+
+// Error describes the failure that opened the circuit
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrCircuitOpen, e.Cause)
+}
+
+// Is makes errors.Is(err, ErrCircuitOpen) match
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// Unwrap returns the failure that opened the circuit
+func (e *CircuitOpenError) Unwrap() error {
+	return e.Cause
+}
+
+// Unavailable marks the error as a temporary outage
+func (e *CircuitOpenError) Unavailable() bool {
+	return true
+}
+
+// RetryAfter returns the time until the next recovery probe
+func (e *CircuitOpenError) RetryAfter() time.Duration {
+	return e.Retry
+}
+
+// Resilience retries transient database errors with exponential backoff and opens a
+// circuit breaker after repeated connection failures
+// While the circuit is open queries fail fast with a CircuitOpenError and a probe checks
+// the database every ProbeInterval; the first successful probe closes the circuit
+type Resilience struct {
+	Retries       int             // retries of a transient failure (default 3)
+	MinDelay      time.Duration   // delay before the first retry, doubled for each one (default 50ms)
+	MaxDelay      time.Duration   // upper bound for the retry delay (default 1s)
+	Threshold     int             // consecutive connection failures that open the circuit (default 5)
+	ProbeInterval time.Duration   // time between recovery probes while the circuit is open (default 5s)
+	OnStateChange func(open bool) // called when the circuit opens or closes
+	probe         func(context.Context) error
+
+	mu        sync.Mutex
+	failures  int
+	open      bool
+	cause     error
+	nextProbe time.Time
+	stop      chan struct{}
+}
+
+// NewResilience creates a Resilience whose recovery probe is probe, e.g. a ping
+func NewResilience(probe func(ctx context.Context) error) *Resilience {
+	return &Resilience{
+		Retries:       3,
+		MinDelay:      50 * time.Millisecond,
+		MaxDelay:      time.Second,
+		Threshold:     5,
+		ProbeInterval: 5 * time.Second,
+		probe:         probe,
+		stop:          make(chan struct{}),
+	}
+}
+
+// Wrap returns a QuerySet whose operations go through the retry policy and circuit breaker
+func (r *Resilience) Wrap(qs QuerySet) QuerySet {
+	return &resilientQuerySet{qs: qs, r: r}
+}
+
+// Open reports whether the circuit is open, i.e. the database is considered down
+func (r *Resilience) Open() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.open
+}
+
+// Stop ends recovery probing, e.g. when the connection is closed
+func (r *Resilience) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	select {
+	case <-r.stop:
+	default:
+		close(r.stop)
+	}
+}
+
+// Do runs op, retrying transient failures; write marks operations that change data,
+// which are only retried when the failed attempt can't have been applied
+func (r *Resilience) Do(write bool, op func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := r.check(); err != nil {
+			return err
+		}
+
+		err := op()
+		kind := classify(err)
+		if kind == failureNone || kind == failurePermanent {
+			r.succeeded()
+			return err
+		}
+		if attempt >= r.Retries || (write && kind == failureAmbiguous) {
+			r.failed(kind, err)
+			return err
+		}
+		time.Sleep(r.backoff(attempt))
+	}
+}
+
+// check fails fast while the circuit is open
+func (r *Resilience) check() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.open {
+		return nil
+	}
+	return &CircuitOpenError{Cause: r.cause, Retry: max(time.Until(r.nextProbe), 0)}
+}
+
+// backoff returns the delay before a retry: exponential with equal jitter
+func (r *Resilience) backoff(attempt int) time.Duration {
+	delay := r.MinDelay << attempt
+	if delay <= 0 || delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// succeeded resets the failure count; any answer from the database counts
+func (r *Resilience) succeeded() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = 0
+}
+
+// failed counts a connection failure and opens the circuit at the threshold
+func (r *Resilience) failed(kind failureKind, err error) {
+	if kind == failureContention {
+		r.succeeded()
+		return
+	}
+
+	r.mu.Lock()
+	r.failures++
+	opening := !r.open && r.failures >= r.Threshold
+	if opening {
+		r.open = true
+		r.cause = err
+		r.nextProbe = time.Now().Add(r.ProbeInterval)
+	}
+	r.mu.Unlock()
+
+	if opening {
+		r.stateChanged(true)
+		go r.probeUntilRecovered()
+	}
+}
+
+// probeUntilRecovered runs the probe every ProbeInterval and closes the circuit once it passes
+func (r *Resilience) probeUntilRecovered() {
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-time.After(r.ProbeInterval):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), r.ProbeInterval)
+		err := r.probe(ctx)
+		cancel()
+
+		r.mu.Lock()
+		if err != nil {
+			r.cause = err
+			r.nextProbe = time.Now().Add(r.ProbeInterval)
+			r.mu.Unlock()
+			continue
+		}
+		r.open = false
+		r.failures = 0
+		r.cause = nil
+		r.mu.Unlock()
+
+		r.stateChanged(false)
+		return
+	}
+}
+
+// stateChanged notifies OnStateChange
+func (r *Resilience) stateChanged(open bool) {
+	if r.OnStateChange != nil {
+		r.OnStateChange(open)
+	}
+}
+
+// failureKind classifies an error for retries and the circuit breaker
+type failureKind int
+
+const (
+	failureNone       failureKind = iota
+	failurePermanent              // e.g. a constraint violation or a missing record; not retried
+	failureContention             // a lock or deadlock; the statement was rolled back and can be retried
+	failureConnection             // the statement never reached the database
+	failureAmbiguous              // the connection broke mid-statement; a write may have been applied
+)
+
+// IsTransient reports whether an error is a temporary failure worth retrying
+func IsTransient(err error) bool {
+	kind := classify(err)
+	return kind != failureNone && kind != failurePermanent
+}
+
+// classify sorts an error into a failureKind by its type and, for driver errors without
+// exported types, its message
+func classify(err error) failureKind {
+	switch {
+	case err == nil:
+		return failureNone
+	case errors.Is(err, ErrRecordNotFound), errors.Is(err, ErrCircuitOpen),
+		errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return failurePermanent
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, syscall.ECONNREFUSED):
+		return failureConnection
+	case errors.Is(err, sql.ErrConnDone), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return failureAmbiguous
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return failureConnection
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return failureAmbiguous
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, m := range contentionMessages {
+		if strings.Contains(message, m) {
+			return failureContention
+		}
+	}
+	for _, m := range connectionMessages {
+		if strings.Contains(message, m) {
+			return failureConnection
+		}
+	}
+	for _, m := range ambiguousMessages {
+		if strings.Contains(message, m) {
+			return failureAmbiguous
+		}
+	}
+	return failurePermanent
+}
+
+// Driver error messages by failure kind
+var (
+	contentionMessages = []string{
+		"database is locked",         // SQLite busy
+		"deadlock",                   // PostgreSQL 40P01, MySQL 1213
+		"could not serialize access", // PostgreSQL 40001
+		"lock wait timeout exceeded", // MySQL 1205
+	}
+	connectionMessages = []string{
+		"connection refused",
+		"too many connections",                 // MySQL 1040
+		"too many clients",                     // PostgreSQL 53300
+		"the database system is starting up",   // PostgreSQL 57P03
+		"the database system is shutting down", // PostgreSQL 57P03
+		"no such host",
+	}
+	ambiguousMessages = []string{
+		"connection reset",
+		"broken pipe",
+		"bad connection",
+		"server closed the connection",
+		"server has gone away", // MySQL 2006
+		"lost connection",      // MySQL 2013
+		"i/o timeout",
+	}
+)
+
+// resilientQuerySet runs the operations of a QuerySet through a Resilience
+type resilientQuerySet struct {
+	qs QuerySet
+	r  *Resilience
+}
+
+// wrap keeps the policy on a derived QuerySet
+func (q *resilientQuerySet) wrap(qs QuerySet) QuerySet {
+	return &resilientQuerySet{qs: qs, r: q.r}
+}
+
+// All returns all records
+func (q *resilientQuerySet) All(dest interface{}) error {
+	return q.r.Do(false, func() error { return q.qs.All(dest) })
+}
+
+// Filter filters records by conditions
+func (q *resilientQuerySet) Filter(query interface{}, args ...interface{}) QuerySet {
+	return q.wrap(q.qs.Filter(query, args...))
+}
+
+// Exclude excludes records by conditions
+func (q *resilientQuerySet) Exclude(query interface{}, args ...interface{}) QuerySet {
+	return q.wrap(q.qs.Exclude(query, args...))
+}
+
+// Get retrieves a single record
+func (q *resilientQuerySet) Get(dest interface{}) error {
+	return q.r.Do(false, func() error { return q.qs.Get(dest) })
+}
+
+// Count returns the count of records
+func (q *resilientQuerySet) Count() (int64, error) {
+	var count int64
+	err := q.r.Do(false, func() error {
+		var err error
+		count, err = q.qs.Count()
+		return err
+	})
+	return count, err
+}
+
+// Exists checks if records exist
+func (q *resilientQuerySet) Exists() (bool, error) {
+	count, err := q.Count()
+	return count > 0, err
+}
+
+// Model sets the model whose table is queried
+func (q *resilientQuerySet) Model(value interface{}) QuerySet {
+	return q.wrap(q.qs.Model(value))
+}
+
+// Table sets the table to query by name
+func (q *resilientQuerySet) Table(name string) QuerySet {
+	return q.wrap(q.qs.Table(name))
+}
+
+// OrderBy orders the results
+func (q *resilientQuerySet) OrderBy(fields ...string) QuerySet {
+	return q.wrap(q.qs.OrderBy(fields...))
+}
+
+// Limit limits the number of results
+func (q *resilientQuerySet) Limit(limit int) QuerySet {
+	return q.wrap(q.qs.Limit(limit))
+}
+
+// Offset sets the offset for results
+func (q *resilientQuerySet) Offset(offset int) QuerySet {
+	return q.wrap(q.qs.Offset(offset))
+}
+
+// Select specifies fields to retrieve
+func (q *resilientQuerySet) Select(fields ...string) QuerySet {
+	return q.wrap(q.qs.Select(fields...))
+}
+
+// Preload preloads associations
+func (q *resilientQuerySet) Preload(associations ...string) QuerySet {
+	return q.wrap(q.qs.Preload(associations...))
+}
+
+// Create creates a new record
+func (q *resilientQuerySet) Create(value interface{}) error {
+	return q.r.Do(true, func() error { return q.qs.Create(value) })
+}
+
+// Update updates records
+func (q *resilientQuerySet) Update(column string, value interface{}) error {
+	return q.r.Do(true, func() error { return q.qs.Update(column, value) })
+}
+
+// Updates updates multiple columns
+func (q *resilientQuerySet) Updates(values interface{}) error {
+	return q.r.Do(true, func() error { return q.qs.Updates(values) })
+}
+
+// UpdateFields saves only the named fields of a record
+func (q *resilientQuerySet) UpdateFields(value interface{}, fields ...string) error {
+	return q.r.Do(true, func() error { return q.qs.UpdateFields(value, fields...) })
+}
+
+// Delete deletes records
+func (q *resilientQuerySet) Delete(value interface{}) error {
+	return q.r.Do(true, func() error { return q.qs.Delete(value) })
+}
+
+// First gets the first record
+func (q *resilientQuerySet) First(dest interface{}) error {
+	return q.r.Do(false, func() error { return q.qs.First(dest) })
+}
+
+// Last gets the last record
+func (q *resilientQuerySet) Last(dest interface{}) error {
+	return q.r.Do(false, func() error { return q.qs.Last(dest) })
+}
+
+// Stream streams records in batches
+// Only a failure before the first batch is retried, since fn has seen the batches before it
+func (q *resilientQuerySet) Stream(dest interface{}, batchSize int, fn func(batch int) error) error {
+	var streamErr error
+	err := q.r.Do(false, func() error {
+		delivered := false
+		err := q.qs.Stream(dest, batchSize, func(batch int) error {
+			delivered = true
+			return fn(batch)
+		})
+		if delivered {
+			streamErr = err
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return streamErr
+}
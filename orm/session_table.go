@@ -0,0 +1,86 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StoredSession is the data of a session kept in the database, so every node of a
+// deployment reads the same sessions
+type StoredSession struct {
+	ID        string    `gorm:"primaryKey;size:64" json:"id"`
+	Data      []byte    `json:"-"`
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName keeps sessions in stored_sessions
+func (StoredSession) TableName() string {
+	return "stored_sessions"
+}
+
+// SessionTable stores session data by ID in the stored_sessions table
+// Expired rows are ignored when read and deleted by Run
+type SessionTable struct {
+	Interval time.Duration // pause between deletions of expired sessions (default 10m)
+	OnError  func(error)   // called when deleting expired sessions fails
+
+	db *gorm.DB
+}
+
+// NewSessionTable creates a session table on db
+// The stored_sessions table must exist, e.g. via AutoMigrate(&StoredSession{})
+func NewSessionTable(db *gorm.DB) *SessionTable {
+	return &SessionTable{Interval: 10 * time.Minute, db: db}
+}
+
+// Get returns the data of the session id, or nil when there is none or it expired
+func (t *SessionTable) Get(ctx context.Context, id string) ([]byte, error) {
+	var session StoredSession
+	err := t.db.WithContext(ctx).Where("id = ? AND expires_at > ?", id, time.Now()).Take(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return session.Data, nil
+}
+
+// Set stores the data of the session id until ttl has passed
+func (t *SessionTable) Set(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	session := StoredSession{ID: id, Data: data, ExpiresAt: time.Now().Add(ttl)}
+	return t.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data", "expires_at", "updated_at"}),
+	}).Create(&session).Error
+}
+
+// Delete removes the session id
+func (t *SessionTable) Delete(ctx context.Context, id string) error {
+	return t.db.WithContext(ctx).Where("id = ?", id).Delete(&StoredSession{}).Error
+}
+
+// DeleteExpired deletes the sessions that expired and returns how many were removed
+func (t *SessionTable) DeleteExpired(ctx context.Context) (int64, error) {
+	result := t.db.WithContext(ctx).Where("expires_at <= ?", time.Now()).Delete(&StoredSession{})
+	return result.RowsAffected, result.Error
+}
+
+// Run deletes expired sessions every Interval until ctx is cancelled
+func (t *SessionTable) Run(ctx context.Context) error {
+	for {
+		if _, err := t.DeleteExpired(ctx); err != nil && ctx.Err() == nil && t.OnError != nil {
+			t.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(t.Interval):
+		}
+	}
+}
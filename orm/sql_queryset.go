@@ -0,0 +1,621 @@
+package orm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scannerType is the sql.Scanner interface type
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// sqlQuerySet implements QuerySet on database/sql without GORM
+// Queries are written with ? placeholders, which are rewritten for PostgreSQL
+type sqlQuerySet struct {
+	db      *sql.DB
+	driver  string
+	table   string
+	model   interface{}
+	where   []sqlCondition
+	order   []string
+	columns []string
+	limit   int
+	offset  int
+	err     error
+}
+
+// sqlCondition is one WHERE clause with its arguments
+type sqlCondition struct {
+	clause string
+	args   []interface{}
+}
+
+// NewSQLQuerySet creates a QuerySet on a database/sql connection
+// driver is the DatabaseConfig driver name: "sqlite", "postgres" or "mysql". Models are
+// mapped like GORM maps them, so the same structs work with both QuerySets. Preload
+// and soft deletes are not supported
+func NewSQLQuerySet(db *sql.DB, driver string) QuerySet {
+	return &sqlQuerySet{db: db, driver: driver, limit: -1, offset: -1}
+}
+
+// clone copies the query set so chained calls don't change the receiver
+func (q *sqlQuerySet) clone() *sqlQuerySet {
+	c := *q
+	c.where = append([]sqlCondition(nil), q.where...)
+	c.order = append([]string(nil), q.order...)
+	c.columns = append([]string(nil), q.columns...)
+	return &c
+}
+
+// All returns all records
+func (q *sqlQuerySet) All(dest interface{}) error {
+	return q.find(dest, "", false)
+}
+
+// Filter filters records by a condition string with ? placeholders or a map of column values
+func (q *sqlQuerySet) Filter(query interface{}, args ...interface{}) QuerySet {
+	c := q.clone()
+	cond, err := c.condition(query, args)
+	if err != nil {
+		c.err = err
+	} else {
+		c.where = append(c.where, cond)
+	}
+	return c
+}
+
+// Exclude excludes records by conditions
+func (q *sqlQuerySet) Exclude(query interface{}, args ...interface{}) QuerySet {
+	c := q.clone()
+	cond, err := c.condition(query, args)
+	if err != nil {
+		c.err = err
+	} else {
+		c.where = append(c.where, sqlCondition{clause: "NOT (" + cond.clause + ")", args: cond.args})
+	}
+	return c
+}
+
+// Get retrieves a single record
+func (q *sqlQuerySet) Get(dest interface{}) error {
+	return q.First(dest)
+}
+
+// Count returns the count of records
+func (q *sqlQuerySet) Count() (int64, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
+	table, _, err := q.tableFor(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	where, args := q.whereSQL(nil)
+	var count int64
+	err = q.db.QueryRow(q.rebind("SELECT COUNT(*) FROM "+table+where), args...).Scan(&count)
+	return count, err
+}
+
+// Exists checks if records exist
+func (q *sqlQuerySet) Exists() (bool, error) {
+	count, err := q.Count()
+	return count > 0, err
+}
+
+// Model sets the model whose table is queried, e.g. for Count or Update
+// A model with its primary key set also limits updates and deletes to that record
+func (q *sqlQuerySet) Model(value interface{}) QuerySet {
+	c := q.clone()
+	c.model = value
+	return c
+}
+
+// Table sets the table to query by name
+func (q *sqlQuerySet) Table(name string) QuerySet {
+	c := q.clone()
+	c.table = name
+	return c
+}
+
+// OrderBy orders the results, e.g. OrderBy("name", "created_at desc")
+func (q *sqlQuerySet) OrderBy(fields ...string) QuerySet {
+	c := q.clone()
+	c.order = append(c.order, fields...)
+	return c
+}
+
+// Limit limits the number of results
+func (q *sqlQuerySet) Limit(limit int) QuerySet {
+	c := q.clone()
+	c.limit = limit
+	return c
+}
+
+// Offset sets the offset for results
+func (q *sqlQuerySet) Offset(offset int) QuerySet {
+	c := q.clone()
+	c.offset = offset
+	return c
+}
+
+// Select specifies fields to retrieve
+func (q *sqlQuerySet) Select(fields ...string) QuerySet {
+	c := q.clone()
+	c.columns = fields
+	return c
+}
+
+// Preload is not supported without GORM; queries using it fail
+func (q *sqlQuerySet) Preload(associations ...string) QuerySet {
+	c := q.clone()
+	c.err = fmt.Errorf("orm: Preload(%s) needs the GORM QuerySet", strings.Join(associations, ", "))
+	return c
+}
+
+// Create creates a new record and sets its primary key
+// Zero CreatedAt and UpdatedAt fields are set to the current time, as in GORM
+func (q *sqlQuerySet) Create(value interface{}) error {
+	if q.err != nil {
+		return q.err
+	}
+	table, schema, err := q.tableFor(value)
+	if err != nil {
+		return err
+	}
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if !v.CanAddr() {
+		return fmt.Errorf("orm: Create needs a pointer, got %T", value)
+	}
+
+	now := time.Now()
+	var columns, marks []string
+	var args []interface{}
+	for i := range schema.fields {
+		f := &schema.fields[i]
+		fv := f.value(v)
+		if f == schema.primary && fv.IsZero() {
+			continue // generated by the database
+		}
+		if (f.name == "CreatedAt" || f.name == "UpdatedAt") && fv.IsZero() && fv.Type() == reflect.TypeOf(now) {
+			fv.Set(reflect.ValueOf(now))
+		}
+		columns = append(columns, f.column)
+		marks = append(marks, "?")
+		args = append(args, fv.Interface())
+	}
+
+	query := "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES (" + strings.Join(marks, ", ") + ")"
+	pk := schema.primary
+	if pk == nil || !pk.value(v).IsZero() {
+		_, err = q.db.Exec(q.rebind(query), args...)
+		return err
+	}
+
+	// Read back the generated primary key
+	if q.isPostgres() {
+		return q.db.QueryRow(q.rebind(query+" RETURNING "+pk.column), args...).Scan(pk.value(v).Addr().Interface())
+	}
+	result, err := q.db.Exec(q.rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	return setInt(pk.value(v), id)
+}
+
+// Update updates one column of the matching records
+func (q *sqlQuerySet) Update(column string, value interface{}) error {
+	return q.Updates(map[string]interface{}{column: value})
+}
+
+// Updates updates multiple columns from a map, or the non-zero fields of a struct
+func (q *sqlQuerySet) Updates(values interface{}) error {
+	if q.err != nil {
+		return q.err
+	}
+	target := q.model
+	if target == nil {
+		if _, ok := values.(map[string]interface{}); !ok {
+			target = values
+		}
+	}
+	table, schema, err := q.tableFor(target)
+	if err != nil {
+		return err
+	}
+
+	var sets []string
+	var args []interface{}
+	if m, ok := values.(map[string]interface{}); ok {
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			column := key
+			if schema != nil {
+				f, err := schema.field(key)
+				if err != nil {
+					return err
+				}
+				column = f.column
+			}
+			sets = append(sets, column+" = ?")
+			args = append(args, m[key])
+		}
+	} else {
+		v := reflect.Indirect(reflect.ValueOf(values))
+		for i := range schema.fields {
+			f := &schema.fields[i]
+			if f == schema.primary || f.value(v).IsZero() {
+				continue
+			}
+			sets = append(sets, f.column+" = ?")
+			args = append(args, f.value(v).Interface())
+		}
+	}
+	return q.update(table, schema, target, sets, args)
+}
+
+// UpdateFields saves only the named fields of a record, e.g. the changed fields of an edit form
+// Zero values are written too, so a field cleared by the user is saved
+func (q *sqlQuerySet) UpdateFields(value interface{}, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	if q.err != nil {
+		return q.err
+	}
+	table, schema, err := q.tableFor(value)
+	if err != nil {
+		return err
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(value))
+	var sets []string
+	var args []interface{}
+	for _, name := range fields {
+		f, err := schema.field(name)
+		if err != nil {
+			return err
+		}
+		sets = append(sets, f.column+" = ?")
+		args = append(args, f.value(v).Interface())
+	}
+	return q.update(table, schema, value, sets, args)
+}
+
+// update runs an UPDATE limited to the conditions and the record's primary key
+func (q *sqlQuerySet) update(table string, schema *sqlSchema, record interface{}, sets []string, args []interface{}) error {
+	if len(sets) == 0 {
+		return nil
+	}
+	where, whereArgs := q.whereSQL(q.primaryKeyCondition(schema, record))
+	if where == "" {
+		return ErrMissingWhereClause
+	}
+	_, err := q.db.Exec(q.rebind("UPDATE "+table+" SET "+strings.Join(sets, ", ")+where), append(args, whereArgs...)...)
+	return err
+}
+
+// Delete deletes a record by primary key, or the records matching the conditions
+func (q *sqlQuerySet) Delete(value interface{}) error {
+	if q.err != nil {
+		return q.err
+	}
+	table, schema, err := q.tableFor(value)
+	if err != nil {
+		return err
+	}
+	where, args := q.whereSQL(q.primaryKeyCondition(schema, value))
+	if where == "" {
+		return ErrMissingWhereClause
+	}
+	_, err = q.db.Exec(q.rebind("DELETE FROM "+table+where), args...)
+	return err
+}
+
+// First gets the first record by primary key
+func (q *sqlQuerySet) First(dest interface{}) error {
+	return q.find(dest, "ASC", true)
+}
+
+// Last gets the last record by primary key
+func (q *sqlQuerySet) Last(dest interface{}) error {
+	return q.find(dest, "DESC", true)
+}
+
+// Stream loads the matching records batch by batch into dest, a pointer to a slice,
+// and calls fn with the batch number after each one; an error from fn stops the stream
+// Batches are paged by primary key rather than offset and come in primary key order;
+// Limit caps the total and Offset skips records before the first batch
+func (q *sqlQuerySet) Stream(dest interface{}, batchSize int, fn func(batch int) error) error {
+	if q.err != nil {
+		return q.err
+	}
+	if batchSize <= 0 {
+		return fmt.Errorf("orm: Stream needs a positive batch size, got %d", batchSize)
+	}
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("orm: Stream needs a pointer to a slice, got %T", dest)
+	}
+	_, schema, err := q.tableFor(dest)
+	if err != nil {
+		return err
+	}
+	if schema.primary == nil {
+		return fmt.Errorf("orm: Stream needs a model with a primary key")
+	}
+
+	page := q.clone()
+	pk := schema.primary.column
+	page.order = []string{pk + " ASC"}
+	if len(page.columns) > 0 && !slices.Contains(page.columns, pk) {
+		page.columns = append(page.columns, pk)
+	}
+
+	remaining := q.limit
+	for batch := 1; ; batch++ {
+		page.limit = batchSize
+		if remaining >= 0 && remaining < batchSize {
+			page.limit = remaining
+		}
+		if err := page.find(dest, "", false); err != nil {
+			return err
+		}
+		rows := dv.Elem()
+		n := rows.Len()
+		if n == 0 {
+			return nil
+		}
+		last := schema.primary.value(rows.Index(n - 1)).Interface()
+
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if remaining >= 0 {
+			remaining -= n
+		}
+		if n < page.limit || remaining == 0 {
+			return nil
+		}
+
+		// Later batches continue after the last key instead of skipping rows
+		page.offset = -1
+		if batch == 1 {
+			page.where = append(page.where, sqlCondition{})
+		}
+		page.where[len(page.where)-1] = sqlCondition{clause: pk + " > ?", args: []interface{}{last}}
+	}
+}
+
+// find runs a SELECT into a struct or a slice of structs
+// pkOrder orders by primary key; single limits the query to one record
+func (q *sqlQuerySet) find(dest interface{}, pkOrder string, single bool) error {
+	if q.err != nil {
+		return q.err
+	}
+	table, schema, err := q.tableFor(dest)
+	if err != nil {
+		return err
+	}
+
+	columns := q.columns
+	if len(columns) == 0 {
+		for _, f := range schema.fields {
+			columns = append(columns, f.column)
+		}
+	}
+
+	query := "SELECT " + strings.Join(columns, ", ") + " FROM " + table
+	where, args := q.whereSQL(nil)
+	query += where
+
+	order := q.order
+	if pkOrder != "" && schema.primary != nil {
+		order = append(append([]string(nil), order...), schema.primary.column+" "+pkOrder)
+	}
+	if len(order) > 0 {
+		query += " ORDER BY " + strings.Join(order, ", ")
+	}
+
+	limit := q.limit
+	if single {
+		limit = 1
+	}
+	if limit >= 0 {
+		query += " LIMIT " + strconv.Itoa(limit)
+	}
+	if q.offset >= 0 {
+		query += " OFFSET " + strconv.Itoa(q.offset)
+	}
+
+	rows, err := q.db.Query(q.rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr {
+		return fmt.Errorf("orm: destination must be a pointer, got %T", dest)
+	}
+	dv = dv.Elem()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	if dv.Kind() == reflect.Slice {
+		dv.SetLen(0)
+		for rows.Next() {
+			item := reflect.New(dv.Type().Elem()).Elem()
+			if err := scanRow(rows, names, schema, item); err != nil {
+				return err
+			}
+			dv.Set(reflect.Append(dv, item))
+		}
+		return rows.Err()
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return ErrRecordNotFound
+	}
+	return scanRow(rows, names, schema, dv)
+}
+
+// scanRow scans the current row into a struct value; unknown columns are discarded
+func scanRow(rows *sql.Rows, names []string, schema *sqlSchema, v reflect.Value) error {
+	targets := make([]interface{}, len(names))
+	for i, name := range names {
+		if f, ok := schema.columns[name]; ok {
+			targets[i] = f.value(v).Addr().Interface()
+		} else {
+			targets[i] = new(interface{})
+		}
+	}
+	return rows.Scan(targets...)
+}
+
+// condition builds a WHERE clause from a Filter or Exclude argument
+func (q *sqlQuerySet) condition(query interface{}, args []interface{}) (sqlCondition, error) {
+	switch query := query.(type) {
+	case string:
+		return sqlCondition{clause: query, args: args}, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var clauses []string
+		var condArgs []interface{}
+		for _, key := range keys {
+			clauses = append(clauses, key+" = ?")
+			condArgs = append(condArgs, query[key])
+		}
+		return sqlCondition{clause: strings.Join(clauses, " AND "), args: condArgs}, nil
+	}
+	return sqlCondition{}, fmt.Errorf("orm: unsupported condition %T", query)
+}
+
+// primaryKeyCondition limits a statement to a record with its primary key set
+func (q *sqlQuerySet) primaryKeyCondition(schema *sqlSchema, record interface{}) *sqlCondition {
+	if schema == nil || schema.primary == nil || record == nil {
+		return nil
+	}
+	if _, ok := record.(map[string]interface{}); ok {
+		return nil
+	}
+	pk := schema.primary.value(reflect.Indirect(reflect.ValueOf(record)))
+	if pk.IsZero() {
+		return nil
+	}
+	return &sqlCondition{clause: schema.primary.column + " = ?", args: []interface{}{pk.Interface()}}
+}
+
+// whereSQL joins the conditions, plus an optional extra one, into a WHERE clause
+func (q *sqlQuerySet) whereSQL(extra *sqlCondition) (string, []interface{}) {
+	conditions := q.where
+	if extra != nil {
+		conditions = append(append([]sqlCondition(nil), conditions...), *extra)
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+
+	clauses := make([]string, len(conditions))
+	var args []interface{}
+	for i, cond := range conditions {
+		clauses[i] = "(" + cond.clause + ")"
+		args = append(args, cond.args...)
+	}
+	return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+// tableFor returns the table queried and the schema of the model or destination
+// Table takes precedence, then Model, then the value passed to the call
+func (q *sqlQuerySet) tableFor(value interface{}) (string, *sqlSchema, error) {
+	model := q.model
+	if model == nil {
+		model = value
+	}
+
+	var schema *sqlSchema
+	if model != nil {
+		s, err := schemaOf(model)
+		if err != nil {
+			return "", nil, err
+		}
+		schema = s
+	}
+
+	switch {
+	case q.table != "":
+		return q.table, schema, nil
+	case schema != nil:
+		return schema.table, schema, nil
+	}
+	return "", nil, errors.New("orm: no table; call Model or Table first")
+}
+
+// isPostgres reports whether the connection uses $n placeholders
+func (q *sqlQuerySet) isPostgres() bool {
+	return q.driver == "postgres" || q.driver == "postgresql"
+}
+
+// rebind rewrites ? placeholders to $1, $2, ... for PostgreSQL
+// Question marks inside quoted strings are left alone
+func (q *sqlQuerySet) rebind(query string) string {
+	if !q.isPostgres() {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	var quote rune
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// setInt stores a generated id in an integer field
+func setInt(v reflect.Value, id int64) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(id))
+	default:
+		return fmt.Errorf("orm: can't store generated id in %s", v.Type())
+	}
+	return nil
+}
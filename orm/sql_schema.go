@@ -0,0 +1,199 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// sqlSchema maps a model struct to its table and columns
+// It follows GORM's defaults, so both QuerySets read the same tables:
+// snake_case plural table names, snake_case columns, and ID as the primary key
+type sqlSchema struct {
+	table   string
+	fields  []sqlField
+	columns map[string]*sqlField // by column and by Go field name
+	primary *sqlField
+	pkName  string // field tagged primaryKey
+}
+
+// sqlField is a mapped struct field
+type sqlField struct {
+	name   string
+	column string
+	index  []int
+	typ    reflect.Type
+}
+
+// Tabler is implemented by models that choose their table name, like in GORM
+type Tabler interface {
+	TableName() string
+}
+
+// schemaCache holds parsed schemas by struct type
+var schemaCache sync.Map
+
+// schemaOf returns the schema of a model, given as a struct, a pointer to one,
+// or a pointer to a slice of them
+func schemaOf(model interface{}) (*sqlSchema, error) {
+	t := reflect.TypeOf(model)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("orm: %T is not a model struct", model)
+	}
+
+	if cached, ok := schemaCache.Load(t); ok {
+		return cached.(*sqlSchema), nil
+	}
+
+	s := &sqlSchema{table: tableName(t), columns: make(map[string]*sqlField)}
+	s.addFields(t, nil)
+	for i := range s.fields {
+		f := &s.fields[i]
+		s.columns[f.column] = f
+		s.columns[f.name] = f
+	}
+	if s.pkName == "" {
+		s.pkName = "ID"
+	}
+	s.primary = s.columns[s.pkName]
+
+	schemaCache.Store(t, s)
+	return s, nil
+}
+
+// addFields maps the exported fields of t, flattening embedded structs such as gorm.Model
+func (s *sqlSchema) addFields(t reflect.Type, index []int) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		idx := append(append([]int(nil), index...), i)
+
+		settings := parseFieldTags(sf)
+		if settings["-"] != "" {
+			continue
+		}
+
+		ft := sf.Type
+		if sf.Anonymous && ft.Kind() == reflect.Struct && !isScalarStruct(ft) {
+			s.addFields(ft, idx)
+			continue
+		}
+		if !isColumnType(ft) {
+			continue // associations are left to GORM
+		}
+
+		column := settings["column"]
+		if column == "" {
+			column = toSnakeCase(sf.Name)
+		}
+		s.fields = append(s.fields, sqlField{name: sf.Name, column: column, index: idx, typ: ft})
+		if settings["primarykey"] != "" {
+			s.pkName = sf.Name
+		}
+	}
+}
+
+// parseFieldTags reads the gorm and db tags of a field into lowercase settings
+func parseFieldTags(sf reflect.StructField) map[string]string {
+	settings := make(map[string]string)
+	for _, part := range strings.Split(sf.Tag.Get("gorm"), ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if key == "" {
+			continue
+		}
+		if value == "" {
+			value = "true"
+		}
+		settings[strings.ToLower(key)] = value
+	}
+	if db := sf.Tag.Get("db"); db == "-" {
+		settings["-"] = "true"
+	} else if db != "" {
+		settings["column"] = db
+	}
+	return settings
+}
+
+// isScalarStruct reports whether a struct type is stored in a single column
+func isScalarStruct(t reflect.Type) bool {
+	if t == reflect.TypeOf(time.Time{}) {
+		return true
+	}
+	// sql.NullString, gorm.DeletedAt and other Scanner types
+	return reflect.PointerTo(t).Implements(scannerType)
+}
+
+// isColumnType reports whether values of t are stored in a column
+func isColumnType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return isScalarStruct(t)
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8 // []byte
+	case reflect.Map, reflect.Interface, reflect.Func, reflect.Chan, reflect.Array:
+		return false
+	}
+	return true
+}
+
+// tableName returns the table of a model type: TableName() or the snake_case plural
+func tableName(t reflect.Type) string {
+	if tabler, ok := reflect.New(t).Interface().(Tabler); ok {
+		return tabler.TableName()
+	}
+	return pluralize(toSnakeCase(t.Name()))
+}
+
+// toSnakeCase converts a Go name to snake_case, keeping initialisms together: UserID → user_id
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// pluralize returns the English plural of a snake_case word for table names
+func pluralize(word string) string {
+	switch {
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		return word[:len(word)-1] + "ies"
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		return word + "es"
+	}
+	return word + "s"
+}
+
+// field returns the field of a Go field or column name
+func (s *sqlSchema) field(name string) (*sqlField, error) {
+	if f, ok := s.columns[name]; ok {
+		return f, nil
+	}
+	return nil, fmt.Errorf("orm: %s has no field %q", s.table, name)
+}
+
+// value returns the value of a field in a struct value
+func (f *sqlField) value(v reflect.Value) reflect.Value {
+	return v.FieldByIndex(f.index)
+}
@@ -0,0 +1,112 @@
+package orm
+
+import (
+	"sync"
+	"time"
+)
+
+// watchHub wakes the subscribers of a key, e.g. an event stream or a workflow run,
+// when it changes on this node and, by polling the keys' revisions, on other nodes
+type watchHub struct {
+	interval *time.Duration                              // poll interval, read from the owner so it can be tuned
+	heads    func(keys []string) (map[string]int, error) // current revision of each key
+	onError  func(error)
+
+	mu     sync.Mutex
+	subs   map[string]map[*subscription]struct{}
+	stopCh chan struct{} // stops the poller; nil while it isn't running
+}
+
+// subscription is a watcher waiting for a key to change
+type subscription struct {
+	wake chan struct{}
+}
+
+// newWatchHub creates a hub polling heads every *interval while it has subscribers
+func newWatchHub(interval *time.Duration, heads func([]string) (map[string]int, error), onError func(error)) *watchHub {
+	return &watchHub{
+		interval: interval,
+		heads:    heads,
+		onError:  onError,
+		subs:     make(map[string]map[*subscription]struct{}),
+	}
+}
+
+// subscribe registers a watcher of key and starts the poller for the first one
+func (w *watchHub) subscribe(key string) *subscription {
+	sub := &subscription{wake: make(chan struct{}, 1)}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.subs[key] == nil {
+		w.subs[key] = make(map[*subscription]struct{})
+	}
+	w.subs[key][sub] = struct{}{}
+	if w.stopCh == nil {
+		w.stopCh = make(chan struct{})
+		go w.poll(w.stopCh)
+	}
+	return sub
+}
+
+// unsubscribe removes a watcher and stops the poller after the last one
+func (w *watchHub) unsubscribe(key string, sub *subscription) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.subs[key], sub)
+	if len(w.subs[key]) == 0 {
+		delete(w.subs, key)
+	}
+	if len(w.subs) == 0 && w.stopCh != nil {
+		close(w.stopCh)
+		w.stopCh = nil
+	}
+}
+
+// notify wakes the watchers of key
+func (w *watchHub) notify(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for sub := range w.subs[key] {
+		select {
+		case sub.wake <- struct{}{}:
+		default: // already due to read
+		}
+	}
+}
+
+// poll wakes the watchers of keys whose revision moved, e.g. because another node changed them
+func (w *watchHub) poll(stop chan struct{}) {
+	ticker := time.NewTicker(*w.interval)
+	defer ticker.Stop()
+
+	seen := make(map[string]int)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		w.mu.Lock()
+		keys := make([]string, 0, len(w.subs))
+		for key := range w.subs {
+			keys = append(keys, key)
+		}
+		w.mu.Unlock()
+		if len(keys) == 0 {
+			continue
+		}
+
+		heads, err := w.heads(keys)
+		if err != nil {
+			w.onError(err)
+			continue
+		}
+		for key, revision := range heads {
+			if revision > seen[key] {
+				seen[key] = revision
+				w.notify(key)
+			}
+		}
+	}
+}
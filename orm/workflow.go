@@ -0,0 +1,470 @@
+package orm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WorkflowStatus is the state of a workflow run
+type WorkflowStatus string
+
+const (
+	WorkflowRunning      WorkflowStatus = "running"      // steps are being run, or a retry is scheduled
+	WorkflowWaiting      WorkflowStatus = "waiting"      // the timer of the next step is pending
+	WorkflowCompleted    WorkflowStatus = "completed"    // every step succeeded
+	WorkflowCompensating WorkflowStatus = "compensating" // a step failed or the run was cancelled; completed steps are being undone
+	WorkflowCompensated  WorkflowStatus = "compensated"  // every completed step was undone
+	WorkflowFailed       WorkflowStatus = "failed"       // a compensation failed; the run needs attention
+)
+
+// WorkflowStep is one step of a workflow
+// Steps run at least once: a node stopping mid-step leaves it to be run again
+// once its lease expires, so Run and Compensate should be idempotent
+type WorkflowStep struct {
+	Name       string
+	Run        func(ctx context.Context, run *WorkflowRun) error
+	Compensate func(ctx context.Context, run *WorkflowRun) error // undoes Run when a later step fails; optional
+	Delay      time.Duration                                     // timer before the step runs; it survives restarts
+	Timeout    time.Duration                                     // bound for Run and Compensate (default the engine's StepTimeout)
+	Retries    int                                               // extra attempts, with backoff, before the step fails
+}
+
+// Workflow is a named sequence of steps, e.g. reserve stock, charge, ship
+type Workflow struct {
+	Name  string
+	Steps []WorkflowStep
+}
+
+// WorkflowRun is the persisted state of a started workflow
+// Steps share data through Decode and Set; it is saved after every step
+type WorkflowRun struct {
+	ID          string          `gorm:"primaryKey;size:64" json:"id"`
+	Workflow    string          `gorm:"size:255;index" json:"workflow"`
+	Status      WorkflowStatus  `gorm:"size:32;index" json:"status"`
+	Step        int             `json:"step"` // index of the step being run or compensated
+	StepName    string          `gorm:"size:255" json:"step_name"`
+	Steps       int             `json:"steps"`    // number of steps of the workflow
+	Attempts    int             `json:"attempts"` // failed attempts of the current step
+	Data        json.RawMessage `gorm:"type:text" json:"data"`
+	Error       string          `gorm:"type:text" json:"error,omitempty"`
+	Cancelled   bool            `json:"cancelled"`
+	Revision    int             `json:"revision"` // bumped by every change
+	WakeAt      time.Time       `gorm:"index" json:"wake_at"`
+	LockedUntil time.Time       `json:"-"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// TableName keeps runs in workflow_runs
+func (WorkflowRun) TableName() string {
+	return "workflow_runs"
+}
+
+// Decode unmarshals the run's data into v
+func (r *WorkflowRun) Decode(v interface{}) error {
+	return json.Unmarshal(r.Data, v)
+}
+
+// Set replaces the run's data with v
+func (r *WorkflowRun) Set(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	r.Data = data
+	return nil
+}
+
+// Done reports whether the run has finished, successfully or not
+func (r WorkflowRun) Done() bool {
+	return r.Status == WorkflowCompleted || r.Status == WorkflowCompensated || r.Status == WorkflowFailed
+}
+
+// activeStatuses are the statuses of runs the engine still advances
+var activeStatuses = []WorkflowStatus{WorkflowRunning, WorkflowWaiting, WorkflowCompensating}
+
+// WorkflowEngine runs workflows and persists their progress, so timers, retries
+// and compensation resume after a restart and on any node
+// If a step fails for good, the completed steps are compensated in reverse order
+type WorkflowEngine struct {
+	Interval    time.Duration                    // how often due runs are claimed and watched runs polled (default 1s)
+	Lease       time.Duration                    // how long a node owns a run it advances before another may take over (default 5m)
+	StepTimeout time.Duration                    // default bound for a step (default 30s)
+	MinBackoff  time.Duration                    // delay before the first retry, doubled for each one (default 1s)
+	MaxBackoff  time.Duration                    // upper bound for the retry delay (default 5m)
+	OnError     func(run WorkflowRun, err error) // called for every failed attempt, with a zero run when runs can't be read
+
+	db        *gorm.DB
+	mu        sync.RWMutex
+	workflows map[string]*Workflow
+	watches   *watchHub
+}
+
+// NewWorkflowEngine creates an engine storing runs on db
+// The workflow_runs table must exist, e.g. via AutoMigrate(&WorkflowRun{})
+func NewWorkflowEngine(db *gorm.DB) *WorkflowEngine {
+	e := &WorkflowEngine{
+		Interval:    time.Second,
+		Lease:       5 * time.Minute,
+		StepTimeout: 30 * time.Second,
+		MinBackoff:  time.Second,
+		MaxBackoff:  5 * time.Minute,
+		db:          db,
+		workflows:   make(map[string]*Workflow),
+	}
+	e.watches = newWatchHub(&e.Interval, e.heads, func(err error) {
+		e.reportError(WorkflowRun{}, err)
+	})
+	return e
+}
+
+// Register adds a workflow; every node advancing runs must register the same steps
+func (e *WorkflowEngine) Register(workflow Workflow) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.workflows[workflow.Name] = &workflow
+}
+
+// workflow finds a registered workflow
+func (e *WorkflowEngine) workflow(name string) *Workflow {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.workflows[name]
+}
+
+// Start creates a run of a workflow with initial data and begins advancing it
+func (e *WorkflowEngine) Start(ctx context.Context, workflow string, data interface{}) (*WorkflowRun, error) {
+	wf := e.workflow(workflow)
+	if wf == nil {
+		return nil, fmt.Errorf("workflow %q not registered", workflow)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	run := &WorkflowRun{
+		ID:       hex.EncodeToString(id),
+		Workflow: workflow,
+		Status:   WorkflowRunning,
+		Steps:    len(wf.Steps),
+		Revision: 1,
+		WakeAt:   time.Now(),
+	}
+	run.setStep(wf, 0)
+	if err := run.Set(data); err != nil {
+		return nil, fmt.Errorf("workflow %s data: %w", workflow, err)
+	}
+	if err := e.db.WithContext(ctx).Create(run).Error; err != nil {
+		return nil, err
+	}
+
+	go e.resume(context.Background(), run.ID)
+	return run, nil
+}
+
+// Get loads a run
+func (e *WorkflowEngine) Get(ctx context.Context, id string) (*WorkflowRun, error) {
+	var run WorkflowRun
+	if err := e.db.WithContext(ctx).First(&run, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// Cancel stops a run before its next step and compensates the steps it completed
+func (e *WorkflowEngine) Cancel(ctx context.Context, id string) error {
+	result := e.db.WithContext(ctx).Model(&WorkflowRun{}).
+		Where("id = ? AND status IN ?", id, []WorkflowStatus{WorkflowRunning, WorkflowWaiting}).
+		Updates(map[string]interface{}{
+			"cancelled": true,
+			"wake_at":   time.Now(),
+			"revision":  gorm.Expr("revision + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("workflow run %s is not running", id)
+	}
+	e.watches.notify(id)
+	go e.resume(context.Background(), id)
+	return nil
+}
+
+// Watch calls fn with the run every time it changes until it is done or ctx is cancelled
+// Changes made on this node are delivered right away, those of other nodes within Interval
+func (e *WorkflowEngine) Watch(ctx context.Context, id string, fn func(WorkflowRun)) error {
+	sub := e.watches.subscribe(id)
+	defer e.watches.unsubscribe(id, sub)
+
+	revision := 0
+	for {
+		run, err := e.Get(ctx, id)
+		if err == nil && run.Revision > revision {
+			revision = run.Revision
+			fn(*run)
+			if run.Done() {
+				return nil
+			}
+		}
+		if err != nil && ctx.Err() == nil {
+			e.reportError(WorkflowRun{ID: id}, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-sub.wake:
+		}
+	}
+}
+
+// Run advances due runs, e.g. those whose timer fired or whose node went away,
+// until ctx is cancelled
+func (e *WorkflowEngine) Run(ctx context.Context) error {
+	for {
+		if err := e.RunDue(ctx); err != nil {
+			e.reportError(WorkflowRun{}, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(e.Interval):
+		}
+	}
+}
+
+// RunDue advances every run that is due and not leased by another node
+func (e *WorkflowEngine) RunDue(ctx context.Context) error {
+	var ids []string
+	now := time.Now()
+	err := e.db.WithContext(ctx).Model(&WorkflowRun{}).
+		Where("status IN ? AND wake_at <= ? AND locked_until < ?", activeStatuses, now, now).
+		Order("wake_at").Limit(100).Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			e.resume(ctx, id)
+		}(id)
+	}
+	wg.Wait()
+	return nil
+}
+
+// resume leases a run and advances it as far as it can go now
+func (e *WorkflowEngine) resume(ctx context.Context, id string) {
+	now := time.Now()
+	result := e.db.WithContext(ctx).Model(&WorkflowRun{}).
+		Where("id = ? AND status IN ? AND locked_until < ?", id, activeStatuses, now).
+		Update("locked_until", now.Add(e.Lease))
+	if result.Error != nil {
+		e.reportError(WorkflowRun{ID: id}, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return // finished, or advanced by another node
+	}
+
+	run, err := e.Get(ctx, id)
+	if err != nil {
+		e.reportError(WorkflowRun{ID: id}, err)
+		return
+	}
+	wf := e.workflow(run.Workflow)
+	if wf == nil {
+		e.reportError(*run, fmt.Errorf("workflow %q not registered", run.Workflow))
+		return
+	}
+
+	e.advance(ctx, wf, run)
+	run.LockedUntil = time.Time{}
+	e.db.Model(run).Select("locked_until").Updates(run)
+}
+
+// advance runs or compensates steps until the run is done or has to wait
+func (e *WorkflowEngine) advance(ctx context.Context, wf *Workflow, run *WorkflowRun) {
+	for !run.Done() {
+		if run.Status == WorkflowCompensating {
+			if !e.compensate(ctx, wf, run) {
+				return
+			}
+			continue
+		}
+
+		var cancelled bool
+		if err := e.db.WithContext(ctx).Model(&WorkflowRun{}).Where("id = ?", run.ID).
+			Select("cancelled").Scan(&cancelled).Error; err != nil {
+			e.reportError(*run, err)
+			return
+		}
+		if cancelled {
+			run.Status = WorkflowCompensating
+			run.Error = "cancelled"
+			run.Attempts = 0
+			run.setStep(wf, run.Step-1)
+			if !e.save(ctx, run) {
+				return
+			}
+			continue
+		}
+
+		if run.Step >= len(wf.Steps) {
+			run.Status = WorkflowCompleted
+			e.save(ctx, run)
+			return
+		}
+
+		step := wf.Steps[run.Step]
+		if step.Delay > 0 && run.Status == WorkflowRunning && run.Attempts == 0 {
+			run.Status = WorkflowWaiting
+			run.WakeAt = time.Now().Add(step.Delay)
+			e.save(ctx, run)
+			return
+		}
+
+		if err := e.call(ctx, step, step.Run, run); err != nil {
+			run.Attempts++
+			e.reportError(*run, err)
+			if run.Attempts <= step.Retries {
+				run.Status = WorkflowRunning
+				run.WakeAt = time.Now().Add(e.backoff(run.Attempts))
+				e.save(ctx, run)
+				return
+			}
+			run.Status = WorkflowCompensating
+			run.Error = fmt.Sprintf("%s: %v", step.Name, err)
+			run.Attempts = 0
+			run.setStep(wf, run.Step-1)
+		} else {
+			run.Status = WorkflowRunning
+			run.Attempts = 0
+			run.setStep(wf, run.Step+1)
+		}
+		if !e.save(ctx, run) {
+			return
+		}
+	}
+}
+
+// compensate undoes the current step and moves to the previous one
+// It reports false when the run has to wait for a retry or can't go on
+func (e *WorkflowEngine) compensate(ctx context.Context, wf *Workflow, run *WorkflowRun) bool {
+	if run.Step < 0 {
+		run.Status = WorkflowCompensated
+		return e.save(ctx, run)
+	}
+
+	step := wf.Steps[run.Step]
+	if step.Compensate != nil {
+		if err := e.call(ctx, step, step.Compensate, run); err != nil {
+			run.Attempts++
+			e.reportError(*run, err)
+			if run.Attempts <= step.Retries {
+				run.WakeAt = time.Now().Add(e.backoff(run.Attempts))
+			} else {
+				run.Status = WorkflowFailed
+				run.Error = fmt.Sprintf("%s; compensating %s: %v", run.Error, step.Name, err)
+			}
+			e.save(ctx, run)
+			return false
+		}
+	}
+
+	run.Attempts = 0
+	run.setStep(wf, run.Step-1)
+	return e.save(ctx, run)
+}
+
+// call runs a step function within the step's timeout, turning a panic into an error
+func (e *WorkflowEngine) call(ctx context.Context, step WorkflowStep, fn func(context.Context, *WorkflowRun) error, run *WorkflowRun) (err error) {
+	timeout := step.Timeout
+	if timeout <= 0 {
+		timeout = e.StepTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx, run)
+}
+
+// save persists the run's progress, renews its lease and tells watchers
+func (e *WorkflowEngine) save(ctx context.Context, run *WorkflowRun) bool {
+	run.Revision++
+	run.LockedUntil = time.Now().Add(e.Lease)
+	// The revision is bumped in place since Cancel may have bumped it meanwhile
+	err := e.db.WithContext(ctx).Model(run).Updates(map[string]interface{}{
+		"status":       run.Status,
+		"step":         run.Step,
+		"step_name":    run.StepName,
+		"attempts":     run.Attempts,
+		"data":         run.Data,
+		"error":        run.Error,
+		"wake_at":      run.WakeAt,
+		"locked_until": run.LockedUntil,
+		"revision":     gorm.Expr("revision + 1"),
+	}).Error
+	if err != nil {
+		e.reportError(*run, err)
+		return false
+	}
+	e.watches.notify(run.ID)
+	return true
+}
+
+// setStep moves the run to a step
+func (r *WorkflowRun) setStep(wf *Workflow, step int) {
+	r.Step = step
+	r.StepName = ""
+	if step >= 0 && step < len(wf.Steps) {
+		r.StepName = wf.Steps[step].Name
+	}
+}
+
+// backoff returns the delay before the next attempt of a step that failed attempts times
+func (e *WorkflowEngine) backoff(attempts int) time.Duration {
+	delay := e.MinBackoff << (attempts - 1)
+	if delay <= 0 || delay > e.MaxBackoff {
+		delay = e.MaxBackoff
+	}
+	return delay
+}
+
+// heads returns the current revision of each run
+func (e *WorkflowEngine) heads(ids []string) (map[string]int, error) {
+	var rows []struct {
+		ID       string
+		Revision int
+	}
+	err := e.db.Model(&WorkflowRun{}).Select("id, revision").Where("id IN ?", ids).Scan(&rows).Error
+	heads := make(map[string]int, len(rows))
+	for _, row := range rows {
+		heads[row.ID] = row.Revision
+	}
+	return heads, err
+}
+
+// reportError hands an error to OnError
+func (e *WorkflowEngine) reportError(run WorkflowRun, err error) {
+	if e.OnError != nil {
+		e.OnError(run, err)
+	}
+}
@@ -0,0 +1,191 @@
+package protocol
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Diff is the change between two renders of a component
+// Keys are indexes of child nodes, text included, starting with "0" for the component's
+// root element. A change holds "s", the HTML or text replacing the node, or "children",
+// a Diff of its child nodes:
+//
+//	{"0": {"children": {"1": {"s": ["<span>New</span>"]}}}}
+type Diff map[string]interface{}
+
+// Tree is a rendered component that diffs are applied to
+type Tree struct {
+	root *html.Node
+}
+
+// ParseTree parses the HTML of a component render, e.g. Render.HTML after a join
+func ParseTree(source string) (*Tree, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(source), nil)
+	if err != nil {
+		return nil, err
+	}
+	// The server diffs the first non-blank node of the body, like this
+	for _, node := range nodes {
+		if body := findBody(node); body != nil {
+			for child := body.FirstChild; child != nil; child = child.NextSibling {
+				if child.Type == html.TextNode && strings.TrimSpace(child.Data) == "" {
+					continue
+				}
+				body.RemoveChild(child)
+				return &Tree{root: child}, nil
+			}
+		}
+	}
+	return &Tree{}, nil
+}
+
+// HTML renders the tree
+func (t *Tree) HTML() string {
+	if t.root == nil {
+		return ""
+	}
+	var sb strings.Builder
+	html.Render(&sb, t.root)
+	return sb.String()
+}
+
+// Apply applies a diff to the tree
+func (t *Tree) Apply(diff Diff) error {
+	if len(diff) == 0 {
+		return nil
+	}
+	if t.root == nil {
+		return fmt.Errorf("protocol: diff applied to an empty render")
+	}
+	change, ok := diff["0"]
+	if !ok {
+		return fmt.Errorf("protocol: diff has no root change")
+	}
+
+	// The root has no parent to be replaced in, so it is parsed on its own
+	if replacement, ok := replacementOf(change); ok {
+		tree, err := ParseTree(replacement)
+		if err != nil {
+			return err
+		}
+		t.root = tree.root
+		return nil
+	}
+	return applyChange(t.root, change)
+}
+
+// ApplyDiff applies a diff to the HTML of the previous render and returns the new HTML
+func ApplyDiff(previous string, diff Diff) (string, error) {
+	tree, err := ParseTree(previous)
+	if err != nil {
+		return "", err
+	}
+	if err := tree.Apply(diff); err != nil {
+		return "", err
+	}
+	return tree.HTML(), nil
+}
+
+// applyChange applies the "children" change of a node
+func applyChange(node *html.Node, change interface{}) error {
+	fields, ok := change.(map[string]interface{})
+	if !ok {
+		if d, isDiff := change.(Diff); isDiff {
+			fields = d
+		} else {
+			return fmt.Errorf("protocol: invalid change %T", change)
+		}
+	}
+	children, ok := fields["children"]
+	if !ok {
+		return nil
+	}
+	childDiff, ok := children.(map[string]interface{})
+	if !ok {
+		if d, isDiff := children.(Diff); isDiff {
+			childDiff = d
+		} else {
+			return fmt.Errorf("protocol: invalid children %T", children)
+		}
+	}
+
+	// Indexes refer to the children before any of them is replaced
+	var nodes []*html.Node
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		nodes = append(nodes, child)
+	}
+	for key, childChange := range childDiff {
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(nodes) {
+			return fmt.Errorf("protocol: diff refers to missing child %q of <%s>", key, node.Data)
+		}
+		child := nodes[index]
+		if replacement, ok := replacementOf(childChange); ok {
+			if err := replaceNode(node, child, replacement); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := applyChange(child, childChange); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replacementOf returns the "s" content of a change
+func replacementOf(change interface{}) (string, bool) {
+	var fields map[string]interface{}
+	switch c := change.(type) {
+	case map[string]interface{}:
+		fields = c
+	case Diff:
+		fields = c
+	default:
+		return "", false
+	}
+	switch s := fields["s"].(type) {
+	case []interface{}:
+		parts := make([]string, len(s))
+		for i, part := range s {
+			parts[i] = fmt.Sprint(part)
+		}
+		return strings.Join(parts, ""), true
+	case []string:
+		return strings.Join(s, ""), true
+	}
+	return "", false
+}
+
+// replaceNode replaces a child with content, which is text for a text node and HTML otherwise
+func replaceNode(parent, child *html.Node, content string) error {
+	if child.Type == html.TextNode {
+		child.Data = content
+		return nil
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(content), parent)
+	if err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		parent.InsertBefore(n, child)
+	}
+	parent.RemoveChild(child)
+	return nil
+}
+
+// findBody returns the body element of a parsed fragment
+func findBody(node *html.Node) *html.Node {
+	if node.Type == html.ElementNode && node.Data == "body" {
+		return node
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if body := findBody(child); body != nil {
+			return body
+		}
+	}
+	return nil
+}
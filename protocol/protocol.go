@@ -0,0 +1,282 @@
+// Package protocol defines the messages LiveNest exchanges over its LiveView WebSocket,
+// so clients other than the browser runtime, such as native apps or other backends,
+// can mount components and send them events. The client package implements it in Go.
+//
+// A client opens a WebSocket on Path, with VersionParam set to Version and the page
+// query in "params", then mounts components on topics it chooses:
+//
+//	-> {"topic":"c1","event":"lv:join","payload":{"component":"counter"}}
+//	<- {"topic":"c1","type":"render","data":{"html":"<div>0</div>"}}
+//	-> {"topic":"c1","event":"increment","payload":{},"ref":"1"}
+//	<- {"topic":"c1","type":"render","data":{"diff":{"0":{"children":{"0":{"s":["1"]}}}},"ref":"1"}}
+//
+// Every client message is a ClientMessage whose Type says how the server handles it:
+// events go to the component of their topic, joins and leaves mount and unmount
+// components, and heartbeats keep the connection checked. A message may name the
+// protocol Version it was written for in "vsn"; others are rejected with ReasonProtocol.
+// Joins, leaves and heartbeats that carry a ref are answered by a TypeReply:
+//
+//	-> {"type":"heartbeat","ref":"7"}
+//	<- {"type":"reply","data":{"ref":"7","status":"ok"}}
+//
+// Every server message is a ServerMessage. Renders carry the full HTML after a join and
+// a Diff against the previous render afterwards; ApplyDiff applies one. Components may
+// opt out of diffs, in which case renders carry the full HTML, or use a Split instead.
+// Events are acknowledged by a render echoing their ref, sent even when nothing changed.
+// Failures arrive as an error message with an Error before that acknowledgement.
+// Components can also render on their own, e.g. from timers or broadcasts, without a ref.
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is the protocol version spoken by this package
+// Servers refuse connections asking for a version they don't speak
+const Version = "1"
+
+// VersionParam is the query parameter a client names its protocol version in
+const VersionParam = "vsn"
+
+// Path is the WebSocket endpoint shared by every component of a client
+const Path = "/live/ws"
+
+// Events with a meaning to the server; any other event goes to the component's handlers
+const (
+	EventJoin      = "lv:join"      // mount a component on the message topic; payload is a JoinPayload
+	EventLeave     = "lv:leave"     // unmount the component of the message topic
+	EventHeartbeat = "lv:heartbeat" // check the connection; needs no topic
+)
+
+// Client message types
+// Clients that predate types send events only, and joins and leaves as EventJoin and
+// EventLeave, which is what a message without a type means
+const (
+	TypeEvent     = "event"     // an event for the component of the topic
+	TypeJoin      = "join"      // payload is a JoinPayload
+	TypeLeave     = "leave"     // unmount the component of the topic
+	TypeHeartbeat = "heartbeat" // answered by a TypeReply
+)
+
+// Server message types
+const (
+	TypeRender = "render" // data is a Render
+	TypeError  = "error"  // data is an Error
+	TypeReply  = "reply"  // data is a Reply
+)
+
+// Reply statuses
+const (
+	StatusOK    = "ok"
+	StatusError = "error" // the join failed; an error message with the reason came first
+)
+
+// Reasons of an Error
+const (
+	ReasonHandlerError = "handler_error"  // an event handler returned an error
+	ReasonPanic        = "panic"          // an event handler or render panicked
+	ReasonTimeout      = "timeout"        // an event handler ran too long; join the topic again
+	ReasonUnavailable  = "unavailable"    // a dependency is down; retry after RetryAfterMS
+	ReasonJoinFailed   = "join_failed"    // the component couldn't be mounted
+	ReasonUnauthorized = "unauthorized"   // the component refused the client; don't retry
+	ReasonDisconnected = "disconnected"   // the server unmounted the component; don't rejoin
+	ReasonProtocol     = "protocol_error" // the message was malformed or over the limits
+	ReasonUnknownEvent = "unknown_event"  // the component has no handler for the event
+)
+
+// ClientMessage is a message from the client
+type ClientMessage struct {
+	Type    string      `json:"type,omitempty"` // TypeEvent when empty, unless Event names a join or leave
+	Topic   string      `json:"topic,omitempty"`
+	Event   string      `json:"event"`
+	Payload interface{} `json:"payload"`
+	Ref     string      `json:"ref,omitempty"` // echoed by the render or reply that acknowledges the message
+	Version string      `json:"vsn,omitempty"` // protocol version the message was written for
+}
+
+// Kind returns the type of the message, derived from its event when it has none
+func (m ClientMessage) Kind() string {
+	return MessageKind(m.Type, m.Event)
+}
+
+// MessageKind returns the type of a message with the given type and event fields
+func MessageKind(messageType, event string) string {
+	if messageType != "" {
+		return messageType
+	}
+	switch event {
+	case EventJoin:
+		return TypeJoin
+	case EventLeave:
+		return TypeLeave
+	case EventHeartbeat:
+		return TypeHeartbeat
+	}
+	return TypeEvent
+}
+
+// JoinPayload is the payload of an EventJoin message
+type JoinPayload struct {
+	Component string            `json:"component"`
+	SocketID  string            `json:"socket_id,omitempty"`
+	Nonce     string            `json:"nonce,omitempty"`
+	Cursors   map[string]string `json:"cursors,omitempty"` // last cursor received by each stream and subscription, to resume after a reconnect
+}
+
+// ServerMessage is a message from the server
+type ServerMessage struct {
+	Topic string          `json:"topic,omitempty"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Render decodes the data of a TypeRender message
+func (m ServerMessage) Render() (*Render, error) {
+	if m.Type != TypeRender {
+		return nil, fmt.Errorf("protocol: %s message is not a render", m.Type)
+	}
+	var render Render
+	if err := json.Unmarshal(m.Data, &render); err != nil {
+		return nil, err
+	}
+	return &render, nil
+}
+
+// Error decodes the data of a TypeError message
+func (m ServerMessage) Error() (*Error, error) {
+	if m.Type != TypeError {
+		return nil, fmt.Errorf("protocol: %s message is not an error", m.Type)
+	}
+	var e Error
+	if err := json.Unmarshal(m.Data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Reply decodes the data of a TypeReply message
+func (m ServerMessage) Reply() (*Reply, error) {
+	if m.Type != TypeReply {
+		return nil, fmt.Errorf("protocol: %s message is not a reply", m.Type)
+	}
+	var reply Reply
+	if err := json.Unmarshal(m.Data, &reply); err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// Reply acknowledges a join, leave or heartbeat
+type Reply struct {
+	Ref    string `json:"ref"`
+	Status string `json:"status"` // StatusOK or StatusError
+}
+
+// Render is the data of a render message
+type Render struct {
+	HTML     string            `json:"html,omitempty"`  // full component HTML, sent after a join
+	Diff     Diff              `json:"diff,omitempty"`  // changes since the previous render
+	Split    *Split            `json:"split,omitempty"` // the render as statics and dynamics, for components using the static split
+	Ref      string            `json:"ref,omitempty"`   // ref of the event this render acknowledges
+	Title    *string           `json:"title,omitempty"`
+	Redirect *Redirect         `json:"redirect,omitempty"`
+	Flashes  []Flash           `json:"flashes,omitempty"`
+	Toasts   []Toast           `json:"toasts,omitempty"`
+	Streams  []StreamOp        `json:"streams,omitempty"`
+	Cursors  map[string]string `json:"cursors,omitempty"` // positions of the component's subscriptions, to report in JoinPayload.Cursors
+	Print    bool              `json:"print,omitempty"`   // the browser should print the page's printable variant
+	Debug    json.RawMessage   `json:"debug,omitempty"`   // timings and queries, in debug mode only
+}
+
+// Redirect asks the client to navigate
+type Redirect struct {
+	To       string `json:"to"`
+	External bool   `json:"external"`
+}
+
+// Flash is a flash message
+type Flash struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Key     string `json:"key,omitempty"`
+	TTL     int64  `json:"ttl"` // milliseconds
+	HTML    string `json:"html,omitempty"`
+}
+
+// Toast is a toast notification
+type Toast struct {
+	ID       string        `json:"id"`
+	Level    string        `json:"level"`
+	Message  string        `json:"message"`
+	Duration int64         `json:"duration"` // milliseconds, 0 until dismissed
+	Actions  []ToastAction `json:"actions,omitempty"`
+}
+
+// ToastAction is a button on a toast that sends an event
+type ToastAction struct {
+	Label   string                 `json:"label"`
+	Event   string                 `json:"event"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+}
+
+// Split is a render split into its markup, the statics, and the text between, the
+// dynamics, for components using the static split. Statics are sent when they changed,
+// with every dynamic; otherwise Dynamics holds only the changed ones, by index
+type Split struct {
+	Statics  []string          `json:"s,omitempty"`
+	Dynamics map[string]string `json:"d"`
+}
+
+// Apply updates the statics and dynamics of the previous split render with s and returns
+// the HTML they make up
+func (s *Split) Apply(statics, dynamics []string) ([]string, []string, string) {
+	if s.Statics != nil {
+		statics = s.Statics
+		dynamics = make([]string, len(statics)-1)
+	}
+	for key, value := range s.Dynamics {
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(dynamics) {
+			dynamics[i] = value
+		}
+	}
+
+	var b strings.Builder
+	for i, static := range statics {
+		b.WriteString(static)
+		if i < len(dynamics) {
+			b.WriteString(dynamics[i])
+		}
+	}
+	return statics, dynamics, b.String()
+}
+
+// StreamOp changes a stream container: Reset empties it, then HTML is appended
+// Cursor marks the position reached, to report in JoinPayload.Cursors
+type StreamOp struct {
+	Target string `json:"target"`
+	Reset  bool   `json:"reset,omitempty"`
+	HTML   string `json:"html,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// Error is the data of an error message
+type Error struct {
+	Event        string   `json:"event"`
+	Reason       string   `json:"reason"`
+	Message      string   `json:"message"`
+	RetryAfterMS int64    `json:"retry_after_ms,omitempty"`
+	Debug        bool     `json:"debug,omitempty"` // Message is the error itself, in debug mode only
+	Stack        string   `json:"stack,omitempty"`
+	Causes       []string `json:"causes,omitempty"`
+}
+
+// Error describes the failure
+func (e *Error) Error() string {
+	if e.Event == "" {
+		return e.Reason + ": " + e.Message
+	}
+	return e.Event + ": " + e.Reason + ": " + e.Message
+}
@@ -0,0 +1,268 @@
+package pubsub
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultCapacity is the number of messages a bus retains when none is given
+const DefaultCapacity = 1000
+
+// Bus is an in-process PubSub retaining the last messages published for replay
+// A bus opened with OpenBus also appends them to a file, so they survive a restart
+// and subscribers can resume from a sequence number they saw before it
+type Bus struct {
+	capacity int
+
+	mu     sync.Mutex
+	seq    uint64
+	ring   []Message // retained messages, oldest first; trimmed to capacity in batches
+	subs   map[string]map[chan struct{}]struct{}
+	file   *os.File
+	path   string
+	lines  int // messages in the file, compacted once twice the capacity
+	closed bool
+}
+
+// NewBus creates a bus retaining the last capacity messages in memory
+// A capacity of 0 or less keeps DefaultCapacity
+func NewBus(capacity int) *Bus {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Bus{capacity: capacity, subs: make(map[string]map[chan struct{}]struct{})}
+}
+
+// OpenBus creates a bus like NewBus that keeps its messages in the file at path
+// Messages in the file from a previous run are retained for replay, and numbering
+// continues after them. A message cut short by a crash is dropped
+func OpenBus(path string, capacity int) (*Bus, error) {
+	b := NewBus(capacity)
+	b.path = path
+
+	f, err := os.Open(path)
+	switch {
+	case err == nil:
+		err = b.load(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("pubsub: read %s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("pubsub: %w", err)
+	}
+
+	if err := b.compact(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Publish encodes payload as JSON and sends it to the subscribers of topic
+// On a bus with a file, the message is written before it is delivered
+func (b *Bus) Publish(ctx context.Context, topic string, payload interface{}) (Message, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Message{}, fmt.Errorf("pubsub payload for %s: %w", topic, err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return Message{}, ErrClosed
+	}
+	msg := Message{Seq: b.seq + 1, Topic: topic, Payload: data, Time: time.Now()}
+	if b.file != nil {
+		if err := b.write(msg); err != nil {
+			return Message{}, err
+		}
+	}
+	b.seq = msg.Seq
+	b.retain(msg)
+	if b.file != nil && b.lines >= 2*b.capacity {
+		// The message is in the file already; if compaction fails, the file keeps
+		// growing until the next attempt succeeds
+		b.compact()
+	}
+
+	for wake := range b.subs[topic] {
+		select {
+		case wake <- struct{}{}:
+		default: // already due to read
+		}
+	}
+	return msg, nil
+}
+
+// Subscribe calls fn with every message of topic published after the sequence number
+// after, in order, until ctx is cancelled; it returns ctx's error
+// A subscriber falling more than the capacity behind skips the messages evicted meanwhile
+func (b *Bus) Subscribe(ctx context.Context, topic string, after uint64, fn func(Message)) error {
+	wake := make(chan struct{}, 1)
+	b.mu.Lock()
+	if b.subs[topic] == nil {
+		b.subs[topic] = make(map[chan struct{}]struct{})
+	}
+	b.subs[topic][wake] = struct{}{}
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.subs[topic], wake)
+		if len(b.subs[topic]) == 0 {
+			delete(b.subs, topic)
+		}
+		b.mu.Unlock()
+	}()
+
+	for {
+		for _, msg := range b.Since(topic, after) {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fn(msg)
+			after = msg.Seq
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		}
+	}
+}
+
+// Since returns the retained messages of topic published after the sequence number after
+func (b *Bus) Since(topic string, after uint64) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var messages []Message
+	for _, msg := range b.retained() {
+		if msg.Seq > after && msg.Topic == topic {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// Seq returns the sequence number of the last message published, 0 before the first
+func (b *Bus) Seq() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.seq
+}
+
+// Close stops publishing and closes the file of the bus
+// Subscribers keep running until their contexts are cancelled
+func (b *Bus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	if b.file != nil {
+		return b.file.Close()
+	}
+	return nil
+}
+
+// retained returns the last capacity messages; b.mu is held
+func (b *Bus) retained() []Message {
+	if len(b.ring) > b.capacity {
+		return b.ring[len(b.ring)-b.capacity:]
+	}
+	return b.ring
+}
+
+// retain adds a message, dropping the oldest ones once twice the capacity are held; b.mu is held
+func (b *Bus) retain(msg Message) {
+	b.ring = append(b.ring, msg)
+	if len(b.ring) >= 2*b.capacity {
+		b.ring = append([]Message(nil), b.retained()...)
+	}
+}
+
+// write appends a message to the file; b.mu is held
+func (b *Bus) write(msg Message) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := b.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("pubsub: write %s: %w", b.path, err)
+	}
+	b.lines++
+	return nil
+}
+
+// load reads the messages of a previous run
+func (b *Bus) load(r io.Reader) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// A last line without its newline was cut short
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var msg Message
+		if json.Unmarshal(line, &msg) != nil || msg.Seq <= b.seq {
+			continue
+		}
+		b.seq = msg.Seq
+		b.retain(msg)
+	}
+}
+
+// compact rewrites the file with the retained messages only and reopens it for appending
+// The new file replaces the old one atomically, so a crash leaves one or the other
+func (b *Bus) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), filepath.Base(b.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("pubsub: %w", err)
+	}
+	w := bufio.NewWriter(tmp)
+	retained := b.retained()
+	for _, msg := range retained {
+		line, err := json.Marshal(msg)
+		if err == nil {
+			w.Write(line)
+			err = w.WriteByte('\n')
+		}
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return fmt.Errorf("pubsub: compact %s: %w", b.path, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("pubsub: compact %s: %w", b.path, err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), b.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("pubsub: compact %s: %w", b.path, err)
+	}
+
+	if b.file != nil {
+		b.file.Close()
+	}
+	b.file, err = os.OpenFile(b.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("pubsub: %w", err)
+	}
+	b.lines = len(retained)
+	return nil
+}
@@ -0,0 +1,46 @@
+// Package pubsub broadcasts messages on named topics, e.g. from a background job to
+// every LiveView showing the data it changed. Messages are numbered in the order they
+// are published, so a subscriber that was away, such as a socket reconnecting, can be
+// replayed what it missed from the last number it saw.
+//
+// Bus is an in-process implementation that keeps recent messages in memory and,
+// when opened on a file, across restarts. Other transports implement PubSub.
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrClosed is returned by Publish on a closed bus
+var ErrClosed = errors.New("pubsub: bus closed")
+
+// Message is a message published on a topic
+type Message struct {
+	Seq     uint64          `json:"seq"` // position in the order of publication, across topics
+	Topic   string          `json:"topic"`
+	Payload json.RawMessage `json:"payload"`
+	Time    time.Time       `json:"time"`
+}
+
+// Decode unmarshals the payload into v
+func (m Message) Decode(v interface{}) error {
+	return json.Unmarshal(m.Payload, v)
+}
+
+// PubSub publishes messages and delivers them to subscribers
+type PubSub interface {
+	// Publish encodes payload as JSON and sends it to the subscribers of topic
+	Publish(ctx context.Context, topic string, payload interface{}) (Message, error)
+
+	// Subscribe calls fn with every message of topic published after the sequence number
+	// after, in order, until ctx is cancelled; it returns ctx's error
+	// Messages still retained are replayed first, so pass the Seq of the last message
+	// seen to resume, or Seq() to receive only new messages
+	Subscribe(ctx context.Context, topic string, after uint64, fn func(Message)) error
+
+	// Seq returns the sequence number of the last message published, 0 before the first
+	Seq() uint64
+}
@@ -0,0 +1,328 @@
+// Package scaffold generates the files of a new LiveNest project, and of the components,
+// GORM models and auto-forms added to it later, so a project doesn't start from a copy of
+// the examples directory. The livenest command wraps it:
+//
+//	livenest new blog
+//	livenest gen component post_list
+//	livenest gen model post title:string body:text published:bool
+//	livenest gen form post title:string body:text
+//	livenest demo demo
+//
+// Generated files are ordinary code to edit; existing files are never overwritten.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// ErrExists is returned when a file to generate already exists
+var ErrExists = errors.New("scaffold: file already exists")
+
+//go:embed templates/*.tmpl templates/demo/*.tmpl
+var templateFS embed.FS
+
+// templates holds the file templates, by file name without ".tmpl"
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"pascal": Pascal,
+	"snake":  Snake,
+	"label":  Label,
+	"lower":  strings.ToLower,
+}).ParseFS(templateFS, "templates/*.tmpl"))
+
+// Field is a field of a generated model or form, e.g. "title:string"
+type Field struct {
+	Name string // as given, e.g. "created_at"
+	Type string // one of FieldTypes
+}
+
+// FieldTypes are the types a field can have
+var FieldTypes = []string{"string", "text", "email", "int", "float", "bool", "time"}
+
+// GoName returns the field's Go name, e.g. CreatedAt
+func (f Field) GoName() string {
+	return Pascal(f.Name)
+}
+
+// GoType returns the Go type of the field in a model
+func (f Field) GoType() string {
+	switch f.Type {
+	case "int":
+		return "int"
+	case "float":
+		return "float64"
+	case "bool":
+		return "bool"
+	case "time":
+		return "time.Time"
+	default:
+		return "string"
+	}
+}
+
+// FormType returns the Go type of the field in a form, which holds dates as text
+func (f Field) FormType() string {
+	if f.Type == "time" {
+		return "string"
+	}
+	return f.GoType()
+}
+
+// FormTag returns the form and validate struct tags of the field in a form
+func (f Field) FormTag() string {
+	form := "label:" + Label(f.Name)
+	validate := "required"
+	switch f.Type {
+	case "text":
+		form += ";type:textarea;rows:4"
+	case "email":
+		form += ";type:email"
+		validate += ";email"
+	case "int", "float":
+		form += ";type:number"
+	case "time":
+		form += ";type:date"
+	case "bool":
+		validate = ""
+	}
+	if validate == "" {
+		return fmt.Sprintf("form:%q", form)
+	}
+	return fmt.Sprintf("form:%q validate:%q", form, validate)
+}
+
+// ParseFields parses field specs of the form name:type; the type defaults to string
+func ParseFields(specs []string) ([]Field, error) {
+	fields := make([]Field, 0, len(specs))
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		name, typ, ok := strings.Cut(spec, ":")
+		if !ok {
+			typ = "string"
+		}
+		if !validName(name) {
+			return nil, fmt.Errorf("scaffold: invalid field name %q", name)
+		}
+		if !slices.Contains(FieldTypes, typ) {
+			return nil, fmt.Errorf("scaffold: field %s: unknown type %q, use one of %s", name, typ, strings.Join(FieldTypes, ", "))
+		}
+		if seen[Pascal(name)] {
+			return nil, fmt.Errorf("scaffold: field %s given twice", name)
+		}
+		seen[Pascal(name)] = true
+		fields = append(fields, Field{Name: name, Type: typ})
+	}
+	return fields, nil
+}
+
+// NewProject creates a project for module in dir: go.mod, main.go, a config file, and a
+// home component with its template. It returns the files it wrote
+func NewProject(dir, module string) ([]string, error) {
+	if module == "" {
+		module = filepath.Base(dir)
+	}
+	data := map[string]interface{}{"Module": module, "Name": "home", "DB": Snake(path.Base(module)) + ".db"}
+	return generate(dir, data, []file{
+		{"go.mod", "go.mod"},
+		{"main.go", "main.go"},
+		{"config.json", "config.json"},
+		{filepath.Join("components", "home.go"), "component.go"},
+		{filepath.Join("templates", "home.html"), "component.html"},
+	})
+}
+
+// Demo creates a complete example app for module in dir that wires the subsystems
+// together: sign-in with sessions and a route group, a CRUD resource with GORM, file
+// uploads, a chat with presence over PubSub, and the LiveDashboard for admins. It
+// doubles as a living integration test of how they compose. It returns the files it wrote
+func Demo(dir, module string) ([]string, error) {
+	if module == "" {
+		module = filepath.Base(dir)
+	}
+	data := map[string]interface{}{"Module": module, "DB": Snake(path.Base(module)) + ".db"}
+	files := []file{
+		{"go.mod", "go.mod"},
+		{"config.json", "config.json"},
+	}
+	for _, name := range []string{
+		"main.go", "auth.go", "notes.go", "uploads.go", "chat.go", "README.md",
+		"templates/login.html", "templates/notes.html", "templates/uploads.html", "templates/chat.html",
+		"static/demo.css",
+	} {
+		files = append(files, file{filepath.FromSlash(name), "demo/" + path.Base(name)})
+	}
+	return generate(dir, data, files)
+}
+
+// Component creates a LiveView component named name, e.g. "post_list", in the
+// components directory of the project in dir, with its template
+func Component(dir, name string) ([]string, error) {
+	if !validName(name) {
+		return nil, fmt.Errorf("scaffold: invalid component name %q", name)
+	}
+	data := map[string]interface{}{"Name": name}
+	return generate(dir, data, []file{
+		{filepath.Join("components", Snake(name)+".go"), "component.go"},
+		{filepath.Join("templates", Snake(name)+".html"), "component.html"},
+	})
+}
+
+// Model creates a GORM model named name with fields in the models directory of the
+// project in dir
+func Model(dir, name string, fields []Field) ([]string, error) {
+	if !validName(name) {
+		return nil, fmt.Errorf("scaffold: invalid model name %q", name)
+	}
+	data := map[string]interface{}{"Name": name, "Fields": fields, "Time": slices.ContainsFunc(fields, func(f Field) bool { return f.Type == "time" })}
+	return generate(dir, data, []file{
+		{filepath.Join("models", Snake(name)+".go"), "model.go"},
+	})
+}
+
+// Form creates an auto-form struct named name with fields, and the constructor of its
+// form component, in the forms directory of the project in dir
+func Form(dir, name string, fields []Field) ([]string, error) {
+	if !validName(name) {
+		return nil, fmt.Errorf("scaffold: invalid form name %q", name)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("scaffold: form %s needs at least one field", name)
+	}
+	data := map[string]interface{}{"Name": name, "Fields": fields}
+	return generate(dir, data, []file{
+		{filepath.Join("forms", Snake(name)+"_form.go"), "form.go"},
+	})
+}
+
+// file is a file to generate and its template
+type file struct {
+	path     string
+	template string
+}
+
+// generate renders files into dir, refusing to overwrite any existing file
+// Templates under demo/ are copied as they are, since the demo's own templates use the
+// same delimiters; Go files are formatted
+func generate(dir string, data map[string]interface{}, files []file) ([]string, error) {
+	contents := make([][]byte, len(files))
+	for i, f := range files {
+		path := filepath.Join(dir, f.path)
+		if _, err := os.Stat(path); err == nil {
+			return nil, fmt.Errorf("%w: %s", ErrExists, path)
+		}
+		if strings.HasPrefix(f.template, "demo/") {
+			content, err := templateFS.ReadFile("templates/" + f.template + ".tmpl")
+			if err != nil {
+				return nil, err
+			}
+			contents[i] = content
+		} else {
+			var buf bytes.Buffer
+			if err := templates.ExecuteTemplate(&buf, f.template+".tmpl", data); err != nil {
+				return nil, err
+			}
+			contents[i] = buf.Bytes()
+		}
+		if strings.HasSuffix(f.path, ".go") {
+			src, err := format.Source(contents[i])
+			if err != nil {
+				return nil, fmt.Errorf("scaffold: %s: %w", f.path, err)
+			}
+			contents[i] = src
+		}
+	}
+
+	written := make([]string, 0, len(files))
+	for i, f := range files {
+		path := filepath.Join(dir, f.path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(path, contents[i], 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// Pascal returns name in PascalCase, e.g. "post_list" becomes "PostList"
+func Pascal(name string) string {
+	var b strings.Builder
+	for _, word := range words(name) {
+		switch lower := strings.ToLower(word); lower {
+		case "id", "url", "api", "html":
+			// Initialisms stay upper case, as Go names them
+			b.WriteString(strings.ToUpper(lower))
+		default:
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// Snake returns name in snake_case, e.g. "PostList" becomes "post_list"
+func Snake(name string) string {
+	parts := words(name)
+	for i, word := range parts {
+		parts[i] = strings.ToLower(word)
+	}
+	return strings.Join(parts, "_")
+}
+
+// Label returns name as a label, e.g. "created_at" becomes "Created at"
+func Label(name string) string {
+	label := strings.ReplaceAll(Snake(name), "_", " ")
+	if label == "" {
+		return ""
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// words splits a name at underscores, dashes, spaces and case changes
+func words(name string) []string {
+	var parts []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if r == '_' || r == '-' || r == ' ' {
+			if len(word) > 0 {
+				parts = append(parts, string(word))
+			}
+			word = nil
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 && (!unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			parts = append(parts, string(word))
+			word = nil
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		parts = append(parts, string(word))
+	}
+	return parts
+}
+
+// validName reports whether name can become a Go identifier
+func validName(name string) bool {
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
@@ -4,15 +4,24 @@ import (
 	"bytes"
 	"html/template"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sync"
+
+	"github.com/paulmanoni/livenest/i18n"
 )
 
 // Engine wraps Go's html/template with additional functionality
 type Engine struct {
 	templates *template.Template
 	dir       string
+	fsys      fs.FS
 	funcs     template.FuncMap
+
+	base      *template.Template                     // never executed, so it can be cloned
+	localized map[*i18n.Localizer]*template.Template // clones of base with t bound to a locale
+	mu        sync.Mutex
 }
 
 // NewEngine creates a new template engine
@@ -23,6 +32,21 @@ func NewEngine(dir string) *Engine {
 	}
 }
 
+// NewEngineFS creates a template engine reading templates from fsys, e.g. an embed.FS,
+// so they ship inside the binary; use fs.Sub to start from a subdirectory
+//
+//	//go:embed templates
+//	var files embed.FS
+//
+//	sub, _ := fs.Sub(files, "templates")
+//	engine := template.NewEngineFS(sub)
+func NewEngineFS(fsys fs.FS) *Engine {
+	return &Engine{
+		fsys:  fsys,
+		funcs: DefaultFuncs(),
+	}
+}
+
 // AddFunc adds a template function
 func (e *Engine) AddFunc(name string, fn interface{}) {
 	e.funcs[name] = fn
@@ -35,26 +59,30 @@ func (e *Engine) AddFuncs(funcs template.FuncMap) {
 	}
 }
 
-// Load loads all templates from the template directory
+// Load loads all templates from the template directory or file system
+// Templates are named by their slash-separated path, e.g. "pages/index.html"
 func (e *Engine) Load() error {
-	if _, err := os.Stat(e.dir); os.IsNotExist(err) {
-		// Create directory if it doesn't exist
-		if err := os.MkdirAll(e.dir, 0755); err != nil {
-			return err
+	fsys := e.fsys
+	if fsys == nil {
+		if _, err := os.Stat(e.dir); os.IsNotExist(err) {
+			// Create directory if it doesn't exist
+			if err := os.MkdirAll(e.dir, 0755); err != nil {
+				return err
+			}
+			// No templates to load yet
+			return e.setTemplates(template.New("").Funcs(e.funcs))
 		}
-		// No templates to load yet
-		e.templates = template.New("").Funcs(e.funcs)
-		return nil
+		fsys = os.DirFS(e.dir)
 	}
 
 	tmpl := template.New("").Funcs(e.funcs)
 
-	err := filepath.Walk(e.dir, func(path string, info os.FileInfo, err error) error {
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if info.IsDir() {
+		if d.IsDir() {
 			return nil
 		}
 
@@ -64,18 +92,12 @@ func (e *Engine) Load() error {
 			return nil
 		}
 
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return err
-		}
-
-		// Get relative path for template name
-		relPath, err := filepath.Rel(e.dir, path)
+		data, err := fs.ReadFile(fsys, path)
 		if err != nil {
 			return err
 		}
 
-		_, err = tmpl.New(relPath).Parse(string(data))
+		_, err = tmpl.New(path).Parse(string(data))
 		return err
 	})
 
@@ -83,14 +105,53 @@ func (e *Engine) Load() error {
 		return err
 	}
 
-	e.templates = tmpl
+	return e.setTemplates(tmpl)
+}
+
+// setTemplates keeps a parsed template set as the base of localized renders and uses a
+// clone of it for the others
+func (e *Engine) setTemplates(tmpl *template.Template) error {
+	templates, err := tmpl.Clone()
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.base = tmpl
+	e.templates = templates
+	e.localized = make(map[*i18n.Localizer]*template.Template)
 	return nil
 }
 
+// templatesFor returns the templates to render data with: when data holds an
+// i18n.Localizer, e.g. LiveView assigns, a set whose t func translates into its locale
+func (e *Engine) templatesFor(data interface{}) (*template.Template, error) {
+	l := i18n.FromData(data)
+	if l == nil || e.base == nil {
+		return e.templates, nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if tmpl, ok := e.localized[l]; ok {
+		return tmpl, nil
+	}
+	tmpl, err := e.base.Clone()
+	if err != nil {
+		return nil, err
+	}
+	tmpl.Funcs(l.Funcs())
+	e.localized[l] = tmpl
+	return tmpl, nil
+}
+
 // Render renders a template with the given data
 func (e *Engine) Render(name string, data interface{}) (template.HTML, error) {
+	tmpl, err := e.templatesFor(data)
+	if err != nil {
+		return "", err
+	}
 	var buf bytes.Buffer
-	if err := e.templates.ExecuteTemplate(&buf, name, data); err != nil {
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
 		return "", err
 	}
 	return template.HTML(buf.String()), nil
@@ -98,13 +159,27 @@ func (e *Engine) Render(name string, data interface{}) (template.HTML, error) {
 
 // RenderTo renders a template to a writer
 func (e *Engine) RenderTo(w io.Writer, name string, data interface{}) error {
-	return e.templates.ExecuteTemplate(w, name, data)
+	tmpl, err := e.templatesFor(data)
+	if err != nil {
+		return err
+	}
+	return tmpl.ExecuteTemplate(w, name, data)
 }
 
 // Parse parses a template string
 func (e *Engine) Parse(name, tmpl string) error {
-	_, err := e.templates.New(name).Parse(tmpl)
-	return err
+	if _, err := e.templates.New(name).Parse(tmpl); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.base != nil {
+		if _, err := e.base.New(name).Parse(tmpl); err != nil {
+			return err
+		}
+		e.localized = make(map[*i18n.Localizer]*template.Template)
+	}
+	return nil
 }
 
 // Exists checks if a template exists
@@ -5,6 +5,8 @@ import (
 	"html/template"
 	"strings"
 	"time"
+
+	"github.com/paulmanoni/livenest/i18n"
 )
 
 // DefaultFuncs returns default template functions
@@ -45,9 +47,18 @@ func DefaultFuncs() template.FuncMap {
 		"lte": lte,
 		"gt":  gt,
 		"gte": gte,
+
+		// Translation, bound to the data's i18n.Localizer when it has one
+		"t":      i18n.Format,
+		"locale": noLocale,
 	}
 }
 
+// noLocale is the locale of data without a localizer
+func noLocale() string {
+	return ""
+}
+
 // formatDate formats a time.Time to a date string
 func formatDate(t time.Time, format string) string {
 	if format == "" {